@@ -26,6 +26,8 @@ func main() {
 		log.Fatal(err.Error())
 	}
 
+	agentCfg.Identity.Version = buildVersion
+
 	// Output the build information
 	agentCfg.Log.InfoContext(context.Background(), "agent built info",
 		slog.String("version", buildVersion),
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+const (
+	// exportUsage describes the arguments of the export subcommand.
+	exportUsage = "usage: server [flags] export [--format=json|csv|ndjson] [--output=file]"
+	// importUsage describes the arguments of the import subcommand.
+	importUsage = "usage: server [flags] import [--format=json|csv|ndjson] [file]"
+)
+
+// export runs the export subcommand, writing every series of the configured storage
+// to the --output file, or to out when it is not set. Counters hold their totals.
+func (app *ServerApp) export(ctx context.Context, out io.Writer, args []string) (err error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	formatName := fs.String("format", "", "Export format: json, csv or ndjson; json by default.")
+	output := fs.String("output", "", "File the metrics are written to, stdout by default.")
+	if err = fs.Parse(args); err != nil || fs.NArg() > 0 {
+		return fmt.Errorf("invalid arguments: %s", exportUsage)
+	}
+
+	format, err := storage.ParseFormat(*formatName)
+	if err != nil {
+		return fmt.Errorf("%w, %s", err, exportUsage)
+	}
+
+	store, err := app.openStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer app.closeStorage(ctx, store)
+
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return fmt.Errorf("failed to create the export file: %w", err)
+		}
+		defer func() {
+			err = errors.Join(err, f.Close())
+		}()
+		out = f
+	}
+
+	exported, err := storage.Export(ctx, store, out, format)
+	if err != nil {
+		return fmt.Errorf("failed to export the metrics: %w", err)
+	}
+	app.logger.InfoContext(ctx, fmt.Sprintf("exported %d metric(s) as %s", exported, format))
+
+	return nil
+}
+
+// importMetrics runs the import subcommand, reading metrics from the file argument,
+// or from in when it is not given, and replacing their series in the configured
+// storage. Without --format the format follows the extension of the file, json by default.
+func (app *ServerApp) importMetrics(ctx context.Context, in io.Reader, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	formatName := fs.String("format", "", "Import format: json, csv or ndjson.")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+		return fmt.Errorf("invalid arguments: %s", importUsage)
+	}
+
+	path := fs.Arg(0)
+	if *formatName == "" && path != "" {
+		*formatName = strings.TrimPrefix(filepath.Ext(path), ".")
+	}
+	format, err := storage.ParseFormat(*formatName)
+	if err != nil {
+		return fmt.Errorf("%w, %s", err, importUsage)
+	}
+
+	if path != "" {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open the import file: %w", err)
+		}
+		defer func() {
+			_ = f.Close()
+		}()
+		in = f
+	}
+
+	// Every record is read and validated before the storage is touched.
+	metrics, err := storage.DecodeRecords(in, format)
+	if err != nil {
+		return fmt.Errorf("failed to read the metrics: %w", err)
+	}
+
+	store, err := app.openStorage(ctx)
+	if err != nil {
+		return err
+	}
+	defer app.closeStorage(ctx, store)
+
+	imported, err := storage.Import(ctx, store, metrics)
+	if err != nil {
+		return fmt.Errorf("imported %d of %d metric(s): %w", imported, len(metrics), err)
+	}
+	app.logger.InfoContext(ctx, fmt.Sprintf("imported %d metric(s) from %s", imported, format))
+
+	return nil
+}
+
+// closeStorage closes a storage, so the file storage writes its final snapshot and the
+// write-behind cache flushes its pending writes, and only then the database connection
+// pool the storage may use until it is closed.
+func (app *ServerApp) closeStorage(ctx context.Context, store storage.Storage) {
+	app.logger.DebugContext(ctx, "shutting down storage")
+	if err := store.Close(ctx); err != nil {
+		app.logger.ErrorContext(ctx, "failed to close storage", helpers.ErrAttr(err))
+	}
+
+	if app.db != nil {
+		app.logger.DebugContext(ctx, "shutting down the db connection pool")
+		app.db.Close()
+	}
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"log"
 	"log/slog"
 	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"syscall"
@@ -64,17 +66,23 @@ type ServerApp struct {
 }
 
 func main() {
-	// Output the build information
-	fmt.Println(utils.BuildTagsFormatedString(buildVersion, buildDate, buildCommit))
-
 	// appConfig - holds a pointer to the server configurations
 	appConfig, err := config.NewServerConfig()
 	if err != nil {
 		log.Fatal("failed to initiate server config: " + err.Error())
 	}
 
+	// the subcommands may write their result to stdout, so they log to stderr
+	logOutput := os.Stdout
+	if flag.NArg() > 0 {
+		logOutput = os.Stderr
+	}
+
+	// Output the build information
+	_, _ = fmt.Fprintln(logOutput, utils.BuildTagsFormatedString(buildVersion, buildDate, buildCommit))
+
 	// initializing a new logger
-	newLogger, err := logger.NewLogger(os.Stdout, appConfig.Envs.LogLevel)
+	newLogger, err := logger.NewLogger(logOutput, appConfig.Envs.LogLevel)
 	if err != nil {
 		log.Fatal("failed to initiate server logger: " + err.Error())
 	}
@@ -94,6 +102,25 @@ func main() {
 		app.db = db
 	}
 
+	// running a subcommand instead of the server
+	if args := flag.Args(); len(args) > 0 {
+		switch args[0] {
+		case "migrate":
+			err = app.migrate(ctx, os.Stdout, args[1:])
+		case "export":
+			err = app.export(ctx, os.Stdout, args[1:])
+		case "import":
+			err = app.importMetrics(ctx, os.Stdin, args[1:])
+		default:
+			err = fmt.Errorf("unknown command %q, expected one of:\n%s\n%s\n%s",
+				args[0], migrateUsage, exportUsage, importUsage)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// running the server in the main thread
 	if err = app.run(ctx); err != nil {
 		app.logger.ErrorContext(context.Background(),
@@ -103,36 +130,110 @@ func main() {
 	}
 }
 
+// openStorage opens the storage selected by the configuration, bounding its calls
+// with the storage timeout.
+func (app *ServerApp) openStorage(ctx context.Context) (storage.Storage, error) {
+	store, err := storage.Open(ctx, app.cfg.Envs.StorageURL, storage.Dependencies{
+		Logger: app.logger,
+		DB:     app.db,
+		File: storage.FileOptions{
+			Path:          app.cfg.Envs.StorePath,
+			RestoreFrom:   app.cfg.Envs.RestoreFrom,
+			StoreInterval: app.cfg.Envs.StoreInterval,
+			SnapshotsKept: app.cfg.Envs.SnapshotsKept,
+			NoRestore:     !app.cfg.Envs.ReStore,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup storage: %w", err)
+	}
+
+	return storage.WithTimeout(store, app.cfg.Envs.StorageTimeout), nil
+}
+
 // run function starts the server and handles the server's lifecycle.
 func (app *ServerApp) run(ctx context.Context) error {
 	app.logger.DebugContext(ctx, "provided_config",
 		slog.String("ServerAddress", app.cfg.Envs.Address),
+		slog.String("StorageURL", redactURL(app.cfg.Envs.StorageURL)),
 		slog.String("StorePath", app.cfg.Envs.StorePath),
 		slog.String("LogLevel", app.cfg.Envs.LogLevel),
 		slog.String("ConfigPath", app.cfg.Envs.ConfigPath),
 		slog.String("TrustedIP", app.cfg.Envs.TrustedSubnet),
 		slog.Int("StoreInterval", app.cfg.Envs.StoreInterval),
+		slog.String("BoltPath", app.cfg.Envs.BoltPath),
 		slog.Bool("ReStore", app.cfg.Envs.ReStore),
-		slog.Bool("Secret", app.cfg.Envs.Key != ""))
+		slog.String("RestoreFrom", app.cfg.Envs.RestoreFrom),
+		slog.Int("SnapshotsKept", app.cfg.Envs.SnapshotsKept),
+		slog.Duration("StorageTimeout", app.cfg.Envs.StorageTimeout),
+		slog.Int("MaxBatchSize", app.cfg.Envs.MaxBatchSize),
+		slog.Duration("WriteBehind", app.cfg.Envs.WriteBehind),
+		slog.Duration("RateWindow", app.cfg.Envs.RateWindow),
+		slog.String("TenantsPath", app.cfg.Envs.TenantsPath),
+		slog.String("TenantHeader", app.cfg.Envs.TenantHeader),
+		slog.Int("MaxNameLength", app.cfg.Envs.MaxNameLength),
+		slog.Bool("AllowNonFinite", app.cfg.Envs.AllowNonFinite),
+		slog.Bool("AllowNegativeGauges", app.cfg.Envs.AllowNegativeGauges),
+		slog.Int("MaxSeries", app.cfg.Envs.MaxSeries),
+		slog.Int("MaxSeriesPerSource", app.cfg.Envs.MaxSeriesPerSource),
+		slog.String("PrefixQuotas", app.cfg.Envs.PrefixQuotas),
+		slog.Int("MissedReports", app.cfg.Envs.MissedReports),
+		slog.String("TrustedProxies", app.cfg.Envs.TrustedProxies),
+		slog.Duration("MetricTTL", app.cfg.Envs.MetricTTL),
+		slog.String("MetricTTLRules", app.cfg.Envs.MetricTTLRules),
+		slog.Bool("Secret", app.cfg.Envs.Key != ""),
+		slog.Bool("AdminKey", app.cfg.Envs.AdminKey != ""))
 
 	// Initialize storage
-	store, err := storage.NewStorage(app.db, app.logger, app.cfg.Envs.StoreInterval, app.cfg.Envs.StorePath)
+	store, err := app.openStorage(ctx)
 	if err != nil {
 		app.logger.ErrorContext(ctx, "failed to initialize storage")
-		return fmt.Errorf("failed to setup storage: %w", err)
+		return err
 	}
-	defer func() {
-		app.logger.DebugContext(ctx, "shutting down storage")
-		if err := store.Close(ctx); err != nil {
-			app.logger.ErrorContext(ctx, "failed to close storage", helpers.ErrAttr(err))
+	if app.cfg.Envs.WriteBehind > 0 {
+		cached, err := storage.NewWriteBehindStorage(ctx, store, app.logger, app.cfg.Envs.WriteBehind)
+		if err != nil {
+			_ = store.Close(ctx)
+			return fmt.Errorf("failed to setup the write-behind cache: %w", err)
 		}
-	}()
+		app.logger.InfoContext(ctx, "write-behind cache enabled",
+			slog.Duration("flush_interval", app.cfg.Envs.WriteBehind))
+		store = cached
+	}
+	defer app.closeStorage(ctx, store)
+
+	// Expire the series that are not updated and purge them in the background, the
+	// janitor also prunes the history of the storages keeping it in a table
+	store.SetTTLPolicy(app.cfg.TTL)
+	janitorCtx, stopJanitor := context.WithCancel(ctx)
+	defer stopJanitor()
+	go storage.NewJanitor(store, app.logger, app.cfg.Envs.JanitorInterval).Run(janitorCtx)
+
+	// Every request only sees the series of its tenant
+	tenants, err := storage.NewTenantRegistry(app.cfg.Envs.TenantsPath)
+	if err != nil {
+		return fmt.Errorf("failed to load the tenants: %w", err)
+	}
 
 	// Initialize repositories and services
-	repos := repositories.NewRepository(store)
+	repos := repositories.NewRepository(storage.WithNamespaces(store))
 	service := server.NewMetricsService(repos, app.logger)
+	service.SetMaxBatchSize(app.cfg.Envs.MaxBatchSize)
+	service.SetRateWindow(app.cfg.Envs.RateWindow)
+	service.SetTTLPolicy(app.cfg.TTL)
+	service.SetValidationPolicy(server.ValidationPolicy{
+		MaxNameLength:       app.cfg.Envs.MaxNameLength,
+		MaxLabelValueLength: server.DefaultMaxLabelValueLength,
+		AllowNonFinite:      app.cfg.Envs.AllowNonFinite,
+		AllowNegativeGauges: app.cfg.Envs.AllowNegativeGauges,
+	})
+	service.SetQuotaPolicy(app.cfg.Quotas)
+	service.SetMissedReports(app.cfg.Envs.MissedReports)
 	serverHandlers := handlers.NewHandler(service, app.logger, app.db, app.cfg.Envs.Key,
 		app.cfg.PrivateKey, app.cfg.TrustedSubnet)
+	serverHandlers.SetTenants(tenants, app.cfg.Envs.TenantHeader)
+	serverHandlers.SetAdminKey(app.cfg.Envs.AdminKey)
+	serverHandlers.SetTrustedProxies(app.cfg.TrustedProxies)
 
 	grpcLis, errTCP := net.Listen("tcp", ":50051")
 	if errTCP != nil {
@@ -140,7 +241,11 @@ func (app *ServerApp) run(ctx context.Context) error {
 		return fmt.Errorf("failed to listen on gRPC port: %w", errTCP)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(
+			grpcserver.SourceInterceptor(app.cfg.TrustedProxies),
+			grpcserver.AgentInterceptor(),
+			grpcserver.TenantInterceptor(tenants, app.cfg.Envs.TenantHeader)))
 	grpcMetricsService := grpcserver.NewMetricsService(service, app.logger)
 	pb.RegisterMetricServiceServer(grpcServer, grpcMetricsService)
 	reflection.Register(grpcServer)
@@ -186,12 +291,16 @@ func (app *ServerApp) run(ctx context.Context) error {
 		app.logger.ErrorContext(ctx, "failed to shutdown server gracefully", helpers.ErrAttr(err))
 	}
 
-	// Additional cleanup for the database connection pool
-	if app.db != nil {
-		app.logger.DebugContext(ctx, "shutting down the db connection pool")
-		app.db.Close()
-	}
-
 	app.logger.InfoContext(ctx, "server stopped successfully")
 	return nil
 }
+
+// redactURL hides the password of a URL before it is logged.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "invalid url"
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// poolBackedStorage is a storage recording the metrics stored in it and whether the
+// connection pool it depends on was already closed when they were stored.
+type poolBackedStorage struct {
+	storage.Storage
+	pool       *pgxpool.Pool
+	stored     []entities.Metrics
+	poolClosed bool
+}
+
+func (s *poolBackedStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	s.poolClosed = isPoolClosed(ctx, s.pool)
+	s.stored = append(s.stored, metrics...)
+
+	return s.Storage.StoreMetricsBatch(ctx, metrics)
+}
+
+// isPoolClosed reports whether the pool refuses connections because it is closed.
+func isPoolClosed(ctx context.Context, pool *pgxpool.Pool) bool {
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return strings.Contains(err.Error(), "closed pool")
+	}
+	conn.Release()
+
+	return false
+}
+
+func TestCloseStorageFlushesBeforeClosingThePool(t *testing.T) {
+	ctx := context.Background()
+	// The pool connects lazily, nothing listens on the address.
+	pool, err := pgxpool.New(ctx, "postgres://metrics@127.0.0.1:1/metrics?connect_timeout=1")
+	require.NoError(t, err)
+
+	mem, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	backing := &poolBackedStorage{Storage: mem, pool: pool}
+	store, err := storage.NewWriteBehindStorage(ctx, backing, slog.Default(), time.Hour)
+	require.NoError(t, err)
+
+	value := 1.5
+	require.NoError(t, store.CreateRecord(ctx, entities.Metrics{ID: "Alloc", MType: "gauge", Value: &value}))
+	require.Empty(t, backing.stored, "the write is pending in the cache")
+
+	app := ServerApp{logger: slog.Default(), db: pool}
+	app.closeStorage(ctx, store)
+
+	require.Len(t, backing.stored, 1, "the pending write reaches the backing storage")
+	assert.False(t, backing.poolClosed, "the pending write is flushed while the pool is open")
+	assert.True(t, isPoolClosed(ctx, pool), "the pool is closed with the storage")
+}
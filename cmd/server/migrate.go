@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+)
+
+// migrateUsage describes the arguments of the migrate subcommand.
+const migrateUsage = "usage: server [flags] migrate up|down [steps]|status"
+
+// errMigrateUsage is returned when the migrate subcommand is called with invalid arguments.
+var errMigrateUsage = errors.New(migrateUsage)
+
+// migrate runs the migrate subcommand against the database given by -d or DATABASE_DSN:
+//   - up applies the pending migrations;
+//   - down [steps] reverts the last steps migrations, one by default;
+//   - status lists the migrations and when they were applied.
+func (app *ServerApp) migrate(ctx context.Context, out io.Writer, args []string) error {
+	if len(args) == 0 || len(args) > 2 || (len(args) == 2 && args[0] != "down") {
+		return errMigrateUsage
+	}
+	if app.db == nil {
+		return errors.New("migrate needs a database, set it with -d or DATABASE_DSN")
+	}
+
+	migrator, err := storage.NewMigrator(app.db, app.logger)
+	if err != nil {
+		return fmt.Errorf("failed to load the schema migrations: %w", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to apply the migrations: %w", err)
+		}
+		_, _ = fmt.Fprintf(out, "applied %d migration(s)\n", applied)
+		return nil
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q: %w", args[1], errMigrateUsage)
+			}
+		}
+		reverted, err := migrator.Down(ctx, steps)
+		if err != nil {
+			return fmt.Errorf("failed to revert the migrations: %w", err)
+		}
+		_, _ = fmt.Fprintf(out, "reverted %d migration(s)\n", reverted)
+		return nil
+	case "status":
+		status, err := migrator.Status(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to read the migrations status: %w", err)
+		}
+		return printMigrationStatus(out, status)
+	default:
+		return errMigrateUsage
+	}
+}
+
+// printMigrationStatus writes the migrations as a table.
+func printMigrationStatus(out io.Writer, status []storage.MigrationStatus) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+	for _, s := range status {
+		appliedAt := "pending"
+		if s.Applied {
+			appliedAt = s.AppliedAt.Format(time.RFC3339)
+		}
+		_, _ = fmt.Fprintf(w, "%04d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write the migrations status: %w", err)
+	}
+
+	return nil
+}
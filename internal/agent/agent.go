@@ -80,6 +80,9 @@ func RunAgent(agentCfg *config.AgentEnvs) error {
 		&agentCfg.Key,
 		agentCfg.PublicKey,
 		conn,
+		agentCfg.Labels,
+		agentCfg.APIKey,
+		agentCfg.Identity,
 	)
 
 	// Set up a worker pool with rate limiting.
@@ -128,9 +131,10 @@ func RunAgent(agentCfg *config.AgentEnvs) error {
 			select {
 			case <-reportTicker.C:
 				task := &agent.SendMetricsTask{
-					Service:    metricsService,
-					ServerAddr: agentCfg.ServerAddr,
-					Log:        agentCfg.Log,
+					Service:        metricsService,
+					ServerAddr:     agentCfg.ServerAddr,
+					Log:            agentCfg.Log,
+					ReportInterval: agentCfg.ReportInterval,
 				}
 				workerPool.AddTask(task)
 			case <-ctx.Done():
@@ -19,6 +19,7 @@ import (
 	env11 "github.com/caarlos0/env/v11"
 	"github.com/spf13/viper"
 
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/logger"
 	"github.com/mihailtudos/metrickit/internal/utils"
 	"github.com/mihailtudos/metrickit/pkg/helpers"
@@ -41,6 +42,13 @@ type AgentEnvs struct {
 	RateLimit      int            // Maximum number of concurrent goroutines.
 	PollInterval   time.Duration  // Interval between metric polling operations.
 	ReportInterval time.Duration  // Interval between sending metrics to the server.
+
+	// Labels attached to every reported metric, configurable via environment variable "LABELS".
+	Labels entities.Labels
+	// APIKey of the tenant the metrics are reported to, configurable via environment variable "API_KEY".
+	APIKey string
+	// Identity sent with every request, its ID is configurable via environment variable "AGENT_ID".
+	Identity entities.AgentIdentity
 }
 
 // envAgentConfig is a struct for parsing environment variables into agent configuration settings.
@@ -60,6 +68,12 @@ type envAgentConfig struct {
 	ReportInterval int `env:"REPORT_INTERVAL" json:"report_interval"`
 	// Reporting interval in seconds, configurable via environment variable "REPORT_INTERVAL".
 	RateLimit int `env:"RATE_LIMIT"`
+	// Labels attached to every metric as comma separated key=value pairs, configurable via "LABELS".
+	Labels string `env:"LABELS" json:"labels"`
+	// API key of the tenant the metrics are reported to, configurable via "API_KEY".
+	APIKey string `env:"API_KEY" json:"api_key"`
+	// Stable identifier of the agent, the hostname by default, configurable via "AGENT_ID".
+	AgentID string `env:"AGENT_ID" json:"agent_id"`
 }
 
 // NewAgentConfig creates a new AgentEnvs instance by parsing environment variables
@@ -87,6 +101,16 @@ func NewAgentConfig() (*AgentEnvs, error) {
 		return nil, fmt.Errorf("failed to setup public key: %w", err)
 	}
 
+	labels, err := entities.ParseLabelPairs(envs.Labels)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse agent labels: %w", err)
+	}
+
+	identity, err := newAgentIdentity(envs.AgentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to setup the agent identity: %w", err)
+	}
+
 	return &AgentEnvs{
 		Log:            l,
 		ServerAddr:     envs.ServerAddr,
@@ -96,9 +120,32 @@ func NewAgentConfig() (*AgentEnvs, error) {
 		RateLimit:      envs.RateLimit,
 		PublicKey:      publicKey,
 		GRPCAddress:    envs.GRPCAddress,
+		Labels:         labels,
+		APIKey:         envs.APIKey,
+		Identity:       identity,
 	}, nil
 }
 
+// newAgentIdentity returns the identity of the agent, identified by id or, when it is
+// empty, by the hostname, which is stable across restarts. Several agents running on
+// the same host must be given distinct IDs.
+func newAgentIdentity(id string) (entities.AgentIdentity, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return entities.AgentIdentity{}, fmt.Errorf("failed to get the hostname: %w", err)
+	}
+	if id == "" {
+		id = hostname
+	}
+
+	identity := entities.AgentIdentity{ID: id, Hostname: hostname}
+	if err = identity.Validate(); err != nil {
+		return entities.AgentIdentity{}, fmt.Errorf("agent id: %w", err)
+	}
+
+	return identity, nil
+}
+
 // parseAgentEnvs reads environment variables and command-line flags to populate
 // an envAgentConfig instance. It applies default values first, then overrides
 // them with environment variables, and finally with command-line flags.
@@ -141,6 +188,15 @@ func parseAgentEnvs() (*envAgentConfig, error) {
 	flag.StringVar(&envConfig.GRPCAddress, "grpc-addr",
 		"",
 		"sets the address for gRPC communication")
+	flag.StringVar(&envConfig.Labels, "labels",
+		"",
+		"labels attached to every metric - usage: host=web-1,env=prod")
+	flag.StringVar(&envConfig.APIKey, "api-key",
+		"",
+		"API key of the tenant the metrics are reported to")
+	flag.StringVar(&envConfig.AgentID, "id",
+		"",
+		"stable identifier of the agent, the hostname by default")
 
 	flag.Parse()
 
@@ -169,6 +225,9 @@ func parseAgentEnvs() (*envAgentConfig, error) {
 		utils.Replace(&envConfig.PollInterval, int(viper.GetDuration("poll_interval").Seconds()))
 		utils.Replace(&envConfig.ReportInterval, int(viper.GetDuration("report_interval").Seconds()))
 		utils.Replace(&envConfig.GRPCAddress, viper.GetString("grpc_address"))
+		utils.Replace(&envConfig.Labels, viper.GetString("labels"))
+		utils.Replace(&envConfig.APIKey, viper.GetString("api_key"))
+		utils.Replace(&envConfig.AgentID, viper.GetString("agent_id"))
 	}
 
 	fmt.Printf("%+v", envConfig)
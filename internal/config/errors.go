@@ -7,4 +7,6 @@ import "errors"
 var (
 	ErrPublicKeyPathNotProvided = errors.New("public key path not provided") // Error for missing public key path.
 	ErrPrivateKeyPathNotSet     = errors.New("private key path not set")     // Error for missing private key path.
+	// Error for a postgres STORAGE_URL and a DATABASE_DSN pointing at different databases.
+	ErrConflictingDatabases = errors.New("STORAGE_URL and DATABASE_DSN name different databases")
 )
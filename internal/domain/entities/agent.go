@@ -0,0 +1,97 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// Headers carrying the identity of the agent sending a request.
+const (
+	AgentIDHeader       = "X-Agent-ID"       // Stable identifier of the agent, e.g. web-1.
+	AgentHostnameHeader = "X-Agent-Hostname" // Hostname of the machine running the agent.
+	AgentVersionHeader  = "X-Agent-Version"  // Build version of the agent.
+)
+
+// Synthetic gauge telling whether every agent is up: 1 while the agent reports and 0 once
+// it missed its reports, e.g. up{agent="web-1"}.
+const (
+	AgentUpMetric MetricName = "up"    // Name of the gauge.
+	AgentLabel               = "agent" // Label holding the ID of the agent.
+)
+
+const (
+	maxAgentHostnameLength = 255
+	maxAgentVersionLength  = 64
+)
+
+var (
+	// ErrInvalidAgent is returned for an agent identity with an invalid ID, hostname or version.
+	ErrInvalidAgent = errors.New("invalid agent identity")
+	// ErrInvalidHeartbeat is returned for a heartbeat without an agent ID or a report interval.
+	ErrInvalidHeartbeat = errors.New("invalid heartbeat")
+)
+
+// agentIDRe matches the valid agent IDs, e.g. web-1 or 3f9c2a.
+var agentIDRe = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]{0,127}$`)
+
+// AgentIdentity is what an agent tells about itself in every request.
+type AgentIdentity struct {
+	ID       string `json:"id"`                 // Stable identifier of the agent.
+	Hostname string `json:"hostname,omitempty"` // Hostname of the machine running the agent.
+	Version  string `json:"version,omitempty"`  // Build version of the agent.
+}
+
+// Validate checks that the ID is made of up to 128 letters, digits, '.', '_', ':' and '-'
+// and that the hostname and the version are not longer than a hostname and a version.
+func (a AgentIdentity) Validate() error {
+	if !agentIDRe.MatchString(a.ID) {
+		return fmt.Errorf("%w: id %q must be up to 128 letters, digits, '.', '_', ':' and '-'", ErrInvalidAgent, a.ID)
+	}
+	if len(a.Hostname) > maxAgentHostnameLength {
+		return fmt.Errorf("%w: hostname of %s is longer than %d characters", ErrInvalidAgent, a.ID,
+			maxAgentHostnameLength)
+	}
+	if len(a.Version) > maxAgentVersionLength {
+		return fmt.Errorf("%w: version of %s is longer than %d characters", ErrInvalidAgent, a.ID,
+			maxAgentVersionLength)
+	}
+
+	return nil
+}
+
+// Agent is an agent known to the server, as of its last request.
+type Agent struct {
+	LastSeen time.Time `json:"last_seen"` // Time of the last request of the agent.
+	AgentIdentity
+	Address string `json:"address,omitempty"` // IP address the last request came from.
+	Series  int    `json:"series"`            // Series written by the agent since the server started.
+	// Interval between two reports of the agent in seconds, as told by its heartbeats.
+	ReportInterval int `json:"report_interval,omitempty"`
+	// Whether the agent reported within its last report intervals, it is down once it missed them.
+	Up bool `json:"up"`
+}
+
+// Heartbeat is sent by an agent at every report, even when it has no metric to report,
+// so the server tells a quiet agent from a dead one.
+type Heartbeat struct {
+	ReportInterval int `json:"report_interval"` // Interval between two reports of the agent, in seconds.
+}
+
+// agentKey is the context key of the agent sending a request.
+type agentKey struct{}
+
+// WithAgent returns a copy of ctx carrying the identity of the agent sending a request.
+func WithAgent(ctx context.Context, agent AgentIdentity) context.Context {
+	return context.WithValue(ctx, agentKey{}, agent)
+}
+
+// AgentFromContext returns the identity of the agent sending a request, and false for
+// a request that did not identify its agent.
+func AgentFromContext(ctx context.Context) (AgentIdentity, bool) {
+	agent, ok := ctx.Value(agentKey{}).(AgentIdentity)
+	return agent, ok
+}
@@ -0,0 +1,36 @@
+package entities
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAgentIdentityValidate(t *testing.T) {
+	require.NoError(t, AgentIdentity{ID: "web-1.prod:2", Hostname: "web-1", Version: "1.2.0"}.Validate())
+	require.NoError(t, AgentIdentity{ID: "web_1"}.Validate())
+
+	for _, identity := range []AgentIdentity{
+		{ID: ""},
+		{ID: "-web"},
+		{ID: "web 1"},
+		{ID: strings.Repeat("a", 129)},
+		{ID: "web", Hostname: strings.Repeat("h", 256)},
+		{ID: "web", Version: strings.Repeat("v", 65)},
+	} {
+		require.ErrorIs(t, identity.Validate(), ErrInvalidAgent, identity.ID)
+	}
+}
+
+func TestAgentContext(t *testing.T) {
+	_, ok := AgentFromContext(context.Background())
+	assert.False(t, ok)
+
+	identity := AgentIdentity{ID: "web-1", Hostname: "web-1", Version: "1.2.0"}
+	agent, ok := AgentFromContext(WithAgent(context.Background(), identity))
+	require.True(t, ok)
+	assert.Equal(t, identity, agent)
+}
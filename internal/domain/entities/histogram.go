@@ -0,0 +1,152 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// HistogramMetricName is the metric type of histograms.
+const HistogramMetricName MetricType = "histogram"
+
+var (
+	// ErrInvalidHistogram is returned when a histogram is malformed, e.g. its bounds
+	// are not sorted or the number of bucket counts does not match the bounds.
+	ErrInvalidHistogram = errors.New("invalid histogram")
+
+	// ErrHistogramBoundsMismatch is returned when merging histograms with different bucket bounds.
+	ErrHistogramBoundsMismatch = errors.New("histogram bucket bounds do not match")
+)
+
+// DefaultLatencyBuckets are the bucket bounds, in seconds, used for request latencies.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// GCPauseBuckets are the bucket bounds, in nanoseconds, used for garbage collection pauses.
+var GCPauseBuckets = []float64{1e4, 5e4, 1e5, 2.5e5, 5e5, 1e6, 2.5e6, 5e6, 1e7, 5e7, 1e8}
+
+// Histogram samples observations into buckets with configurable upper bounds and keeps
+// the sum and count of all observations. Counts holds one entry per bound plus a final
+// entry for the implicit +Inf bucket. Bucket counts are not cumulative.
+type Histogram struct {
+	Bounds []float64 `json:"bounds"` // Inclusive upper bounds of the buckets, strictly increasing.
+	Counts []uint64  `json:"counts"` // Number of observations per bucket, the last one is +Inf.
+	Sum    float64   `json:"sum"`    // Sum of all observed values.
+	Count  uint64    `json:"count"`  // Total number of observations.
+}
+
+// NewHistogram creates an empty histogram with the given bucket bounds.
+func NewHistogram(bounds []float64) *Histogram {
+	return &Histogram{
+		Bounds: append([]float64(nil), bounds...),
+		Counts: make([]uint64, len(bounds)+1),
+	}
+}
+
+// Observe adds a single value to the histogram.
+func (h *Histogram) Observe(v float64) {
+	i := 0
+	for i < len(h.Bounds) && v > h.Bounds[i] {
+		i++
+	}
+	h.Counts[i]++
+	h.Sum += v
+	h.Count++
+}
+
+// Validate checks that the bounds are strictly increasing and that the bucket
+// counts match both the bounds and the total count.
+func (h *Histogram) Validate() error {
+	if len(h.Counts) != len(h.Bounds)+1 {
+		return fmt.Errorf("%w: expected %d bucket counts, got %d", ErrInvalidHistogram, len(h.Bounds)+1, len(h.Counts))
+	}
+
+	for i, b := range h.Bounds {
+		if math.IsNaN(b) || math.IsInf(b, 0) {
+			return fmt.Errorf("%w: bound %v is not finite", ErrInvalidHistogram, b)
+		}
+		if i > 0 && b <= h.Bounds[i-1] {
+			return fmt.Errorf("%w: bounds must be strictly increasing", ErrInvalidHistogram)
+		}
+	}
+
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total != h.Count {
+		return fmt.Errorf("%w: bucket counts add up to %d, count is %d", ErrInvalidHistogram, total, h.Count)
+	}
+
+	return nil
+}
+
+// Merge adds the observations of other to the histogram. Both histograms must
+// have the same bucket bounds. An empty histogram adopts the bounds of other.
+func (h *Histogram) Merge(other *Histogram) error {
+	if err := other.Validate(); err != nil {
+		return err
+	}
+
+	if h.Counts == nil {
+		*h = *other.Clone()
+		return nil
+	}
+
+	if !h.SameBounds(other) {
+		return ErrHistogramBoundsMismatch
+	}
+
+	for i, c := range other.Counts {
+		h.Counts[i] += c
+	}
+	h.Sum += other.Sum
+	h.Count += other.Count
+
+	return nil
+}
+
+// SameBounds reports whether both histograms have identical bucket bounds.
+func (h *Histogram) SameBounds(other *Histogram) bool {
+	if len(h.Bounds) != len(other.Bounds) {
+		return false
+	}
+
+	for i, b := range h.Bounds {
+		if b != other.Bounds[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Clone returns a deep copy of the histogram.
+func (h *Histogram) Clone() *Histogram {
+	return &Histogram{
+		Bounds: append([]float64(nil), h.Bounds...),
+		Counts: append([]uint64(nil), h.Counts...),
+		Sum:    h.Sum,
+		Count:  h.Count,
+	}
+}
+
+// String returns a plain text representation of the histogram with cumulative
+// bucket counts, one line per bucket followed by the sum and the count.
+func (h *Histogram) String() string {
+	var sb strings.Builder
+	var cumulative uint64
+	for i, c := range h.Counts {
+		cumulative += c
+		le := "+Inf"
+		if i < len(h.Bounds) {
+			le = strconv.FormatFloat(h.Bounds[i], 'g', -1, 64)
+		}
+		fmt.Fprintf(&sb, "bucket{le=%q} %d\n", le, cumulative)
+	}
+	fmt.Fprintf(&sb, "sum %v\ncount %d", h.Sum, h.Count)
+
+	return sb.String()
+}
@@ -0,0 +1,58 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	h := NewHistogram([]float64{1, 5})
+	for _, v := range []float64{0.5, 1, 3, 10} {
+		h.Observe(v)
+	}
+
+	assert.Equal(t, []uint64{2, 1, 1}, h.Counts)
+	assert.Equal(t, uint64(4), h.Count)
+	assert.InDelta(t, 14.5, h.Sum, 0)
+	require.NoError(t, h.Validate())
+	assert.Equal(t, "bucket{le=\"1\"} 2\nbucket{le=\"5\"} 3\nbucket{le=\"+Inf\"} 4\nsum 14.5\ncount 4", h.String())
+}
+
+func TestHistogramMerge(t *testing.T) {
+	a := NewHistogram([]float64{1, 5})
+	a.Observe(0.5)
+	b := NewHistogram([]float64{1, 5})
+	b.Observe(3)
+	b.Observe(7)
+
+	empty := &Histogram{}
+	require.NoError(t, empty.Merge(a))
+	assert.Equal(t, a.Bounds, empty.Bounds)
+
+	require.NoError(t, a.Merge(b))
+	assert.Equal(t, []uint64{1, 1, 1}, a.Counts)
+	assert.Equal(t, uint64(3), a.Count)
+	assert.InDelta(t, 10.5, a.Sum, 0)
+	assert.Equal(t, []uint64{0, 1, 1}, b.Counts, "merge must not modify the source")
+
+	require.ErrorIs(t, a.Merge(NewHistogram([]float64{1, 2})), ErrHistogramBoundsMismatch)
+}
+
+func TestHistogramValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		h    Histogram
+	}{
+		{name: "missing +Inf bucket", h: Histogram{Bounds: []float64{1}, Counts: []uint64{1}, Count: 1}},
+		{name: "unsorted bounds", h: Histogram{Bounds: []float64{2, 1}, Counts: []uint64{0, 0, 0}}},
+		{name: "count mismatch", h: Histogram{Bounds: []float64{1}, Counts: []uint64{1, 1}, Count: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.ErrorIs(t, tt.h.Validate(), ErrInvalidHistogram)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import "time"
+
+// Aggregation is the function used to combine the samples falling into the same step bucket.
+type Aggregation string
+
+// Supported aggregation functions for history range queries.
+const (
+	AggregationAvg  Aggregation = "avg"  // Average of the samples in the bucket.
+	AggregationMin  Aggregation = "min"  // Smallest sample in the bucket.
+	AggregationMax  Aggregation = "max"  // Largest sample in the bucket.
+	AggregationSum  Aggregation = "sum"  // Sum of the samples in the bucket.
+	AggregationLast Aggregation = "last" // Most recent sample in the bucket.
+)
+
+// IsValid reports whether the aggregation is one of the supported functions.
+func (a Aggregation) IsValid() bool {
+	switch a {
+	case AggregationAvg, AggregationMin, AggregationMax, AggregationSum, AggregationLast:
+		return true
+	default:
+		return false
+	}
+}
+
+// Sample is a single value of a series accepted at a point in time.
+// For counters the value is the accumulated total after the update was applied.
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricHistory holds the points of a single series returned by a range query.
+type MetricHistory struct {
+	Labels Labels   `json:"labels,omitempty"`
+	ID     string   `json:"id"`
+	MType  string   `json:"type"`
+	Points []Sample `json:"points"`
+}
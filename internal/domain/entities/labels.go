@@ -0,0 +1,131 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSeriesKey is returned when a series key cannot be parsed back into
+// a metric name and its labels.
+var ErrInvalidSeriesKey = errors.New("invalid series key")
+
+// Labels is an optional set of dimensions (e.g. host, env, service) attached to a metric.
+// A metric name together with its labels identifies a single series.
+type Labels map[string]string
+
+// String returns the canonical representation of the label set in the form
+// {k1="v1",k2="v2"} with the keys sorted. An empty label set yields an empty string,
+// so unlabelled series keep their plain metric name as identity.
+func (l Labels) String() string {
+	if len(l) == 0 {
+		return ""
+	}
+
+	keys := make([]string, 0, len(l))
+	for k := range l {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	sb.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(k)
+		sb.WriteByte('=')
+		sb.WriteString(strconv.Quote(l[k]))
+	}
+	sb.WriteByte('}')
+
+	return sb.String()
+}
+
+// SeriesKey builds the identity of a series from a metric name and its labels.
+// For a metric without labels the key is the metric name itself.
+func SeriesKey(name MetricName, labels Labels) MetricName {
+	return name + MetricName(labels.String())
+}
+
+// ParseSeriesKey splits a series key produced by SeriesKey back into the metric
+// name and its labels.
+func ParseSeriesKey(key MetricName) (MetricName, Labels, error) {
+	s := string(key)
+	idx := strings.IndexByte(s, '{')
+	if idx < 0 {
+		return key, nil, nil
+	}
+
+	labels, err := ParseLabels(s[idx:])
+	if err != nil {
+		return "", nil, fmt.Errorf("%w %q: %w", ErrInvalidSeriesKey, s, err)
+	}
+
+	return MetricName(s[:idx]), labels, nil
+}
+
+// ParseLabels parses the canonical label representation returned by Labels.String.
+// An empty string yields nil labels.
+func ParseLabels(s string) (Labels, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	if !strings.HasPrefix(s, "{") || !strings.HasSuffix(s, "}") {
+		return nil, errors.New("labels must be enclosed in curly braces")
+	}
+
+	body := s[1 : len(s)-1]
+	labels := make(Labels)
+	for body != "" {
+		eq := strings.IndexByte(body, '=')
+		if eq <= 0 {
+			return nil, errors.New("label name is missing")
+		}
+		name := body[:eq]
+		body = body[eq+1:]
+
+		quoted, err := strconv.QuotedPrefix(body)
+		if err != nil {
+			return nil, fmt.Errorf("label %s has malformed value: %w", name, err)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("label %s has malformed value: %w", name, err)
+		}
+		labels[name] = value
+		body = strings.TrimPrefix(body[len(quoted):], ",")
+	}
+
+	return labels, nil
+}
+
+// ParseLabelPairs parses a comma separated list of key=value pairs, as used on
+// the command line (e.g. "host=web-1,env=prod").
+func ParseLabelPairs(s string) (Labels, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+
+	labels := make(Labels)
+	for _, pair := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		k = strings.TrimSpace(k)
+		if !ok || k == "" {
+			return nil, fmt.Errorf("invalid label pair %q, expected key=value", pair)
+		}
+		labels[k] = strings.TrimSpace(v)
+	}
+
+	return labels, nil
+}
+
+// Key returns the series key of the metric, combining its ID with its labels.
+func (m Metrics) Key() MetricName {
+	return SeriesKey(MetricName(m.ID), m.Labels)
+}
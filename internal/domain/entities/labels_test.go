@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		name   string
+		metric MetricName
+		labels Labels
+		want   MetricName
+	}{
+		{
+			name:   "no labels",
+			metric: HeapAlloc,
+			labels: nil,
+			want:   "HeapAlloc",
+		},
+		{
+			name:   "sorted labels",
+			metric: HeapAlloc,
+			labels: Labels{"host": "web-1", "env": "prod"},
+			want:   `HeapAlloc{env="prod",host="web-1"}`,
+		},
+		{
+			name:   "value with special characters",
+			metric: Alloc,
+			labels: Labels{"service": `a,"b"}`},
+			want:   `Alloc{service="a,\"b\"}"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := SeriesKey(tt.metric, tt.labels)
+			assert.Equal(t, tt.want, key)
+
+			name, labels, err := ParseSeriesKey(key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.metric, name)
+			assert.Equal(t, len(tt.labels), len(labels))
+			for k, v := range tt.labels {
+				assert.Equal(t, v, labels[k])
+			}
+		})
+	}
+}
+
+func TestParseSeriesKeyInvalid(t *testing.T) {
+	_, _, err := ParseSeriesKey(`HeapAlloc{host=web-1}`)
+	require.ErrorIs(t, err, ErrInvalidSeriesKey)
+}
+
+func TestParseLabelPairs(t *testing.T) {
+	labels, err := ParseLabelPairs("host=web-1, env=prod")
+	require.NoError(t, err)
+	assert.Equal(t, Labels{"host": "web-1", "env": "prod"}, labels)
+
+	labels, err = ParseLabelPairs("")
+	require.NoError(t, err)
+	assert.Nil(t, labels)
+
+	_, err = ParseLabelPairs("host")
+	require.Error(t, err)
+}
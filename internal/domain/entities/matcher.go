@@ -0,0 +1,118 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// PatternSyntax is the syntax of a pattern selecting series, e.g. for a bulk delete.
+type PatternSyntax string
+
+// Supported pattern syntaxes.
+const (
+	PatternGlob  PatternSyntax = "glob"  // Shell-like pattern where * matches any run of characters and ? one character.
+	PatternRegex PatternSyntax = "regex" // Regular expression in the RE2 syntax.
+)
+
+// ErrInvalidPattern is returned when a series pattern is empty, has an unknown syntax or does not compile.
+var ErrInvalidPattern = errors.New("invalid series pattern")
+
+// SeriesMatcher selects series by matching a pattern against their series keys,
+// e.g. `cpu_*` or `*{host="web-1"}`. The pattern must match the whole key.
+type SeriesMatcher struct {
+	re  *regexp.Regexp
+	key func(MetricName) (MetricName, bool) // Optional mapping of the keys before they are matched
+}
+
+// NewSeriesMatcher compiles a pattern of the given syntax. An empty syntax defaults to glob.
+func NewSeriesMatcher(pattern string, syntax PatternSyntax) (*SeriesMatcher, error) {
+	if pattern == "" {
+		return nil, fmt.Errorf("%w: pattern must not be empty", ErrInvalidPattern)
+	}
+
+	switch syntax {
+	case "", PatternGlob:
+		pattern = globToRegexp(pattern)
+	case PatternRegex:
+	default:
+		return nil, fmt.Errorf("%w: unsupported syntax %s", ErrInvalidPattern, syntax)
+	}
+
+	re, err := regexp.Compile("^(?:" + pattern + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidPattern, err)
+	}
+
+	return &SeriesMatcher{re: re}, nil
+}
+
+// Match reports whether the series key matches the pattern.
+func (m *SeriesMatcher) Match(key MetricName) bool {
+	if m.key != nil {
+		var ok bool
+		if key, ok = m.key(key); !ok {
+			return false
+		}
+	}
+
+	return m.re.MatchString(string(key))
+}
+
+// Mapped returns a matcher applying the pattern to the keys mapped by fn, e.g. to match
+// the keys of a namespace without their namespace label. Keys for which fn reports
+// false never match.
+func (m *SeriesMatcher) Mapped(fn func(MetricName) (MetricName, bool)) *SeriesMatcher {
+	return &SeriesMatcher{re: m.re, key: fn}
+}
+
+// NamePrefix returns a prefix of the metric name of every series the pattern selects,
+// empty when it may select any name, so a storage can narrow its scans. Mapped matchers
+// return the prefix of the pattern, their mapping must keep the metric names.
+func (m *SeriesMatcher) NamePrefix() string {
+	prefix, _ := m.re.LiteralPrefix()
+	name, _, _ := strings.Cut(prefix, "{")
+
+	return name
+}
+
+// String returns the compiled regular expression.
+func (m *SeriesMatcher) String() string {
+	return m.re.String()
+}
+
+// globToRegexp translates a glob pattern into an equivalent regular expression.
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	for _, r := range glob {
+		switch r {
+		case '*':
+			sb.WriteString(".*")
+		case '?':
+			sb.WriteByte('.')
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+
+	return sb.String()
+}
+
+// DeleteRequest selects the series removed by a bulk delete.
+type DeleteRequest struct {
+	Pattern string        `json:"pattern"`        // Pattern matched against the whole series key.
+	Syntax  PatternSyntax `json:"syntax"`         // Syntax of the pattern, glob by default.
+	MType   string        `json:"type,omitempty"` // Type of the removed series, all types if empty.
+}
+
+// DeleteResult reports the outcome of a bulk delete.
+type DeleteResult struct {
+	Deleted int `json:"deleted"` // Number of removed series.
+}
+
+// ImportResult reports the outcome of an import.
+type ImportResult struct {
+	Imported int `json:"imported"` // Number of imported series.
+}
@@ -0,0 +1,67 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesMatcher(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		syntax  PatternSyntax
+		key     MetricName
+		want    bool
+	}{
+		{name: "glob prefix", pattern: "cpu_*", key: "cpu_user", want: true},
+		{name: "glob is anchored", pattern: "cpu", key: "cpu_user", want: false},
+		{name: "glob single character", pattern: "cpu?", key: "cpu1", want: true},
+		{name: "glob labels", pattern: `*{host="web-1"}`, syntax: PatternGlob, key: `Alloc{host="web-1"}`, want: true},
+		{name: "glob quotes metacharacters", pattern: "a.b", key: "axb", want: false},
+		{name: "regex", pattern: `Heap(Alloc|Idle)`, syntax: PatternRegex, key: "HeapIdle", want: true},
+		{name: "regex is anchored", pattern: `Heap`, syntax: PatternRegex, key: "HeapIdle", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewSeriesMatcher(tt.pattern, tt.syntax)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, m.Match(tt.key))
+		})
+	}
+}
+
+func TestSeriesMatcherInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		pattern string
+		syntax  PatternSyntax
+	}{
+		{pattern: "", syntax: PatternGlob},
+		{pattern: "a", syntax: "sql"},
+		{pattern: "(", syntax: PatternRegex},
+	} {
+		_, err := NewSeriesMatcher(tt.pattern, tt.syntax)
+		require.ErrorIs(t, err, ErrInvalidPattern)
+	}
+}
+
+func TestSeriesMatcherNamePrefix(t *testing.T) {
+	tests := []struct {
+		pattern string
+		syntax  PatternSyntax
+		want    string
+	}{
+		{pattern: "cpu_*", want: "cpu_"},
+		{pattern: `Alloc{host="a"}`, want: "Alloc"},
+		{pattern: "*_total", want: ""},
+		{pattern: `Heap(Alloc|Idle)`, syntax: PatternRegex, want: "Heap"},
+	}
+
+	for _, tt := range tests {
+		m, err := NewSeriesMatcher(tt.pattern, tt.syntax)
+		require.NoError(t, err)
+		assert.Equal(t, tt.want, m.NamePrefix(), tt.pattern)
+	}
+}
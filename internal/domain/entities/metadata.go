@@ -0,0 +1,174 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidMetadata is returned for metadata with an empty or labelled name, or an
+// unknown kind or display hint.
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// DisplayHint tells how the values of a metric are rendered for humans.
+type DisplayHint string
+
+// Supported display hints. The empty hint renders the raw value followed by the unit.
+const (
+	DisplayBytes    DisplayHint = "bytes"    // Size in bytes rendered with binary prefixes, e.g. 11.4 MiB.
+	DisplayDuration DisplayHint = "duration" // Duration in the unit of the metric (ns, us, ms or s), e.g. 1.5ms.
+	DisplayPercent  DisplayHint = "percent"  // Percentage rendered with two decimals, e.g. 12.50%.
+)
+
+// durationUnits maps the units a duration may be given in to their length.
+var durationUnits = map[string]time.Duration{
+	"ns":           time.Nanosecond,
+	"nanoseconds":  time.Nanosecond,
+	"us":           time.Microsecond,
+	"microseconds": time.Microsecond,
+	"ms":           time.Millisecond,
+	"milliseconds": time.Millisecond,
+	"s":            time.Second,
+	"seconds":      time.Second,
+}
+
+// Metadata describes a metric name, whatever the labels of its series: the unit of its
+// values, what it measures and how its values are displayed.
+type Metadata struct {
+	Name        MetricName  `json:"name"`                  // Name of the described metric, e.g. HeapAlloc.
+	Kind        MetricType  `json:"kind,omitempty"`        // Type of the metric, e.g. gauge.
+	Unit        string      `json:"unit,omitempty"`        // Unit of the values, e.g. bytes.
+	Description string      `json:"description,omitempty"` // Help text telling what the metric measures.
+	Display     DisplayHint `json:"display,omitempty"`     // How the values are rendered, raw when empty.
+}
+
+// Validate checks that the metadata names a metric without labels and uses a known
+// kind and display hint. A duration is only displayed for a metric with a time unit.
+func (md Metadata) Validate() error {
+	if md.Name == "" || strings.ContainsAny(string(md.Name), "{}") {
+		return fmt.Errorf("%w: name %q must be a metric name without labels", ErrInvalidMetadata, md.Name)
+	}
+
+	switch md.Kind {
+	case "", CounterMetricName, GaugeMetricName, HistogramMetricName, SummaryMetricName:
+	default:
+		return fmt.Errorf("%w: unknown kind %q of %s", ErrInvalidMetadata, md.Kind, md.Name)
+	}
+
+	switch md.Display {
+	case "", DisplayBytes, DisplayPercent:
+	case DisplayDuration:
+		if _, ok := durationUnits[md.Unit]; !ok {
+			return fmt.Errorf("%w: %s is displayed as a duration but its unit %q is not ns, us, ms or s",
+				ErrInvalidMetadata, md.Name, md.Unit)
+		}
+	default:
+		return fmt.Errorf("%w: unknown display hint %q of %s", ErrInvalidMetadata, md.Display, md.Name)
+	}
+
+	return nil
+}
+
+// Format renders a value of the metric following its display hint, e.g. 11.4 MiB
+// for 1.2e7 bytes, or the raw value followed by the unit without a hint.
+func (md Metadata) Format(v float64) string {
+	switch md.Display {
+	case DisplayBytes:
+		return formatBytes(v)
+	case DisplayDuration:
+		if unit, ok := durationUnits[md.Unit]; ok {
+			return time.Duration(v * float64(unit)).String()
+		}
+	case DisplayPercent:
+		return strconv.FormatFloat(v, 'f', 2, 64) + "%"
+	}
+
+	value := strconv.FormatFloat(v, 'g', -1, 64)
+	if md.Unit == "" {
+		return value
+	}
+
+	return value + " " + md.Unit
+}
+
+// formatBytes renders a size in bytes with the largest binary prefix keeping it above one.
+func formatBytes(v float64) string {
+	const unit = 1024
+	prefixes := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB"}
+
+	i := 0
+	for ; (v >= unit || v <= -unit) && i < len(prefixes)-1; i++ {
+		v /= unit
+	}
+	if i == 0 {
+		return strconv.FormatFloat(v, 'f', -1, 64) + " " + prefixes[i]
+	}
+
+	return strconv.FormatFloat(v, 'f', 1, 64) + " " + prefixes[i]
+}
+
+// RegisterMetadataResult reports the outcome of a metadata registration.
+type RegisterMetadataResult struct {
+	Registered int `json:"registered"` // Number of registered metric names.
+}
+
+// MetadataName returns the name of the metric of a series key, the name its metadata is
+// registered under. A key that cannot be parsed is returned as is.
+func MetadataName(key MetricName) MetricName {
+	name, _, err := ParseSeriesKey(key)
+	if err != nil {
+		return key
+	}
+
+	return name
+}
+
+// PredefinedMetadata returns the metadata of the metrics reported by the agent.
+func PredefinedMetadata() []Metadata {
+	gauge := func(name MetricName, unit string, display DisplayHint, description string) Metadata {
+		return Metadata{Name: name, Kind: GaugeMetricName, Unit: unit, Display: display, Description: description}
+	}
+	bytes := func(name MetricName, description string) Metadata {
+		return gauge(name, "bytes", DisplayBytes, description)
+	}
+
+	return []Metadata{
+		bytes(Alloc, "Bytes of allocated heap objects."),
+		bytes(BuckHashSys, "Bytes of memory in profiling bucket hash tables."),
+		gauge(Frees, "objects", "", "Cumulative count of heap objects freed."),
+		gauge(GCCPUFraction, "", "", "Fraction of the available CPU time used by the GC since the program started."),
+		bytes(GCSys, "Bytes of memory in garbage collection metadata."),
+		bytes(HeapAlloc, "Bytes of allocated heap objects."),
+		bytes(HeapIdle, "Bytes in idle (unused) heap spans."),
+		bytes(HeapInuse, "Bytes in in-use heap spans."),
+		gauge(HeapObjects, "objects", "", "Number of allocated heap objects."),
+		bytes(HeapReleased, "Bytes of physical memory returned to the OS."),
+		bytes(HeapSys, "Bytes of heap memory obtained from the OS."),
+		gauge(LastGC, "ns", "", "Time the last garbage collection finished, in nanoseconds since the Unix epoch."),
+		gauge(Lookups, "lookups", "", "Number of pointer lookups performed by the runtime."),
+		bytes(MCacheInuse, "Bytes of allocated mcache structures."),
+		bytes(MCacheSys, "Bytes of memory obtained from the OS for mcache structures."),
+		bytes(MSpanInuse, "Bytes of allocated mspan structures."),
+		bytes(MSpanSys, "Bytes of memory obtained from the OS for mspan structures."),
+		gauge(Mallocs, "objects", "", "Cumulative count of heap objects allocated."),
+		bytes(NextGC, "Target heap size of the next GC cycle."),
+		gauge(NumForcedGC, "cycles", "", "Number of GC cycles forced by the application calling runtime.GC."),
+		gauge(NumGC, "cycles", "", "Number of completed GC cycles."),
+		bytes(OtherSys, "Bytes of memory in miscellaneous off-heap runtime allocations."),
+		gauge(PauseTotalNs, "ns", DisplayDuration, "Cumulative time spent in GC stop-the-world pauses."),
+		bytes(StackInuse, "Bytes in stack spans."),
+		bytes(StackSys, "Bytes of stack memory obtained from the OS."),
+		bytes(Sys, "Total bytes of memory obtained from the OS."),
+		bytes(TotalAlloc, "Cumulative bytes allocated for heap objects."),
+		gauge(RandomValue, "", "", "Random value refreshed at every poll."),
+		{Name: PollCount, Kind: CounterMetricName, Unit: "polls", Description: "Number of metric polls of the agent."},
+		bytes(TotalMemory, "Total physical memory of the host."),
+		bytes(FreeMemory, "Free physical memory of the host."),
+		gauge(CPUutilization1, "percent", DisplayPercent, "CPU utilization of the host."),
+		{Name: GCPauseNs, Kind: HistogramMetricName, Unit: "ns", Description: "Garbage collection pause durations."},
+		{Name: ReportLatency, Kind: HistogramMetricName, Unit: "s", Description: "Latency of the agent reports."},
+	}
+}
@@ -0,0 +1,63 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadataValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		md      Metadata
+		wantErr bool
+	}{
+		{name: "name only", md: Metadata{Name: "custom"}},
+		{name: "bytes", md: Metadata{Name: "Alloc", Kind: GaugeMetricName, Unit: "bytes", Display: DisplayBytes}},
+		{name: "duration", md: Metadata{Name: "PauseTotalNs", Unit: "ns", Display: DisplayDuration}},
+		{name: "empty name", md: Metadata{Unit: "bytes"}, wantErr: true},
+		{name: "labelled name", md: Metadata{Name: `Alloc{host="web-1"}`}, wantErr: true},
+		{name: "unknown kind", md: Metadata{Name: "Alloc", Kind: "meter"}, wantErr: true},
+		{name: "unknown display", md: Metadata{Name: "Alloc", Display: "color"}, wantErr: true},
+		{name: "duration without time unit", md: Metadata{Name: "Alloc", Unit: "bytes", Display: DisplayDuration},
+			wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.md.Validate()
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidMetadata)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+
+	for _, md := range PredefinedMetadata() {
+		require.NoError(t, md.Validate(), md.Name)
+	}
+}
+
+func TestMetadataFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		md   Metadata
+		v    float64
+		want string
+	}{
+		{name: "raw", md: Metadata{}, v: 1.2e7, want: "1.2e+07"},
+		{name: "unit", md: Metadata{Unit: "polls"}, v: 42, want: "42 polls"},
+		{name: "small bytes", md: Metadata{Display: DisplayBytes}, v: 512, want: "512 B"},
+		{name: "bytes", md: Metadata{Display: DisplayBytes}, v: 1.2e7, want: "11.4 MiB"},
+		{name: "duration", md: Metadata{Unit: "ns", Display: DisplayDuration}, v: 1.5e6, want: "1.5ms"},
+		{name: "percent", md: Metadata{Unit: "percent", Display: DisplayPercent}, v: 12.5, want: "12.50%"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.md.Format(tt.v))
+		})
+	}
+}
@@ -10,23 +10,27 @@ type MetricName string
 // MetricType represents the type of a metric (e.g., gauge or counter).
 type MetricType string
 
-// MetricsCollection holds collections of gauge and counter metrics.
+// MetricsCollection holds collections of gauge, counter and histogram metrics.
 //
-// It uses maps to store gauge and counter metrics, with MetricName as the key.
+// It uses maps to store gauge, counter and histogram metrics, with MetricName as the key.
 type MetricsCollection struct {
 	GaugeMetrics   map[MetricName]Gauge   // GaugeMetrics maps metric names to their corresponding Gauge values.
 	CounterMetrics map[MetricName]Counter // CounterMetrics maps metric names to their corresponding Counter values.
+
+	// HistogramMetrics maps metric names to the histograms observed since they were last reported.
+	HistogramMetrics map[MetricName]*Histogram
 }
 
 // NewMetricsCollection creates a new, empty instance of MetricsCollection.
 //
-// It initializes the maps for storing gauge and counter metrics.
+// It initializes the maps for storing gauge, counter and histogram metrics.
 //
 // Returns:
 //   - *MetricsCollection: A pointer to an empty MetricsCollection instance.
 func NewMetricsCollection() *MetricsCollection {
 	return &MetricsCollection{
-		GaugeMetrics:   make(map[MetricName]Gauge),
-		CounterMetrics: make(map[MetricName]Counter),
+		GaugeMetrics:     make(map[MetricName]Gauge),
+		CounterMetrics:   make(map[MetricName]Counter),
+		HistogramMetrics: make(map[MetricName]*Histogram),
 	}
 }
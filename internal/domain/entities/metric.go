@@ -54,6 +54,8 @@ const (
 	TotalMemory     MetricName = "TotalMemory"     // Total memory allocated.
 	FreeMemory      MetricName = "FreeMemory"      // Total free memory available.
 	CPUutilization1 MetricName = "CPUutilization1" // Example metric for CPU utilization.
+	GCPauseNs       MetricName = "GCPauseNs"       // Histogram of garbage collection pauses in nanoseconds.
+	ReportLatency   MetricName = "ReportLatency"   // Histogram of the agent report latencies in seconds.
 )
 
 // GaugeMetric represents a gauge metric with its associated name and value.
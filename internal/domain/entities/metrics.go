@@ -9,14 +9,31 @@ package entities
 // - Delta: An optional pointer to an int64 that holds the value for metrics of type counter.
 // - Value: An optional pointer to a float64 that holds the value for metrics of type gauge.
 // - ID: A string that uniquely identifies the metric. This field is required.
-// - MType: A string that specifies the type of metric, which can be either "gauge" or "counter".
+// - MType: A string that specifies the type of metric, which can be "gauge", "counter", "histogram" or "summary".
+// - Labels: An optional set of dimensions that, together with ID, identifies the series.
+// - Histogram: An optional pointer to the buckets, sum and count of metrics of type histogram.
+// - Summary: An optional pointer to the quantile sketch of metrics of type summary.
+// - Total: An optional pointer to the raw monotonic total of a cumulative counter, sent instead of Delta.
+// - Rate: An optional pointer to the per-second rate of a counter, set in responses only.
 type Metrics struct {
 	// Value for metrics of type counter
 	Delta *int64 `json:"delta,omitempty" protobuf:"varint,4,opt,name=delta,proto3,oneof"`
+	// Raw total of a cumulative counter, the server derives the delta and detects the resets
+	Total *int64 `json:"total,omitempty"`
+	// Per-second rate of a counter over the rate window of the server
+	Rate *float64 `json:"rate,omitempty"`
 	// Value for metrics of type gauge
 	Value *float64 `json:"value,omitempty" protobuf:"fixed64,3,opt,name=value,proto3,oneof"`
 	// ID is the name of the metric
 	ID string `json:"id,omitempty" protobuf:"bytes,1,opt,name=id,proto3"`
 	// MType is the type of metric, which can be either "gauge" or "counter"
 	MType string `json:"type,omitempty" protobuf:"bytes,2,opt,name=m_type,json=mType,proto3"`
+	// Labels are optional dimensions (e.g. host, env, service) of the metric
+	Labels Labels `json:"labels,omitempty"`
+	// Value for metrics of type histogram
+	Histogram *Histogram `json:"histogram,omitempty"`
+	// Value for metrics of type summary
+	Summary *Summary `json:"summary,omitempty"`
+	// Metadata registered for the name of the metric, set in the responses only
+	Metadata *Metadata `json:"metadata,omitempty"`
 }
@@ -0,0 +1,168 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidQuota is returned when a quota cannot be parsed.
+	ErrInvalidQuota = errors.New("invalid quota")
+	// ErrQuotaExceeded is returned when a write would create more series than a quota allows.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrInvalidProxy is returned when a trusted proxy network cannot be parsed.
+	ErrInvalidProxy = errors.New("invalid trusted proxy")
+)
+
+// PrefixQuota limits the number of series whose key starts with a prefix.
+type PrefixQuota struct {
+	Prefix    string `json:"prefix"`     // Prefix of the limited series keys, e.g. cpu_.
+	MaxSeries int    `json:"max_series"` // Largest number of series starting with the prefix.
+}
+
+// QuotaPolicy limits the number of series a tenant may hold, so a faulty client
+// cannot exhaust the storage by sending ever new metric names or labels. Writes to
+// existing series are always accepted, only the creation of new series is limited.
+type QuotaPolicy struct {
+	// Largest number of series of a tenant, zero for no limit.
+	MaxSeries int `json:"max_series"`
+	// Largest number of series created by a single source, an agent or else a client IP,
+	// zero for no limit.
+	MaxSeriesPerSource int `json:"max_series_per_source"`
+	// Per prefix limits, a series counts towards every prefix it starts with.
+	Prefixes []PrefixQuota `json:"prefixes,omitempty"`
+}
+
+// NewQuotaPolicy creates a policy with the given limits and the prefix quotas given
+// in the format accepted by ParsePrefixQuotas.
+func NewQuotaPolicy(maxSeries, maxSeriesPerSource int, prefixes string) (*QuotaPolicy, error) {
+	if maxSeries < 0 || maxSeriesPerSource < 0 {
+		return nil, fmt.Errorf("%w: series limits must not be negative", ErrInvalidQuota)
+	}
+
+	parsed, err := ParsePrefixQuotas(prefixes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QuotaPolicy{MaxSeries: maxSeries, MaxSeriesPerSource: maxSeriesPerSource, Prefixes: parsed}, nil
+}
+
+// ParsePrefixQuotas parses semicolon separated prefix=limit pairs, e.g.
+// `cpu_=100;http_=500`. An empty string yields no quotas.
+func ParsePrefixQuotas(s string) ([]PrefixQuota, error) {
+	var quotas []PrefixQuota
+	for _, pair := range strings.Split(s, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		idx := strings.LastIndexByte(pair, '=')
+		if idx <= 0 {
+			return nil, fmt.Errorf("%w %q: expected prefix=limit", ErrInvalidQuota, pair)
+		}
+
+		limit, err := strconv.Atoi(strings.TrimSpace(pair[idx+1:]))
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("%w %q: the limit must be a positive integer", ErrInvalidQuota, pair)
+		}
+
+		quotas = append(quotas, PrefixQuota{Prefix: strings.TrimSpace(pair[:idx]), MaxSeries: limit})
+	}
+
+	return quotas, nil
+}
+
+// Enabled reports whether any limit is set. A nil policy sets no limit.
+func (p *QuotaPolicy) Enabled() bool {
+	return p != nil && (p.MaxSeries > 0 || p.MaxSeriesPerSource > 0 || len(p.Prefixes) > 0)
+}
+
+// QuotaUsage is the number of series a tenant holds, in total, by source and by
+// limited prefix, and the number of writes rejected for exceeding a quota.
+type QuotaUsage struct {
+	Sources  map[string]int `json:"sources,omitempty"`  // Series created by every known source.
+	Prefixes map[string]int `json:"prefixes,omitempty"` // Series starting with every limited prefix.
+	Tenant   string         `json:"tenant"`             // Tenant, empty for the default namespace.
+	Series   int            `json:"series"`             // Series of the tenant.
+	Rejected int            `json:"rejected"`           // Writes rejected for exceeding a quota.
+}
+
+// QuotaReport is the state of the quotas: the limits and the usage of every tenant.
+type QuotaReport struct {
+	Tenants []QuotaUsage `json:"tenants"` // Usage of the tenants sorted by name.
+	Limits  QuotaPolicy  `json:"limits"`  // Limits applied to every tenant.
+}
+
+// sourceKey is the context key of the source of a request.
+type sourceKey struct{}
+
+// WithSource returns a copy of ctx carrying the source of a request, the IP address of
+// the client. The series created by a request without an agent are counted towards it.
+func WithSource(ctx context.Context, source string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, source)
+}
+
+// SourceFromContext returns the source of a request, empty when it is unknown.
+func SourceFromContext(ctx context.Context) string {
+	source, _ := ctx.Value(sourceKey{}).(string)
+	return source
+}
+
+// TrustedProxies are the networks of the proxies trusted to tell the IP address of the
+// client of a request, e.g. in X-Real-IP. Clients cannot choose their source otherwise.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses comma separated networks in CIDR notation or single IP
+// addresses, e.g. `10.0.0.0/8,192.168.1.10`. An empty string trusts no proxy.
+func ParseTrustedProxies(s string) (TrustedProxies, error) {
+	var proxies TrustedProxies
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("%w %q: expected an IP address or a CIDR network", ErrInvalidProxy, item)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidProxy, item, err)
+		}
+		proxies = append(proxies, network)
+	}
+
+	return proxies, nil
+}
+
+// Contains reports whether the IP address is the address of a trusted proxy.
+func (tp TrustedProxies) Contains(addr string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range tp {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,55 @@
+package entities
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotaPolicy(t *testing.T) {
+	policy, err := NewQuotaPolicy(1000, 100, ` cpu_=10; http_=50 ;`)
+	require.NoError(t, err)
+	assert.True(t, policy.Enabled())
+	assert.Equal(t, []PrefixQuota{{Prefix: "cpu_", MaxSeries: 10}, {Prefix: "http_", MaxSeries: 50}}, policy.Prefixes)
+
+	var disabled *QuotaPolicy
+	assert.False(t, disabled.Enabled())
+	disabled, err = NewQuotaPolicy(0, 0, "")
+	require.NoError(t, err)
+	assert.False(t, disabled.Enabled())
+
+	_, err = NewQuotaPolicy(-1, 0, "")
+	require.ErrorIs(t, err, ErrInvalidQuota)
+	for _, quotas := range []string{"cpu_", "=5", "cpu_=many", "cpu_=0"} {
+		_, err = ParsePrefixQuotas(quotas)
+		require.ErrorIs(t, err, ErrInvalidQuota, quotas)
+	}
+}
+
+func TestSourceContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, SourceFromContext(ctx))
+	assert.Equal(t, "10.0.0.1", SourceFromContext(WithSource(ctx, "10.0.0.1")))
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(" 10.0.0.0/8, 192.168.1.10,::1 ")
+	require.NoError(t, err)
+	require.Len(t, proxies, 3)
+	assert.True(t, proxies.Contains("10.1.2.3"))
+	assert.True(t, proxies.Contains("192.168.1.10"))
+	assert.True(t, proxies.Contains("::1"))
+	assert.False(t, proxies.Contains("192.168.1.11"))
+	assert.False(t, proxies.Contains("not an ip"))
+
+	proxies, err = ParseTrustedProxies("")
+	require.NoError(t, err)
+	assert.False(t, proxies.Contains("10.1.2.3"), "no proxy is trusted by default")
+
+	for _, s := range []string{"10.0.0.0/33", "proxy.local"} {
+		_, err = ParseTrustedProxies(s)
+		require.ErrorIs(t, err, ErrInvalidProxy, s)
+	}
+}
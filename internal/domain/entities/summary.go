@@ -0,0 +1,400 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// SummaryMetricName is the metric type of summaries.
+const SummaryMetricName MetricType = "summary"
+
+// DefaultSummaryAccuracy is the relative accuracy of the quantiles estimated by a summary.
+const DefaultSummaryAccuracy = 0.01
+
+// summaryEncodingVersion is the version of the binary encoding of a summary.
+const summaryEncodingVersion byte = 1
+
+// minIndexableValue is the smallest magnitude tracked in the sketch buckets,
+// smaller values are counted as zero.
+const minIndexableValue = 1e-9
+
+var (
+	// ErrInvalidSummary is returned when a summary is malformed, e.g. its accuracy is out
+	// of range or its bucket counts do not add up to its count.
+	ErrInvalidSummary = errors.New("invalid summary")
+
+	// ErrSummaryAccuracyMismatch is returned when merging summaries with different relative accuracy.
+	ErrSummaryAccuracyMismatch = errors.New("summary relative accuracy does not match")
+)
+
+// SummaryQuantiles are the quantiles reported for every summary.
+var SummaryQuantiles = []float64{0.5, 0.9, 0.99}
+
+// Summary is a mergeable quantile sketch (DDSketch). Observed values are counted in
+// logarithmically sized buckets, so any quantile is estimated within the relative
+// accuracy of the sketch, and sketches from many agents are merged by adding up
+// their bucket counts.
+type Summary struct {
+	Positive         map[int]uint64 `json:"positive,omitempty"` // Counts of positive values per bucket index.
+	Negative         map[int]uint64 `json:"negative,omitempty"` // Counts of negative values per bucket index.
+	RelativeAccuracy float64        `json:"relative_accuracy"`  // Relative accuracy of the estimated quantiles.
+	Zero             uint64         `json:"zero,omitempty"`     // Number of values too close to zero to be indexed.
+	Count            uint64         `json:"count"`              // Total number of observations.
+	Sum              float64        `json:"sum"`                // Sum of all observed values.
+	Min              float64        `json:"min"`                // Smallest observed value.
+	Max              float64        `json:"max"`                // Largest observed value.
+}
+
+// NewSummary creates an empty summary with the given relative accuracy.
+func NewSummary(relativeAccuracy float64) *Summary {
+	return &Summary{
+		RelativeAccuracy: relativeAccuracy,
+		Positive:         make(map[int]uint64),
+		Negative:         make(map[int]uint64),
+	}
+}
+
+// gamma returns the growth factor of the bucket boundaries.
+func (s *Summary) gamma() float64 {
+	return (1 + s.RelativeAccuracy) / (1 - s.RelativeAccuracy)
+}
+
+// index returns the bucket of a positive value.
+func (s *Summary) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / math.Log(s.gamma())))
+}
+
+// value returns the estimate of the values falling into a bucket.
+func (s *Summary) value(index int) float64 {
+	g := s.gamma()
+	return 2 * math.Pow(g, float64(index)) / (g + 1)
+}
+
+// Observe adds a single value to the summary.
+func (s *Summary) Observe(v float64) {
+	switch {
+	case v > minIndexableValue:
+		if s.Positive == nil {
+			s.Positive = make(map[int]uint64)
+		}
+		s.Positive[s.index(v)]++
+	case v < -minIndexableValue:
+		if s.Negative == nil {
+			s.Negative = make(map[int]uint64)
+		}
+		s.Negative[s.index(-v)]++
+	default:
+		s.Zero++
+	}
+
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Count++
+	s.Sum += v
+}
+
+// Validate checks that the accuracy is within (0, 1) and that the bucket counts
+// add up to the total count.
+func (s *Summary) Validate() error {
+	if !(s.RelativeAccuracy > 0 && s.RelativeAccuracy < 1) {
+		return fmt.Errorf("%w: relative accuracy must be within (0, 1)", ErrInvalidSummary)
+	}
+
+	total := s.Zero
+	for _, c := range s.Positive {
+		total += c
+	}
+	for _, c := range s.Negative {
+		total += c
+	}
+	if total != s.Count {
+		return fmt.Errorf("%w: bucket counts add up to %d, count is %d", ErrInvalidSummary, total, s.Count)
+	}
+
+	return nil
+}
+
+// Merge adds the observations of other to the summary. Both summaries must have
+// the same relative accuracy. An empty summary adopts the accuracy of other.
+func (s *Summary) Merge(other *Summary) error {
+	if err := other.Validate(); err != nil {
+		return err
+	}
+
+	if s.RelativeAccuracy == 0 && s.Count == 0 {
+		*s = *other.Clone()
+		return nil
+	}
+
+	if s.RelativeAccuracy != other.RelativeAccuracy {
+		return ErrSummaryAccuracyMismatch
+	}
+
+	if other.Count == 0 {
+		return nil
+	}
+
+	if s.Positive == nil {
+		s.Positive = make(map[int]uint64)
+	}
+	if s.Negative == nil {
+		s.Negative = make(map[int]uint64)
+	}
+	for i, c := range other.Positive {
+		s.Positive[i] += c
+	}
+	for i, c := range other.Negative {
+		s.Negative[i] += c
+	}
+
+	if s.Count == 0 || other.Min < s.Min {
+		s.Min = other.Min
+	}
+	if s.Count == 0 || other.Max > s.Max {
+		s.Max = other.Max
+	}
+	s.Zero += other.Zero
+	s.Count += other.Count
+	s.Sum += other.Sum
+
+	return nil
+}
+
+// Clone returns a deep copy of the summary.
+func (s *Summary) Clone() *Summary {
+	c := *s
+	c.Positive = make(map[int]uint64, len(s.Positive))
+	for i, v := range s.Positive {
+		c.Positive[i] = v
+	}
+	c.Negative = make(map[int]uint64, len(s.Negative))
+	for i, v := range s.Negative {
+		c.Negative[i] = v
+	}
+
+	return &c
+}
+
+// Quantile estimates the value at quantile q, which must be within [0, 1].
+// It returns NaN for an empty summary.
+func (s *Summary) Quantile(q float64) float64 {
+	if s.Count == 0 || q < 0 || q > 1 {
+		return math.NaN()
+	}
+
+	rank := q * float64(s.Count-1)
+	var cumulative uint64
+
+	// Negative values come first, the largest magnitude being the smallest value.
+	negative := sortedIndexes(s.Negative)
+	for i := len(negative) - 1; i >= 0; i-- {
+		cumulative += s.Negative[negative[i]]
+		if float64(cumulative) > rank {
+			return s.clamp(-s.value(negative[i]))
+		}
+	}
+
+	cumulative += s.Zero
+	if float64(cumulative) > rank {
+		return 0
+	}
+
+	for _, i := range sortedIndexes(s.Positive) {
+		cumulative += s.Positive[i]
+		if float64(cumulative) > rank {
+			return s.clamp(s.value(i))
+		}
+	}
+
+	return s.Max
+}
+
+// clamp keeps an estimate within the exact observed range.
+func (s *Summary) clamp(v float64) float64 {
+	return math.Max(s.Min, math.Min(s.Max, v))
+}
+
+// Quantiles returns the estimates of SummaryQuantiles keyed as p50, p90 and p99.
+// It returns nil for an empty summary.
+func (s *Summary) Quantiles() map[string]float64 {
+	if s.Count == 0 {
+		return nil
+	}
+
+	quantiles := make(map[string]float64, len(SummaryQuantiles))
+	for _, q := range SummaryQuantiles {
+		quantiles[quantileName(q)] = s.Quantile(q)
+	}
+
+	return quantiles
+}
+
+// String returns a plain text representation of the summary, one line per
+// reported quantile followed by the sum and the count.
+func (s *Summary) String() string {
+	var sb strings.Builder
+	if s.Count > 0 {
+		for _, q := range SummaryQuantiles {
+			fmt.Fprintf(&sb, "%s %v\n", quantileName(q), s.Quantile(q))
+		}
+	}
+	fmt.Fprintf(&sb, "sum %v\ncount %d", s.Sum, s.Count)
+
+	return sb.String()
+}
+
+// MarshalJSON encodes the sketch together with its reported quantiles.
+// The quantiles are informative only and ignored when decoding.
+func (s *Summary) MarshalJSON() ([]byte, error) {
+	type sketch Summary
+	data, err := json.Marshal(struct {
+		*sketch
+		Quantiles map[string]float64 `json:"quantiles,omitempty"`
+	}{
+		sketch:    (*sketch)(s),
+		Quantiles: s.Quantiles(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal summary: %w", err)
+	}
+
+	return data, nil
+}
+
+// MarshalBinary encodes the summary in a compact binary form, as stored by the database.
+func (s *Summary) MarshalBinary() ([]byte, error) {
+	data := []byte{summaryEncodingVersion}
+	for _, f := range []float64{s.RelativeAccuracy, s.Sum, s.Min, s.Max} {
+		data = binary.BigEndian.AppendUint64(data, math.Float64bits(f))
+	}
+	data = binary.AppendUvarint(data, s.Zero)
+	data = binary.AppendUvarint(data, s.Count)
+	data = appendBins(data, s.Positive)
+	data = appendBins(data, s.Negative)
+
+	return data, nil
+}
+
+// UnmarshalBinary decodes a summary encoded by MarshalBinary.
+func (s *Summary) UnmarshalBinary(data []byte) error {
+	const floatsCount = 4
+	const floatSize = 8
+	if len(data) < 1+floatsCount*floatSize || data[0] != summaryEncodingVersion {
+		return fmt.Errorf("%w: unsupported encoding", ErrInvalidSummary)
+	}
+
+	floats := make([]float64, floatsCount)
+	for i := range floats {
+		floats[i] = math.Float64frombits(binary.BigEndian.Uint64(data[1+i*floatSize:]))
+	}
+	r := &binReader{data: data[1+floatsCount*floatSize:]}
+
+	decoded := Summary{
+		RelativeAccuracy: floats[0],
+		Sum:              floats[1],
+		Min:              floats[2],
+		Max:              floats[3],
+		Zero:             r.uvarint(),
+		Count:            r.uvarint(),
+		Positive:         r.bins(),
+		Negative:         r.bins(),
+	}
+	if r.err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidSummary, r.err)
+	}
+
+	*s = decoded
+	return nil
+}
+
+// appendBins appends the number of bins followed by the index and count of every bin,
+// sorted by index so equal summaries have equal encodings.
+func appendBins(data []byte, bins map[int]uint64) []byte {
+	data = binary.AppendUvarint(data, uint64(len(bins)))
+	for _, i := range sortedIndexes(bins) {
+		data = binary.AppendVarint(data, int64(i))
+		data = binary.AppendUvarint(data, bins[i])
+	}
+
+	return data
+}
+
+// binReader decodes the varints of an encoded summary, remembering the first error.
+type binReader struct {
+	err  error
+	data []byte
+}
+
+// uvarint reads an unsigned varint.
+func (r *binReader) uvarint() uint64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.err = errors.New("truncated data")
+		return 0
+	}
+	r.data = r.data[n:]
+
+	return v
+}
+
+// varint reads a signed varint.
+func (r *binReader) varint() int64 {
+	if r.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.err = errors.New("truncated data")
+		return 0
+	}
+	r.data = r.data[n:]
+
+	return v
+}
+
+// bins reads a set of bins written by appendBins.
+func (r *binReader) bins() map[int]uint64 {
+	n := r.uvarint()
+	if uint64(len(r.data)) < n {
+		r.err = errors.New("truncated data")
+		return nil
+	}
+
+	bins := make(map[int]uint64, n)
+	for range n {
+		i := r.varint()
+		bins[int(i)] = r.uvarint()
+	}
+
+	return bins
+}
+
+// sortedIndexes returns the bucket indexes in ascending order.
+func sortedIndexes(bins map[int]uint64) []int {
+	indexes := make([]int, 0, len(bins))
+	for i := range bins {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	return indexes
+}
+
+// quantileName formats a quantile as a percentile name, e.g. 0.99 as p99.
+func quantileName(q float64) string {
+	return "p" + strconv.FormatFloat(q*100, 'g', -1, 64)
+}
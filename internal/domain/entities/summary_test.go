@@ -0,0 +1,69 @@
+package entities
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummaryQuantiles(t *testing.T) {
+	s := NewSummary(DefaultSummaryAccuracy)
+	for i := 1; i <= 1000; i++ {
+		s.Observe(float64(i))
+	}
+
+	for q, want := range map[float64]float64{0.5: 500, 0.9: 900, 0.99: 990} {
+		assert.InEpsilon(t, want, s.Quantile(q), 2*DefaultSummaryAccuracy, "quantile %v", q)
+	}
+	assert.InDelta(t, 1, s.Quantile(0), 0)
+	assert.InDelta(t, 1000, s.Quantile(1), 0)
+	assert.True(t, math.IsNaN(NewSummary(DefaultSummaryAccuracy).Quantile(0.5)))
+	assert.Len(t, s.Quantiles(), len(SummaryQuantiles))
+}
+
+func TestSummaryMerge(t *testing.T) {
+	a := NewSummary(DefaultSummaryAccuracy)
+	b := NewSummary(DefaultSummaryAccuracy)
+	for i := 1; i <= 500; i++ {
+		a.Observe(float64(i))
+		b.Observe(float64(i + 500))
+	}
+
+	merged := &Summary{}
+	require.NoError(t, merged.Merge(a))
+	require.NoError(t, merged.Merge(b))
+	assert.Equal(t, uint64(1000), merged.Count)
+	assert.InDelta(t, 1, merged.Min, 0)
+	assert.InDelta(t, 1000, merged.Max, 0)
+	assert.InEpsilon(t, 990, merged.Quantile(0.99), 2*DefaultSummaryAccuracy)
+	assert.Equal(t, uint64(500), a.Count, "merge must not modify the source")
+
+	require.ErrorIs(t, merged.Merge(NewSummary(0.05)), ErrSummaryAccuracyMismatch)
+	require.ErrorIs(t, merged.Merge(&Summary{RelativeAccuracy: DefaultSummaryAccuracy, Count: 1}), ErrInvalidSummary)
+}
+
+func TestSummaryEncoding(t *testing.T) {
+	s := NewSummary(DefaultSummaryAccuracy)
+	for _, v := range []float64{-3, 0, 0.25, 7, 1e6} {
+		s.Observe(v)
+	}
+
+	data, err := s.MarshalBinary()
+	require.NoError(t, err)
+
+	decoded := &Summary{}
+	require.NoError(t, decoded.UnmarshalBinary(data))
+	assert.Equal(t, s, decoded)
+	require.ErrorIs(t, decoded.UnmarshalBinary(data[:len(data)-1]), ErrInvalidSummary)
+
+	payload, err := json.Marshal(s)
+	require.NoError(t, err)
+	assert.Contains(t, string(payload), `"p99"`)
+
+	fromJSON := &Summary{}
+	require.NoError(t, json.Unmarshal(payload, fromJSON))
+	assert.Equal(t, s, fromJSON)
+}
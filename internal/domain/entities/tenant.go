@@ -0,0 +1,85 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TenantLabel is the reserved label holding the tenant of a series. The storage sets it
+// from the tenant of the request, so a label of that name sent by a client is ignored.
+const TenantLabel = "__tenant__"
+
+var (
+	// ErrInvalidTenant is returned for a tenant name that does not match tenantNameRe.
+	ErrInvalidTenant = errors.New("invalid tenant name")
+	// ErrTenantExists is returned when a tenant is created twice.
+	ErrTenantExists = errors.New("tenant already exists")
+	// ErrUnknownTenant is returned for an API key or tenant name that matches no tenant.
+	ErrUnknownTenant = errors.New("unknown tenant")
+)
+
+// withoutTenant returns a series key without its tenant label.
+func withoutTenant(key MetricName) (MetricName, bool) {
+	if !strings.Contains(string(key), TenantLabel+"=") {
+		return key, true
+	}
+
+	name, labels, err := ParseSeriesKey(key)
+	if err != nil {
+		return key, true
+	}
+	delete(labels, TenantLabel)
+
+	return SeriesKey(name, labels), true
+}
+
+// tenantNameRe matches the valid tenant names, e.g. team-a.
+var tenantNameRe = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// Tenant is a team sharing the server. Its series live in their own namespace,
+// so they never collide with the series of the other tenants.
+type Tenant struct {
+	CreatedAt time.Time `json:"created_at"` // Time the tenant was created.
+	Name      string    `json:"name"`       // Name of the tenant, e.g. team-a.
+}
+
+// TenantCredentials is a new tenant together with its API key, which is only returned once.
+type TenantCredentials struct {
+	APIKey string `json:"api_key"` // Key sent in the X-API-Key header by the clients of the tenant.
+	Tenant
+}
+
+// CreateTenantRequest is the body of a request creating a tenant.
+type CreateTenantRequest struct {
+	Name string `json:"name"` // Name of the new tenant.
+}
+
+// ValidateTenantName checks that name is made of lowercase letters, digits, '-' and '_'
+// and starts with a letter or a digit.
+func ValidateTenantName(name string) error {
+	if !tenantNameRe.MatchString(name) {
+		return fmt.Errorf("%w %q: use up to 63 lowercase letters, digits, '-' and '_'", ErrInvalidTenant, name)
+	}
+
+	return nil
+}
+
+// tenantKey is the context key of the tenant of a request.
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant of a request. An empty name
+// stands for the default namespace, used by the requests without a tenant.
+func WithTenant(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, name)
+}
+
+// TenantFromContext returns the tenant of a request, empty for the default namespace.
+func TenantFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(tenantKey{}).(string)
+	return name
+}
@@ -0,0 +1,140 @@
+// Package entities defines the data structures used for metrics in the metrics service.
+package entities
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidTTLRule is returned when a TTL rule cannot be parsed.
+var ErrInvalidTTLRule = errors.New("invalid ttl rule")
+
+// TTLRule overrides the default TTL for the series whose key matches a glob pattern.
+type TTLRule struct {
+	Matcher *SeriesMatcher // Series selected by the rule.
+	TTL     time.Duration  // Time to live of the selected series, zero keeps them forever.
+}
+
+// TTLPolicy decides when a series that has not been updated expires. Expired series
+// are hidden from reads until they are purged or updated again.
+type TTLPolicy struct {
+	Rules   []TTLRule     // Per pattern overrides, the first matching rule wins.
+	Default time.Duration // TTL of the series not matched by any rule, zero keeps them forever.
+}
+
+// NewTTLPolicy creates a policy with the default TTL and the rules given in the
+// format accepted by ParseTTLRules.
+func NewTTLPolicy(defaultTTL time.Duration, rules string) (*TTLPolicy, error) {
+	if defaultTTL < 0 {
+		return nil, fmt.Errorf("%w: ttl must not be negative", ErrInvalidTTLRule)
+	}
+
+	parsed, err := ParseTTLRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TTLPolicy{Default: defaultTTL, Rules: parsed}, nil
+}
+
+// ParseTTLRules parses semicolon separated pattern=ttl pairs, e.g.
+// `cpu_*=5m;*{env="dev"}=1m`. The patterns are globs matched against the whole
+// series key without its tenant label, so the rules apply to the series of every
+// tenant, and the TTLs are Go durations. An empty string yields no rules.
+func ParseTTLRules(s string) ([]TTLRule, error) {
+	var rules []TTLRule
+	for _, pair := range strings.Split(s, ";") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		idx := strings.LastIndexByte(pair, '=')
+		if idx <= 0 {
+			return nil, fmt.Errorf("%w %q: expected pattern=ttl", ErrInvalidTTLRule, pair)
+		}
+
+		ttl, err := time.ParseDuration(strings.TrimSpace(pair[idx+1:]))
+		if err != nil || ttl < 0 {
+			return nil, fmt.Errorf("%w %q: invalid duration", ErrInvalidTTLRule, pair)
+		}
+
+		matcher, err := NewSeriesMatcher(strings.TrimSpace(pair[:idx]), PatternGlob)
+		if err != nil {
+			return nil, fmt.Errorf("%w %q: %w", ErrInvalidTTLRule, pair, err)
+		}
+
+		rules = append(rules, TTLRule{Matcher: matcher.Mapped(withoutTenant), TTL: ttl})
+	}
+
+	return rules, nil
+}
+
+// Enabled reports whether any series can expire. A nil policy never expires series.
+func (p *TTLPolicy) Enabled() bool {
+	if p == nil {
+		return false
+	}
+
+	if p.Default > 0 {
+		return true
+	}
+	for _, r := range p.Rules {
+		if r.TTL > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// TTL returns the time to live of a series, zero if it never expires.
+func (p *TTLPolicy) TTL(key MetricName) time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	for _, r := range p.Rules {
+		if r.Matcher.Match(key) {
+			return r.TTL
+		}
+	}
+
+	return p.Default
+}
+
+// Shortest returns the shortest positive TTL of the policy, zero if no series expires.
+// The series updated within it have not expired, whatever their key.
+func (p *TTLPolicy) Shortest() time.Duration {
+	if p == nil {
+		return 0
+	}
+
+	shortest := p.Default
+	for _, r := range p.Rules {
+		if r.TTL > 0 && (shortest == 0 || r.TTL < shortest) {
+			shortest = r.TTL
+		}
+	}
+
+	return shortest
+}
+
+// Expired reports whether a series last updated at updatedAt has expired at now.
+func (p *TTLPolicy) Expired(key MetricName, updatedAt, now time.Time) bool {
+	ttl := p.TTL(key)
+	return ttl > 0 && now.Sub(updatedAt) > ttl
+}
+
+// Cutoff returns the oldest update time at which a series is still alive at now,
+// or the zero time if the series never expires.
+func (p *TTLPolicy) Cutoff(key MetricName, now time.Time) time.Time {
+	ttl := p.TTL(key)
+	if ttl == 0 {
+		return time.Time{}
+	}
+
+	return now.Add(-ttl)
+}
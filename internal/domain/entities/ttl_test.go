@@ -0,0 +1,51 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTTLPolicy(t *testing.T) {
+	policy, err := NewTTLPolicy(time.Hour, `cpu_*=5m; *{env="dev"}=1m ;Alloc=0s`)
+	require.NoError(t, err)
+	require.True(t, policy.Enabled())
+
+	assert.Equal(t, 5*time.Minute, policy.TTL("cpu_user"))
+	assert.Equal(t, time.Minute, policy.TTL(`HeapAlloc{env="dev"}`))
+	assert.Equal(t, time.Duration(0), policy.TTL("Alloc"))
+	assert.Equal(t, time.Hour, policy.TTL("HeapAlloc"))
+
+	// The rules apply to the series of the tenants, whatever their tenant label.
+	assert.Equal(t, 5*time.Minute, policy.TTL(`cpu_user{__tenant__="team-a"}`))
+	assert.Equal(t, time.Minute, policy.TTL(`HeapAlloc{__tenant__="team-a",env="dev"}`))
+	assert.Equal(t, time.Duration(0), policy.TTL(`Alloc{__tenant__="team-a"}`))
+
+	now := time.Now()
+	assert.True(t, policy.Expired("cpu_user", now.Add(-6*time.Minute), now))
+	assert.False(t, policy.Expired("HeapAlloc", now.Add(-6*time.Minute), now))
+	assert.False(t, policy.Expired("Alloc", now.Add(-24*time.Hour), now))
+	assert.Equal(t, now.Add(-time.Hour), policy.Cutoff("HeapAlloc", now))
+	assert.True(t, policy.Cutoff("Alloc", now).IsZero())
+	assert.Equal(t, time.Minute, policy.Shortest())
+}
+
+func TestTTLPolicyDisabled(t *testing.T) {
+	var policy *TTLPolicy
+	assert.False(t, policy.Enabled())
+	assert.Zero(t, policy.Shortest())
+	assert.False(t, policy.Expired("Alloc", time.Time{}, time.Now()))
+
+	policy, err := NewTTLPolicy(0, "")
+	require.NoError(t, err)
+	assert.False(t, policy.Enabled())
+}
+
+func TestParseTTLRulesInvalid(t *testing.T) {
+	for _, rules := range []string{"cpu_*", "=5m", "cpu_*=soon", "cpu_*=-1m"} {
+		_, err := ParseTTLRules(rules)
+		require.ErrorIs(t, err, ErrInvalidTTLRule, rules)
+	}
+}
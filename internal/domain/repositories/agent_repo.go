@@ -18,6 +18,18 @@ type MetricsCollectionRepository interface {
 	// GetAll retrieves all metrics from the repository.
 	// It returns a pointer to a MetricsCollection and an error if the operation fails.
 	GetAll() (*entities.MetricsCollection, error)
+
+	// ObserveHistogram adds the values to the named histogram, creating it with the given bounds if needed.
+	// It returns an error if the operation fails.
+	ObserveHistogram(mName entities.MetricName, bounds []float64, values ...float64) error
+
+	// TakeHistograms retrieves the histograms observed since the last call and resets them.
+	// It returns an error if the operation fails.
+	TakeHistograms() (map[entities.MetricName]*entities.Histogram, error)
+
+	// RestoreHistograms merges histograms returned by TakeHistograms back into the repository.
+	// It returns an error if the operation fails.
+	RestoreHistograms(histograms map[entities.MetricName]*entities.Histogram) error
 }
 
 // AgentRepository is a concrete implementation of the MetricsCollectionRepository interface.
@@ -82,3 +82,37 @@ func (m *MetricsCollectionMemRepository) GetAll() (*entities.MetricsCollection,
 
 	return &newMetricsCollection, nil
 }
+
+// ObserveHistogram adds the values to the named histogram, creating it with
+// the given bucket bounds if it does not exist yet.
+func (m *MetricsCollectionMemRepository) ObserveHistogram(mName entities.MetricName,
+	bounds []float64, values ...float64) error {
+	if err := m.store.ObserveHistogram(mName, bounds, values...); err != nil {
+		return fmt.Errorf("failed to observe the histogram: %w", err)
+	}
+
+	return nil
+}
+
+// TakeHistograms retrieves the histograms observed since the last call and
+// resets them. Returns an error if retrieval fails.
+func (m *MetricsCollectionMemRepository) TakeHistograms() (map[entities.MetricName]*entities.Histogram, error) {
+	histograms, err := m.store.TakeHistogramCollection()
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve the Histogram collection: %w", err)
+	}
+
+	return histograms, nil
+}
+
+// RestoreHistograms merges histograms returned by TakeHistograms back into
+// the repository, so they are retrieved again with the next ones.
+// Returns an error if some of them cannot be merged.
+func (m *MetricsCollectionMemRepository) RestoreHistograms(
+	histograms map[entities.MetricName]*entities.Histogram) error {
+	if err := m.store.RestoreHistogramCollection(histograms); err != nil {
+		return fmt.Errorf("failed to restore the Histogram collection: %w", err)
+	}
+
+	return nil
+}
@@ -6,8 +6,10 @@
 package repositories
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
@@ -28,8 +30,8 @@ func NewMetricsMemRepository(store storage.Storage) *MetricsMemRepository {
 
 // Create saves a new metric record in the repository. It returns an error
 // if the creation fails.
-func (cmr *MetricsMemRepository) Create(metric entities.Metrics) error {
-	err := cmr.store.CreateRecord(metric)
+func (cmr *MetricsMemRepository) Create(ctx context.Context, metric entities.Metrics) error {
+	err := cmr.store.CreateRecord(ctx, metric)
 	if err != nil {
 		return fmt.Errorf("failed to create the record: %w", err)
 	}
@@ -39,8 +41,9 @@ func (cmr *MetricsMemRepository) Create(metric entities.Metrics) error {
 
 // Get retrieves a metric record by its key and type. It returns an error
 // if the item is not found or if retrieval fails.
-func (cmr *MetricsMemRepository) Get(key entities.MetricName, mType entities.MetricType) (entities.Metrics, error) {
-	record, err := cmr.store.GetRecord(key, mType)
+func (cmr *MetricsMemRepository) Get(ctx context.Context, key entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	record, err := cmr.store.GetRecord(ctx, key, mType)
 
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
@@ -55,8 +58,8 @@ func (cmr *MetricsMemRepository) Get(key entities.MetricName, mType entities.Met
 
 // GetAll retrieves all metrics records from the repository. It returns a
 // MetricsStorage object or an error if retrieval fails.
-func (cmr *MetricsMemRepository) GetAll() (*storage.MetricsStorage, error) {
-	store, err := cmr.store.GetAllRecords()
+func (cmr *MetricsMemRepository) GetAll(ctx context.Context) (*storage.MetricsStorage, error) {
+	store, err := cmr.store.GetAllRecords(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the metrics: %w", err)
 	}
@@ -67,9 +70,9 @@ func (cmr *MetricsMemRepository) GetAll() (*storage.MetricsStorage, error) {
 // GetAllByType retrieves all metrics of a specific type from the repository.
 // It returns a map of metric names to their corresponding metrics or an error
 // if retrieval fails.
-func (cmr *MetricsMemRepository) GetAllByType(mType entities.MetricType) (
+func (cmr *MetricsMemRepository) GetAllByType(ctx context.Context, mType entities.MetricType) (
 	map[entities.MetricName]entities.Metrics, error) {
-	metrics, err := cmr.store.GetAllRecordsByType(mType)
+	metrics, err := cmr.store.GetAllRecordsByType(ctx, mType)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the metrics: %w", err)
 	}
@@ -79,10 +82,101 @@ func (cmr *MetricsMemRepository) GetAllByType(mType entities.MetricType) (
 
 // StoreMetricsBatch stores a batch of metric records in the repository.
 // It returns an error if the batch storage operation fails.
-func (cmr *MetricsMemRepository) StoreMetricsBatch(metrics []entities.Metrics) error {
-	if err := cmr.store.StoreMetricsBatch(metrics); err != nil {
+func (cmr *MetricsMemRepository) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	if err := cmr.store.StoreMetricsBatch(ctx, metrics); err != nil {
 		return fmt.Errorf("mem storage error: %w", err)
 	}
 
 	return nil
 }
+
+// GetHistory retrieves the samples of a series recorded within [from, to].
+// It returns an error if the series is not found or if retrieval fails.
+func (cmr *MetricsMemRepository) GetHistory(ctx context.Context, key entities.MetricName, mType entities.MetricType,
+	from, to time.Time) ([]entities.Sample, error) {
+	samples, err := cmr.store.GetHistory(ctx, key, mType, from, to)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, fmt.Errorf("history for key %s was not found: %w", key, err)
+		}
+
+		return nil, fmt.Errorf("failed to get the history: %w", err)
+	}
+
+	return samples, nil
+}
+
+// Delete removes a metric record and its history. It returns an error
+// if the item is not found or if the removal fails.
+func (cmr *MetricsMemRepository) Delete(ctx context.Context, key entities.MetricName, mType entities.MetricType) error {
+	if err := cmr.store.DeleteRecord(ctx, key, mType); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("item with key %s was not found: %w", key, err)
+		}
+
+		return fmt.Errorf("failed to delete the item: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteMatching removes the metric records whose series key matches the matcher.
+// It returns the number of removed records or an error if the removal fails.
+func (cmr *MetricsMemRepository) DeleteMatching(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	deleted, err := cmr.store.DeleteRecords(ctx, mType, matcher)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete the items: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// Import replaces the series of the given metric records with their values.
+// It returns the number of imported records or an error if the import fails.
+func (cmr *MetricsMemRepository) Import(ctx context.Context, metrics []entities.Metrics) (int, error) {
+	imported, err := storage.Import(ctx, cmr.store, metrics)
+	if err != nil {
+		return imported, fmt.Errorf("failed to import the items: %w", err)
+	}
+
+	return imported, nil
+}
+
+// SetMetadata registers the metadata of metric names in the repository.
+// It returns an error if the registration fails.
+func (cmr *MetricsMemRepository) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	if err := cmr.store.SetMetadata(ctx, metadata); err != nil {
+		return fmt.Errorf("failed to set the metadata: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetadata retrieves the metadata registered for a metric name. It returns
+// an error if no metadata is registered or if retrieval fails.
+func (cmr *MetricsMemRepository) GetMetadata(ctx context.Context, name entities.MetricName) (
+	entities.Metadata, error) {
+	md, err := cmr.store.GetMetadata(ctx, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return entities.Metadata{}, fmt.Errorf("metadata of %s was not found: %w", name, err)
+		}
+
+		return entities.Metadata{}, fmt.Errorf("failed to get the metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+// GetAllMetadata retrieves the registered metadata keyed by metric name.
+// It returns an error if retrieval fails.
+func (cmr *MetricsMemRepository) GetAllMetadata(ctx context.Context) (
+	map[entities.MetricName]entities.Metadata, error) {
+	metadata, err := cmr.store.GetAllMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the metadata: %w", err)
+	}
+
+	return metadata, nil
+}
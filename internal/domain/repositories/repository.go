@@ -2,31 +2,62 @@
 package repositories
 
 import (
+	"context"
+	"time"
+
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
 )
 
 // MetricsRepository defines the methods for interacting with metric records.
 // Implementations of this interface should provide concrete storage and retrieval mechanisms.
+// Every method passes its context down to the storage, bounding the call.
 type MetricsRepository interface {
 	// Create stores a new metric record in the repository.
-	Create(metric entities.Metrics) error
+	Create(ctx context.Context, metric entities.Metrics) error
 
 	// Get retrieves a metric record based on the provided key and type.
 	// It returns the metric and an error if the operation fails.
-	Get(key entities.MetricName, mType entities.MetricType) (entities.Metrics, error)
+	Get(ctx context.Context, key entities.MetricName, mType entities.MetricType) (entities.Metrics, error)
 
 	// GetAll retrieves all metric records from the repository.
 	// It returns a pointer to MetricsStorage and an error if the operation fails.
-	GetAll() (*storage.MetricsStorage, error)
+	GetAll(ctx context.Context) (*storage.MetricsStorage, error)
 
 	// GetAllByType retrieves all metric records of a specific type from the repository.
 	// It returns a map of metric names to metrics and an error if the operation fails.
-	GetAllByType(mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error)
+	GetAllByType(ctx context.Context, mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error)
 
 	// StoreMetricsBatch stores a batch of metric records in the repository.
 	// It returns an error if the operation fails.
-	StoreMetricsBatch(metrics []entities.Metrics) error
+	StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error
+
+	// GetHistory retrieves the samples of a series recorded within the given time range.
+	// It returns the samples in chronological order and an error if the operation fails.
+	GetHistory(ctx context.Context, key entities.MetricName, mType entities.MetricType,
+		from, to time.Time) ([]entities.Sample, error)
+
+	// Delete removes a metric record and its history from the repository.
+	// It returns an error if the record is not found or if the operation fails.
+	Delete(ctx context.Context, key entities.MetricName, mType entities.MetricType) error
+
+	// DeleteMatching removes the metric records whose series key matches the matcher.
+	// An empty mType selects records of all types. It returns the number of removed records.
+	DeleteMatching(ctx context.Context, mType entities.MetricType, matcher *entities.SeriesMatcher) (int, error)
+
+	// Import replaces the series of the given metric records with their values, restoring
+	// counters to the given totals. It returns the number of imported records.
+	Import(ctx context.Context, metrics []entities.Metrics) (int, error)
+
+	// SetMetadata registers the metadata of metric names, replacing the metadata already registered.
+	SetMetadata(ctx context.Context, metadata []entities.Metadata) error
+
+	// GetMetadata retrieves the metadata registered for a metric name.
+	// It returns an error if no metadata is registered or if the operation fails.
+	GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error)
+
+	// GetAllMetadata retrieves the registered metadata keyed by metric name.
+	GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error)
 }
 
 // Repository is a struct that holds the MetricsRepository interface.
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+)
+
+// AdminKeyHeader is the header carrying the admin key, required by the /admin/ endpoints.
+const AdminKeyHeader = "X-Admin-Key"
+
+// SetAdminKey sets the key the requests to the /admin/ endpoints must carry in the
+// X-Admin-Key header. An empty key disables the admin endpoints.
+func (sh *ServerHandler) SetAdminKey(key string) {
+	sh.adminKey = key
+}
+
+// allowAdmin reports whether the request carries the admin key. It answers the other
+// requests itself: 403 when no admin key is configured and 401 for a missing or wrong key.
+func (sh *ServerHandler) allowAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if sh.adminKey == "" {
+		http.Error(w, "admin endpoints are disabled, no admin key is configured", http.StatusForbidden)
+		return false
+	}
+
+	key := r.Header.Get(AdminKeyHeader)
+	if subtle.ConstantTimeCompare([]byte(key), []byte(sh.adminKey)) != 1 {
+		sh.logger.InfoContext(r.Context(), "admin request with an invalid key",
+			slog.String("path", r.URL.Path))
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// listAgents returns the agents of the tenant seen within the last day.
+// //nolint:godot // this comment is part of the Swagger documentation
+// List Agents
+// @Tags Agents
+// @Summary List the agents
+// @ID listAgents
+// @Produce json
+// @Success 200 {array} entities.Agent "Agents sorted by ID, with their last request and series count"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /agents [get]
+func (sh *ServerHandler) listAgents(w http.ResponseWriter, r *http.Request) {
+	agents, err := sh.services.ListAgents(r.Context())
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to list the agents: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sh.writeJSON(w, r, http.StatusOK, agents)
+}
+
+// heartbeat records that the agent of the request, identified by its X-Agent-ID header,
+// is alive and reports at the interval of the body. An agent that misses the reports of
+// several intervals is down.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Heartbeat
+// @Tags Agents
+// @Summary Record the heartbeat of an agent
+// @ID heartbeat
+// @Accept json
+// @Produce json
+// @Param X-Agent-ID header string true "ID of the agent"
+// @Param request body entities.Heartbeat true "Report interval of the agent in seconds"
+// @Success 200 {object} entities.Agent "State of the agent"
+// @Failure 400 {string} string "Bad Request - Missing agent ID or report interval"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /heartbeat [post]
+func (sh *ServerHandler) heartbeat(w http.ResponseWriter, r *http.Request) {
+	var heartbeat entities.Heartbeat
+	if err := json.NewDecoder(r.Body).Decode(&heartbeat); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	agent, err := sh.services.Heartbeat(r.Context(), heartbeat)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidHeartbeat) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sh.logger.ErrorContext(r.Context(),
+			"failed to record the heartbeat: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sh.writeJSON(w, r, http.StatusOK, agent)
+}
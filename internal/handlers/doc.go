@@ -22,28 +22,50 @@ Key HTTP routes defined in this package:
   - Retrieves the value of a specific metric type and name.
   - Content-Type: text/plain
   - Returns the metric value or an error if not found.
+  - Labels of the series are passed as ?labels=host=web-1,env=prod or ?label.host=web-1.
 
-2. GET /:
+2. GET /history/{metricType}/{metricName}:
+  - Returns the samples of a series recorded within a time range as JSON.
+  - Query parameters: from and to (RFC 3339 or Unix seconds), step (e.g. 1m)
+    and agg (avg, min, max, sum or last).
+  - Labels of the series are passed as ?labels=host=web-1,env=prod or ?label.host=web-1.
+
+3. GET /:
   - Displays collected metrics in an HTML format.
   - Content-Type: text/html
 
-3. POST /update/{metricType}/{metricName}/{metricValue}:
+4. POST /update/{metricType}/{metricName}/{metricValue}:
   - Updates a metric value by type and name.
   - Content-Type: text/plain
+  - Labels of the series are passed as ?labels=host=web-1,env=prod or ?label.host=web-1.
 
-4. POST /update/:
+5. POST /update/:
   - Handles metric updates in JSON format.
+  - Histograms carry their bucket bounds, counts, sum and count and are merged
+    with the stored histogram, which must have the same bounds.
+  - Summaries carry a quantile sketch merged with the stored one, which must
+    have the same relative accuracy. Their p50, p90 and p99 are returned by /value/.
 
-5. POST /updates/:
+6. POST /updates/:
   - Handles batch updates for metrics.
 
-6. GET /ping:
+7. DELETE /value/{metricType}/{metricName}:
+  - Removes a series and its history.
+  - Labels of the series are passed as ?labels=host=web-1,env=prod or ?label.host=web-1.
+
+8. POST /delete/:
+  - Removes every series whose key matches a pattern, e.g.
+    {"pattern": "*{host=\"web-1\"}", "syntax": "glob", "type": "gauge"}.
+  - The syntax is glob (default) or regex and the pattern must match the whole key.
+  - Returns the number of removed series as JSON.
+
+9. GET /ping:
   - Checks the database connectivity.
 
-7. Debug and profiling routes under /debug/pprof/:
+10. Debug and profiling routes under /debug/pprof/:
   - Allows performance profiling of the application.
 
-8. Swagger documentation routes:
+11. Swagger documentation routes:
   - Serves Swagger API documentation and UI for the application.
 
 This package also includes error handling for unknown metric types and
@@ -0,0 +1,43 @@
+// Package server implements the gRPC server for the metrics service.
+package server
+
+import (
+	"context"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// AgentInterceptor records the agent making every call, identified by the x-agent-id,
+// x-agent-hostname and x-agent-version metadata. A call carrying an invalid identity
+// fails with InvalidArgument, and one carrying no agent ID is served anonymously.
+func AgentInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		first := func(header string) string {
+			if values := md.Get(header); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+
+		identity := entities.AgentIdentity{
+			ID:       first(entities.AgentIDHeader),
+			Hostname: first(entities.AgentHostnameHeader),
+			Version:  first(entities.AgentVersionHeader),
+		}
+		if identity.ID == "" {
+			return handler(ctx, req)
+		}
+		if err := identity.Validate(); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return handler(entities.WithAgent(ctx, identity), req)
+	}
+}
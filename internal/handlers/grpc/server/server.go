@@ -6,6 +6,7 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
@@ -13,10 +14,12 @@ import (
 	"github.com/mihailtudos/metrickit/pkg/helpers"
 	pb "github.com/mihailtudos/metrickit/proto/metrics"
 
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 // MetricsService implement interface.
@@ -52,18 +55,27 @@ func (ms *MetricsService) CreateMetric(ctx context.Context,
 		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
 	}
 
-	err := ms.services.Create(entities.Metrics{
-		ID:    metric.GetId(),
-		MType: metric.GetMType(),
-		Value: proto.Float64(metric.GetValue()),
-		Delta: proto.Int64(metric.GetDelta()),
-	})
+	m, err := metricFromPB(metric)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	err = ms.services.Create(ctx, m)
 
 	if err != nil {
+		if st, ok := validationStatus(err); ok {
+			return nil, st.Err()
+		}
+		if isMergeConflict(err) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		if errors.Is(err, entities.ErrQuotaExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
 		return nil, fmt.Errorf("create metric: %w", err)
 	}
 
-	return &pb.CreateMetricResponse{Message: "Metric created successfully"}, fmt.Errorf("create metric: %w", err)
+	return &pb.CreateMetricResponse{Message: "Metric created successfully"}, nil
 }
 
 func (ms *MetricsService) CreateMetrics(ctx context.Context,
@@ -81,23 +93,26 @@ func (ms *MetricsService) CreateMetrics(ctx context.Context,
 	// Handle bulk creation logic
 	for _, metric := range m {
 		ms.logger.InfoContext(ctx, "processing metric", slog.Any(metricKey, metric))
-		mm := entities.Metrics{
-			ID:    metric.GetId(),
-			MType: metric.GetMType(),
-		}
-
-		if mm.MType == string(entities.CounterMetricName) {
-			mm.Delta = proto.Int64(metric.GetDelta())
-		} else {
-			mm.Value = proto.Float64(metric.GetValue())
+		mm, err := metricFromPB(metric)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
 		}
-
 		metrics = append(metrics, mm)
 	}
 
 	ms.logger.InfoContext(ctx, "metrics to be stored", slog.Any("metrics", metrics))
 
-	if err := ms.services.StoreMetricsBatch(metrics); err != nil {
+	if err := ms.services.StoreMetricsBatch(ctx, metrics); err != nil {
+		if st, ok := validationStatus(err); ok {
+			return nil, st.Err()
+		}
+		if isMergeConflict(err) || errors.Is(err, server.ErrBatchTooLarge) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+		if errors.Is(err, entities.ErrQuotaExceeded) {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+
 		return nil, status.Errorf(codes.Internal, "failed to store metrics: %v", err)
 	}
 
@@ -120,7 +135,8 @@ func (ms *MetricsService) GetMetric(ctx context.Context,
 	ms.logger.InfoContext(ctx, "received metric",
 		slog.String("id", id), slog.String("mType", mType))
 
-	m, err := ms.services.Get(entities.MetricName(id), entities.MetricType(mType))
+	key := entities.SeriesKey(entities.MetricName(id), req.GetLabels())
+	m, err := ms.services.Get(ctx, key, entities.MetricType(mType))
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			return nil, status.Errorf(codes.NotFound, "metric not found")
@@ -131,10 +147,15 @@ func (ms *MetricsService) GetMetric(ctx context.Context,
 
 	return &pb.GetMetricResponse{
 		Metric: &pb.Metric{
-			Id:    m.ID,
-			MType: m.MType,
-			Value: m.Value,
-			Delta: m.Delta,
+			Id:        m.ID,
+			MType:     m.MType,
+			Value:     m.Value,
+			Delta:     m.Delta,
+			Rate:      m.Rate,
+			Labels:    m.Labels,
+			Histogram: histogramToPB(m.Histogram),
+			Summary:   summaryToPB(m.Summary),
+			Metadata:  metadataToPB(m.Metadata),
 		},
 		Message: "Metric retrieved successfully",
 	}, nil
@@ -142,7 +163,7 @@ func (ms *MetricsService) GetMetric(ctx context.Context,
 
 func (ms *MetricsService) GetMetrics(ctx context.Context,
 	_ *emptypb.Empty) (*pb.GetMetricsResponse, error) {
-	m, err := ms.services.GetAll()
+	m, err := ms.services.GetAll(ctx)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			return nil, status.Errorf(codes.NotFound, "metric not found")
@@ -151,25 +172,71 @@ func (ms *MetricsService) GetMetrics(ctx context.Context,
 		return nil, status.Errorf(codes.Internal, "server error: %v", err)
 	}
 
-	metrics := make([]*pb.Metric, 0, len(m.Counter)+len(m.Gauge))
+	registered, err := ms.services.ListMetadata(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+	metadata := make(map[entities.MetricName]*pb.MetricMetadata, len(registered))
+	for i := range registered {
+		metadata[registered[i].Name] = metadataToPB(&registered[i])
+	}
+
+	metrics := make([]*pb.Metric, 0, len(m.Counter)+len(m.Gauge)+len(m.Histogram)+len(m.Summary))
 
 	for k, metric := range m.Counter {
 		ms.logger.InfoContext(ctx, "processing counter metric",
 			slog.Any(metricKey, metric))
+		rate, err := ms.services.Rate(ctx, k)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "server error: %v", err)
+		}
+		name, labels := splitSeriesKey(k)
 		metrics = append(metrics, &pb.Metric{
-			Id:    string(k),
-			MType: string(entities.CounterMetricName),
-			Delta: proto.Int64(int64(metric)),
+			Id:       string(name),
+			MType:    string(entities.CounterMetricName),
+			Delta:    proto.Int64(int64(metric)),
+			Rate:     proto.Float64(rate),
+			Labels:   labels,
+			Metadata: metadata[name],
 		})
 	}
 
 	for k, metric := range m.Gauge {
 		ms.logger.InfoContext(ctx, "processing gauge metric",
 			slog.Any(metricKey, metric))
+		name, labels := splitSeriesKey(k)
 		metrics = append(metrics, &pb.Metric{
-			Id:    string(k),
-			MType: string(entities.GaugeMetricName),
-			Value: proto.Float64(float64(metric)),
+			Id:       string(name),
+			MType:    string(entities.GaugeMetricName),
+			Value:    proto.Float64(float64(metric)),
+			Labels:   labels,
+			Metadata: metadata[name],
+		})
+	}
+
+	for k, metric := range m.Histogram {
+		ms.logger.InfoContext(ctx, "processing histogram metric",
+			slog.Any(metricKey, metric))
+		name, labels := splitSeriesKey(k)
+		metrics = append(metrics, &pb.Metric{
+			Id:        string(name),
+			MType:     string(entities.HistogramMetricName),
+			Labels:    labels,
+			Histogram: histogramToPB(metric),
+			Metadata:  metadata[name],
+		})
+	}
+
+	for k, metric := range m.Summary {
+		ms.logger.InfoContext(ctx, "processing summary metric",
+			slog.Any(metricKey, metric))
+		name, labels := splitSeriesKey(k)
+		metrics = append(metrics, &pb.Metric{
+			Id:       string(name),
+			MType:    string(entities.SummaryMetricName),
+			Labels:   labels,
+			Summary:  summaryToPB(metric),
+			Metadata: metadata[name],
 		})
 	}
 
@@ -178,3 +245,325 @@ func (ms *MetricsService) GetMetrics(ctx context.Context,
 		Message: "Metrics retrieved successfully",
 	}, nil
 }
+
+// GetMetricHistory returns the samples of a series within the requested time range,
+// downsampled into step buckets when a step is given.
+func (ms *MetricsService) GetMetricHistory(ctx context.Context,
+	req *pb.GetMetricHistoryRequest) (*pb.GetMetricHistoryResponse, error) {
+	if err := req.Validate(); err != nil {
+		ms.logger.InfoContext(ctx, "validation failed", helpers.ErrAttr(err))
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	var from, to time.Time
+	if req.GetFrom() != nil {
+		from = req.GetFrom().AsTime()
+	}
+	if req.GetTo() != nil {
+		to = req.GetTo().AsTime()
+	}
+
+	key := entities.SeriesKey(entities.MetricName(req.GetId()), req.GetLabels())
+	samples, err := ms.services.GetHistory(ctx, key, entities.MetricType(req.GetMType()),
+		from, to, req.GetStep().AsDuration(), entities.Aggregation(req.GetAggregation()))
+	if err != nil {
+		if errors.Is(err, server.ErrInvalidHistoryQuery) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "metric not found")
+		}
+
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+
+	points := make([]*pb.Point, 0, len(samples))
+	for _, sample := range samples {
+		points = append(points, &pb.Point{
+			Timestamp: timestamppb.New(sample.Timestamp),
+			Value:     sample.Value,
+		})
+	}
+
+	return &pb.GetMetricHistoryResponse{
+		Points:  points,
+		Message: "Metric history retrieved successfully",
+	}, nil
+}
+
+// DeleteMetric removes a series and its history.
+func (ms *MetricsService) DeleteMetric(ctx context.Context,
+	req *pb.DeleteMetricRequest) (*pb.DeleteMetricResponse, error) {
+	if err := req.Validate(); err != nil {
+		ms.logger.InfoContext(ctx, "validation failed", helpers.ErrAttr(err))
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	key := entities.SeriesKey(entities.MetricName(req.GetId()), req.GetLabels())
+	if err := ms.services.Delete(ctx, key, entities.MetricType(req.GetMType())); err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, status.Errorf(codes.NotFound, "metric not found")
+		}
+
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+
+	return &pb.DeleteMetricResponse{
+		Message: "Metric deleted successfully",
+	}, nil
+}
+
+// DeleteMetrics removes every series whose key matches a glob or regex pattern.
+func (ms *MetricsService) DeleteMetrics(ctx context.Context,
+	req *pb.DeleteMetricsRequest) (*pb.DeleteMetricsResponse, error) {
+	if err := req.Validate(); err != nil {
+		ms.logger.InfoContext(ctx, "validation failed", helpers.ErrAttr(err))
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	deleted, err := ms.services.DeleteMatching(ctx, entities.MetricType(req.GetMType()),
+		req.GetPattern(), entities.PatternSyntax(req.GetSyntax()))
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidPattern) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+
+	return &pb.DeleteMetricsResponse{
+		Deleted: int64(deleted),
+		Message: "Metrics deleted successfully",
+	}, nil
+}
+
+// RegisterMetadata registers the unit, description and display hint of metric names.
+func (ms *MetricsService) RegisterMetadata(ctx context.Context,
+	req *pb.RegisterMetadataRequest) (*pb.RegisterMetadataResponse, error) {
+	if err := req.Validate(); err != nil {
+		ms.logger.InfoContext(ctx, "validation failed", helpers.ErrAttr(err))
+		return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+	}
+
+	metadata := make([]entities.Metadata, 0, len(req.GetMetadata()))
+	for _, md := range req.GetMetadata() {
+		metadata = append(metadata, entities.Metadata{
+			Name:        entities.MetricName(md.GetName()),
+			Kind:        entities.MetricType(md.GetKind()),
+			Unit:        md.GetUnit(),
+			Description: md.GetDescription(),
+			Display:     entities.DisplayHint(md.GetDisplay()),
+		})
+	}
+
+	if err := ms.services.RegisterMetadata(ctx, metadata); err != nil {
+		if errors.Is(err, entities.ErrInvalidMetadata) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+
+	return &pb.RegisterMetadataResponse{
+		Registered: int64(len(metadata)),
+		Message:    "Metadata registered successfully",
+	}, nil
+}
+
+// GetMetadata returns the registered metadata sorted by metric name.
+func (ms *MetricsService) GetMetadata(ctx context.Context,
+	_ *emptypb.Empty) (*pb.GetMetadataResponse, error) {
+	metadata, err := ms.services.ListMetadata(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+
+	out := make([]*pb.MetricMetadata, 0, len(metadata))
+	for i := range metadata {
+		out = append(out, metadataToPB(&metadata[i]))
+	}
+
+	return &pb.GetMetadataResponse{
+		Metadata: out,
+		Message:  "Metadata retrieved successfully",
+	}, nil
+}
+
+// ListAgents returns the agents of the tenant seen within the last day, sorted by ID.
+func (ms *MetricsService) ListAgents(ctx context.Context,
+	_ *emptypb.Empty) (*pb.ListAgentsResponse, error) {
+	agents, err := ms.services.ListAgents(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+
+	out := make([]*pb.Agent, 0, len(agents))
+	for _, a := range agents {
+		out = append(out, agentToPB(a))
+	}
+
+	return &pb.ListAgentsResponse{
+		Agents:  out,
+		Message: "Agents retrieved successfully",
+	}, nil
+}
+
+// Heartbeat records that the agent identified by the metadata of the call is alive and
+// reports at the interval of the request.
+func (ms *MetricsService) Heartbeat(ctx context.Context,
+	in *pb.HeartbeatRequest) (*pb.HeartbeatResponse, error) {
+	agent, err := ms.services.Heartbeat(ctx, entities.Heartbeat{ReportInterval: int(in.GetReportInterval())})
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidHeartbeat) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid request: %v", err)
+		}
+
+		return nil, status.Errorf(codes.Internal, "server error: %v", err)
+	}
+
+	return &pb.HeartbeatResponse{
+		Agent:   agentToPB(agent),
+		Message: "Heartbeat recorded successfully",
+	}, nil
+}
+
+// agentToPB converts an agent entity into its protobuf message.
+func agentToPB(a entities.Agent) *pb.Agent {
+	return &pb.Agent{
+		Id:             a.ID,
+		Hostname:       a.Hostname,
+		Version:        a.Version,
+		Address:        a.Address,
+		LastSeen:       timestamppb.New(a.LastSeen),
+		Series:         int64(a.Series),
+		ReportInterval: int64(a.ReportInterval),
+		Up:             a.Up,
+	}
+}
+
+// metricFromPB converts a protobuf metric into a metrics entity, setting only
+// the value fields that match the metric type and are set in the message, so a
+// missing value is reported by the validation policy. It fails if the summary
+// sketch cannot be decoded.
+func metricFromPB(metric *pb.Metric) (entities.Metrics, error) {
+	m := entities.Metrics{
+		ID:     metric.GetId(),
+		MType:  metric.GetMType(),
+		Labels: metric.GetLabels(),
+	}
+
+	switch entities.MetricType(m.MType) {
+	case entities.CounterMetricName:
+		m.Delta = metric.Delta
+		m.Total = metric.Total
+	case entities.HistogramMetricName:
+		if h := metric.GetHistogram(); h != nil {
+			m.Histogram = &entities.Histogram{
+				Bounds: h.GetBounds(),
+				Counts: h.GetCounts(),
+				Sum:    h.GetSum(),
+				Count:  h.GetCount(),
+			}
+		}
+	case entities.SummaryMetricName:
+		if s := metric.GetSummary(); s != nil {
+			m.Summary = &entities.Summary{}
+			if err := m.Summary.UnmarshalBinary(s.GetSketch()); err != nil {
+				return m, fmt.Errorf("summary %s: %w", m.ID, err)
+			}
+		}
+	default:
+		m.Value = metric.Value
+	}
+
+	return m, nil
+}
+
+// validationStatus returns the InvalidArgument status of metrics rejected by the
+// validation policy, carrying the rejected fields as google.rpc.BadRequest details,
+// and whether err was such a rejection.
+func validationStatus(err error) (*status.Status, bool) {
+	var ve *server.ValidationError
+	if !errors.As(err, &ve) {
+		return nil, false
+	}
+
+	violations := make([]*errdetails.BadRequest_FieldViolation, 0, len(ve.Fields))
+	for _, f := range ve.Fields {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: f.Field, Description: f.Reason})
+	}
+
+	st := status.New(codes.InvalidArgument, "invalid request: "+ve.Error())
+	if detailed, detailsErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); detailsErr == nil {
+		return detailed, true
+	}
+
+	return st, true
+}
+
+// isMergeConflict reports whether the error was caused by an incoming histogram or
+// summary that cannot be merged with the stored one.
+func isMergeConflict(err error) bool {
+	return errors.Is(err, entities.ErrHistogramBoundsMismatch) || errors.Is(err, entities.ErrSummaryAccuracyMismatch)
+}
+
+// histogramToPB converts a histogram entity into its protobuf representation.
+func histogramToPB(h *entities.Histogram) *pb.Histogram {
+	if h == nil {
+		return nil
+	}
+
+	return &pb.Histogram{
+		Bounds: h.Bounds,
+		Counts: h.Counts,
+		Sum:    h.Sum,
+		Count:  h.Count,
+	}
+}
+
+// summaryToPB converts a summary entity into its protobuf representation,
+// including the estimates of the reported quantiles.
+func summaryToPB(s *entities.Summary) *pb.Summary {
+	if s == nil {
+		return nil
+	}
+
+	sketch, err := s.MarshalBinary()
+	if err != nil {
+		return nil
+	}
+
+	return &pb.Summary{
+		Sketch:    sketch,
+		Quantiles: s.Quantiles(),
+		Count:     s.Count,
+		Sum:       s.Sum,
+	}
+}
+
+// metadataToPB converts metric metadata into its protobuf representation.
+func metadataToPB(md *entities.Metadata) *pb.MetricMetadata {
+	if md == nil {
+		return nil
+	}
+
+	return &pb.MetricMetadata{
+		Name:        string(md.Name),
+		Kind:        string(md.Kind),
+		Unit:        md.Unit,
+		Description: md.Description,
+		Display:     string(md.Display),
+	}
+}
+
+// splitSeriesKey splits a series key into the metric name and its labels.
+// Keys that cannot be parsed are returned as plain metric names.
+func splitSeriesKey(key entities.MetricName) (entities.MetricName, entities.Labels) {
+	name, labels, err := entities.ParseSeriesKey(key)
+	if err != nil {
+		return key, nil
+	}
+
+	return name, labels
+}
@@ -0,0 +1,47 @@
+// Package server implements the gRPC server for the metrics service.
+package server
+
+import (
+	"context"
+	"net"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// RealIPMetadata is the metadata key carrying the IP address of the client.
+const RealIPMetadata = "x-real-ip"
+
+// SourceInterceptor records the source of every call, the client the series it creates
+// are counted towards: the address of the peer or, for the calls relayed by a trusted
+// proxy, the IP address of the x-real-ip metadata.
+func SourceInterceptor(proxies entities.TrustedProxies) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		return handler(entities.WithSource(ctx, callSource(ctx, proxies)), req)
+	}
+}
+
+// callSource returns the IP address of the client of a call, empty when it is unknown.
+func callSource(ctx context.Context, proxies entities.TrustedProxies) string {
+	var host string
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		var err error
+		if host, _, err = net.SplitHostPort(p.Addr.String()); err != nil {
+			host = p.Addr.String()
+		}
+	}
+	if !proxies.Contains(host) {
+		return host
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(RealIPMetadata); len(values) > 0 && values[0] != "" {
+		return values[0]
+	}
+
+	return host
+}
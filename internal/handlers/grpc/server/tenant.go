@@ -0,0 +1,48 @@
+// Package server implements the gRPC server for the metrics service.
+package server
+
+import (
+	"context"
+	"strings"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/service/server"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// APIKeyMetadata is the metadata key carrying the API key of a tenant.
+const APIKeyMetadata = "x-api-key"
+
+// TenantInterceptor places every call in the namespace of its tenant, identified by the
+// API key of the x-api-key metadata or, when tenantHeader is set, by the tenant name of
+// that metadata key. A call carrying an unknown key or name fails with Unauthenticated,
+// and one carrying neither uses the default namespace.
+func TenantInterceptor(tenants server.Tenants, tenantHeader string) grpc.UnaryServerInterceptor {
+	tenantHeader = strings.ToLower(tenantHeader)
+
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler) (any, error) {
+		md, _ := metadata.FromIncomingContext(ctx)
+		first := func(key string) string {
+			if values := md.Get(key); len(values) > 0 {
+				return values[0]
+			}
+			return ""
+		}
+
+		var name string
+		if tenantHeader != "" {
+			name = first(tenantHeader)
+		}
+		tenant, err := server.ResolveTenant(tenants, first(APIKeyMetadata), name)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+
+		return handler(entities.WithTenant(ctx, tenant), req)
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"crypto/hmac"
 	"crypto/rsa"
@@ -17,7 +18,9 @@ import (
 	"net/http/pprof"
 	"path"
 	"strconv"
+	"strings"
 	"text/template"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
@@ -34,6 +37,10 @@ import (
 // ErrUnknownMetric is an error indicating that an unknown metric type was encountered.
 var ErrUnknownMetric = errors.New("unknown metric type")
 
+// labelQueryPrefix is the prefix of the query parameters giving a single series label,
+// e.g. label.host=web-1.
+const labelQueryPrefix = "label."
+
 const bodyKey = "body"
 
 //go:embed templates
@@ -47,8 +54,15 @@ type ServerHandler struct {
 	trustedIP   *net.IPNet
 	db          *pgxpool.Pool
 	services    server.Metrics
+	tenants     server.Tenants
 	TemplatesFs embed.FS
 	secret      string
+	// tenantHeader is the header trusted to carry the tenant name, empty to only accept API keys.
+	tenantHeader string
+	// adminKey is the key required by the /admin/ endpoints, empty to disable them.
+	adminKey string
+	// trustedProxies are the proxies trusted to tell the IP address of the client of a request.
+	trustedProxies entities.TrustedProxies
 }
 
 // NewHandler initializes a new ServerHandler and registers the application routes.
@@ -66,6 +80,13 @@ func NewHandler(services server.Metrics, logger *slog.Logger,
 	}
 }
 
+// SetTenants enables the tenant namespaces, resolving the tenant of every request from its
+// API key or, when tenantHeader is set, from the tenant name carried by that header.
+func (sh *ServerHandler) SetTenants(tenants server.Tenants, tenantHeader string) {
+	sh.tenants = tenants
+	sh.tenantHeader = tenantHeader
+}
+
 // Router sets up the HTTP routes for the application.
 // It returns an http.Handler with the configured routes.
 func Router(logger *slog.Logger, sh *ServerHandler, gwmux *runtime.ServeMux) http.Handler {
@@ -74,6 +95,9 @@ func Router(logger *slog.Logger, sh *ServerHandler, gwmux *runtime.ServeMux) htt
 	mux.Use(
 		RequestLogger(logger),
 		WithRequestIPValidator(sh.trustedIP, logger),
+		WithSource(sh.trustedProxies),
+		WithAgent(logger),
+		WithTenant(sh.tenants, sh.tenantHeader, logger),
 		WithCompressedResponse(logger),
 		WithBodyValidator(sh.secret, logger),
 		WithRequestDecryptor(sh.privateKey, logger),
@@ -84,6 +108,7 @@ func Router(logger *slog.Logger, sh *ServerHandler, gwmux *runtime.ServeMux) htt
 
 	// Existing routes
 	mux.Get("/value/{metricType}/{metricName}", sh.getMetricValue)
+	mux.Get("/history/{metricType}/{metricName}", sh.getMetricHistory)
 	mux.Get("/", sh.showMetrics(""))
 
 	mux.Post("/update/{metricType}/{metricName}/{metricValue}", sh.handleUploads)
@@ -91,6 +116,22 @@ func Router(logger *slog.Logger, sh *ServerHandler, gwmux *runtime.ServeMux) htt
 	mux.Post("/updates/", sh.handleBatchUploads)
 	mux.Post("/value/", sh.getJSONMetricValue)
 
+	mux.Delete("/value/{metricType}/{metricName}", sh.deleteMetric)
+	mux.Post("/delete/", sh.deleteMetrics)
+
+	mux.Get("/metadata/", sh.listMetadata)
+	mux.Get("/metadata/{metricName}", sh.getMetadata)
+	mux.Post("/metadata/", sh.registerMetadata)
+
+	mux.Get("/agents", sh.listAgents)
+	mux.Post("/heartbeat", sh.heartbeat)
+
+	mux.Get("/admin/export", sh.exportMetrics)
+	mux.Post("/admin/import", sh.importMetrics)
+	mux.Get("/admin/tenants", sh.listTenants)
+	mux.Post("/admin/tenants", sh.createTenant)
+	mux.Get("/admin/quotas", sh.getQuotas)
+
 	mux.Get("/ping", sh.handleDBPing)
 
 	// pprof handlers
@@ -108,7 +149,14 @@ func Router(logger *slog.Logger, sh *ServerHandler, gwmux *runtime.ServeMux) htt
 	return mux
 }
 
-// showMetrics displays collected metrics in an HTML format.
+// metricsPage is the data of the HTML page: the metrics and the agents of the tenant.
+type metricsPage struct {
+	*storage.MetricsStorage
+	Agents []entities.Agent
+}
+
+// showMetrics displays collected metrics in an HTML format, rendered with the unit and
+// description registered for their names, and the agents with whether they are up.
 // It responds with an HTML page containing the metrics or an error if the retrieval fails.
 // //nolint:godot // this comment is part of the Swagger documentation
 // Show Metrics
@@ -127,7 +175,7 @@ func (sh *ServerHandler) showMetrics(templatePath string) http.HandlerFunc {
 		if templatePath != "" {
 			tmplPath = templatePath
 		}
-		tmpl, err := template.ParseFS(sh.TemplatesFs, tmplPath)
+		tmpl, err := template.New(path.Base(tmplPath)).Funcs(metadataFuncs(nil)).ParseFS(sh.TemplatesFs, tmplPath)
 		if err != nil {
 			sh.logger.ErrorContext(r.Context(),
 				"failed to parse the template: ",
@@ -136,7 +184,7 @@ func (sh *ServerHandler) showMetrics(templatePath string) http.HandlerFunc {
 			return
 		}
 
-		metrics, err := sh.services.GetAll()
+		metrics, err := sh.services.GetAll(r.Context())
 		if err != nil {
 			sh.logger.ErrorContext(r.Context(),
 				"failed to get the metrics: ",
@@ -145,8 +193,31 @@ func (sh *ServerHandler) showMetrics(templatePath string) http.HandlerFunc {
 			return
 		}
 
+		metadata, err := sh.services.ListMetadata(r.Context())
+		if err != nil {
+			sh.logger.ErrorContext(r.Context(),
+				"failed to get the metadata: ",
+				helpers.ErrAttr(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		byName := make(map[entities.MetricName]entities.Metadata, len(metadata))
+		for _, md := range metadata {
+			byName[md.Name] = md
+		}
+		tmpl.Funcs(metadataFuncs(byName))
+
+		agents, err := sh.services.ListAgents(r.Context())
+		if err != nil {
+			sh.logger.ErrorContext(r.Context(),
+				"failed to list the agents: ",
+				helpers.ErrAttr(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
 		w.Header().Set(helpers.ContentType, "text/html; charset=utf-8")
-		err = tmpl.ExecuteTemplate(w, "index.html", metrics)
+		err = tmpl.ExecuteTemplate(w, "index.html", metricsPage{MetricsStorage: metrics, Agents: agents})
 
 		if err != nil {
 			sh.logger.ErrorContext(r.Context(),
@@ -168,8 +239,9 @@ func (sh *ServerHandler) showMetrics(templatePath string) http.HandlerFunc {
 // @ID getMetricValue
 // @Accept  json
 // @Produce text/plain
-// @Param metricType path string true "Metric Type" Enum("counter", "gauge")
+// @Param metricType path string true "Metric Type" Enum("counter", "gauge", "histogram", "summary")
 // @Param metricName path string true "Metric Name"
+// @Param labels query string false "Series labels as key=value pairs separated by commas, or label.<name>=<value>"
 // @Success 200 {string} string "Metric value returned successfully"
 // @Failure 400 {string} string "Bad Request - Unknown metric type"
 // @Failure 404 {string} string "Not Found - Metric not found"
@@ -179,8 +251,14 @@ func (sh *ServerHandler) getMetricValue(w http.ResponseWriter, r *http.Request)
 	metricType := chiv5.URLParam(r, "metricType")
 	metricName := chiv5.URLParam(r, "metricName")
 
-	metric := entities.Metrics{ID: metricName, MType: metricType}
-	currentMetric, err := sh.getMetric(metric)
+	labels, err := labelsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	metric := entities.Metrics{ID: metricName, MType: metricType, Labels: labels}
+	currentMetric, err := sh.getMetric(r.Context(), metric)
 	if err != nil {
 		sh.logger.DebugContext(r.Context(),
 			"failed to get the metric struct",
@@ -210,6 +288,12 @@ func (sh *ServerHandler) getMetricValue(w http.ResponseWriter, r *http.Request)
 	case entities.GaugeMetricName:
 		w.WriteHeader(http.StatusOK)
 		_, _ = fmt.Fprintf(w, "%v", *currentMetric.Value)
+	case entities.HistogramMetricName:
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, currentMetric.Histogram.String())
+	case entities.SummaryMetricName:
+		w.WriteHeader(http.StatusOK)
+		_, _ = fmt.Fprint(w, currentMetric.Summary.String())
 
 	default:
 		sh.logger.ErrorContext(r.Context(),
@@ -218,6 +302,329 @@ func (sh *ServerHandler) getMetricValue(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// deleteMetric removes a series of a specific metric type and name together with its history.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Delete Metric
+// @Tags Metrics
+// @Summary Delete a metric by type and name
+// @ID deleteMetric
+// @Param metricType path string true "Metric Type" Enum("counter", "gauge", "histogram", "summary")
+// @Param metricName path string true "Metric Name"
+// @Param labels query string false "Series labels as key=value pairs separated by commas, or label.<name>=<value>"
+// @Success 200 {string} string "Metric deleted successfully"
+// @Failure 400 {string} string "Bad Request - Unknown metric type"
+// @Failure 404 {string} string "Not Found - Metric not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /value/{metricType}/{metricName} [delete]
+func (sh *ServerHandler) deleteMetric(w http.ResponseWriter, r *http.Request) {
+	metricType := chiv5.URLParam(r, "metricType")
+	metricName := chiv5.URLParam(r, "metricName")
+
+	if !isValidMetricType(metricType) {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	labels, err := labelsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	key := entities.SeriesKey(entities.MetricName(metricName), labels)
+	if err := sh.services.Delete(r.Context(), key, entities.MetricType(metricType)); err != nil {
+		sh.logger.DebugContext(r.Context(),
+			"failed to delete the metric",
+			helpers.ErrAttr(err))
+		if errors.Is(err, storage.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		sh.logger.ErrorContext(r.Context(),
+			"failed to delete metric: ",
+			helpers.ErrAttr(err))
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// deleteMetrics removes every series whose key matches a glob or regex pattern,
+// e.g. {"pattern": "*{host=\"web-1\"}"} to drop all series of a decommissioned host.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Delete Metrics
+// @Tags Metrics
+// @Summary Delete the metrics matching a pattern
+// @ID deleteMetrics
+// @Accept  json
+// @Produce json
+// @Param request body entities.DeleteRequest true "Pattern selecting the deleted series"
+// @Success 200 {object} entities.DeleteResult "Number of deleted series"
+// @Failure 400 {string} string "Bad Request - Invalid pattern or metric type"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /delete/ [post]
+func (sh *ServerHandler) deleteMetrics(w http.ResponseWriter, r *http.Request) {
+	var req entities.DeleteRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if req.MType != "" && !isValidMetricType(req.MType) {
+		http.Error(w, ErrUnknownMetric.Error(), http.StatusBadRequest)
+		return
+	}
+
+	deleted, err := sh.services.DeleteMatching(r.Context(), entities.MetricType(req.MType), req.Pattern, req.Syntax)
+	if err != nil {
+		if errors.Is(err, entities.ErrInvalidPattern) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sh.logger.ErrorContext(r.Context(),
+			"failed to delete metrics: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	response, err := json.Marshal(entities.DeleteResult{Deleted: deleted})
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to marshal the delete result: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(helpers.ContentType, "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to write response: ",
+			helpers.ErrAttr(err))
+	}
+}
+
+// exportMetrics writes every metric in the format given by the format query
+// parameter, json by default. Counters hold their totals. It requires the admin key.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Export Metrics
+// @Tags Admin
+// @Summary Export every metric as a backup
+// @ID exportMetrics
+// @Produce json
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param X-Admin-Key header string true "Admin key"
+// @Param format query string false "Export format: json, ndjson or csv"
+// @Success 200 {array} entities.Metrics "Exported metrics"
+// @Failure 400 {string} string "Bad Request - Unknown format"
+// @Failure 401 {string} string "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {string} string "Forbidden - No admin key configured"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/export [get]
+func (sh *ServerHandler) exportMetrics(w http.ResponseWriter, r *http.Request) {
+	if !sh.allowAdmin(w, r) {
+		return
+	}
+
+	format, err := storage.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var buf bytes.Buffer
+	exported, err := sh.services.Export(r.Context(), &buf, format)
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to export the metrics: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	sh.logger.InfoContext(r.Context(), "exported metrics",
+		slog.Int("count", exported), slog.String("format", string(format)))
+
+	w.Header().Set(helpers.ContentType, format.ContentType())
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="metrics.%s"`, format))
+	w.WriteHeader(http.StatusOK)
+	if _, err = buf.WriteTo(w); err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to write response: ",
+			helpers.ErrAttr(err))
+	}
+}
+
+// importMetrics replaces the series of the metrics in the body, given in the format of
+// the format query parameter, json by default. Counters are restored to the given totals
+// rather than added to the stored ones. Nothing is stored unless every metric is valid.
+// It requires the admin key.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Import Metrics
+// @Tags Admin
+// @Summary Restore metrics from an export
+// @ID importMetrics
+// @Accept json
+// @Accept text/csv
+// @Accept application/x-ndjson
+// @Produce json
+// @Param X-Admin-Key header string true "Admin key"
+// @Param format query string false "Import format: json, ndjson or csv"
+// @Param request body []entities.Metrics true "Exported metrics"
+// @Success 200 {object} entities.ImportResult "Number of imported series"
+// @Failure 400 {string} string "Bad Request - Unknown format or invalid metric"
+// @Failure 401 {string} string "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {string} string "Forbidden - No admin key configured"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/import [post]
+func (sh *ServerHandler) importMetrics(w http.ResponseWriter, r *http.Request) {
+	if !sh.allowAdmin(w, r) {
+		return
+	}
+
+	format, err := storage.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	imported, err := sh.services.Import(r.Context(), r.Body, format)
+	if err != nil {
+		if sh.writeRejection(w, r, err) {
+			return
+		}
+		if errors.Is(err, storage.ErrInvalidRecord) || isMergeConflict(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sh.logger.ErrorContext(r.Context(),
+			"failed to import the metrics: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	sh.logger.InfoContext(r.Context(), "imported metrics",
+		slog.Int("count", imported), slog.String("format", string(format)))
+
+	response, err := json.Marshal(entities.ImportResult{Imported: imported})
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to marshal the import result: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(helpers.ContentType, "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to write response: ",
+			helpers.ErrAttr(err))
+	}
+}
+
+// getMetricHistory returns the samples of a series recorded within a time range.
+// The range is given by the from and to query parameters, either in RFC 3339 format
+// or as Unix seconds. When step is set the samples are downsampled into buckets of
+// that width using the agg function (avg, min, max, sum or last; avg by default).
+// //nolint:godot // this comment is part of the Swagger documentation
+// Get Metric History
+// @Tags Metrics
+// @Summary Retrieve the history of a series within a time range
+// @ID getMetricHistory
+// @Accept  json
+// @Produce json
+// @Param metricType path string true "Metric Type" Enum("counter", "gauge")
+// @Param metricName path string true "Metric Name"
+// @Param from query string false "Start of the range, RFC 3339 or Unix seconds"
+// @Param to query string false "End of the range, RFC 3339 or Unix seconds"
+// @Param step query string false "Bucket width as a Go duration, e.g. 1m"
+// @Param agg query string false "Aggregation function" Enum("avg", "min", "max", "sum", "last")
+// @Param labels query string false "Series labels as key=value pairs separated by commas, or label.<name>=<value>"
+// @Success 200 {object} entities.MetricHistory "Series history returned successfully"
+// @Failure 400 {string} string "Bad Request - Invalid query parameters"
+// @Failure 404 {string} string "Not Found - Metric not found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /history/{metricType}/{metricName} [get]
+func (sh *ServerHandler) getMetricHistory(w http.ResponseWriter, r *http.Request) {
+	metricType := chiv5.URLParam(r, "metricType")
+	metricName := chiv5.URLParam(r, "metricName")
+	query := r.URL.Query()
+
+	from, err := parseQueryTime(query.Get("from"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to, err := parseQueryTime(query.Get("to"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var step time.Duration
+	if v := query.Get("step"); v != "" {
+		if step, err = time.ParseDuration(v); err != nil {
+			http.Error(w, fmt.Sprintf("invalid step %q", v), http.StatusBadRequest)
+			return
+		}
+	}
+
+	labels, err := labelsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	history := entities.MetricHistory{ID: metricName, MType: metricType, Labels: labels}
+
+	history.Points, err = sh.services.GetHistory(r.Context(),
+		entities.SeriesKey(entities.MetricName(metricName), history.Labels),
+		entities.MetricType(metricType), from, to, step, entities.Aggregation(query.Get("agg")))
+	if err != nil {
+		sh.logger.DebugContext(r.Context(),
+			"failed to get the metric history",
+			helpers.ErrAttr(err))
+		switch {
+		case errors.Is(err, server.ErrInvalidHistoryQuery):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, storage.ErrNotFound):
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		default:
+			sh.logger.ErrorContext(r.Context(),
+				"failed to get metric history: ",
+				helpers.ErrAttr(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response, err := json.Marshal(history)
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to marshal the metric history: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(helpers.ContentType, "application/json; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(response); err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to write response: ",
+			helpers.ErrAttr(err))
+	}
+}
+
 // getJSONMetricValue retrieves a metric's value in JSON format.
 // It responds with the metric data or an error if the retrieval fails.
 // //nolint:godot // this comment is part of the Swagger documentation
@@ -254,7 +661,7 @@ func (sh *ServerHandler) getJSONMetricValue(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	currentMetric, err := sh.getMetric(metric)
+	currentMetric, err := sh.getMetric(r.Context(), metric)
 	if err != nil {
 		sh.logger.DebugContext(context.Background(),
 			"failed to get the metric struct",
@@ -312,12 +719,12 @@ func (sh *ServerHandler) getJSONMetricValue(w http.ResponseWriter, r *http.Reque
 // @Failure 404 {string} string "Not Found - Metric not found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /value/ [get]
-func (sh *ServerHandler) getMetric(metric entities.Metrics) (*entities.Metrics, error) {
+func (sh *ServerHandler) getMetric(ctx context.Context, metric entities.Metrics) (*entities.Metrics, error) {
 	if entities.MetricType(metric.MType) == entities.CounterMetricName {
-		record, err := sh.services.Get(entities.MetricName(metric.ID), entities.MetricType(metric.MType))
+		record, err := sh.services.Get(ctx, metric.Key(), entities.MetricType(metric.MType))
 		if err != nil {
 			if errors.Is(err, storage.ErrNotFound) {
-				return nil, fmt.Errorf("metric with type=%s, name=%s not found: %w", metric.MType, metric.MType, err)
+				return nil, fmt.Errorf("metric with type=%s, name=%s not found: %w", metric.MType, metric.Key(), err)
 			}
 
 			return nil, fmt.Errorf("failed to get the given metric: %w", err)
@@ -326,11 +733,13 @@ func (sh *ServerHandler) getMetric(metric entities.Metrics) (*entities.Metrics,
 		return &record, nil
 	}
 
-	if entities.MetricType(metric.MType) == entities.GaugeMetricName {
-		record, err := sh.services.Get(entities.MetricName(metric.ID), entities.MetricType(metric.MType))
+	if entities.MetricType(metric.MType) == entities.GaugeMetricName ||
+		entities.MetricType(metric.MType) == entities.HistogramMetricName ||
+		entities.MetricType(metric.MType) == entities.SummaryMetricName {
+		record, err := sh.services.Get(ctx, metric.Key(), entities.MetricType(metric.MType))
 		if err != nil {
 			if errors.Is(err, storage.ErrNotFound) {
-				return nil, fmt.Errorf("metric with type=%s, name=%s not found: %w", metric.MType, metric.MType, err)
+				return nil, fmt.Errorf("metric with type=%s, name=%s not found: %w", metric.MType, metric.Key(), err)
 			}
 
 			return nil, fmt.Errorf("failed to get the given metric: %w", err)
@@ -381,14 +790,21 @@ func formatBodyMessageErrors(err error) error {
 // @Param metricType path string true "Type of metric (counter/gauge)"
 // @Param metricName path string true "Name of the metric"
 // @Param metricValue path string true "Value of the metric"
+// @Param labels query string false "Series labels as key=value pairs separated by commas, or label.<name>=<value>"
 // @Success 200 {string} string "Metric uploaded successfully"
 // @Failure 400 {string} string "Invalid request"
 // @Failure 404 {string} string "Metric type not found"
+// @Failure 429 {string} string "New series exceeding a quota"
 // @Router /upload/{metricType}/{metricName}/{metricValue} [post]
 func (sh *ServerHandler) handleUploads(w http.ResponseWriter, r *http.Request) {
 	metricType := chiv5.URLParam(r, "metricType")
 	metricName := chiv5.URLParam(r, "metricName")
 	metricValue := chiv5.URLParam(r, "metricValue")
+	labels, err := labelsFromQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	sh.logger.InfoContext(r.Context(),
 		"received metric type: ",
 		slog.String("metric_type", metricType),
@@ -415,13 +831,15 @@ func (sh *ServerHandler) handleUploads(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		metric := entities.Metrics{ID: metricName, MType: metricType, Delta: &delta}
-		if err = sh.services.Create(metric); err != nil {
+		metric := entities.Metrics{ID: metricName, MType: metricType, Delta: &delta, Labels: labels}
+		if err = sh.services.Create(r.Context(), metric); err != nil {
 			sh.logger.DebugContext(r.Context(),
 				"failed to create the metric",
 				helpers.ErrAttr(err),
 				slog.String("url", r.RequestURI))
-			w.WriteHeader(http.StatusBadRequest)
+			if !sh.writeRejection(w, r, err) {
+				w.WriteHeader(http.StatusBadRequest)
+			}
 			return
 		}
 	case entities.GaugeMetricName:
@@ -433,13 +851,15 @@ func (sh *ServerHandler) handleUploads(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
 			return
 		}
-		metric := entities.Metrics{ID: metricName, MType: metricType, Value: &value}
-		if err = sh.services.Create(metric); err != nil {
+		metric := entities.Metrics{ID: metricName, MType: metricType, Value: &value, Labels: labels}
+		if err = sh.services.Create(r.Context(), metric); err != nil {
 			sh.logger.DebugContext(r.Context(),
 				"failed to create the metric",
 				helpers.ErrAttr(err),
 				slog.String("url", r.RequestURI))
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			if !sh.writeRejection(w, r, err) {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			}
 			return
 		}
 	default:
@@ -454,6 +874,7 @@ func (sh *ServerHandler) handleUploads(w http.ResponseWriter, r *http.Request) {
 }
 
 // handleBatchUploads handles batch metric uploads, validating the metrics and storing them.
+// Nothing is stored unless every metric is valid, the rejected fields are listed otherwise.
 // //nolint:godot // this comment is part of the Swagger documentation
 // @Summary Upload a batch of metrics
 // @Description Uploads multiple metrics in a single request. Returns status OK if successful.
@@ -462,8 +883,9 @@ func (sh *ServerHandler) handleUploads(w http.ResponseWriter, r *http.Request) {
 // @Produce application/json
 // @Param body []entities.Metrics true "List of metrics"
 // @Success 200 {string} string "Metrics uploaded successfully"
-// @Failure 400 {string} string "Invalid request"
-// @Failure 404 {string} string "Metric type not found"
+// @Failure 400 {object} server.ValidationError "Invalid metrics, with the rejected fields"
+// @Failure 413 {string} string "Batch larger than the maximum batch size"
+// @Failure 429 {string} string "New series exceeding a quota"
 // @Router /upload/batch [post]
 func (sh *ServerHandler) handleBatchUploads(w http.ResponseWriter, r *http.Request) {
 	metrics := make([]entities.Metrics, 0)
@@ -490,24 +912,24 @@ func (sh *ServerHandler) handleBatchUploads(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	// Validate each metric type
-	for _, metric := range metrics {
-		if !isValidMetricType(metric.MType) {
-			sh.logger.DebugContext(r.Context(),
-				"unsupported metric type",
-				slog.String("metric_type", metric.MType))
-			http.Error(w, "Unsupported metric type", http.StatusInternalServerError)
-			return
-		}
-	}
-
 	sh.logger.DebugContext(r.Context(),
 		fmt.Sprintf("received batch of %d metrics", len(metrics)))
-	// return http.StatusNotFound if metric type is not provided
 
 	w.Header().Set("Content-Type", "application/json")
-	err = sh.services.StoreMetricsBatch(metrics)
+	err = sh.services.StoreMetricsBatch(r.Context(), metrics)
 	if err != nil {
+		if sh.writeRejection(w, r, err) {
+			return
+		}
+		if isMergeConflict(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, server.ErrBatchTooLarge) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			return
+		}
+
 		sh.logger.ErrorContext(r.Context(),
 			"failed to batch write the metrics",
 			helpers.ErrAttr(err))
@@ -527,8 +949,9 @@ func (sh *ServerHandler) handleBatchUploads(w http.ResponseWriter, r *http.Reque
 // @Produce application/json
 // @Param body entities.Metrics true "Metric"
 // @Success 200 {object} entities.Metrics "Metric uploaded successfully"
-// @Failure 400 {string} string "Invalid request"
+// @Failure 400 {object} server.ValidationError "Invalid metric, with the rejected fields"
 // @Failure 404 {string} string "Metric type not found"
+// @Failure 429 {string} string "New series exceeding a quota"
 // @Router /upload/json [post]
 func (sh *ServerHandler) handleJSONUploads(w http.ResponseWriter, r *http.Request) {
 	metric := entities.Metrics{}
@@ -557,8 +980,7 @@ func (sh *ServerHandler) handleJSONUploads(w http.ResponseWriter, r *http.Reques
 
 	sh.logger.DebugContext(r.Context(), "received", slog.String("metric", string(body)))
 	// return http.StatusNotFound if metric type is not provided
-	if metric.MType != string(entities.GaugeMetricName) &&
-		metric.MType != string(entities.CounterMetricName) {
+	if !isValidMetricType(metric.MType) {
 		sh.logger.DebugContext(r.Context(),
 			"invalid metric received",
 			slog.String("metric", string(body)),
@@ -567,43 +989,24 @@ func (sh *ServerHandler) handleJSONUploads(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if !isMetricNameAndValuePresent(metric) {
-		sh.logger.DebugContext(r.Context(),
-			"invalid metric received",
-			slog.String("metric", string(body)),
-		)
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
-	}
-
 	w.Header().Set("Content-Type", "application/json")
 
-	switch entities.MetricType(metric.MType) {
-	case entities.CounterMetricName:
-		if err = sh.services.Create(metric); err != nil {
-			sh.logger.ErrorContext(r.Context(),
-				"failed to crete the counter metric",
-				helpers.ErrAttr(err),
-			)
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+	if err = sh.services.Create(r.Context(), metric); err != nil {
+		if sh.writeRejection(w, r, err) {
 			return
 		}
-	case entities.GaugeMetricName:
-		if err = sh.services.Create(metric); err != nil {
-			sh.logger.ErrorContext(r.Context(),
-				"failed to create the gauge metric",
-				helpers.ErrAttr(err))
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		if isMergeConflict(err) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
-	default:
-		sh.logger.DebugContext(r.Context(),
-			"no valid metric received")
-		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		sh.logger.ErrorContext(r.Context(),
+			"failed to create the "+metric.MType+" metric",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
 
-	updatedMetric, err := sh.getResponseMetric(metric)
+	updatedMetric, err := sh.getResponseMetric(r.Context(), metric)
 	if err != nil {
 		sh.logger.ErrorContext(r.Context(),
 			"failed generate response",
@@ -632,34 +1035,16 @@ func (sh *ServerHandler) handleJSONUploads(w http.ResponseWriter, r *http.Reques
 	}
 }
 
-// isMetricNameAndValuePresent checks if the metric has a valid name and value.
-// It returns true if the metric is of type Counter and has a non-nil Delta and a non-empty ID,
-// or if the metric is of type Gauge and has a non-nil Value and a non-empty ID.
-func isMetricNameAndValuePresent(metric entities.Metrics) bool {
-	if metric.MType == string(entities.CounterMetricName) &&
-		metric.Delta != nil &&
-		metric.ID != "" {
-		return true
-	}
-
-	if metric.MType == string(entities.GaugeMetricName) &&
-		metric.Value != nil &&
-		metric.ID != "" {
-		return true
-	}
-
-	return false
-}
-
 // getResponseMetric retrieves the current value of the metric for response generation.
 // If the metric is of type Gauge, it returns the metric as is.
-// If the metric is of type Counter, it retrieves the current delta value from the MetricsService.
+// If the metric is of type Counter, Histogram or Summary, it retrieves the current merged value
+// from the MetricsService.
 // It returns the metric and any error encountered during retrieval.
-func (sh *ServerHandler) getResponseMetric(metric entities.Metrics) (*entities.Metrics, error) {
+func (sh *ServerHandler) getResponseMetric(ctx context.Context, metric entities.Metrics) (*entities.Metrics, error) {
 	if entities.MetricType(metric.MType) == entities.GaugeMetricName {
 		return &metric, nil
 	} else {
-		currentDelta, err := sh.services.Get(entities.MetricName(metric.ID), entities.CounterMetricName)
+		currentDelta, err := sh.services.Get(ctx, metric.Key(), entities.MetricType(metric.MType))
 		if err != nil {
 			return nil, fmt.Errorf("failed to get the counter value %w", err)
 		}
@@ -677,7 +1062,86 @@ func getHash(data []byte, secret string) string {
 }
 
 // isValidMetricType checks if the provided metric type is valid.
-// It returns true if the metric type is either Counter or Gauge; otherwise, it returns false.
+// It returns true if the metric type is Counter, Gauge, Histogram or Summary; otherwise, it returns false.
 func isValidMetricType(mType string) bool {
-	return mType == string(entities.CounterMetricName) || mType == string(entities.GaugeMetricName)
+	return mType == string(entities.CounterMetricName) ||
+		mType == string(entities.GaugeMetricName) ||
+		isMergeableMetricType(mType)
+}
+
+// isMergeableMetricType reports whether metrics of the type are merged with the
+// stored value, which is the case for histograms and summaries.
+func isMergeableMetricType(mType string) bool {
+	return mType == string(entities.HistogramMetricName) || mType == string(entities.SummaryMetricName)
+}
+
+// writeRejection answers a request whose metrics were rejected by the validation
+// policy with the rejected fields, or rejected for exceeding a quota with 429 Too Many
+// Requests, and reports whether err was such a rejection.
+func (sh *ServerHandler) writeRejection(w http.ResponseWriter, r *http.Request, err error) bool {
+	var ve *server.ValidationError
+	switch {
+	case errors.As(err, &ve):
+		sh.writeJSON(w, r, http.StatusBadRequest, ve)
+	case errors.Is(err, entities.ErrQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+	default:
+		return false
+	}
+
+	return true
+}
+
+// isMergeConflict reports whether the error was caused by an incoming histogram or
+// summary that cannot be merged with the stored one.
+func isMergeConflict(err error) bool {
+	return errors.Is(err, entities.ErrHistogramBoundsMismatch) || errors.Is(err, entities.ErrSummaryAccuracyMismatch)
+}
+
+// labelsFromQuery builds the series labels from the labels query parameter and the
+// label.<name> ones, e.g. /value/gauge/HeapAlloc?labels=host=web-1,env=prod or
+// /value/gauge/HeapAlloc?label.host=web-1&label.env=prod. A label.<name> parameter
+// overrides the same label of the labels one, only the first value of a repeated
+// parameter is used and the other query parameters are not labels.
+func labelsFromQuery(r *http.Request) (entities.Labels, error) {
+	query := r.URL.Query()
+	labels, err := entities.ParseLabelPairs(query.Get("labels"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid labels: %w", err)
+	}
+
+	for k := range query {
+		name, ok := strings.CutPrefix(k, labelQueryPrefix)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			return nil, fmt.Errorf("invalid labels: the %s parameter has no label name", k)
+		}
+		if labels == nil {
+			labels = make(entities.Labels)
+		}
+		labels[name] = query.Get(k)
+	}
+
+	return labels, nil
+}
+
+// parseQueryTime parses a time query parameter given either in RFC 3339 format
+// or as Unix seconds. An empty value yields the zero time.
+func parseQueryTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0).UTC(), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", value, err)
+	}
+
+	return t, nil
 }
@@ -29,7 +29,7 @@ import (
 func setupDependencies(t *testing.T) server.Metrics {
 	t.Helper()
 	logger := slog.Default()
-	store, err := storage.NewStorage(nil, logger, -1, ".")
+	store, err := storage.NewMemStorage(logger)
 	require.NoError(t, err)
 
 	repos := repositories.NewRepository(store)
@@ -70,7 +70,7 @@ func TestServerHandler_showMetrics(t *testing.T) {
 		{
 			name: "successful metrics retrieval",
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().GetAll().Return(&storage.MetricsStorage{
+				m.EXPECT().GetAll(gomock.Any()).Return(&storage.MetricsStorage{
 					Gauge: map[entities.MetricName]entities.Gauge{
 						"HeapAlloc": 1234.56,
 					},
@@ -78,17 +78,30 @@ func TestServerHandler_showMetrics(t *testing.T) {
 						"TotalAlloc": 7890,
 					},
 				}, nil)
+				m.EXPECT().ListMetadata(gomock.Any()).Return([]entities.Metadata{{
+					Name:        "HeapAlloc",
+					Kind:        entities.GaugeMetricName,
+					Unit:        "bytes",
+					Description: "Bytes of allocated heap objects.",
+					Display:     entities.DisplayBytes,
+				}}, nil)
+				m.EXPECT().ListAgents(gomock.Any()).Return([]entities.Agent{{
+					AgentIdentity: entities.AgentIdentity{ID: "web-1", Version: "v1.2.0"},
+					Address:       "10.0.0.7",
+					Series:        2,
+				}}, nil)
 			},
 			expectedStatus: http.StatusOK,
 			expectedValues: map[string]string{
-				"HeapAlloc":  "1234.56",
+				"HeapAlloc":  "1.2 KiB Bytes of allocated heap objects.",
 				"TotalAlloc": "7890",
+				"web-1":      "down, last seen 0001-01-01T00:00:00Z from 10.0.0.7, 2 series version v1.2.0",
 			},
 		},
 		{
 			name: "service returns error",
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().GetAll().Return(nil, errors.New("service error"))
+				m.EXPECT().GetAll(gomock.Any()).Return(nil, errors.New("service error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
 			expectedValues: nil,
@@ -154,7 +167,7 @@ func TestServerHandler_getMetricValue(t *testing.T) {
 			metricName: "PollCount",
 			setupMock: func(m *mocks.MockMetrics) {
 				delta := int64(100)
-				m.EXPECT().Get(entities.MetricName("PollCount"), entities.CounterMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("PollCount"), entities.CounterMetricName).
 					Return(entities.Metrics{
 						ID:    "PollCount",
 						MType: string(entities.CounterMetricName),
@@ -170,7 +183,7 @@ func TestServerHandler_getMetricValue(t *testing.T) {
 			metricName: "HeapAlloc",
 			setupMock: func(m *mocks.MockMetrics) {
 				value := float64(123.45)
-				m.EXPECT().Get(entities.MetricName("HeapAlloc"), entities.GaugeMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("HeapAlloc"), entities.GaugeMetricName).
 					Return(entities.Metrics{
 						ID:    "HeapAlloc",
 						MType: string(entities.GaugeMetricName),
@@ -185,7 +198,7 @@ func TestServerHandler_getMetricValue(t *testing.T) {
 			metricType: string(entities.GaugeMetricName),
 			metricName: "NonExistent",
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Get(entities.MetricName("NonExistent"), entities.GaugeMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("NonExistent"), entities.GaugeMetricName).
 					Return(entities.Metrics{}, storage.ErrNotFound)
 			},
 			expectedStatus: http.StatusNotFound,
@@ -206,7 +219,7 @@ func TestServerHandler_getMetricValue(t *testing.T) {
 			metricType: string(entities.GaugeMetricName),
 			metricName: "HeapAlloc",
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Get(entities.MetricName("HeapAlloc"), entities.GaugeMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("HeapAlloc"), entities.GaugeMetricName).
 					Return(entities.Metrics{}, errors.New("unexpected database error"))
 			},
 			expectedStatus: http.StatusInternalServerError,
@@ -323,7 +336,7 @@ func TestServerHandler_handleUploads(t *testing.T) {
 			}
 
 			if tt.expectedStatus == http.StatusOK {
-				data, err := serverService.Get(tt.metricName, tt.metricType)
+				data, err := serverService.Get(context.Background(), tt.metricName, tt.metricType)
 				require.NoError(t, err)
 				t.Logf("data: %+v\n", data)
 
@@ -357,7 +370,7 @@ func TestServerHandler_getJSONMetricValue(t *testing.T) {
 				MType: string(entities.CounterMetricName),
 			},
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Get(entities.MetricName("test_counter"), entities.CounterMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("test_counter"), entities.CounterMetricName).
 					Return(entities.Metrics{
 						ID:    "test_counter",
 						MType: string(entities.CounterMetricName),
@@ -378,7 +391,7 @@ func TestServerHandler_getJSONMetricValue(t *testing.T) {
 				MType: string(entities.GaugeMetricName),
 			},
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Get(entities.MetricName("test_gauge"), entities.GaugeMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("test_gauge"), entities.GaugeMetricName).
 					Return(entities.Metrics{
 						ID:    "test_gauge",
 						MType: string(entities.GaugeMetricName),
@@ -399,7 +412,7 @@ func TestServerHandler_getJSONMetricValue(t *testing.T) {
 				MType: string(entities.CounterMetricName),
 			},
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Get(entities.MetricName("non_existent"), entities.CounterMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("non_existent"), entities.CounterMetricName).
 					Return(entities.Metrics{}, storage.ErrNotFound)
 			},
 			expectedCode: http.StatusNotFound,
@@ -420,7 +433,7 @@ func TestServerHandler_getJSONMetricValue(t *testing.T) {
 				MType: string(entities.CounterMetricName),
 			},
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Get(entities.MetricName("test_error"), entities.CounterMetricName).
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("test_error"), entities.CounterMetricName).
 					Return(entities.Metrics{}, errors.New("service error"))
 			},
 			expectedCode: http.StatusInternalServerError,
@@ -493,8 +506,8 @@ func TestServerHandler_handleJSONUploads(t *testing.T) {
 				Delta: int64Ptr(42),
 			},
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Create(gomock.Any()).Return(nil)
-				m.EXPECT().Get(entities.MetricName("test_counter"), entities.CounterMetricName).
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("test_counter"), entities.CounterMetricName).
 					Return(entities.Metrics{
 						ID:    "test_counter",
 						MType: string(entities.CounterMetricName),
@@ -522,7 +535,7 @@ func TestServerHandler_handleJSONUploads(t *testing.T) {
 				Value: float64Ptr(123.45),
 			},
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Create(gomock.Any()).Return(errors.New("service error"))
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(errors.New("service error"))
 			},
 			expectedCode: http.StatusInternalServerError,
 		},
@@ -534,8 +547,8 @@ func TestServerHandler_handleJSONUploads(t *testing.T) {
 				Delta: int64Ptr(42),
 			},
 			setupMock: func(m *mocks.MockMetrics) {
-				m.EXPECT().Create(gomock.Any()).Return(nil)
-				m.EXPECT().Get(entities.MetricName("test_error"), entities.CounterMetricName).
+				m.EXPECT().Create(gomock.Any(), gomock.Any()).Return(nil)
+				m.EXPECT().Get(gomock.Any(), entities.MetricName("test_error"), entities.CounterMetricName).
 					Return(entities.Metrics{}, errors.New("get error"))
 			},
 			expectedCode: http.StatusInternalServerError,
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"text/template"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+
+	chiv5 "github.com/go-chi/chi/v5"
+)
+
+// registerMetadata registers the unit, description and display hint of the metric names
+// in the body, replacing the metadata already registered for the same names. Nothing is
+// registered unless every entry is valid.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Register Metadata
+// @Tags Metadata
+// @Summary Register the metadata of metric names
+// @ID registerMetadata
+// @Accept json
+// @Produce json
+// @Param request body []entities.Metadata true "Metadata of the metric names"
+// @Success 200 {object} entities.RegisterMetadataResult "Number of registered metric names"
+// @Failure 400 {string} string "Bad Request - Invalid metadata"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /metadata/ [post]
+func (sh *ServerHandler) registerMetadata(w http.ResponseWriter, r *http.Request) {
+	var metadata []entities.Metadata
+	if err := json.NewDecoder(r.Body).Decode(&metadata); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := sh.services.RegisterMetadata(r.Context(), metadata); err != nil {
+		if errors.Is(err, entities.ErrInvalidMetadata) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		sh.logger.ErrorContext(r.Context(),
+			"failed to register the metadata: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	sh.logger.DebugContext(r.Context(), "registered metadata", slog.Int("count", len(metadata)))
+
+	sh.writeJSON(w, r, http.StatusOK, entities.RegisterMetadataResult{Registered: len(metadata)})
+}
+
+// listMetadata returns the registered metadata sorted by metric name.
+// //nolint:godot // this comment is part of the Swagger documentation
+// List Metadata
+// @Tags Metadata
+// @Summary List the registered metadata
+// @ID listMetadata
+// @Produce json
+// @Success 200 {array} entities.Metadata "Metadata sorted by metric name"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /metadata/ [get]
+func (sh *ServerHandler) listMetadata(w http.ResponseWriter, r *http.Request) {
+	metadata, err := sh.services.ListMetadata(r.Context())
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to get the metadata: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sh.writeJSON(w, r, http.StatusOK, metadata)
+}
+
+// getMetadata returns the metadata registered for a metric name.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Get Metadata
+// @Tags Metadata
+// @Summary Retrieve the metadata of a metric name
+// @ID getMetadata
+// @Produce json
+// @Param metricName path string true "Metric Name"
+// @Success 200 {object} entities.Metadata "Metadata of the metric name"
+// @Failure 404 {string} string "Not Found - No metadata registered"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /metadata/{metricName} [get]
+func (sh *ServerHandler) getMetadata(w http.ResponseWriter, r *http.Request) {
+	name := entities.MetricName(chiv5.URLParam(r, "metricName"))
+
+	md, err := sh.services.GetMetadata(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+			return
+		}
+
+		sh.logger.ErrorContext(r.Context(),
+			"failed to get the metadata: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sh.writeJSON(w, r, http.StatusOK, md)
+}
+
+// metadataFuncs returns the template functions rendering the metrics of the HTML page
+// with their metadata: value formats the value of a series following the display hint
+// of its name, and description returns the help text of its name.
+func metadataFuncs(metadata map[entities.MetricName]entities.Metadata) template.FuncMap {
+	return template.FuncMap{
+		"value": func(key entities.MetricName, v any) string {
+			md, ok := metadata[entities.MetadataName(key)]
+			if !ok {
+				return fmt.Sprint(v)
+			}
+
+			switch v := v.(type) {
+			case entities.Counter:
+				return md.Format(float64(v))
+			case entities.Gauge:
+				return md.Format(float64(v))
+			case float64:
+				return md.Format(v)
+			default:
+				return fmt.Sprint(v)
+			}
+		},
+		"description": func(key entities.MetricName) string {
+			return metadata[entities.MetadataName(key)].Description
+		},
+	}
+}
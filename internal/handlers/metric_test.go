@@ -12,6 +12,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	chiv5 "github.com/go-chi/chi/v5"
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
@@ -235,7 +236,7 @@ func TestBatchUploadsHandler(t *testing.T) {
 			metrics: []entities.Metrics{
 				{ID: "invalid", MType: "unsupported"},
 			},
-			expectedCode: http.StatusInternalServerError,
+			expectedCode: http.StatusBadRequest,
 			includeHash:  false,
 			correctHash:  false,
 			setupSecret:  false,
@@ -305,6 +306,231 @@ func TestBatchUploadsHandler(t *testing.T) {
 	}
 }
 
+func TestLabelledSeriesUploads(t *testing.T) {
+	sh := helperServerSetup(t)
+
+	upload := func(query, value string) int {
+		req := httptest.NewRequest(http.MethodPost, "/update/gauge/HeapAlloc/"+value+query, http.NoBody)
+		rctx := chiv5.NewRouteContext()
+		rctx.URLParams.Add("metricType", string(entities.GaugeMetricName))
+		rctx.URLParams.Add("metricName", string(entities.HeapAlloc))
+		rctx.URLParams.Add("metricValue", value)
+		req = req.WithContext(context.WithValue(req.Context(), chiv5.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+		sh.handleUploads(recorder, req)
+		return recorder.Code
+	}
+
+	require.Equal(t, http.StatusOK, upload("?label.host=web-1", "10"))
+	// The query parameters other than the labels ones are not labels.
+	require.Equal(t, http.StatusOK, upload("?labels=host=web-2&utm_source=mail", "20"))
+	assert.Equal(t, http.StatusBadRequest, upload("?labels=web-3", "30"))
+	assert.Equal(t, http.StatusBadRequest, upload("?label.=web-3", "30"))
+
+	for host, want := range map[string]string{"web-1": "10", "web-2": "20"} {
+		req := httptest.NewRequest(http.MethodGet, "/value/gauge/HeapAlloc?label.host="+host, http.NoBody)
+		rctx := chiv5.NewRouteContext()
+		rctx.URLParams.Add("metricType", string(entities.GaugeMetricName))
+		rctx.URLParams.Add("metricName", string(entities.HeapAlloc))
+		req = req.WithContext(context.WithValue(req.Context(), chiv5.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+		sh.getMetricValue(recorder, req)
+		assert.Equal(t, http.StatusOK, recorder.Code)
+		assert.Equal(t, want, recorder.Body.String())
+	}
+
+	payload, err := json.Marshal(entities.Metrics{
+		ID:     string(entities.HeapAlloc),
+		MType:  string(entities.GaugeMetricName),
+		Labels: entities.Labels{"host": "web-2"},
+	})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPost, "/value/", bytes.NewReader(payload))
+	recorder := httptest.NewRecorder()
+	sh.getJSONMetricValue(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var metric entities.Metrics
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &metric))
+	assert.Equal(t, entities.Labels{"host": "web-2"}, metric.Labels)
+	assert.InDelta(t, 20, *metric.Value, 0)
+}
+
+func TestHistogramUploads(t *testing.T) {
+	sh := helperServerSetup(t)
+
+	upload := func(h *entities.Histogram) int {
+		payload, err := json.Marshal([]entities.Metrics{{
+			ID:        "latency",
+			MType:     string(entities.HistogramMetricName),
+			Histogram: h,
+		}})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(payload))
+		recorder := httptest.NewRecorder()
+		sh.handleBatchUploads(recorder, req)
+		return recorder.Code
+	}
+
+	first := entities.NewHistogram([]float64{0.1, 1})
+	first.Observe(0.05)
+	second := entities.NewHistogram([]float64{0.1, 1})
+	second.Observe(0.5)
+	second.Observe(2)
+
+	require.Equal(t, http.StatusOK, upload(first))
+	require.Equal(t, http.StatusOK, upload(second))
+	assert.Equal(t, http.StatusBadRequest, upload(&entities.Histogram{Bounds: []float64{1}, Counts: []uint64{1}}))
+	assert.Equal(t, http.StatusBadRequest, upload(entities.NewHistogram([]float64{5})))
+
+	req := httptest.NewRequest(http.MethodGet, "/value/histogram/latency", http.NoBody)
+	rctx := chiv5.NewRouteContext()
+	rctx.URLParams.Add("metricType", string(entities.HistogramMetricName))
+	rctx.URLParams.Add("metricName", "latency")
+	req = req.WithContext(context.WithValue(req.Context(), chiv5.RouteCtxKey, rctx))
+
+	recorder := httptest.NewRecorder()
+	sh.getMetricValue(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "bucket{le=\"0.1\"} 1\nbucket{le=\"1\"} 2\nbucket{le=\"+Inf\"} 3\nsum 2.55\ncount 3",
+		recorder.Body.String())
+}
+
+func TestSummaryUploads(t *testing.T) {
+	sh := helperServerSetup(t)
+
+	upload := func(s *entities.Summary) int {
+		payload, err := json.Marshal([]entities.Metrics{{
+			ID:      "latency",
+			MType:   string(entities.SummaryMetricName),
+			Summary: s,
+		}})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, "/updates/", bytes.NewReader(payload))
+		recorder := httptest.NewRecorder()
+		sh.handleBatchUploads(recorder, req)
+		return recorder.Code
+	}
+
+	first := entities.NewSummary(entities.DefaultSummaryAccuracy)
+	second := entities.NewSummary(entities.DefaultSummaryAccuracy)
+	for i := 1; i <= 50; i++ {
+		first.Observe(float64(i))
+		second.Observe(float64(i + 50))
+	}
+
+	require.Equal(t, http.StatusOK, upload(first))
+	require.Equal(t, http.StatusOK, upload(second))
+	assert.Equal(t, http.StatusBadRequest, upload(&entities.Summary{RelativeAccuracy: 2}))
+	assert.Equal(t, http.StatusBadRequest, upload(entities.NewSummary(0.05)))
+
+	req := httptest.NewRequest(http.MethodGet, "/value/summary/latency", http.NoBody)
+	rctx := chiv5.NewRouteContext()
+	rctx.URLParams.Add("metricType", string(entities.SummaryMetricName))
+	rctx.URLParams.Add("metricName", "latency")
+	req = req.WithContext(context.WithValue(req.Context(), chiv5.RouteCtxKey, rctx))
+
+	recorder := httptest.NewRecorder()
+	sh.getMetricValue(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	body := recorder.Body.String()
+	for _, line := range []string{"p50 ", "p90 ", "p99 ", "sum 5050", "count 100"} {
+		assert.Contains(t, body, line)
+	}
+}
+
+func TestGetMetricHistory(t *testing.T) {
+	sh := helperServerSetup(t)
+
+	for _, delta := range []int64{1, 2, 3} {
+		require.NoError(t, sh.services.Create(context.Background(), entities.Metrics{
+			ID:     "requests",
+			MType:  string(entities.CounterMetricName),
+			Delta:  &delta,
+			Labels: entities.Labels{"host": "web-1"},
+		}))
+	}
+
+	tests := []struct {
+		name         string
+		metricName   string
+		query        string
+		expectedCode int
+		wantValues   []float64
+	}{
+		{
+			name:         "raw samples",
+			metricName:   "requests",
+			query:        "?label.host=web-1",
+			expectedCode: http.StatusOK,
+			wantValues:   []float64{1, 3, 6},
+		},
+		{
+			name:         "aggregated samples",
+			metricName:   "requests",
+			query:        "?labels=host=web-1&step=876000h&agg=max",
+			expectedCode: http.StatusOK,
+			wantValues:   []float64{6},
+		},
+		{
+			name:         "unknown series",
+			metricName:   "requests",
+			query:        "?label.host=web-2",
+			expectedCode: http.StatusNotFound,
+		},
+		{
+			name:         "invalid step",
+			metricName:   "requests",
+			query:        "?label.host=web-1&step=soon",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid aggregation",
+			metricName:   "requests",
+			query:        "?label.host=web-1&agg=median",
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "invalid time range",
+			metricName:   "requests",
+			query:        "?label.host=web-1&from=2024-01-02T00:00:00Z&to=2024-01-01T00:00:00Z",
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/history/counter/"+tt.metricName+tt.query, http.NoBody)
+			rctx := chiv5.NewRouteContext()
+			rctx.URLParams.Add("metricType", string(entities.CounterMetricName))
+			rctx.URLParams.Add("metricName", tt.metricName)
+			req = req.WithContext(context.WithValue(req.Context(), chiv5.RouteCtxKey, rctx))
+
+			recorder := httptest.NewRecorder()
+			sh.getMetricHistory(recorder, req)
+			require.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var history entities.MetricHistory
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &history))
+			assert.Equal(t, entities.Labels{"host": "web-1"}, history.Labels)
+
+			values := make([]float64, 0, len(history.Points))
+			for _, p := range history.Points {
+				values = append(values, p.Value)
+			}
+			assert.Equal(t, tt.wantValues, values)
+		})
+	}
+}
+
 func intPtr(i int64) *int64 {
 	return &i
 }
@@ -369,70 +595,498 @@ func TestIsBodyValid(t *testing.T) {
 	}
 }
 
-func TestIsMetricNameAndValuePresent(t *testing.T) {
+func TestDeleteMetric(t *testing.T) {
+	sh := helperServerSetup(t)
+
+	value := 1.5
+	for _, host := range []string{"web-1", "web-2"} {
+		require.NoError(t, sh.services.Create(context.Background(), entities.Metrics{
+			ID:     "Alloc",
+			MType:  string(entities.GaugeMetricName),
+			Value:  &value,
+			Labels: entities.Labels{"host": host},
+		}))
+	}
+
+	del := func(metricType, query string) int {
+		req := httptest.NewRequest(http.MethodDelete, "/value/"+metricType+"/Alloc"+query, http.NoBody)
+		rctx := chiv5.NewRouteContext()
+		rctx.URLParams.Add("metricType", metricType)
+		rctx.URLParams.Add("metricName", "Alloc")
+		req = req.WithContext(context.WithValue(req.Context(), chiv5.RouteCtxKey, rctx))
+
+		recorder := httptest.NewRecorder()
+		sh.deleteMetric(recorder, req)
+		return recorder.Code
+	}
+
+	assert.Equal(t, http.StatusBadRequest, del("unknown", "?label.host=web-1"))
+	assert.Equal(t, http.StatusNotFound, del("gauge", "?host=web-1"), "host is not a label parameter")
+	assert.Equal(t, http.StatusOK, del("gauge", "?label.host=web-1"))
+	assert.Equal(t, http.StatusNotFound, del("gauge", "?label.host=web-1"))
+
+	_, err := sh.services.Get(context.Background(), `Alloc{host="web-2"}`, entities.GaugeMetricName)
+	require.NoError(t, err, "other series must be kept")
+
+	_, err = sh.services.GetHistory(context.Background(), `Alloc{host="web-1"}`, entities.GaugeMetricName,
+		time.Time{}, time.Time{}, 0, "")
+	require.ErrorIs(t, err, storage.ErrNotFound, "history must be deleted with the series")
+}
+
+func TestDeleteMetrics(t *testing.T) {
+	value := 1.5
+	delta := int64(1)
+	seed := []entities.Metrics{
+		{ID: "Alloc", MType: string(entities.GaugeMetricName), Value: &value, Labels: entities.Labels{"host": "web-1"}},
+		{ID: "Alloc", MType: string(entities.GaugeMetricName), Value: &value, Labels: entities.Labels{"host": "web-2"}},
+		{ID: "PollCount", MType: string(entities.CounterMetricName), Delta: &delta, Labels: entities.Labels{"host": "web-1"}},
+		{ID: "HeapIdle", MType: string(entities.GaugeMetricName), Value: &value},
+	}
+
 	tests := []struct {
-		metric   entities.Metrics
-		expected bool
+		name         string
+		body         string
+		expectedCode int
+		wantDeleted  int
 	}{
-		// Valid Counter Metric
 		{
-			metric: entities.Metrics{
-				Delta: ptrInt64(10),
-				ID:    "counter1",
-				MType: string(entities.CounterMetricName),
-			},
-			expected: true,
+			name:         "glob on labels",
+			body:         `{"pattern": "*{host=\"web-1\"}"}`,
+			expectedCode: http.StatusOK,
+			wantDeleted:  2,
 		},
-		// Invalid Counter Metric (missing Delta)
 		{
-			metric: entities.Metrics{
-				ID:    "counter2",
-				MType: string(entities.CounterMetricName),
-			},
-			expected: false,
+			name:         "glob restricted to a type",
+			body:         `{"pattern": "*{host=\"web-1\"}", "type": "counter"}`,
+			expectedCode: http.StatusOK,
+			wantDeleted:  1,
 		},
-		// Valid Gauge Metric
 		{
-			metric: entities.Metrics{
-				Value: ptrFloat64(10.5),
-				ID:    "gauge1",
-				MType: string(entities.GaugeMetricName),
-			},
-			expected: true,
+			name:         "regex",
+			body:         `{"pattern": "(Alloc|HeapIdle).*", "syntax": "regex"}`,
+			expectedCode: http.StatusOK,
+			wantDeleted:  3,
 		},
-		// Invalid Gauge Metric (missing Value)
 		{
-			metric: entities.Metrics{
-				ID:    "gauge2",
-				MType: string(entities.GaugeMetricName),
-			},
-			expected: false,
+			name:         "invalid regex",
+			body:         `{"pattern": "(", "syntax": "regex"}`,
+			expectedCode: http.StatusBadRequest,
 		},
-		// Invalid Metric Type
 		{
-			metric: entities.Metrics{
-				ID:    "invalidMetric",
-				MType: "invalid",
-			},
-			expected: false,
+			name:         "empty pattern",
+			body:         `{"pattern": ""}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "unknown type",
+			body:         `{"pattern": "*", "type": "unknown"}`,
+			expectedCode: http.StatusBadRequest,
 		},
 	}
 
 	for _, tt := range tests {
-		t.Run(tt.metric.ID, func(t *testing.T) {
-			result := isMetricNameAndValuePresent(tt.metric)
-			if result != tt.expected {
-				t.Errorf("expected %v, got %v", tt.expected, result)
+		t.Run(tt.name, func(t *testing.T) {
+			sh := helperServerSetup(t)
+			require.NoError(t, sh.services.StoreMetricsBatch(context.Background(), seed))
+
+			req := httptest.NewRequest(http.MethodPost, "/delete/", bytes.NewBufferString(tt.body))
+			recorder := httptest.NewRecorder()
+			sh.deleteMetrics(recorder, req)
+			require.Equal(t, tt.expectedCode, recorder.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
 			}
+
+			var result entities.DeleteResult
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+			assert.Equal(t, tt.wantDeleted, result.Deleted)
+
+			all, err := sh.services.GetAll(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, len(seed)-tt.wantDeleted, len(all.Gauge)+len(all.Counter))
 		})
 	}
 }
 
-// Helper functions to create pointers.
-func ptrInt64(i int64) *int64 {
-	return &i
+func TestExportImportMetrics(t *testing.T) {
+	source := helperServerSetup(t)
+	delta := int64(5)
+	value := 1.5
+	require.NoError(t, source.services.StoreMetricsBatch(context.Background(), []entities.Metrics{
+		{ID: "PollCount", MType: string(entities.CounterMetricName), Delta: &delta},
+		{ID: "Alloc", MType: string(entities.GaugeMetricName), Value: &value, Labels: entities.Labels{"host": "web-1"}},
+	}))
+	source.SetAdminKey("admin-secret")
+	admin := func(req *http.Request) *http.Request {
+		req.Header.Set(AdminKeyHeader, "admin-secret")
+		return req
+	}
+
+	// The export and the import require the admin key.
+	recorder := httptest.NewRecorder()
+	source.exportMetrics(recorder, httptest.NewRequest(http.MethodGet, "/admin/export", http.NoBody))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	recorder = httptest.NewRecorder()
+	source.importMetrics(recorder, httptest.NewRequest(http.MethodPost, "/admin/import", bytes.NewBufferString(`[]`)))
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	req := admin(httptest.NewRequest(http.MethodGet, "/admin/export?format=csv", http.NoBody))
+	recorder = httptest.NewRecorder()
+	source.exportMetrics(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+	export := recorder.Body.String()
+
+	target := helperServerSetup(t)
+	target.SetAdminKey("admin-secret")
+	require.NoError(t, target.services.Create(context.Background(),
+		entities.Metrics{ID: "PollCount", MType: string(entities.CounterMetricName), Delta: &delta}))
+
+	req = admin(httptest.NewRequest(http.MethodPost, "/admin/import?format=csv", bytes.NewBufferString(export)))
+	recorder = httptest.NewRecorder()
+	target.importMetrics(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var result entities.ImportResult
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &result))
+	assert.Equal(t, 2, result.Imported)
+
+	counter, err := target.services.Get(context.Background(), "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, delta, *counter.Delta, "counters are restored, not added")
+	_, err = target.services.Get(context.Background(), `Alloc{host="web-1"}`, entities.GaugeMetricName)
+	require.NoError(t, err)
+
+	recorder = httptest.NewRecorder()
+	source.exportMetrics(recorder, admin(httptest.NewRequest(http.MethodGet, "/admin/export?format=xml", http.NoBody)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = httptest.NewRecorder()
+	target.importMetrics(recorder, admin(httptest.NewRequest(http.MethodPost, "/admin/import?format=xml", http.NoBody)))
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+
+	req = admin(httptest.NewRequest(http.MethodPost, "/admin/import",
+		bytes.NewBufferString(`[{"id": "a", "type": "gauge"}]`)))
+	recorder = httptest.NewRecorder()
+	target.importMetrics(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
 }
 
-func ptrFloat64(f float64) *float64 {
-	return &f
+func TestTenants(t *testing.T) {
+	sh := helperServerSetup(t)
+	tenants, err := storage.NewTenantRegistry("")
+	require.NoError(t, err)
+	sh.SetTenants(tenants, "X-Tenant")
+	router := WithTenant(tenants, "X-Tenant", sh.logger)
+
+	// The tenants cannot be managed without an admin key configured.
+	req := httptest.NewRequest(http.MethodPost, "/admin/tenants", bytes.NewBufferString(`{"name": "team-a"}`))
+	recorder := httptest.NewRecorder()
+	router(http.HandlerFunc(sh.createTenant)).ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusForbidden, recorder.Code)
+
+	// Nor without carrying it.
+	sh.SetAdminKey("admin-secret")
+	for _, key := range []string{"", "wrong"} {
+		req = httptest.NewRequest(http.MethodPost, "/admin/tenants", bytes.NewBufferString(`{"name": "team-a"}`))
+		req.Header.Set(AdminKeyHeader, key)
+		recorder = httptest.NewRecorder()
+		router(http.HandlerFunc(sh.createTenant)).ServeHTTP(recorder, req)
+		require.Equal(t, http.StatusUnauthorized, recorder.Code, "admin key %q", key)
+		recorder = httptest.NewRecorder()
+		router(http.HandlerFunc(sh.listTenants)).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+		require.Equal(t, http.StatusUnauthorized, recorder.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/tenants", bytes.NewBufferString(`{"name": "team-a"}`))
+	req.Header.Set(AdminKeyHeader, "admin-secret")
+	recorder = httptest.NewRecorder()
+	router(http.HandlerFunc(sh.createTenant)).ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+	var credentials entities.TenantCredentials
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &credentials))
+	assert.Equal(t, "team-a", credentials.Name)
+
+	req = httptest.NewRequest(http.MethodPost, "/admin/tenants", bytes.NewBufferString(`{"name": "team-a"}`))
+	req.Header.Set(AdminKeyHeader, "admin-secret")
+	recorder = httptest.NewRecorder()
+	router(http.HandlerFunc(sh.createTenant)).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusConflict, recorder.Code)
+
+	// The requests of a tenant cannot manage the tenants.
+	req = httptest.NewRequest(http.MethodGet, "/admin/tenants", http.NoBody)
+	req.Header.Set(APIKeyHeader, credentials.APIKey)
+	req.Header.Set(AdminKeyHeader, "admin-secret")
+	recorder = httptest.NewRecorder()
+	router(http.HandlerFunc(sh.listTenants)).ServeHTTP(recorder, req)
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/admin/tenants", http.NoBody)
+	req.Header.Set(AdminKeyHeader, "admin-secret")
+	recorder = httptest.NewRecorder()
+	router(http.HandlerFunc(sh.listTenants)).ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"name":"team-a"`)
+
+	tests := []struct {
+		name       string
+		header     string
+		value      string
+		statusCode int
+	}{
+		{name: "api key", header: APIKeyHeader, value: credentials.APIKey, statusCode: http.StatusOK},
+		{name: "tenant header", header: "X-Tenant", value: "team-a", statusCode: http.StatusOK},
+		{name: "unknown api key", header: APIKeyHeader, value: "unknown", statusCode: http.StatusUnauthorized},
+		{name: "unknown tenant", header: "X-Tenant", value: "team-b", statusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := router(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = entities.TenantFromContext(r.Context())
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.Header.Set(tt.header, tt.value)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, req)
+			assert.Equal(t, tt.statusCode, recorder.Code)
+			if tt.statusCode == http.StatusOK {
+				assert.Equal(t, "team-a", seen)
+			}
+		})
+	}
+}
+
+func TestCumulativeCounterUploads(t *testing.T) {
+	sh := helperServerSetup(t)
+
+	for _, body := range []string{
+		`{"id": "requests", "type": "counter", "total": 10}`,
+		`{"id": "requests", "type": "counter", "total": 25}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/update/", bytes.NewBufferString(body))
+		recorder := httptest.NewRecorder()
+		sh.handleJSONUploads(recorder, req)
+		require.Equal(t, http.StatusOK, recorder.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/value/",
+		bytes.NewBufferString(`{"id": "requests", "type": "counter"}`))
+	recorder := httptest.NewRecorder()
+	sh.getJSONMetricValue(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var counter entities.Metrics
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &counter))
+	assert.Equal(t, int64(25), *counter.Delta)
+	assert.NotNil(t, counter.Rate, "counters are returned with their rate")
+
+	req = httptest.NewRequest(http.MethodPost, "/updates/",
+		bytes.NewBufferString(`[{"id": "requests", "type": "counter", "delta": 1, "total": 30}]`))
+	recorder = httptest.NewRecorder()
+	sh.handleBatchUploads(recorder, req)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestMetadataEndpoints(t *testing.T) {
+	sh := helperServerSetup(t)
+	mux := chiv5.NewMux()
+	mux.Get("/metadata/", sh.listMetadata)
+	mux.Get("/metadata/{metricName}", sh.getMetadata)
+	mux.Post("/metadata/", sh.registerMetadata)
+	mux.Post("/update/", sh.handleJSONUploads)
+	mux.Post("/value/", sh.getJSONMetricValue)
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := send(http.MethodPost, "/metadata/", `[{"name": "Alloc", "display": "color"}]`)
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = send(http.MethodGet, "/metadata/Alloc", "")
+	assert.Equal(t, http.StatusNotFound, recorder.Code)
+
+	recorder = send(http.MethodPost, "/metadata/",
+		`[{"name": "Alloc", "kind": "gauge", "unit": "bytes", "display": "bytes", "description": "Heap bytes."},
+		  {"name": "PollCount", "kind": "counter", "unit": "polls"}]`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{"registered": 2}`, recorder.Body.String())
+
+	recorder = send(http.MethodGet, "/metadata/", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var list []entities.Metadata
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &list))
+	require.Len(t, list, 2)
+	assert.Equal(t, []entities.MetricName{"Alloc", "PollCount"}, []entities.MetricName{list[0].Name, list[1].Name})
+
+	recorder = send(http.MethodGet, "/metadata/Alloc", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t,
+		`{"name": "Alloc", "kind": "gauge", "unit": "bytes", "display": "bytes", "description": "Heap bytes."}`,
+		recorder.Body.String())
+
+	recorder = send(http.MethodPost, "/update/", `{"id": "Alloc", "type": "gauge", "value": 2048}`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	recorder = send(http.MethodPost, "/value/", `{"id": "Alloc", "type": "gauge"}`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var gauge entities.Metrics
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &gauge))
+	require.NotNil(t, gauge.Metadata, "the JSON responses carry the metadata")
+	assert.Equal(t, "2.0 KiB", gauge.Metadata.Format(*gauge.Value))
+
+	recorder = httptest.NewRecorder()
+	sh.showMetrics("")(recorder, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "2.0 KiB <em>Heap bytes.</em>")
+}
+
+func TestValidationErrors(t *testing.T) {
+	sh := helperServerSetup(t)
+	mux := chiv5.NewMux()
+	mux.Post("/updates/", sh.handleBatchUploads)
+	mux.Post("/update/", sh.handleJSONUploads)
+	mux.Post("/update/{metricType}/{metricName}/{metricValue}", sh.handleUploads)
+
+	send := func(url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, url, bytes.NewBufferString(body))
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	recorder := send("/updates/", `[{"id": "ok", "type": "counter", "delta": 1}, {"id": "cpu usage", "type": "gauge"}]`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	var ve server.ValidationError
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &ve))
+	fields := make([]string, 0, len(ve.Fields))
+	for _, f := range ve.Fields {
+		fields = append(fields, f.Field)
+	}
+	assert.Equal(t, []string{"metrics[1].id", "metrics[1].value"}, fields)
+
+	recorder = send("/update/", `{"id": "g", "type": "gauge", "value": -1}`)
+	require.Equal(t, http.StatusBadRequest, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), `"field":"value"`)
+
+	recorder = send("/update/gauge/g/NaN", "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+	recorder = send("/update/counter/c/-1", "")
+	assert.Equal(t, http.StatusBadRequest, recorder.Code)
+}
+
+func TestQuotas(t *testing.T) {
+	sh := helperServerSetup(t)
+	policy, err := entities.NewQuotaPolicy(0, 1, "")
+	require.NoError(t, err)
+	sh.services.(*server.MetricsService).SetQuotaPolicy(policy)
+	sh.SetAdminKey("admin-secret")
+	proxies, err := entities.ParseTrustedProxies("192.0.2.1")
+	require.NoError(t, err)
+
+	mux := chiv5.NewMux()
+	mux.Use(WithSource(proxies))
+	mux.Post("/update/{metricType}/{metricName}/{metricValue}", sh.handleUploads)
+	mux.Post("/updates/", sh.handleBatchUploads)
+	mux.Get("/admin/quotas", sh.getQuotas)
+
+	send := func(method, url, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, bytes.NewBufferString(body))
+		req.Header.Set("X-Real-IP", "10.0.0.1")
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/update/gauge/g1/1", "").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/update/gauge/g1/2", "").Code)
+	recorder := send(http.MethodPost, "/update/gauge/g2/1", "")
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "source 10.0.0.1")
+	recorder = send(http.MethodPost, "/updates/", `[{"id": "g3", "type": "gauge", "value": 1}]`)
+	assert.Equal(t, http.StatusTooManyRequests, recorder.Code)
+
+	// The quotas require the admin key.
+	recorder = send(http.MethodGet, "/admin/quotas", "")
+	require.Equal(t, http.StatusUnauthorized, recorder.Code)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/quotas", http.NoBody)
+	req.Header.Set(AdminKeyHeader, "admin-secret")
+	recorder = httptest.NewRecorder()
+	mux.ServeHTTP(recorder, req)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.JSONEq(t, `{
+		"limits": {"max_series": 0, "max_series_per_source": 1},
+		"tenants": [{"tenant": "", "series": 1, "sources": {"10.0.0.1": 1}, "rejected": 2}]
+	}`, recorder.Body.String())
+
+	recorder = httptest.NewRecorder()
+	sh.getQuotas(recorder, req.WithContext(entities.WithTenant(req.Context(), "team-a")))
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+}
+
+func TestAgents(t *testing.T) {
+	sh := helperServerSetup(t)
+	proxies, err := entities.ParseTrustedProxies("192.0.2.1")
+	require.NoError(t, err)
+	mux := chiv5.NewMux()
+	mux.Use(WithSource(proxies), WithAgent(sh.logger))
+	mux.Post("/update/{metricType}/{metricName}/{metricValue}", sh.handleUploads)
+	mux.Get("/agents", sh.listAgents)
+
+	send := func(method, url, agentID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, url, http.NoBody)
+		req.Header.Set("X-Real-IP", "10.0.0.1")
+		req.Header.Set(entities.AgentIDHeader, agentID)
+		req.Header.Set(entities.AgentHostnameHeader, "web-host")
+		req.Header.Set(entities.AgentVersionHeader, "1.0.0")
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/update/gauge/Alloc/1", "web-1").Code)
+	assert.Equal(t, http.StatusOK, send(http.MethodPost, "/update/counter/PollCount/1", "web-1").Code)
+	assert.Equal(t, http.StatusBadRequest, send(http.MethodPost, "/update/gauge/Alloc/1", "web 1").Code)
+
+	recorder := send(http.MethodGet, "/agents", "")
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var agents []entities.Agent
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &agents))
+	require.Len(t, agents, 1)
+	assert.Equal(t, entities.AgentIdentity{ID: "web-1", Hostname: "web-host", Version: "1.0.0"}, agents[0].AgentIdentity)
+	assert.Equal(t, "10.0.0.1", agents[0].Address)
+	assert.Equal(t, 2, agents[0].Series)
+}
+
+func TestHeartbeat(t *testing.T) {
+	sh := helperServerSetup(t)
+	mux := chiv5.NewMux()
+	mux.Use(WithSource(nil), WithAgent(sh.logger))
+	mux.Post("/heartbeat", sh.heartbeat)
+
+	send := func(agentID, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/heartbeat", bytes.NewBufferString(body))
+		req.Header.Set(entities.AgentIDHeader, agentID)
+		recorder := httptest.NewRecorder()
+		mux.ServeHTTP(recorder, req)
+		return recorder
+	}
+
+	assert.Equal(t, http.StatusBadRequest, send("", `{"report_interval": 10}`).Code, "the agent ID is required")
+	assert.Equal(t, http.StatusBadRequest, send("web-1", `{"report_interval": 0}`).Code)
+	assert.Equal(t, http.StatusBadRequest, send("web-1", `{`).Code)
+
+	recorder := send("web-1", `{"report_interval": 10}`)
+	require.Equal(t, http.StatusOK, recorder.Code)
+	var agent entities.Agent
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &agent))
+	assert.Equal(t, "web-1", agent.ID)
+	assert.Equal(t, 10, agent.ReportInterval)
+	assert.True(t, agent.Up)
+
+	recorder = httptest.NewRecorder()
+	sh.showMetrics("")(recorder, httptest.NewRequest(http.MethodGet, "/", http.NoBody))
+	require.Equal(t, http.StatusOK, recorder.Code)
+	assert.Contains(t, recorder.Body.String(), "<strong>web-1 </strong>: up, last seen")
+	assert.Contains(t, recorder.Body.String(), `<strong>up{agent="web-1"} </strong>: 1`)
 }
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// getQuotas returns the limits on the number of series and the usage of every tenant
+// to the requests of the default namespace carrying the admin key.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Get Quotas
+// @Tags Admin
+// @Summary Show the series quotas and their usage
+// @ID getQuotas
+// @Produce json
+// @Param X-Admin-Key header string true "Admin key"
+// @Success 200 {object} entities.QuotaReport "Limits and usage of the tenants"
+// @Failure 401 {string} string "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {string} string "Forbidden - Request of a tenant or no admin key configured"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/quotas [get]
+func (sh *ServerHandler) getQuotas(w http.ResponseWriter, r *http.Request) {
+	if !sh.allowAdmin(w, r) {
+		return
+	}
+	if entities.TenantFromContext(r.Context()) != "" {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return
+	}
+
+	report, err := sh.services.Quotas(r.Context())
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to get the quotas: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	sh.writeJSON(w, r, http.StatusOK, report)
+}
@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// WithAgent returns a middleware recording the agent sending every request, identified by
+// the X-Agent-ID, X-Agent-Hostname and X-Agent-Version headers. A request carrying an
+// invalid identity is rejected, and one carrying no agent ID is served anonymously.
+func WithAgent(logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity := entities.AgentIdentity{
+				ID:       r.Header.Get(entities.AgentIDHeader),
+				Hostname: r.Header.Get(entities.AgentHostnameHeader),
+				Version:  r.Header.Get(entities.AgentVersionHeader),
+			}
+			if identity.ID == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := identity.Validate(); err != nil {
+				logger.InfoContext(r.Context(), "request with an invalid agent identity", helpers.ErrAttr(err))
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(entities.WithAgent(r.Context(), identity)))
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// WithSource returns a middleware recording the source of every request, the client the
+// series it creates are counted towards: the remote address of the connection or, for the
+// requests relayed by a trusted proxy, the IP address of the X-Real-IP header, else the
+// first one of X-Forwarded-For. The headers of the other requests are ignored, so a client
+// cannot choose its source.
+func WithSource(proxies entities.TrustedProxies) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(entities.WithSource(r.Context(), requestSource(r, proxies))))
+		})
+	}
+}
+
+// SetTrustedProxies sets the proxies trusted to tell the IP address of the client of a
+// request in the X-Real-IP and X-Forwarded-For headers.
+func (sh *ServerHandler) SetTrustedProxies(proxies entities.TrustedProxies) {
+	sh.trustedProxies = proxies
+}
+
+// requestSource returns the IP address of the client of a request.
+func requestSource(r *http.Request, proxies entities.TrustedProxies) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !proxies.Contains(host) {
+		return host
+	}
+
+	if ip := r.Header.Get("X-Real-IP"); ip != "" {
+		return ip
+	}
+	if ips := r.Header.Get("X-Forwarded-For"); ips != "" {
+		first, _, _ := strings.Cut(ips, ",")
+		return strings.TrimSpace(first)
+	}
+
+	return host
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithSource(t *testing.T) {
+	proxies, err := entities.ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		want       string
+	}{
+		{
+			name:       "direct client",
+			remoteAddr: "192.0.2.1:1234",
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "headers of an untrusted client are ignored",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.7", "X-Forwarded-For": "198.51.100.8"},
+			want:       "192.0.2.1",
+		},
+		{
+			name:       "real ip of a trusted proxy",
+			remoteAddr: "10.0.0.5:1234",
+			headers:    map[string]string{"X-Real-IP": "198.51.100.7", "X-Forwarded-For": "198.51.100.8"},
+			want:       "198.51.100.7",
+		},
+		{
+			name:       "forwarded for of a trusted proxy",
+			remoteAddr: "10.0.0.5:1234",
+			headers:    map[string]string{"X-Forwarded-For": "198.51.100.8, 10.0.0.4"},
+			want:       "198.51.100.8",
+		},
+		{
+			name:       "trusted proxy without headers",
+			remoteAddr: "10.0.0.5:1234",
+			want:       "10.0.0.5",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var seen string
+			handler := WithSource(proxies)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				seen = entities.SourceFromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/", http.NoBody)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			assert.Equal(t, tt.want, seen)
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/service/server"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// APIKeyHeader is the header carrying the API key of a tenant.
+const APIKeyHeader = "X-API-Key"
+
+// WithTenant returns a middleware placing every request in the namespace of its tenant,
+// identified by the API key of the X-API-Key header or, when tenantHeader is set, by the
+// tenant name of that header, e.g. set by an authenticating proxy. A request carrying an
+// unknown key or name is rejected, and one carrying neither uses the default namespace.
+func WithTenant(tenants server.Tenants, tenantHeader string, logger *slog.Logger) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if tenants == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			var name string
+			if tenantHeader != "" {
+				name = r.Header.Get(tenantHeader)
+			}
+			tenant, err := server.ResolveTenant(tenants, r.Header.Get(APIKeyHeader), name)
+			if err != nil {
+				logger.InfoContext(r.Context(), "request with an invalid tenant", helpers.ErrAttr(err))
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(entities.WithTenant(r.Context(), tenant)))
+		})
+	}
+}
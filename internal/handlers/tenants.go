@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// allowTenantAdmin reports whether the request may manage the tenants, which is the case
+// for the requests of the default namespace carrying the admin key only. It answers the
+// other requests itself.
+func (sh *ServerHandler) allowTenantAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if sh.tenants == nil {
+		http.Error(w, "tenants are not enabled", http.StatusNotFound)
+		return false
+	}
+	if !sh.allowAdmin(w, r) {
+		return false
+	}
+	if entities.TenantFromContext(r.Context()) != "" {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// listTenants returns the tenants sorted by name.
+// //nolint:godot // this comment is part of the Swagger documentation
+// List Tenants
+// @Tags Admin
+// @Summary List the tenants
+// @ID listTenants
+// @Produce json
+// @Param X-Admin-Key header string true "Admin key"
+// @Success 200 {array} entities.Tenant "Tenants sorted by name"
+// @Failure 401 {string} string "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {string} string "Forbidden - Request of a tenant or no admin key configured"
+// @Router /admin/tenants [get]
+func (sh *ServerHandler) listTenants(w http.ResponseWriter, r *http.Request) {
+	if !sh.allowTenantAdmin(w, r) {
+		return
+	}
+
+	sh.writeJSON(w, r, http.StatusOK, sh.tenants.List())
+}
+
+// createTenant creates a tenant and returns its API key, which cannot be retrieved later.
+// //nolint:godot // this comment is part of the Swagger documentation
+// Create Tenant
+// @Tags Admin
+// @Summary Create a tenant
+// @ID createTenant
+// @Accept json
+// @Produce json
+// @Param X-Admin-Key header string true "Admin key"
+// @Param request body entities.CreateTenantRequest true "Name of the tenant"
+// @Success 201 {object} entities.TenantCredentials "Tenant and its API key"
+// @Failure 400 {string} string "Bad Request - Invalid tenant name"
+// @Failure 401 {string} string "Unauthorized - Missing or invalid admin key"
+// @Failure 403 {string} string "Forbidden - Request of a tenant or no admin key configured"
+// @Failure 409 {string} string "Conflict - Tenant already exists"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /admin/tenants [post]
+func (sh *ServerHandler) createTenant(w http.ResponseWriter, r *http.Request) {
+	if !sh.allowTenantAdmin(w, r) {
+		return
+	}
+
+	var req entities.CreateTenantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	credentials, err := sh.tenants.Create(req.Name)
+	if err != nil {
+		switch {
+		case errors.Is(err, entities.ErrInvalidTenant):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, entities.ErrTenantExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			sh.logger.ErrorContext(r.Context(),
+				"failed to create the tenant: ",
+				helpers.ErrAttr(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		}
+		return
+	}
+	sh.logger.InfoContext(r.Context(), "tenant created", slog.String("tenant", credentials.Name))
+
+	sh.writeJSON(w, r, http.StatusCreated, credentials)
+}
+
+// writeJSON writes v as the JSON body of the response.
+func (sh *ServerHandler) writeJSON(w http.ResponseWriter, r *http.Request, code int, v any) {
+	response, err := json.Marshal(v)
+	if err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to marshal the response: ",
+			helpers.ErrAttr(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(helpers.ContentType, "application/json; charset=utf-8")
+	w.WriteHeader(code)
+	if _, err = w.Write(response); err != nil {
+		sh.logger.ErrorContext(r.Context(),
+			"failed to write response: ",
+			helpers.ErrAttr(err))
+	}
+}
@@ -0,0 +1,617 @@
+/*
+Package storage provides functionality for storing and retrieving metrics in an embedded bbolt database.
+It defines a BoltStore struct that keeps every series in a single file with ACID transactions,
+without running a database server and without rewriting the whole state on every save.
+*/
+package storage
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"time"
+
+	"go.etcd.io/bbolt"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// boltOpenTimeout bounds the wait for the lock on a database file held by another process.
+const boltOpenTimeout = time.Second
+
+// boltTimeSize is the size of the update time stored in front of every record and sample.
+const boltTimeSize = 8
+
+// ErrCorruptRecord is returned when a record read from the bolt database cannot be decoded.
+var ErrCorruptRecord = errors.New("corrupt record")
+
+// boltTypes are the metric types, each stored in a bucket named after the type.
+var boltTypes = []entities.MetricType{
+	entities.CounterMetricName,
+	entities.GaugeMetricName,
+	entities.HistogramMetricName,
+	entities.SummaryMetricName,
+}
+
+// samplesBucket holds a nested bucket with the recent samples of every counter and gauge series.
+var samplesBucket = []byte("samples")
+
+// metadataBucket holds the JSON encoded metadata of every metric name keyed by the name.
+var metadataBucket = []byte("metadata")
+
+// BoltStore is a struct that provides methods for storing and retrieving metrics in a bbolt database.
+// Every series is a key of the bucket of its type, its value being the time of the last update followed
+// by the encoded metric value.
+type BoltStore struct {
+	db     *bbolt.DB           // Embedded database
+	logger *slog.Logger        // Logger for logging operations
+	ttl    *entities.TTLPolicy // Expiry policy of the series, nil keeps them forever
+}
+
+// NewBoltStorage opens or creates the bbolt database at path and its buckets.
+// Returns a pointer to the BoltStore and an error if the database cannot be opened.
+func NewBoltStorage(path string, logger *slog.Logger) (*BoltStore, error) {
+	db, err := bbolt.Open(path, ownerFilePerm, &bbolt.Options{Timeout: boltOpenTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the bolt db: %w", err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, mType := range boltTypes {
+			if _, err := tx.CreateBucketIfNotExists([]byte(mType)); err != nil {
+				return fmt.Errorf("failed to create the %s bucket: %w", mType, err)
+			}
+		}
+		if _, err := tx.CreateBucketIfNotExists(samplesBucket); err != nil {
+			return fmt.Errorf("failed to create the samples bucket: %w", err)
+		}
+		if _, err := tx.CreateBucketIfNotExists(metadataBucket); err != nil {
+			return fmt.Errorf("failed to create the metadata bucket: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to create the bolt db buckets: %w", err)
+	}
+	logger.DebugContext(context.Background(), "created bolt storage", slog.String("path", path))
+
+	return &BoltStore{db: db, logger: logger}, nil
+}
+
+// CreateRecord adds a new metric record to the database or merges it into the stored one.
+func (bs *BoltStore) CreateRecord(ctx context.Context, metric entities.Metrics) error {
+	err := bs.db.Update(func(tx *bbolt.Tx) error {
+		return bs.storeMetric(tx, metric, time.Now())
+	})
+	if err != nil {
+		return fmt.Errorf("bolt store: %w", err)
+	}
+
+	return nil
+}
+
+// StoreMetricsBatch stores a batch of metrics records within a single transaction,
+// so a metric that cannot be stored, or a context done before the batch is stored,
+// rejects the whole batch.
+func (bs *BoltStore) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	err := bs.db.Update(func(tx *bbolt.Tx) error {
+		now := time.Now()
+		for _, metric := range metrics {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("batch abandoned: %w", err)
+			}
+			if err := bs.storeMetric(tx, metric, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("bolt batch store: %w", err)
+	}
+
+	return nil
+}
+
+// storeMetric adds a metric to its series, incrementing counters, replacing gauges and
+// merging histograms and summaries, and records a sample of counters and gauges.
+func (bs *BoltStore) storeMetric(tx *bbolt.Tx, metric entities.Metrics, now time.Time) error {
+	mType := entities.MetricType(metric.MType)
+	bucket := tx.Bucket([]byte(mType))
+	if bucket == nil {
+		return errors.New("unsupported record type " + metric.MType)
+	}
+
+	key := []byte(metric.Key())
+	stored := entities.Metrics{}
+	if data := bucket.Get(key); data != nil {
+		var err error
+		if _, stored, err = decodeBoltRecord(metric.Key(), mType, data); err != nil {
+			return err
+		}
+	}
+
+	var sample *float64
+	switch mType {
+	case entities.CounterMetricName:
+		if metric.Delta == nil {
+			return fmt.Errorf("store counter %s: missing delta", metric.ID)
+		}
+		total := *metric.Delta
+		if stored.Delta != nil {
+			total += *stored.Delta
+		}
+		metric.Delta = &total
+		value := float64(total)
+		sample = &value
+	case entities.GaugeMetricName:
+		if metric.Value == nil {
+			return fmt.Errorf("store gauge %s: missing value", metric.ID)
+		}
+		sample = metric.Value
+	case entities.HistogramMetricName:
+		if metric.Histogram == nil {
+			return fmt.Errorf("store histogram %s: %w", metric.ID, entities.ErrInvalidHistogram)
+		}
+		merged, err := mergedHistogram(stored.Histogram, metric.Histogram)
+		if err != nil {
+			return fmt.Errorf("store histogram %s: %w", metric.ID, err)
+		}
+		metric.Histogram = merged
+	case entities.SummaryMetricName:
+		if metric.Summary == nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, entities.ErrInvalidSummary)
+		}
+		merged, err := mergedSummary(stored.Summary, metric.Summary)
+		if err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
+		}
+		metric.Summary = merged
+	}
+
+	data, err := encodeBoltRecord(metric, now)
+	if err != nil {
+		return err
+	}
+	if err = bucket.Put(key, data); err != nil {
+		return fmt.Errorf("failed to put %s: %w", metric.Key(), err)
+	}
+
+	if sample != nil {
+		return storeBoltSample(tx, mType, key, entities.Sample{Timestamp: now.UTC(), Value: *sample})
+	}
+
+	return nil
+}
+
+// GetRecord retrieves a specific metrics record by its series key and type.
+// It returns ErrNotFound if the series does not exist or has expired.
+func (bs *BoltStore) GetRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	var metric entities.Metrics
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(mType))
+		if bucket == nil {
+			return ErrNotFound
+		}
+		data := bucket.Get([]byte(mName))
+		if data == nil {
+			return ErrNotFound
+		}
+
+		updatedAt, m, err := decodeBoltRecord(mName, mType, data)
+		if err != nil {
+			return err
+		}
+		if bs.ttl.Expired(mName, updatedAt, time.Now()) {
+			return ErrNotFound // Expired series are hidden until purged.
+		}
+		metric = m
+		return nil
+	})
+	if err != nil {
+		return entities.Metrics{}, fmt.Errorf("failed to get record: %w", err)
+	}
+
+	return metric, nil
+}
+
+// GetAllRecords retrieves all the series that have not expired, read within a single transaction.
+func (bs *BoltStore) GetAllRecords(ctx context.Context) (*MetricsStorage, error) {
+	records := NewMetricsStorage()
+	now := time.Now()
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		for _, mType := range boltTypes {
+			metrics, err := bs.recordsByType(tx, mType, now)
+			if err != nil {
+				return err
+			}
+
+			for key, m := range metrics {
+				switch mType {
+				case entities.CounterMetricName:
+					records.Counter[key] = entities.Counter(*m.Delta)
+				case entities.GaugeMetricName:
+					records.Gauge[key] = entities.Gauge(*m.Value)
+				case entities.HistogramMetricName:
+					records.Histogram[key] = m.Histogram
+				case entities.SummaryMetricName:
+					records.Summary[key] = m.Summary
+				}
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get records: %w", err)
+	}
+
+	return records, nil
+}
+
+// GetAllRecordsByType retrieves all the series of a specified type that have not expired.
+func (bs *BoltStore) GetAllRecordsByType(ctx context.Context,
+	mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
+	var metrics map[entities.MetricName]entities.Metrics
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		metrics, err = bs.recordsByType(tx, mType, time.Now())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s records: %w", mType, err)
+	}
+
+	return metrics, nil
+}
+
+// recordsByType reads the series of a type that have not expired at now.
+func (bs *BoltStore) recordsByType(tx *bbolt.Tx, mType entities.MetricType,
+	now time.Time) (map[entities.MetricName]entities.Metrics, error) {
+	metrics := make(map[entities.MetricName]entities.Metrics)
+	bucket := tx.Bucket([]byte(mType))
+	if bucket == nil {
+		return metrics, nil
+	}
+
+	err := bucket.ForEach(func(k, v []byte) error {
+		key := entities.MetricName(k)
+		updatedAt, m, err := decodeBoltRecord(key, mType, v)
+		if err != nil {
+			return err
+		}
+		if !bs.ttl.Expired(key, updatedAt, now) {
+			metrics[key] = m
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the %s bucket: %w", mType, err)
+	}
+
+	return metrics, nil
+}
+
+// GetHistory returns the samples of a series recorded within [from, to].
+// Only the most recent samples are kept, the older ones are dropped on write.
+func (bs *BoltStore) GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+	from, to time.Time) ([]entities.Sample, error) {
+	samples := make([]entities.Sample, 0)
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		series := tx.Bucket(samplesBucket).Bucket(boltSeriesID(mType, []byte(mName)))
+		if series == nil {
+			return ErrNotFound
+		}
+
+		return series.ForEach(func(_, v []byte) error {
+			sample := decodeBoltSample(v)
+			if (from.IsZero() || !sample.Timestamp.Before(from)) && (to.IsZero() || !sample.Timestamp.After(to)) {
+				samples = append(samples, sample)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	return samples, nil
+}
+
+// DeleteRecord removes a series of the given type together with its samples.
+func (bs *BoltStore) DeleteRecord(ctx context.Context, mName entities.MetricName, mType entities.MetricType) error {
+	err := bs.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(mType))
+		if bucket == nil || bucket.Get([]byte(mName)) == nil {
+			return ErrNotFound
+		}
+
+		return deleteBoltSeries(tx, mType, []byte(mName))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRecords removes every series of the given type, or of all types if mType
+// is empty, whose key matches the matcher.
+func (bs *BoltStore) DeleteRecords(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	types := boltTypes
+	if mType != "" {
+		types = []entities.MetricType{mType}
+	}
+
+	return bs.deleteSeriesWhere(types, func(key entities.MetricName, _ time.Time) bool {
+		return matcher.Match(key)
+	})
+}
+
+// SetTTLPolicy sets the policy deciding when series that are not updated expire,
+// based on the update time stored with them. It must be called before the storage is used.
+func (bs *BoltStore) SetTTLPolicy(policy *entities.TTLPolicy) {
+	bs.ttl = policy
+}
+
+// PurgeExpired removes the series that have expired at now together with their samples.
+func (bs *BoltStore) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	if !bs.ttl.Enabled() {
+		return 0, nil
+	}
+
+	return bs.deleteSeriesWhere(boltTypes, func(key entities.MetricName, updatedAt time.Time) bool {
+		return bs.ttl.Expired(key, updatedAt, now)
+	})
+}
+
+// deleteSeriesWhere removes the series of the given types selected by the match function,
+// together with their samples, within a single transaction. It returns the number of removed series.
+func (bs *BoltStore) deleteSeriesWhere(types []entities.MetricType,
+	match func(key entities.MetricName, updatedAt time.Time) bool) (int, error) {
+	deleted := 0
+	err := bs.db.Update(func(tx *bbolt.Tx) error {
+		deleted = 0
+		for _, mType := range types {
+			bucket := tx.Bucket([]byte(mType))
+			if bucket == nil {
+				continue
+			}
+
+			// Keys are collected first, the bucket must not be modified while it is iterated.
+			var keys [][]byte
+			err := bucket.ForEach(func(k, v []byte) error {
+				if len(v) < boltTimeSize {
+					return fmt.Errorf("%w: truncated record %s", ErrCorruptRecord, k)
+				}
+				if match(entities.MetricName(k), decodeBoltTime(v)) {
+					keys = append(keys, append([]byte(nil), k...))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for _, k := range keys {
+				if err = deleteBoltSeries(tx, mType, k); err != nil {
+					return err
+				}
+			}
+			deleted += len(keys)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete series: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// SetMetadata registers the metadata of metric names within a single transaction.
+func (bs *BoltStore) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	err := bs.db.Update(func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket(metadataBucket)
+		for _, md := range metadata {
+			data, err := json.Marshal(md)
+			if err != nil {
+				return fmt.Errorf("failed to encode the metadata of %s: %w", md.Name, err)
+			}
+			if err = bucket.Put([]byte(md.Name), data); err != nil {
+				return fmt.Errorf("failed to store the metadata of %s: %w", md.Name, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set metadata: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetadata returns the metadata registered for a metric name, or ErrNotFound.
+func (bs *BoltStore) GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error) {
+	var md entities.Metadata
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		data := tx.Bucket(metadataBucket).Get([]byte(name))
+		if data == nil {
+			return ErrNotFound
+		}
+		if err := json.Unmarshal(data, &md); err != nil {
+			return fmt.Errorf("%w: metadata of %s: %w", ErrCorruptRecord, name, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return entities.Metadata{}, fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+// GetAllMetadata returns the registered metadata keyed by metric name.
+func (bs *BoltStore) GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error) {
+	metadata := make(map[entities.MetricName]entities.Metadata)
+	err := bs.db.View(func(tx *bbolt.Tx) error {
+		return tx.Bucket(metadataBucket).ForEach(func(k, v []byte) error {
+			var md entities.Metadata
+			if err := json.Unmarshal(v, &md); err != nil {
+				return fmt.Errorf("%w: metadata of %s: %w", ErrCorruptRecord, k, err)
+			}
+			metadata[entities.MetricName(k)] = md
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
+// Close closes the database file.
+func (bs *BoltStore) Close(ctx context.Context) error {
+	if err := bs.db.Close(); err != nil {
+		return fmt.Errorf("failed to close the bolt db: %w", err)
+	}
+
+	return nil
+}
+
+// encodeBoltRecord encodes the update time followed by the value of the metric: an int64 for
+// counters, a float64 for gauges, JSON for histograms and the binary encoding for summaries.
+func encodeBoltRecord(metric entities.Metrics, updatedAt time.Time) ([]byte, error) {
+	data := binary.BigEndian.AppendUint64(make([]byte, 0, boltTimeSize*2), uint64(updatedAt.UnixNano()))
+
+	switch entities.MetricType(metric.MType) {
+	case entities.CounterMetricName:
+		return binary.BigEndian.AppendUint64(data, uint64(*metric.Delta)), nil
+	case entities.GaugeMetricName:
+		return binary.BigEndian.AppendUint64(data, math.Float64bits(*metric.Value)), nil
+	case entities.HistogramMetricName:
+		value, err := json.Marshal(metric.Histogram)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode histogram: %w", err)
+		}
+		return append(data, value...), nil
+	case entities.SummaryMetricName:
+		value, err := metric.Summary.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode summary: %w", err)
+		}
+		return append(data, value...), nil
+	}
+
+	return nil, errors.New("unsupported record type " + metric.MType)
+}
+
+// decodeBoltRecord decodes a record encoded by encodeBoltRecord into its update time and metric.
+// The returned metric does not share memory with data, which is only valid within its transaction.
+func decodeBoltRecord(key entities.MetricName, mType entities.MetricType,
+	data []byte) (time.Time, entities.Metrics, error) {
+	if len(data) < boltTimeSize {
+		return time.Time{}, entities.Metrics{}, fmt.Errorf("%w: truncated record %s", ErrCorruptRecord, key)
+	}
+	updatedAt := decodeBoltTime(data)
+	value := data[boltTimeSize:]
+
+	metric := metricFromKey(key, mType)
+	switch mType {
+	case entities.CounterMetricName, entities.GaugeMetricName:
+		if len(value) != boltTimeSize {
+			return time.Time{}, entities.Metrics{}, fmt.Errorf("%w: bad value of %s", ErrCorruptRecord, key)
+		}
+		bits := binary.BigEndian.Uint64(value)
+		if mType == entities.CounterMetricName {
+			delta := int64(bits)
+			metric.Delta = &delta
+		} else {
+			gauge := math.Float64frombits(bits)
+			metric.Value = &gauge
+		}
+	case entities.HistogramMetricName:
+		metric.Histogram = &entities.Histogram{}
+		if err := json.Unmarshal(value, metric.Histogram); err != nil {
+			return time.Time{}, entities.Metrics{}, fmt.Errorf("%w: %s: %w", ErrCorruptRecord, key, err)
+		}
+	case entities.SummaryMetricName:
+		metric.Summary = &entities.Summary{}
+		if err := metric.Summary.UnmarshalBinary(value); err != nil {
+			return time.Time{}, entities.Metrics{}, fmt.Errorf("%w: %s: %w", ErrCorruptRecord, key, err)
+		}
+	}
+
+	return updatedAt, metric, nil
+}
+
+// decodeBoltTime decodes the update time in front of a record.
+func decodeBoltTime(data []byte) time.Time {
+	return time.Unix(0, int64(binary.BigEndian.Uint64(data)))
+}
+
+// boltSeriesID is the name of the nested samples bucket of a series.
+func boltSeriesID(mType entities.MetricType, key []byte) []byte {
+	id := make([]byte, 0, len(mType)+1+len(key))
+	id = append(id, mType...)
+	id = append(id, 0)
+	return append(id, key...)
+}
+
+// storeBoltSample appends a sample to the samples bucket of a series under the next sequence
+// number, dropping the sample that falls out of the defaultHistorySize most recent ones.
+func storeBoltSample(tx *bbolt.Tx, mType entities.MetricType, key []byte, sample entities.Sample) error {
+	series, err := tx.Bucket(samplesBucket).CreateBucketIfNotExists(boltSeriesID(mType, key))
+	if err != nil {
+		return fmt.Errorf("failed to create the samples bucket of %s: %w", key, err)
+	}
+
+	seq, err := series.NextSequence()
+	if err != nil {
+		return fmt.Errorf("failed to number the sample of %s: %w", key, err)
+	}
+
+	value := binary.BigEndian.AppendUint64(make([]byte, 0, boltTimeSize*2), uint64(sample.Timestamp.UnixNano()))
+	value = binary.BigEndian.AppendUint64(value, math.Float64bits(sample.Value))
+	if err = series.Put(binary.BigEndian.AppendUint64(nil, seq), value); err != nil {
+		return fmt.Errorf("failed to put the sample of %s: %w", key, err)
+	}
+
+	if seq > defaultHistorySize {
+		if err = series.Delete(binary.BigEndian.AppendUint64(nil, seq-defaultHistorySize)); err != nil {
+			return fmt.Errorf("failed to drop the oldest sample of %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// decodeBoltSample decodes a sample stored by storeBoltSample.
+func decodeBoltSample(data []byte) entities.Sample {
+	return entities.Sample{
+		Timestamp: decodeBoltTime(data).UTC(),
+		Value:     math.Float64frombits(binary.BigEndian.Uint64(data[boltTimeSize:])),
+	}
+}
+
+// deleteBoltSeries removes a series and its samples bucket.
+func deleteBoltSeries(tx *bbolt.Tx, mType entities.MetricType, key []byte) error {
+	if err := tx.Bucket([]byte(mType)).Delete(key); err != nil {
+		return fmt.Errorf("failed to delete %s: %w", key, err)
+	}
+
+	err := tx.Bucket(samplesBucket).DeleteBucket(boltSeriesID(mType, key))
+	if err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
+		return fmt.Errorf("failed to delete the samples of %s: %w", key, err)
+	}
+
+	return nil
+}
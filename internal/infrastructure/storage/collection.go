@@ -4,13 +4,14 @@ package storage
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 )
 
 // MetricsCollection manages a collection of metrics, providing concurrency-safe operations
-// for storing and retrieving counter and gauge metrics.
+// for storing and retrieving counter, gauge and histogram metrics.
 type MetricsCollection struct {
 	collection *entities.MetricsCollection // The underlying metrics collection.
 	mu         sync.Mutex                  // Mutex to ensure concurrent access safety.
@@ -106,6 +107,61 @@ func (m *MetricsCollection) GetGaugeCollection() (
 	return newGaugeMetrics, nil
 }
 
+// ObserveHistogram adds the values to the named histogram, creating it with
+// the given bucket bounds if it does not exist yet.
+func (m *MetricsCollection) ObserveHistogram(mName entities.MetricName, bounds []float64, values ...float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.collection.HistogramMetrics == nil {
+		return errors.New("histogram store not initialized") // Error if histogram store is uninitialized.
+	}
+
+	h, ok := m.collection.HistogramMetrics[mName]
+	if !ok {
+		h = entities.NewHistogram(bounds)
+		m.collection.HistogramMetrics[mName] = h
+	}
+
+	for _, v := range values {
+		h.Observe(v)
+	}
+
+	return nil
+}
+
+// TakeHistogramCollection returns the histograms observed so far and resets them,
+// so every observation is reported only once.
+func (m *MetricsCollection) TakeHistogramCollection() (map[entities.MetricName]*entities.Histogram, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	histograms := m.collection.HistogramMetrics
+	m.collection.HistogramMetrics = make(map[entities.MetricName]*entities.Histogram)
+	return histograms, nil
+}
+
+// RestoreHistogramCollection merges histograms returned by TakeHistogramCollection back
+// into the collection, e.g. when they failed to be reported, so their observations are
+// reported with the ones made since.
+func (m *MetricsCollection) RestoreHistogramCollection(histograms map[entities.MetricName]*entities.Histogram) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var errs []error
+	for name, taken := range histograms {
+		if current, ok := m.collection.HistogramMetrics[name]; ok {
+			if err := taken.Merge(current); err != nil {
+				errs = append(errs, fmt.Errorf("histogram %s: %w", name, err))
+				continue
+			}
+		}
+		m.collection.HistogramMetrics[name] = taken
+	}
+
+	return errors.Join(errs...)
+}
+
 // copyMap copies elements from the source map to the destination map.
 func copyMap[T any](src, dst map[entities.MetricName]T) {
 	for key, value := range src {
@@ -0,0 +1,452 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// Format is the encoding of exported metrics.
+type Format string
+
+// Supported export formats.
+const (
+	FormatJSON   Format = "json"   // A JSON array of metrics, as accepted by /updates/.
+	FormatNDJSON Format = "ndjson" // One JSON metric per line.
+	FormatCSV    Format = "csv"    // One metric per row, see csvHeader.
+)
+
+// importChunk is the number of series replaced by a single storage call during an import.
+const importChunk = 1000
+
+var (
+	// ErrUnknownFormat is returned for an export format other than json, ndjson and csv.
+	ErrUnknownFormat = errors.New("unknown export format")
+	// ErrInvalidRecord is returned when an imported record is malformed or repeats a series.
+	ErrInvalidRecord = errors.New("invalid record")
+)
+
+// csvHeader names the columns of a CSV export. The value column holds the total of a
+// counter or the value of a gauge, the data column the JSON of a histogram or summary.
+var csvHeader = []string{"type", "name", "labels", "value", "data"}
+
+// ParseFormat parses an export format, json when s is empty.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(s)); f {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatNDJSON, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w %q, expected json, ndjson or csv", ErrUnknownFormat, s)
+	}
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case FormatNDJSON:
+		return "application/x-ndjson"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	default:
+		return "application/json"
+	}
+}
+
+// Records lists the series of records as metrics sorted by type and series key.
+// Counters hold their total in Delta.
+func Records(records *MetricsStorage) ([]entities.Metrics, error) {
+	metrics := make([]entities.Metrics, 0,
+		len(records.Counter)+len(records.Gauge)+len(records.Histogram)+len(records.Summary))
+	add := func(key entities.MetricName, m entities.Metrics) error {
+		name, labels, err := entities.ParseSeriesKey(key)
+		if err != nil {
+			return fmt.Errorf("failed to list the records: %w", err)
+		}
+		m.ID, m.Labels = string(name), labels
+		metrics = append(metrics, m)
+		return nil
+	}
+
+	for _, key := range sortedKeys(records.Counter) {
+		delta := int64(records.Counter[key])
+		if err := add(key, entities.Metrics{MType: string(entities.CounterMetricName), Delta: &delta}); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range sortedKeys(records.Gauge) {
+		value := float64(records.Gauge[key])
+		if err := add(key, entities.Metrics{MType: string(entities.GaugeMetricName), Value: &value}); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range sortedKeys(records.Histogram) {
+		m := entities.Metrics{MType: string(entities.HistogramMetricName), Histogram: records.Histogram[key]}
+		if err := add(key, m); err != nil {
+			return nil, err
+		}
+	}
+	for _, key := range sortedKeys(records.Summary) {
+		m := entities.Metrics{MType: string(entities.SummaryMetricName), Summary: records.Summary[key]}
+		if err := add(key, m); err != nil {
+			return nil, err
+		}
+	}
+
+	return metrics, nil
+}
+
+// sortedKeys returns the series keys of a map in order.
+func sortedKeys[V any](series map[entities.MetricName]V) []entities.MetricName {
+	keys := make([]entities.MetricName, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	return keys
+}
+
+// EncodeRecords writes the metrics to w in the given format.
+func EncodeRecords(w io.Writer, format Format, metrics []entities.Metrics) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(metrics); err != nil {
+			return fmt.Errorf("failed to encode the records: %w", err)
+		}
+		return nil
+	case FormatNDJSON:
+		enc := json.NewEncoder(w)
+		for _, m := range metrics {
+			if err := enc.Encode(m); err != nil {
+				return fmt.Errorf("failed to encode %s: %w", m.Key(), err)
+			}
+		}
+		return nil
+	case FormatCSV:
+		return encodeCSV(w, metrics)
+	default:
+		return fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+}
+
+// encodeCSV writes the metrics as CSV rows following csvHeader.
+func encodeCSV(w io.Writer, metrics []entities.Metrics) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to encode the records: %w", err)
+	}
+
+	for _, m := range metrics {
+		var value, data string
+		switch entities.MetricType(m.MType) {
+		case entities.CounterMetricName:
+			value = strconv.FormatInt(*m.Delta, 10)
+		case entities.GaugeMetricName:
+			value = strconv.FormatFloat(*m.Value, 'g', -1, 64)
+		case entities.HistogramMetricName, entities.SummaryMetricName:
+			var raw any = m.Histogram
+			if m.Summary != nil {
+				raw = m.Summary
+			}
+			encoded, err := json.Marshal(raw)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s: %w", m.Key(), err)
+			}
+			data = string(encoded)
+		}
+		if err := cw.Write([]string{m.MType, m.ID, m.Labels.String(), value, data}); err != nil {
+			return fmt.Errorf("failed to encode %s: %w", m.Key(), err)
+		}
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("failed to encode the records: %w", err)
+	}
+
+	return nil
+}
+
+// DecodeRecords reads metrics in the given format from r. Every metric is validated, and a
+// malformed one or a series given twice fails the whole input with ErrInvalidRecord.
+func DecodeRecords(r io.Reader, format Format) ([]entities.Metrics, error) {
+	var metrics []entities.Metrics
+	switch format {
+	case FormatJSON:
+		if err := json.NewDecoder(r).Decode(&metrics); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRecord, err)
+		}
+	case FormatNDJSON:
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(nil, 1<<20)
+		for line := 1; scanner.Scan(); line++ {
+			if strings.TrimSpace(scanner.Text()) == "" {
+				continue
+			}
+			var m entities.Metrics
+			if err := json.Unmarshal(scanner.Bytes(), &m); err != nil {
+				return nil, fmt.Errorf("%w on line %d: %w", ErrInvalidRecord, line, err)
+			}
+			metrics = append(metrics, m)
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read the records: %w", err)
+		}
+	case FormatCSV:
+		var err error
+		if metrics, err = decodeCSV(r); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+
+	seen := make(map[seriesID]bool, len(metrics))
+	for _, m := range metrics {
+		if err := validateRecord(m); err != nil {
+			return nil, err
+		}
+		id := seriesID{key: m.Key(), mType: entities.MetricType(m.MType)}
+		if seen[id] {
+			return nil, fmt.Errorf("%w: %s %s is given twice", ErrInvalidRecord, m.MType, id.key)
+		}
+		seen[id] = true
+	}
+
+	return metrics, nil
+}
+
+// decodeCSV reads metrics from CSV rows following csvHeader.
+func decodeCSV(r io.Reader) ([]entities.Metrics, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	if err != nil || !slices.Equal(header, csvHeader) {
+		return nil, fmt.Errorf("%w: the header must be %s", ErrInvalidRecord, strings.Join(csvHeader, ","))
+	}
+
+	var metrics []entities.Metrics
+	for {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return metrics, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidRecord, err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		m, err := metricFromRow(row)
+		if err != nil {
+			return nil, fmt.Errorf("%w on line %d: %w", ErrInvalidRecord, line, err)
+		}
+		metrics = append(metrics, m)
+	}
+}
+
+// metricFromRow builds a metric from the columns of a CSV row.
+func metricFromRow(row []string) (entities.Metrics, error) {
+	labels, err := entities.ParseLabels(row[2])
+	if err != nil {
+		return entities.Metrics{}, fmt.Errorf("invalid labels: %w", err)
+	}
+	m := entities.Metrics{MType: row[0], ID: row[1], Labels: labels}
+
+	switch entities.MetricType(m.MType) {
+	case entities.CounterMetricName:
+		delta, err := strconv.ParseInt(row[3], 10, 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid counter value: %w", err)
+		}
+		m.Delta = &delta
+	case entities.GaugeMetricName:
+		value, err := strconv.ParseFloat(row[3], 64)
+		if err != nil {
+			return m, fmt.Errorf("invalid gauge value: %w", err)
+		}
+		m.Value = &value
+	case entities.HistogramMetricName:
+		if err := json.Unmarshal([]byte(row[4]), &m.Histogram); err != nil {
+			return m, fmt.Errorf("invalid histogram: %w", err)
+		}
+	case entities.SummaryMetricName:
+		if err := json.Unmarshal([]byte(row[4]), &m.Summary); err != nil {
+			return m, fmt.Errorf("invalid summary: %w", err)
+		}
+	}
+
+	return m, nil
+}
+
+// validateRecord checks that an imported metric names a series and holds a value of its type.
+func validateRecord(m entities.Metrics) error {
+	if m.ID == "" {
+		return fmt.Errorf("%w: a %s metric has no name", ErrInvalidRecord, m.MType)
+	}
+
+	var err error
+	switch entities.MetricType(m.MType) {
+	case entities.CounterMetricName:
+		if m.Delta == nil {
+			err = errors.New("the counter has no value")
+		}
+	case entities.GaugeMetricName:
+		if m.Value == nil {
+			err = errors.New("the gauge has no value")
+		}
+	case entities.HistogramMetricName:
+		if m.Histogram == nil {
+			err = entities.ErrInvalidHistogram
+		} else {
+			err = m.Histogram.Validate()
+		}
+	case entities.SummaryMetricName:
+		if m.Summary == nil {
+			err = entities.ErrInvalidSummary
+		} else {
+			err = m.Summary.Validate()
+		}
+	default:
+		err = fmt.Errorf("unsupported type %q", m.MType)
+	}
+	if err != nil {
+		return fmt.Errorf("%w %s: %w", ErrInvalidRecord, m.Key(), err)
+	}
+
+	return nil
+}
+
+// Export writes every series of the storage to w in the given format. It returns
+// the number of exported series.
+func Export(ctx context.Context, store Storage, w io.Writer, format Format) (int, error) {
+	records, err := store.GetAllRecords(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the records: %w", err)
+	}
+
+	metrics, err := Records(records)
+	if err != nil {
+		return 0, err
+	}
+	if err = EncodeRecords(w, format, metrics); err != nil {
+		return 0, err
+	}
+
+	return len(metrics), nil
+}
+
+// Import replaces the given series in the storage with the imported values, so a
+// counter is restored to its exported total rather than added to the stored one.
+// An imported series starts a new history. Series that are not imported are kept.
+// Every metric is validated before any series is replaced, and the series of a chunk
+// that fails to be replaced are restored to their previous values. It returns the
+// number of imported series.
+func Import(ctx context.Context, store Storage, metrics []entities.Metrics) (int, error) {
+	chunks := make([]importBatch, 0, (len(metrics)+importChunk-1)/importChunk)
+	for start := 0; start < len(metrics); start += importChunk {
+		batch, err := newImportBatch(metrics[start:min(start+importChunk, len(metrics))])
+		if err != nil {
+			return 0, err
+		}
+		chunks = append(chunks, batch)
+	}
+	if len(chunks) == 0 {
+		return 0, nil
+	}
+
+	records, err := store.GetAllRecords(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the records: %w", err)
+	}
+	current, err := Records(records)
+	if err != nil {
+		return 0, err
+	}
+	previous := make(map[seriesID]entities.Metrics, len(current))
+	for _, m := range current {
+		previous[seriesID{key: m.Key(), mType: entities.MetricType(m.MType)}] = m
+	}
+
+	for i, batch := range chunks {
+		if err = batch.replace(ctx, store, batch.metrics); err == nil {
+			continue
+		}
+		restore := make([]entities.Metrics, 0, len(batch.metrics))
+		for _, m := range batch.metrics {
+			if p, ok := previous[seriesID{key: m.Key(), mType: entities.MetricType(m.MType)}]; ok {
+				restore = append(restore, p)
+			}
+		}
+		if rerr := batch.replace(ctx, store, restore); rerr != nil {
+			err = errors.Join(err, fmt.Errorf("failed to restore the previous series: %w", rerr))
+		}
+		return i * importChunk, err
+	}
+
+	return len(metrics), nil
+}
+
+// importBatch is a validated chunk of imported metrics with the matchers of their
+// series by type.
+type importBatch struct {
+	matchers map[entities.MetricType]*entities.SeriesMatcher
+	metrics  []entities.Metrics
+}
+
+// newImportBatch validates the metrics of a chunk and compiles the matchers of their series.
+func newImportBatch(metrics []entities.Metrics) (importBatch, error) {
+	keys := make(map[entities.MetricType][]string)
+	for _, m := range metrics {
+		if err := validateRecord(m); err != nil {
+			return importBatch{}, err
+		}
+		mType := entities.MetricType(m.MType)
+		keys[mType] = append(keys[mType], regexp.QuoteMeta(string(m.Key())))
+	}
+
+	batch := importBatch{matchers: make(map[entities.MetricType]*entities.SeriesMatcher, len(keys)), metrics: metrics}
+	for mType, quoted := range keys {
+		matcher, err := entities.NewSeriesMatcher(strings.Join(quoted, "|"), entities.PatternRegex)
+		if err != nil {
+			return importBatch{}, fmt.Errorf("failed to match the imported series: %w", err)
+		}
+		batch.matchers[mType] = matcher
+	}
+
+	return batch, nil
+}
+
+// replace deletes the series of the batch and stores the given metrics in their place.
+func (b importBatch) replace(ctx context.Context, store Storage, metrics []entities.Metrics) error {
+	for mType, matcher := range b.matchers {
+		if _, err := store.DeleteRecords(ctx, mType, matcher); err != nil {
+			return fmt.Errorf("failed to replace the imported series: %w", err)
+		}
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+	if err := store.StoreMetricsBatch(ctx, metrics); err != nil {
+		return fmt.Errorf("failed to store the imported series: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func exportSeed(t *testing.T) []entities.Metrics {
+	t.Helper()
+
+	delta := int64(7)
+	value := -2.5
+	histogram := entities.NewHistogram([]float64{1, 10})
+	histogram.Observe(0.5)
+	histogram.Observe(12)
+	summary := entities.NewSummary(0.01)
+	summary.Observe(3)
+
+	return []entities.Metrics{
+		{ID: "PollCount", MType: "counter", Delta: &delta, Labels: entities.Labels{"host": "web,1"}},
+		{ID: "Alloc", MType: "gauge", Value: &value},
+		{ID: "latency", MType: "histogram", Histogram: histogram},
+		{ID: "size", MType: "summary", Summary: summary},
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	source, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	require.NoError(t, source.StoreMetricsBatch(ctx, exportSeed(t)))
+	want, err := source.GetAllRecords(ctx)
+	require.NoError(t, err)
+
+	for _, format := range []Format{FormatJSON, FormatNDJSON, FormatCSV} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+			exported, err := Export(ctx, source, &buf, format)
+			require.NoError(t, err)
+			assert.Equal(t, 4, exported)
+
+			metrics, err := DecodeRecords(&buf, format)
+			require.NoError(t, err)
+
+			target, err := NewMemStorage(slog.Default())
+			require.NoError(t, err)
+			// Importing twice restores the same totals.
+			for range 2 {
+				imported, err := Import(ctx, target, metrics)
+				require.NoError(t, err)
+				assert.Equal(t, 4, imported)
+			}
+
+			got, err := target.GetAllRecords(ctx)
+			require.NoError(t, err)
+			assert.Equal(t, want.Counter, got.Counter)
+			assert.Equal(t, want.Gauge, got.Gauge)
+			assert.Equal(t, want.Histogram, got.Histogram)
+			assert.Equal(t, want.Summary["size"].Count, got.Summary["size"].Count)
+			assert.Equal(t, want.Summary["size"].Quantile(0.5), got.Summary["size"].Quantile(0.5))
+		})
+	}
+}
+
+func TestImportKeepsOtherSeries(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+
+	delta := int64(100)
+	require.NoError(t, store.StoreMetricsBatch(ctx, []entities.Metrics{
+		{ID: "PollCount", MType: "counter", Delta: &delta, Labels: entities.Labels{"host": "web,1"}},
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+	}))
+
+	_, err = Import(ctx, store, exportSeed(t)[:1])
+	require.NoError(t, err)
+
+	counters, err := store.GetAllRecordsByType(ctx, entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), *counters[`PollCount{host="web,1"}`].Delta, "the counter is replaced")
+	assert.Equal(t, int64(100), *counters["PollCount"].Delta, "series that are not imported are kept")
+}
+
+// failingBatchStorage fails the next batches stored while fail is set.
+type failingBatchStorage struct {
+	Storage
+	fail bool
+}
+
+func (fs *failingBatchStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	if fs.fail {
+		fs.fail = false
+		return assert.AnError
+	}
+
+	return fs.Storage.StoreMetricsBatch(ctx, metrics)
+}
+
+func TestImportRestoresFailedSeries(t *testing.T) {
+	ctx := context.Background()
+	mem, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	store := &failingBatchStorage{Storage: mem}
+
+	delta := int64(100)
+	require.NoError(t, store.StoreMetricsBatch(ctx, []entities.Metrics{
+		{ID: "PollCount", MType: "counter", Delta: &delta, Labels: entities.Labels{"host": "web,1"}},
+	}))
+
+	store.fail = true
+	imported, err := Import(ctx, store, exportSeed(t))
+	require.ErrorIs(t, err, assert.AnError)
+	assert.Zero(t, imported)
+
+	records, err := store.GetAllRecords(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[entities.MetricName]entities.Counter{`PollCount{host="web,1"}`: 100}, records.Counter,
+		"the replaced counter is restored")
+	assert.Empty(t, records.Gauge, "the series that did not exist are not created")
+
+	invalid := exportSeed(t)
+	invalid[1].Value = nil
+	_, err = Import(ctx, store, invalid)
+	require.ErrorIs(t, err, ErrInvalidRecord)
+	records, err = store.GetAllRecords(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, entities.Counter(100), records.Counter[`PollCount{host="web,1"}`],
+		"an invalid metric fails the import before any series is replaced")
+}
+
+func TestDecodeRecords(t *testing.T) {
+	tests := []struct {
+		name   string
+		format Format
+		input  string
+	}{
+		{name: "malformed json", format: FormatJSON, input: `[{"id": "a"`},
+		{name: "counter without value", format: FormatJSON, input: `[{"id": "a", "type": "counter"}]`},
+		{name: "unknown type", format: FormatNDJSON, input: `{"id": "a", "type": "meter", "value": 1}`},
+		{
+			name:   "series given twice",
+			format: FormatNDJSON,
+			input:  "{\"id\":\"a\",\"type\":\"gauge\",\"value\":1}\n{\"id\":\"a\",\"type\":\"gauge\",\"value\":2}",
+		},
+		{name: "unexpected header", format: FormatCSV, input: "name,value\na,1\n"},
+		{name: "invalid csv value", format: FormatCSV, input: "type,name,labels,value,data\ngauge,a,,high,\n"},
+		{name: "invalid histogram", format: FormatCSV, input: "type,name,labels,value,data\nhistogram,a,,,{}\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := DecodeRecords(strings.NewReader(tt.input), tt.format)
+			assert.ErrorIs(t, err, ErrInvalidRecord)
+		})
+	}
+
+	_, err := ParseFormat("xml")
+	require.ErrorIs(t, err, ErrUnknownFormat)
+	format, err := ParseFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FormatJSON, format)
+}
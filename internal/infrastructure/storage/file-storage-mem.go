@@ -4,7 +4,6 @@ package storage
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log/slog"
 	"os"
@@ -12,61 +11,105 @@ import (
 	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
 )
 
 // ownerFilePerm defines the permissions for the storage file.
 var ownerFilePerm os.FileMode = 0o600
 
+// walSuffix is appended to the storage file path to name its write-ahead log.
+const walSuffix = ".wal"
+
 // FileStorage represents a storage backend that persists metrics to a file.
-// It embeds MemStorage to utilize in-memory metrics handling and provides
-// mechanisms for periodic saving of metrics to a file.
+// It embeds MemStorage to utilize in-memory metrics handling. Every change is
+// appended to a write-ahead log next to the file, and the log is periodically
+// compacted into a snapshot of the whole storage written to the file. The
+// previous snapshots are kept as path.1, path.2, ... up to snapshotsKept.
 type FileStorage struct {
 	stopSaveChan  chan struct{} // Channel for signaling when to stop saving
-	file          *os.File      // File to persist metrics
+	wal           *wal          // Write-ahead log of the changes since the snapshot
 	logger        *slog.Logger  // Logger for logging messages
+	path          string        // Path of the current snapshot
 	MemStorage                  // Embedded in-memory metrics storage
-	storeInterval int           // Interval for periodic saving of metrics
+	storeInterval int           // Interval for periodic compaction of the log, in seconds
+	snapshotsKept int           // Number of previous snapshots kept next to the current one
+	walMu         sync.Mutex    // Keeps the log in the order the changes are applied
+	// Started empty with NoRestore and not written to since, the files on disk are left as they are
+	pristine bool
+}
+
+// FileOptions configures a FileStorage.
+type FileOptions struct {
+	Path          string // Path of the current snapshot
+	RestoreFrom   string // Snapshot to start from instead of the latest state, see findSnapshot
+	StoreInterval int    // Interval for periodic compaction of the log, in seconds
+	SnapshotsKept int    // Number of previous snapshots kept, negative for DefaultSnapshotsKept
+	NoRestore     bool   // Start empty instead of loading the state on disk
 }
 
-// NewFileStorage creates a new instance of FileStorage. It opens the specified
-// file for reading and writing, initializes the in-memory storage, and starts
-// a periodic saving routine if the storeInterval is greater than zero.
-func NewFileStorage(logger *slog.Logger, storeInterval int, storePath string) (*FileStorage, error) {
-	file, err := os.OpenFile(storePath, os.O_RDWR|os.O_CREATE, ownerFilePerm)
+// fileSnapshot is the content of the storage file. Seq is the sequence number
+// of the last write-ahead log entry contained in the snapshot.
+type fileSnapshot struct {
+	Saved     time.Time                                   `json:"Saved"`
+	Counter   map[entities.MetricName]entities.Counter    `json:"Counter"`
+	Gauge     map[entities.MetricName]entities.Gauge      `json:"Gauge"`
+	Histogram map[entities.MetricName]*entities.Histogram `json:"Histogram"`
+	Summary   map[entities.MetricName]*entities.Summary   `json:"Summary"`
+	Metadata  map[entities.MetricName]entities.Metadata   `json:"Metadata,omitempty"`
+	Seq       uint64                                      `json:"Seq,omitempty"`
+}
+
+// NewFileStorage creates a new instance of FileStorage. It opens the write-ahead
+// log of the specified file, restores the newest readable snapshot and replays
+// the log on top of it, and starts a periodic compaction routine if the
+// storeInterval is greater than zero. With a zero storeInterval every change is
+// flushed to disk before it is acknowledged and the log is compacted once it
+// grows too large. With RestoreFrom set the storage starts from an older state instead,
+// and the latest state is kept as a previous snapshot. With NoRestore it starts empty and
+// the latest state is only rotated to a previous snapshot by the first change.
+func NewFileStorage(logger *slog.Logger, opts FileOptions) (*FileStorage, error) {
+	if opts.SnapshotsKept < 0 {
+		opts.SnapshotsKept = DefaultSnapshotsKept
+	}
+
+	log, err := openWAL(opts.Path+walSuffix, opts.StoreInterval == 0)
 	if err != nil {
-		return nil, fmt.Errorf("store failed to open file: %w", err)
+		return nil, fmt.Errorf("store failed to open the wal: %w", err)
 	}
 	logger.DebugContext(context.Background(), "created file storage")
 
 	fs := &FileStorage{
-		logger: logger,
-		MemStorage: MemStorage{
-			mu: sync.Mutex{},
-			MetricsStorage: MetricsStorage{
-				Counter: make(map[entities.MetricName]entities.Counter),
-				Gauge:   make(map[entities.MetricName]entities.Gauge),
-			},
-			logger: logger,
-		},
+		logger:        logger,
 		stopSaveChan:  make(chan struct{}),
-		file:          file,
-		storeInterval: storeInterval,
+		path:          opts.Path,
+		wal:           log,
+		storeInterval: opts.StoreInterval,
+		snapshotsKept: opts.SnapshotsKept,
 	}
+	fs.MemStorage.logger = logger
+	fs.MemStorage.reset()
 
-	err = fs.loadFromFile()
+	switch {
+	case opts.RestoreFrom != "":
+		err = fs.restoreFrom(opts.RestoreFrom)
+	case opts.NoRestore:
+		err = fs.startEmpty()
+	default:
+		err = fs.loadFromFile()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("storage mem failed to load the file: %w", err)
 	}
 
-	if storeInterval > 0 {
+	if opts.StoreInterval > 0 {
 		go fs.periodicSave()
 	}
 
 	return fs, nil
 }
 
-// periodicSave periodically saves the in-memory metrics to the file based
-// on the configured storeInterval. It runs in a separate goroutine.
+// periodicSave periodically compacts the write-ahead log into the snapshot
+// based on the configured storeInterval. It runs in a separate goroutine.
 func (fs *FileStorage) periodicSave() {
 	ticker := time.NewTicker(time.Second * time.Duration(fs.storeInterval))
 	defer ticker.Stop()
@@ -74,8 +117,8 @@ func (fs *FileStorage) periodicSave() {
 	for {
 		select {
 		case <-ticker.C:
-			if err := fs.saveToFile(); err != nil {
-				fs.logger.ErrorContext(context.Background(), "error saving the file")
+			if err := fs.compact(); err != nil {
+				fs.logger.ErrorContext(context.Background(), "error saving the file", helpers.ErrAttr(err))
 			}
 			fs.logger.DebugContext(context.Background(), "saved storage state")
 		case <-fs.stopSaveChan:
@@ -84,112 +127,300 @@ func (fs *FileStorage) periodicSave() {
 	}
 }
 
-// Close stops the periodic saving routine, saves any remaining metrics to the
-// file, and closes the file.
+// Close stops the periodic compaction routine, compacts the remaining changes
+// into the snapshot, and closes the write-ahead log.
 func (fs *FileStorage) Close(ctx context.Context) error {
 	close(fs.stopSaveChan)
-	err := fs.saveToFile()
+	err := fs.compact()
 	if err != nil {
 		return fmt.Errorf("storage mem failed to save the file: %w", err)
 	}
 
-	err = fs.file.Close()
-	if err != nil {
-		return fmt.Errorf("storage mem failed to close the file: %w", err)
+	if err = fs.wal.close(); err != nil {
+		return fmt.Errorf("storage mem failed to close the wal: %w", err)
 	}
 	return nil
 }
 
-// saveToFile saves the current state of the in-memory metrics to the file
-// in JSON format, truncating the file first to ensure it's overwritten.
-func (fs *FileStorage) saveToFile() error {
-	fs.mu.Lock()
-	defer fs.mu.Unlock()
+// compact writes a snapshot of the storage to the file and empties the
+// write-ahead log. The snapshot records the sequence number of the last log
+// entry it contains, so a crash before the log is emptied does not apply the
+// same entries twice.
+func (fs *FileStorage) compact() error {
+	return fs.compactKeeping(fs.snapshotsKept)
+}
 
-	err := fs.file.Truncate(0)
-	if err != nil {
-		return fmt.Errorf("storage mem failed to truncate the file: %w", err)
+// compactKeeping is compact keeping the given number of previous snapshots. A pristine
+// storage has nothing to save and leaves the snapshots as they are.
+func (fs *FileStorage) compactKeeping(keep int) error {
+	fs.walMu.Lock()
+	defer fs.walMu.Unlock()
+
+	if fs.pristine {
+		return nil
 	}
-	_, err = fs.file.Seek(0, 0)
-	if err != nil {
-		return fmt.Errorf("storage mem failed to reset the file: %w", err)
+
+	if err := fs.saveToFile(fs.wal.seq, keep); err != nil {
+		return err
 	}
 
-	encoder := json.NewEncoder(fs.file)
-	data := struct {
-		Counter map[entities.MetricName]entities.Counter `json:"Counter"`
-		Gauge   map[entities.MetricName]entities.Gauge   `json:"Gauge"`
-	}{
-		Counter: fs.Counter,
-		Gauge:   fs.Gauge,
+	if err := fs.wal.reset(); err != nil {
+		return fmt.Errorf("storage mem failed to reset the wal: %w", err)
 	}
 
-	if err = encoder.Encode(&data); err != nil {
+	return nil
+}
+
+// saveToFile saves the current state of the in-memory metrics as a new snapshot,
+// replacing the file atomically and keeping up to keep previous snapshots.
+// The caller must hold fs.walMu, so no change is applied while the series are copied.
+func (fs *FileStorage) saveToFile(seq uint64, keep int) error {
+	records := fs.copyRecords(false)
+	metadata, _ := fs.MemStorage.GetAllMetadata(context.Background())
+	content, err := encodeSnapshot(&fileSnapshot{
+		Saved:     time.Now().UTC(),
+		Counter:   records.Counter,
+		Gauge:     records.Gauge,
+		Histogram: records.Histogram,
+		Summary:   records.Summary,
+		Metadata:  metadata,
+		Seq:       seq,
+	})
+	if err != nil {
 		return fmt.Errorf("storage mem failed to encode: %w", err)
 	}
+
+	if err = writeSnapshot(fs.path, keep, content); err != nil {
+		return fmt.Errorf("storage mem failed to write the snapshot: %w", err)
+	}
 	return nil
 }
 
-// loadFromFile loads metrics from the file into the in-memory storage,
-// populating the Counter and Gauge maps if data exists.
+// loadFromFile loads the snapshot from the file into the in-memory storage and
+// replays the write-ahead log entries that are newer than the snapshot. When the log
+// does not follow the snapshot, e.g. after falling back to an older snapshot than the
+// one the log was emptied at, the missing changes are lost and their range is logged.
+// The files hold no update times, so the TTL of the loaded series starts at load time.
+// It must not run concurrently with other operations.
 func (fs *FileStorage) loadFromFile() error {
-	fs.mu.Lock()
-	defer fs.mu.Unlock()
-
-	fileInfo, err := fs.file.Stat()
+	seq, err := fs.loadSnapshot()
 	if err != nil {
-		return fmt.Errorf("server service failed to get file info: %w", err)
+		return err
 	}
 
-	if fileInfo.Size() == 0 {
-		return nil
+	replayed, first, err := fs.wal.replay(seq, fs.replay)
+	if err != nil {
+		return fmt.Errorf("storage mem failed to replay the wal: %w", err)
 	}
+	if first > seq+1 {
+		fs.logger.ErrorContext(context.Background(), "the wal does not follow the restored snapshot, changes are lost",
+			slog.Uint64("snapshot_seq", seq), slog.Uint64("lost_from", seq+1), slog.Uint64("lost_to", first-1))
+	}
+	fs.logger.DebugContext(context.Background(), "restored file storage",
+		slog.Uint64("snapshot_seq", seq), slog.Int("replayed", replayed))
 
-	decoder := json.NewDecoder(fs.file)
-
-	data := NewMetricsStorage()
+	return nil
+}
 
-	err = decoder.Decode(&data)
+// loadSnapshot populates the Counter, Gauge, Histogram and Summary maps from the
+// newest readable snapshot, falling back to the rotated ones if the current
+// snapshot is corrupt. It returns the sequence number recorded in the snapshot.
+func (fs *FileStorage) loadSnapshot() (uint64, error) {
+	data, err := readLatestSnapshot(fs.path, fs.snapshotsKept, fs.logger)
 	if err != nil {
-		return fmt.Errorf("storage mem failed to json parse file content: %w", err)
+		return 0, fmt.Errorf("storage mem failed to read a snapshot: %w", err)
 	}
+	if data == nil {
+		return 0, nil
+	}
+	fs.setState(data)
+
+	return data.Seq, nil
+}
 
-	fs.Counter = data.Counter
-	fs.Gauge = data.Gauge
+// setState replaces the in-memory metrics and metadata with the content of a snapshot
+// and starts the TTL clock of the series. It must not run concurrently with other operations.
+func (fs *FileStorage) setState(data *fileSnapshot) {
+	fs.load(&MetricsStorage{
+		Counter:   data.Counter,
+		Gauge:     data.Gauge,
+		Histogram: data.Histogram,
+		Summary:   data.Summary,
+	})
+	for _, md := range data.Metadata {
+		fs.metadata[md.Name] = md
+	}
+}
+
+// replay applies a write-ahead log entry to the in-memory storage.
+func (fs *FileStorage) replay(rec walRecord) error {
+	switch rec.Op {
+	case walOpUpdate:
+		if err := fs.MemStorage.StoreMetricsBatch(context.Background(), rec.Metrics); err != nil {
+			return fmt.Errorf("failed to apply update: %w", err)
+		}
+	case walOpDelete:
+		for _, s := range rec.Series {
+			fs.deleteSeries(s.Key, s.MType)
+		}
+	case walOpMetadata:
+		if err := fs.MemStorage.SetMetadata(context.Background(), rec.Metadata); err != nil {
+			return fmt.Errorf("failed to apply metadata: %w", err)
+		}
+	default:
+		return fmt.Errorf("unknown wal operation %q", rec.Op)
+	}
 
 	return nil
 }
 
-// CreateRecord adds a new metric record to the in-memory storage and saves it
-// to the file immediately if storeInterval is set to zero.
-func (fs *FileStorage) CreateRecord(metrics entities.Metrics) error {
-	if err := fs.MemStorage.CreateRecord(metrics); err != nil {
-		return fmt.Errorf("file store: %w", err)
+// logged applies a change to the in-memory storage and, if it succeeds, appends
+// the entry built by record to the write-ahead log. A nil entry is not logged.
+// With a zero storeInterval the log is compacted once it grows too large.
+func (fs *FileStorage) logged(apply func() error, record func() *walRecord) error {
+	fs.walMu.Lock()
+	defer fs.walMu.Unlock()
+
+	if err := apply(); err != nil {
+		return err
 	}
 
-	if fs.storeInterval == 0 {
-		err := fs.saveToFile()
-		if err != nil {
+	rec := record()
+	if rec == nil {
+		return nil
+	}
+
+	// The first change of a pristine storage replaces the latest state, kept as the previous snapshot.
+	if fs.pristine {
+		if err := fs.saveToFile(fs.wal.seq, max(fs.snapshotsKept, 1)); err != nil {
+			return fmt.Errorf("file store failed to save the first change: %w", err)
+		}
+		fs.pristine = false
+		return nil
+	}
+
+	if err := fs.wal.append(*rec); err != nil {
+		return fmt.Errorf("file store failed to log the change: %w", err)
+	}
+
+	if fs.storeInterval == 0 && fs.wal.size > walCompactSize {
+		if err := fs.saveToFile(fs.wal.seq, fs.snapshotsKept); err != nil {
 			return fmt.Errorf("server service failed to save data to file: %w", err)
 		}
+		if err := fs.wal.reset(); err != nil {
+			return fmt.Errorf("file store failed to reset the wal: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// CreateRecord adds a new metric record to the in-memory storage and appends
+// it to the write-ahead log.
+func (fs *FileStorage) CreateRecord(ctx context.Context, metrics entities.Metrics) error {
+	err := fs.logged(
+		func() error { return fs.MemStorage.CreateRecord(ctx, metrics) },
+		func() *walRecord { return &walRecord{Op: walOpUpdate, Metrics: []entities.Metrics{metrics}} },
+	)
+	if err != nil {
+		return fmt.Errorf("file store: %w", err)
 	}
 
 	return nil
 }
 
+// DeleteRecord removes a series from the in-memory storage and appends the
+// removal to the write-ahead log.
+func (fs *FileStorage) DeleteRecord(ctx context.Context, mName entities.MetricName, mType entities.MetricType) error {
+	err := fs.logged(
+		func() error { return fs.MemStorage.DeleteRecord(ctx, mName, mType) },
+		func() *walRecord {
+			return &walRecord{Op: walOpDelete, Series: []walSeries{{Key: mName, MType: mType}}}
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("file store delete: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRecords removes the matching series from the in-memory storage and
+// appends the removals to the write-ahead log.
+func (fs *FileStorage) DeleteRecords(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	var deleted []seriesID
+	err := fs.logged(
+		func() error {
+			deleted = fs.MemStorage.deleteRecords(mType, matcher)
+			return nil
+		},
+		func() *walRecord { return deletionRecord(deleted) },
+	)
+	if err != nil {
+		return len(deleted), fmt.Errorf("file store delete: %w", err)
+	}
+
+	return len(deleted), nil
+}
+
+// PurgeExpired removes the expired series from the in-memory storage and
+// appends the removals to the write-ahead log.
+func (fs *FileStorage) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	var purged []seriesID
+	err := fs.logged(
+		func() error {
+			purged = fs.MemStorage.purgeExpired(now)
+			return nil
+		},
+		func() *walRecord { return deletionRecord(purged) },
+	)
+	if err != nil {
+		return len(purged), fmt.Errorf("file store purge: %w", err)
+	}
+
+	return len(purged), nil
+}
+
 // StoreMetricsBatch adds multiple metric records to the in-memory storage
-// and saves them to the file immediately if storeInterval is set to zero.
-func (fs *FileStorage) StoreMetricsBatch(metrics []entities.Metrics) error {
-	if err := fs.MemStorage.StoreMetricsBatch(metrics); err != nil {
+// and appends them to the write-ahead log as a single entry.
+func (fs *FileStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	err := fs.logged(
+		func() error { return fs.MemStorage.StoreMetricsBatch(ctx, metrics) },
+		func() *walRecord { return &walRecord{Op: walOpUpdate, Metrics: metrics} },
+	)
+	if err != nil {
 		return fmt.Errorf("file batch store: %w", err)
 	}
 
-	if fs.storeInterval == 0 {
-		err := fs.saveToFile()
-		if err != nil {
-			return fmt.Errorf("server service failed to save data to file: %w", err)
-		}
+	return nil
+}
+
+// SetMetadata registers the metadata of metric names in the in-memory storage and
+// appends it to the write-ahead log.
+func (fs *FileStorage) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	err := fs.logged(
+		func() error { return fs.MemStorage.SetMetadata(ctx, metadata) },
+		func() *walRecord { return &walRecord{Op: walOpMetadata, Metadata: metadata} },
+	)
+	if err != nil {
+		return fmt.Errorf("file store metadata: %w", err)
 	}
 
 	return nil
 }
+
+// deletionRecord builds the write-ahead log entry of removed series,
+// or nil if nothing was removed.
+func deletionRecord(ids []seriesID) *walRecord {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	series := make([]walSeries, 0, len(ids))
+	for _, id := range ids {
+		series = append(series, walSeries{Key: id.key, MType: id.mType})
+	}
+
+	return &walRecord{Op: walOpDelete, Series: series}
+}
@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedFileStorage applies a mix of updates and deletes to a file storage.
+func seedFileStorage(t *testing.T, fs *FileStorage) {
+	t.Helper()
+
+	delta := int64(2)
+	value := 3.5
+	h := entities.NewHistogram([]float64{1})
+	h.Observe(0.5)
+
+	require.NoError(t, fs.CreateRecord(context.Background(),
+		entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	require.NoError(t, fs.StoreMetricsBatch(context.Background(), []entities.Metrics{
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+		{ID: "Alloc", MType: "gauge", Value: &value, Labels: entities.Labels{"host": "web-1"}},
+		{ID: "Stale", MType: "gauge", Value: &value},
+		{ID: "latency", MType: "histogram", Histogram: h},
+	}))
+	require.NoError(t, fs.DeleteRecord(context.Background(), "Stale", entities.GaugeMetricName))
+}
+
+// assertSeeded checks the state left by seedFileStorage.
+func assertSeeded(t *testing.T, fs *FileStorage) {
+	t.Helper()
+
+	all, err := fs.GetAllRecords(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[entities.MetricName]entities.Counter{"PollCount": 4}, all.Counter)
+	assert.Equal(t, map[entities.MetricName]entities.Gauge{`Alloc{host="web-1"}`: 3.5}, all.Gauge)
+	require.Contains(t, all.Histogram, entities.MetricName("latency"))
+	assert.Equal(t, uint64(1), all.Histogram["latency"].Count)
+}
+
+func TestFileStorageReplaysWAL(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	seedFileStorage(t, fs)
+
+	// Simulate a crash: the storage is never closed, no snapshot has been written yet.
+	assert.NoFileExists(t, path)
+
+	restored, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	assertSeeded(t, restored)
+}
+
+func TestFileStorageMetadata(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	heapAlloc := entities.Metadata{Name: "HeapAlloc", Unit: "bytes", Display: entities.DisplayBytes}
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	require.NoError(t, fs.SetMetadata(context.Background(), []entities.Metadata{heapAlloc}))
+
+	// The metadata is replayed from the WAL after a crash.
+	replayed, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	md, err := replayed.GetMetadata(context.Background(), "HeapAlloc")
+	require.NoError(t, err)
+	assert.Equal(t, heapAlloc, md)
+
+	// And loaded from the snapshot written on close.
+	require.NoError(t, replayed.Close(context.Background()))
+	require.FileExists(t, path)
+	restored, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	all, err := restored.GetAllMetadata(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[entities.MetricName]entities.Metadata{"HeapAlloc": heapAlloc}, all)
+}
+
+func TestFileStorageIgnoresTornWALEntry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	seedFileStorage(t, fs)
+
+	walFile, err := os.OpenFile(path+walSuffix, os.O_APPEND|os.O_WRONLY, ownerFilePerm)
+	require.NoError(t, err)
+	_, err = walFile.WriteString(`{"op":"update","metrics":[{"id":"PollCount","type":"cou`)
+	require.NoError(t, err)
+	require.NoError(t, walFile.Close())
+
+	restored, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	assertSeeded(t, restored)
+
+	// The torn entry is dropped, so new entries are appended after the valid ones.
+	delta := int64(1)
+	require.NoError(t, restored.CreateRecord(context.Background(),
+		entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	again, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	record, err := again.GetRecord(context.Background(), "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *record.Delta)
+}
+
+func TestFileStorageCompaction(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	seedFileStorage(t, fs)
+
+	// A crash between writing the snapshot and emptying the log must not apply the entries twice.
+	require.NoError(t, fs.saveToFile(fs.wal.seq, DefaultSnapshotsKept))
+	restored, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	assertSeeded(t, restored)
+
+	require.NoError(t, restored.Close(context.Background()))
+	info, err := os.Stat(path + walSuffix)
+	require.NoError(t, err)
+	assert.Zero(t, info.Size(), "the log must be emptied by the compaction")
+
+	reopened, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	assertSeeded(t, reopened)
+}
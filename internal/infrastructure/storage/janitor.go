@@ -0,0 +1,59 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// DefaultJanitorInterval is the default interval between two purges of expired series.
+const DefaultJanitorInterval = time.Minute
+
+// Janitor periodically purges the expired series of a storage. The purges also let the
+// storages that keep the history in a table, e.g. DBStore, prune the old samples.
+type Janitor struct {
+	store    Storage       // Storage purged by the janitor.
+	logger   *slog.Logger  // Logger for logging purges and errors.
+	interval time.Duration // Interval between two purges.
+}
+
+// NewJanitor creates a janitor purging the store every interval.
+// A non-positive interval falls back to DefaultJanitorInterval.
+func NewJanitor(store Storage, logger *slog.Logger, interval time.Duration) *Janitor {
+	if interval <= 0 {
+		interval = DefaultJanitorInterval
+	}
+
+	return &Janitor{store: store, logger: logger, interval: interval}
+}
+
+// Run purges the expired series every interval until the context is canceled.
+func (j *Janitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			j.purge(ctx, now)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// purge removes the series expired at now, logging the outcome.
+func (j *Janitor) purge(ctx context.Context, now time.Time) {
+	purged, err := j.store.PurgeExpired(ctx, now)
+	if err != nil {
+		j.logger.ErrorContext(ctx, "failed to purge expired series", helpers.ErrAttr(err))
+		return
+	}
+
+	if purged > 0 {
+		j.logger.DebugContext(ctx, "purged expired series", slog.Int("count", purged))
+	}
+}
@@ -8,7 +8,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 )
@@ -16,58 +19,121 @@ import (
 // ErrNotFound is returned when a requested item cannot be found in storage.
 var ErrNotFound = errors.New("item not found")
 
-// MetricsStorage holds collections of counter and gauge metrics.
+// MetricsStorage holds collections of counter, gauge, histogram and summary metrics.
+// The maps are keyed by series key (see entities.SeriesKey), so the same metric name
+// with different labels is stored as separate series.
 type MetricsStorage struct {
-	Counter map[entities.MetricName]entities.Counter `json:"counter"` // Collection of counter metrics.
-	Gauge   map[entities.MetricName]entities.Gauge   `json:"gauge"`   // Collection of gauge metrics.
+	Counter   map[entities.MetricName]entities.Counter    `json:"counter"`   // Collection of counter metrics.
+	Gauge     map[entities.MetricName]entities.Gauge      `json:"gauge"`     // Collection of gauge metrics.
+	Histogram map[entities.MetricName]*entities.Histogram `json:"histogram"` // Collection of histogram metrics.
+	Summary   map[entities.MetricName]*entities.Summary   `json:"summary"`   // Collection of summary metrics.
 }
 
 // NewMetricsStorage initializes a new MetricsStorage instance.
 func NewMetricsStorage() *MetricsStorage {
 	return &MetricsStorage{
-		Counter: make(map[entities.MetricName]entities.Counter),
-		Gauge:   make(map[entities.MetricName]entities.Gauge),
+		Counter:   make(map[entities.MetricName]entities.Counter),
+		Gauge:     make(map[entities.MetricName]entities.Gauge),
+		Histogram: make(map[entities.MetricName]*entities.Histogram),
+		Summary:   make(map[entities.MetricName]*entities.Summary),
+	}
+}
+
+// memShards is the number of shards of a MemStorage. Series are spread over the
+// shards by the hash of their key, so updates of different series rarely contend.
+const memShards = 64
+
+// memShard holds the series whose key hashes to it, guarded by its own lock.
+// Reads take the lock shared, so they do not wait for each other.
+type memShard struct {
+	history        map[seriesID]*sampleRing // Ring buffers with the recent samples of every series.
+	updated        map[seriesID]time.Time   // Time of the last update of every series.
+	MetricsStorage                          // Series of every type.
+	mu             sync.RWMutex             // Lock guarding the shard.
+}
+
+// newMemShard creates an empty shard.
+func newMemShard() *memShard {
+	return &memShard{
+		MetricsStorage: *NewMetricsStorage(),
+		history:        make(map[seriesID]*sampleRing),
+		updated:        make(map[seriesID]time.Time),
 	}
 }
 
 // MemStorage provides an in-memory implementation of metrics storage,
-// supporting concurrent access and operations.
+// supporting concurrent access and operations. The series are split into
+// lock-striped shards, so ingestion of different series and reads proceed in parallel.
 type MemStorage struct {
-	logger         *slog.Logger // Logger for logging events and errors.
-	MetricsStorage              // Embedding MetricsStorage for storage functionalities.
-	mu             sync.Mutex   // Mutex for synchronizing access to storage.
+	logger   *slog.Logger                              // Logger for logging events and errors.
+	metadata map[entities.MetricName]entities.Metadata // Metadata of the metric names.
+	ttl      atomic.Pointer[entities.TTLPolicy]        // Expiry policy of the series, nil keeps them forever.
+	shards   [memShards]*memShard                      // Shards holding the series.
+	metaMu   sync.RWMutex                              // Lock guarding the metadata.
 }
 
 // NewMemStorage creates a new MemStorage instance with logging capabilities.
 func NewMemStorage(logger *slog.Logger) (*MemStorage, error) {
 	logger.DebugContext(context.Background(), "created mem storage")
 
-	ms := &MemStorage{
-		mu: sync.Mutex{},
-		MetricsStorage: MetricsStorage{
-			Counter: make(map[entities.MetricName]entities.Counter),
-			Gauge:   make(map[entities.MetricName]entities.Gauge),
-		},
-		logger: logger,
-	}
+	ms := &MemStorage{logger: logger}
+	ms.reset()
 
 	return ms, nil
 }
 
+// reset replaces every shard with an empty one and drops the metadata.
+func (ms *MemStorage) reset() {
+	for i := range ms.shards {
+		ms.shards[i] = newMemShard()
+	}
+
+	ms.metaMu.Lock()
+	ms.metadata = make(map[entities.MetricName]entities.Metadata)
+	ms.metaMu.Unlock()
+}
+
+// shardIndex returns the index of the shard holding a series, using the FNV-1a hash of its key.
+func shardIndex(key entities.MetricName) int {
+	const (
+		offset32 = 2166136261
+		prime32  = 16777619
+	)
+
+	hash := uint32(offset32)
+	for i := range len(key) {
+		hash ^= uint32(key[i])
+		hash *= prime32
+	}
+
+	return int(hash % memShards)
+}
+
+// shard returns the shard holding a series.
+func (ms *MemStorage) shard(key entities.MetricName) *memShard {
+	return ms.shards[shardIndex(key)]
+}
+
 // CreateRecord stores a new metrics record in memory,
-// determining whether it is a counter or gauge metric.
-func (ms *MemStorage) CreateRecord(metrics entities.Metrics) error {
-	ms.logger.DebugContext(context.Background(), fmt.Sprintf("creating %s record", metrics.MType))
+// determining whether it is a counter, gauge, histogram or summary metric.
+func (ms *MemStorage) CreateRecord(ctx context.Context, metrics entities.Metrics) error {
+	ms.logger.DebugContext(ctx, fmt.Sprintf("creating %s record", metrics.MType))
 
 	switch entities.MetricType(metrics.MType) {
 	case entities.CounterMetricName:
-		if err := ms.createCounterRecord(metrics); err != nil {
-			return fmt.Errorf("store counter: %w", err)
-		}
+		ms.createCounterRecord(metrics)
 		return nil
 	case entities.GaugeMetricName:
-		if err := ms.createGaugeRecord(metrics); err != nil {
-			return fmt.Errorf("store gauge: %w", err)
+		ms.createGaugeRecord(metrics)
+		return nil
+	case entities.HistogramMetricName:
+		if err := ms.createHistogramRecord(metrics); err != nil {
+			return fmt.Errorf("store histogram: %w", err)
+		}
+		return nil
+	case entities.SummaryMetricName:
+		if err := ms.createSummaryRecord(metrics); err != nil {
+			return fmt.Errorf("store summary: %w", err)
 		}
 		return nil
 	default:
@@ -76,142 +142,591 @@ func (ms *MemStorage) CreateRecord(metrics entities.Metrics) error {
 }
 
 // createCounterRecord adds or updates a counter metric in memory.
-func (ms *MemStorage) createCounterRecord(metric entities.Metrics) error {
-	if ms.Counter == nil {
-		return errors.New("mem not initialized") // Error if counter store is uninitialized.
+func (ms *MemStorage) createCounterRecord(metric entities.Metrics) {
+	key := metric.Key()
+	s := ms.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Counter[key] += entities.Counter(*metric.Delta) // Initialize or increment the counter.
+	s.recordSample(key, entities.CounterMetricName, float64(s.Counter[key]))
+	s.touch(key, entities.CounterMetricName)
+}
+
+// createGaugeRecord adds a gauge metric in memory.
+func (ms *MemStorage) createGaugeRecord(metric entities.Metrics) {
+	key := metric.Key()
+	s := ms.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Gauge[key] = entities.Gauge(*metric.Value) // Store gauge metric.
+	s.recordSample(key, entities.GaugeMetricName, *metric.Value)
+	s.touch(key, entities.GaugeMetricName)
+}
+
+// createHistogramRecord merges a histogram metric into the one stored in memory.
+func (ms *MemStorage) createHistogramRecord(metric entities.Metrics) error {
+	if metric.Histogram == nil {
+		return entities.ErrInvalidHistogram
 	}
 
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-	_, ok := ms.Counter[entities.MetricName(metric.ID)]
-	if !ok {
-		ms.Counter[entities.MetricName(metric.ID)] = entities.Counter(*metric.Delta) // Initialize counter if not present.
-	} else {
-		ms.Counter[entities.MetricName(metric.ID)] += entities.Counter(*metric.Delta) // Increment existing counter.
+	key := metric.Key()
+	s := ms.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged, err := mergedHistogram(s.Histogram[key], metric.Histogram)
+	if err != nil {
+		return err
 	}
+	s.Histogram[key] = merged
+	s.touch(key, entities.HistogramMetricName)
 
 	return nil
 }
 
-// createGaugeRecord adds a gauge metric in memory.
-func (ms *MemStorage) createGaugeRecord(metric entities.Metrics) error {
-	if ms.Gauge == nil {
-		return errors.New("gauge memory not initialized") // Error if gauge store is uninitialized.
+// mergedHistogram returns a new histogram holding the observations of both the stored
+// and the incoming histogram, leaving both untouched. A nil stored histogram yields a
+// copy of the incoming one. Stored histograms are therefore never modified in place
+// and can be shared with readers without copying.
+func mergedHistogram(stored, incoming *entities.Histogram) (*entities.Histogram, error) {
+	merged := &entities.Histogram{}
+	if stored != nil {
+		merged = stored.Clone()
+	}
+
+	if err := merged.Merge(incoming); err != nil {
+		return nil, fmt.Errorf("failed to merge histogram: %w", err)
 	}
 
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-	ms.Gauge[entities.MetricName(metric.ID)] = entities.Gauge(*metric.Value) // Store gauge metric.
+	return merged, nil
+}
+
+// createSummaryRecord merges a summary metric into the one stored in memory.
+func (ms *MemStorage) createSummaryRecord(metric entities.Metrics) error {
+	if metric.Summary == nil {
+		return entities.ErrInvalidSummary
+	}
+
+	key := metric.Key()
+	s := ms.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	merged, err := mergedSummary(s.Summary[key], metric.Summary)
+	if err != nil {
+		return err
+	}
+	s.Summary[key] = merged
+	s.touch(key, entities.SummaryMetricName)
 
 	return nil
 }
 
-// GetRecord retrieves a specific metrics record by name and type.
-func (ms *MemStorage) GetRecord(mName entities.MetricName, mType entities.MetricType) (entities.Metrics, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
-	ms.logger.DebugContext(context.Background(), fmt.Sprintf("retrieving %s[%s] record", mType, mName))
+// mergedSummary returns a new summary holding the observations of both the stored
+// and the incoming summary, leaving both untouched. A nil stored summary yields a
+// copy of the incoming one.
+func mergedSummary(stored, incoming *entities.Summary) (*entities.Summary, error) {
+	merged := &entities.Summary{}
+	if stored != nil {
+		merged = stored.Clone()
+	}
 
-	switch mType {
-	case entities.CounterMetricName:
-		m, ok := ms.Counter[mName]
-		if !ok {
-			return entities.Metrics{}, ErrNotFound // Return error if metric not found.
-		}
-		val := int64(m)
-		return entities.Metrics{
-			ID:    string(mName),
-			MType: string(mType),
-			Delta: &val, // Return counter value.
-		}, nil
-	case entities.GaugeMetricName:
-		m, ok := ms.Gauge[mName]
-		if !ok {
-			return entities.Metrics{}, ErrNotFound // Return error if metric not found.
-		}
-		val := float64(m)
-		return entities.Metrics{
-			ID:    string(mName),
-			MType: string(mType),
-			Value: &val, // Return gauge value.
-		}, nil
+	if err := merged.Merge(incoming); err != nil {
+		return nil, fmt.Errorf("failed to merge summary: %w", err)
 	}
 
-	return entities.Metrics{}, ErrNotFound // Unsupported metric type.
+	return merged, nil
 }
 
-// GetAllRecords retrieves all metrics records in storage.
-func (ms *MemStorage) GetAllRecords() (*MetricsStorage, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+// GetRecord retrieves a specific metrics record by its series key and type.
+func (ms *MemStorage) GetRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	ms.logger.DebugContext(ctx, fmt.Sprintf("retrieving %s[%s] record", mType, mName))
+	s := ms.shard(mName)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	if ms.Counter == nil {
-		return nil, errors.New("storage not initialized") // Error if storage is uninitialized.
+	if s.expired(mName, mType, ms.ttl.Load(), time.Now()) {
+		return entities.Metrics{}, ErrNotFound // Expired series are hidden until purged.
 	}
 
-	copyCounterMap := make(map[entities.MetricName]entities.Counter)
-	copyGaugeMap := make(map[entities.MetricName]entities.Gauge)
-	for k, v := range ms.Counter {
-		copyCounterMap[k] = v // Copy counter metrics for safe return.
+	metric, ok := s.record(mName, mType)
+	if !ok {
+		return entities.Metrics{}, ErrNotFound // Return error if metric not found.
 	}
 
-	for k, v := range ms.Gauge {
-		copyGaugeMap[k] = v // Copy gauge metrics for safe return.
+	return metric, nil
+}
+
+// GetAllRecords retrieves all metrics records in storage. Every shard is copied
+// under its own read lock, so the result is consistent per series but may mix
+// updates that happen while the shards are copied.
+func (ms *MemStorage) GetAllRecords(ctx context.Context) (*MetricsStorage, error) {
+	return ms.copyRecords(true), nil
+}
+
+// copyRecords copies the series of every shard, leaving out the expired ones if hideExpired is set.
+// Histograms and summaries are shared, they are replaced rather than modified when updated.
+func (ms *MemStorage) copyRecords(hideExpired bool) *MetricsStorage {
+	records := NewMetricsStorage()
+	policy := ms.ttl.Load()
+	now := time.Now()
+	for _, s := range ms.shards {
+		s.mu.RLock()
+		visible := func(k entities.MetricName, mType entities.MetricType) bool {
+			return !hideExpired || !s.expired(k, mType, policy, now)
+		}
+		for k, v := range s.Counter {
+			if visible(k, entities.CounterMetricName) {
+				records.Counter[k] = v
+			}
+		}
+		for k, v := range s.Gauge {
+			if visible(k, entities.GaugeMetricName) {
+				records.Gauge[k] = v
+			}
+		}
+		for k, v := range s.Histogram {
+			if visible(k, entities.HistogramMetricName) {
+				records.Histogram[k] = v
+			}
+		}
+		for k, v := range s.Summary {
+			if visible(k, entities.SummaryMetricName) {
+				records.Summary[k] = v
+			}
+		}
+		s.mu.RUnlock()
 	}
 
-	return &MetricsStorage{
-		Counter: copyCounterMap,
-		Gauge:   copyGaugeMap,
-	}, nil
+	return records
 }
 
 // GetAllRecordsByType retrieves all metrics records of a specified type.
-func (ms *MemStorage) GetAllRecordsByType(mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+func (ms *MemStorage) GetAllRecordsByType(ctx context.Context,
+	mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
 	copyMetricsMap := make(map[entities.MetricName]entities.Metrics)
-
-	switch mType {
-	case entities.CounterMetricName:
-		for k, v := range ms.Counter {
-			val := int64(v)
-			copyMetricsMap[k] = entities.Metrics{ID: string(k), MType: string(mType), Delta: &val} // Store counter metrics.
-		}
-	case entities.GaugeMetricName:
-		for k, v := range ms.Gauge {
-			val := float64(v)
-			copyMetricsMap[k] = entities.Metrics{ID: string(k), MType: string(mType), Value: &val} // Store gauge metrics.
+	policy := ms.ttl.Load()
+	now := time.Now()
+	for _, s := range ms.shards {
+		s.mu.RLock()
+		for _, k := range s.keys(mType) {
+			if s.expired(k, mType, policy, now) {
+				continue
+			}
+			if metric, ok := s.record(k, mType); ok {
+				copyMetricsMap[k] = metric
+			}
 		}
+		s.mu.RUnlock()
 	}
 
 	return copyMetricsMap, nil
 }
 
 // StoreMetricsBatch stores a batch of metrics records in memory.
-func (ms *MemStorage) StoreMetricsBatch(metrics []entities.Metrics) error {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+// The shards of all the series in the batch are locked for its whole duration, and
+// histograms and summaries are merged up front, so one that cannot be merged
+// rejects the whole batch without modifying the storage.
+func (ms *MemStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	unlock := ms.lockShards(metrics)
+	defer unlock()
+
+	histograms := make(map[entities.MetricName]*entities.Histogram)
+	summaries := make(map[entities.MetricName]*entities.Summary)
+	for _, metric := range metrics {
+		key := metric.Key()
+		switch entities.MetricType(metric.MType) {
+		case entities.HistogramMetricName:
+			if metric.Histogram == nil {
+				return fmt.Errorf("store histogram %s: %w", metric.ID, entities.ErrInvalidHistogram)
+			}
+
+			stored, ok := histograms[key]
+			if !ok {
+				stored = ms.shard(key).Histogram[key]
+			}
+			merged, err := mergedHistogram(stored, metric.Histogram)
+			if err != nil {
+				return fmt.Errorf("store histogram %s: %w", metric.ID, err)
+			}
+			histograms[key] = merged
+		case entities.SummaryMetricName:
+			if metric.Summary == nil {
+				return fmt.Errorf("store summary %s: %w", metric.ID, entities.ErrInvalidSummary)
+			}
+
+			stored, ok := summaries[key]
+			if !ok {
+				stored = ms.shard(key).Summary[key]
+			}
+			merged, err := mergedSummary(stored, metric.Summary)
+			if err != nil {
+				return fmt.Errorf("store summary %s: %w", metric.ID, err)
+			}
+			summaries[key] = merged
+		}
+	}
+
+	for key, h := range histograms {
+		s := ms.shard(key)
+		s.Histogram[key] = h // Store merged histogram metric.
+		s.touch(key, entities.HistogramMetricName)
+	}
+
+	for key, sm := range summaries {
+		s := ms.shard(key)
+		s.Summary[key] = sm // Store merged summary metric.
+		s.touch(key, entities.SummaryMetricName)
+	}
+
 	for _, metric := range metrics {
+		key := metric.Key()
+		s := ms.shard(key)
 		switch entities.MetricType(metric.MType) {
 		case entities.GaugeMetricName:
-			ms.Gauge[entities.MetricName(metric.ID)] = entities.Gauge(*metric.Value) // Store gauge metric.
+			s.Gauge[key] = entities.Gauge(*metric.Value) // Store gauge metric.
+			s.recordSample(key, entities.GaugeMetricName, *metric.Value)
+			s.touch(key, entities.GaugeMetricName)
 		case entities.CounterMetricName:
-			ms.Counter[entities.MetricName(metric.ID)] += entities.Counter(*metric.Delta) // Increment counter metric.
+			s.Counter[key] += entities.Counter(*metric.Delta) // Increment counter metric.
+			s.recordSample(key, entities.CounterMetricName, float64(s.Counter[key]))
+			s.touch(key, entities.CounterMetricName)
 		}
 	}
 
 	return nil
 }
 
+// lockShards locks the shards of the given metrics in index order, so concurrent
+// batches cannot deadlock, and returns the function unlocking them.
+func (ms *MemStorage) lockShards(metrics []entities.Metrics) func() {
+	var locked [memShards]bool
+	for _, metric := range metrics {
+		locked[shardIndex(metric.Key())] = true
+	}
+
+	for i, ok := range locked {
+		if ok {
+			ms.shards[i].mu.Lock()
+		}
+	}
+
+	return func() {
+		for i, ok := range locked {
+			if ok {
+				ms.shards[i].mu.Unlock()
+			}
+		}
+	}
+}
+
 // Close resets the metrics storage, clearing all metrics data.
 func (ms *MemStorage) Close(ctx context.Context) error {
-	ms.mu.Lock()
-	defer ms.mu.Unlock()
+	for _, s := range ms.shards {
+		s.mu.Lock()
+		s.MetricsStorage = *NewMetricsStorage() // Reinitialize metrics storage.
+		s.history = make(map[seriesID]*sampleRing)
+		s.updated = make(map[seriesID]time.Time)
+		s.mu.Unlock()
+	}
+
+	ms.metaMu.Lock()
+	ms.metadata = make(map[entities.MetricName]entities.Metadata)
+	ms.metaMu.Unlock()
+
+	return nil
+}
+
+// load replaces the content of the storage with the given series and starts
+// their TTL clock. It must not run concurrently with other operations.
+func (ms *MemStorage) load(records *MetricsStorage) {
+	ms.reset()
+	for k, v := range records.Counter {
+		s := ms.shard(k)
+		s.Counter[k] = v
+		s.touch(k, entities.CounterMetricName)
+	}
+	for k, v := range records.Gauge {
+		s := ms.shard(k)
+		s.Gauge[k] = v
+		s.touch(k, entities.GaugeMetricName)
+	}
+	for k, v := range records.Histogram {
+		s := ms.shard(k)
+		s.Histogram[k] = v
+		s.touch(k, entities.HistogramMetricName)
+	}
+	for k, v := range records.Summary {
+		s := ms.shard(k)
+		s.Summary[k] = v
+		s.touch(k, entities.SummaryMetricName)
+	}
+}
+
+// GetHistory returns the samples of a series recorded within [from, to].
+// Only the most recent samples are kept, the older ones are dropped once the
+// ring buffer of the series is full.
+func (ms *MemStorage) GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+	from, to time.Time) ([]entities.Sample, error) {
+	s := ms.shard(mName)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
 
-	ms.MetricsStorage = MetricsStorage{ // Reinitialize metrics storage.
-		Counter: make(map[entities.MetricName]entities.Counter),
-		Gauge:   make(map[entities.MetricName]entities.Gauge),
+	ring, ok := s.history[seriesID{key: mName, mType: mType}]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return ring.between(from, to), nil
+}
+
+// DeleteRecord removes a series of the given type together with its history.
+func (ms *MemStorage) DeleteRecord(ctx context.Context, mName entities.MetricName, mType entities.MetricType) error {
+	ms.logger.DebugContext(ctx, fmt.Sprintf("deleting %s[%s] record", mType, mName))
+
+	if !ms.deleteSeries(mName, mType) {
+		return ErrNotFound
 	}
 
 	return nil
 }
+
+// deleteSeries removes a series and its history, reporting whether the series existed.
+func (ms *MemStorage) deleteSeries(key entities.MetricName, mType entities.MetricType) bool {
+	s := ms.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.deleteSeries(key, mType)
+}
+
+// DeleteRecords removes every series of the given type, or of all types if mType
+// is empty, whose key matches the matcher.
+func (ms *MemStorage) DeleteRecords(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	return len(ms.deleteRecords(mType, matcher)), nil
+}
+
+// deleteRecords removes the matching series and returns their identities.
+func (ms *MemStorage) deleteRecords(mType entities.MetricType, matcher *entities.SeriesMatcher) []seriesID {
+	return ms.deleteWhere(func(s *memShard, id seriesID) bool {
+		return (mType == "" || mType == id.mType) && matcher.Match(id.key)
+	})
+}
+
+// SetTTLPolicy sets the policy deciding when series that are not updated expire.
+// A nil policy keeps the series forever.
+func (ms *MemStorage) SetTTLPolicy(policy *entities.TTLPolicy) {
+	ms.ttl.Store(policy)
+}
+
+// PurgeExpired removes the series that have expired at now together with their history.
+func (ms *MemStorage) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	return len(ms.purgeExpired(now)), nil
+}
+
+// purgeExpired removes the series expired at now and returns their identities.
+func (ms *MemStorage) purgeExpired(now time.Time) []seriesID {
+	policy := ms.ttl.Load()
+	if !policy.Enabled() {
+		return nil
+	}
+
+	return ms.deleteWhere(func(s *memShard, id seriesID) bool {
+		return s.expired(id.key, id.mType, policy, now)
+	})
+}
+
+// deleteWhere removes, shard by shard, the series selected by the match function
+// together with their history, and returns their identities.
+func (ms *MemStorage) deleteWhere(match func(s *memShard, id seriesID) bool) []seriesID {
+	var deleted []seriesID
+	for _, s := range ms.shards {
+		s.mu.Lock()
+		start := len(deleted)
+		for _, mType := range memTypes {
+			for _, k := range s.keys(mType) {
+				if id := (seriesID{key: k, mType: mType}); match(s, id) {
+					deleted = append(deleted, id)
+				}
+			}
+		}
+		for _, id := range deleted[start:] {
+			s.deleteSeries(id.key, id.mType)
+		}
+		s.mu.Unlock()
+	}
+
+	return deleted
+}
+
+// memTypes are the metric types held by a shard.
+var memTypes = []entities.MetricType{
+	entities.CounterMetricName,
+	entities.GaugeMetricName,
+	entities.HistogramMetricName,
+	entities.SummaryMetricName,
+}
+
+// keys returns the keys of the series of a type. The caller must hold the shard lock.
+func (s *memShard) keys(mType entities.MetricType) []entities.MetricName {
+	var keys []entities.MetricName
+	switch mType {
+	case entities.CounterMetricName:
+		for k := range s.Counter {
+			keys = append(keys, k)
+		}
+	case entities.GaugeMetricName:
+		for k := range s.Gauge {
+			keys = append(keys, k)
+		}
+	case entities.HistogramMetricName:
+		for k := range s.Histogram {
+			keys = append(keys, k)
+		}
+	case entities.SummaryMetricName:
+		for k := range s.Summary {
+			keys = append(keys, k)
+		}
+	}
+
+	return keys
+}
+
+// record builds the metrics entity of a series, reporting whether it exists.
+// Histograms and summaries are copied. The caller must hold the shard lock.
+func (s *memShard) record(key entities.MetricName, mType entities.MetricType) (entities.Metrics, bool) {
+	metric := metricFromKey(key, mType)
+	switch mType {
+	case entities.CounterMetricName:
+		m, ok := s.Counter[key]
+		if !ok {
+			return entities.Metrics{}, false
+		}
+		val := int64(m)
+		metric.Delta = &val // Return counter value.
+	case entities.GaugeMetricName:
+		m, ok := s.Gauge[key]
+		if !ok {
+			return entities.Metrics{}, false
+		}
+		val := float64(m)
+		metric.Value = &val // Return gauge value.
+	case entities.HistogramMetricName:
+		h, ok := s.Histogram[key]
+		if !ok {
+			return entities.Metrics{}, false
+		}
+		metric.Histogram = h.Clone() // Return a copy of the histogram.
+	case entities.SummaryMetricName:
+		sm, ok := s.Summary[key]
+		if !ok {
+			return entities.Metrics{}, false
+		}
+		metric.Summary = sm.Clone() // Return a copy of the summary.
+	default:
+		return entities.Metrics{}, false // Unsupported metric type.
+	}
+
+	return metric, true
+}
+
+// deleteSeries removes a series and its history, reporting whether the series existed.
+// The caller must hold the shard lock.
+func (s *memShard) deleteSeries(key entities.MetricName, mType entities.MetricType) bool {
+	var ok bool
+	switch mType {
+	case entities.CounterMetricName:
+		_, ok = s.Counter[key]
+		delete(s.Counter, key)
+	case entities.GaugeMetricName:
+		_, ok = s.Gauge[key]
+		delete(s.Gauge, key)
+	case entities.HistogramMetricName:
+		_, ok = s.Histogram[key]
+		delete(s.Histogram, key)
+	case entities.SummaryMetricName:
+		_, ok = s.Summary[key]
+		delete(s.Summary, key)
+	}
+	delete(s.history, seriesID{key: key, mType: mType})
+	delete(s.updated, seriesID{key: key, mType: mType})
+
+	return ok
+}
+
+// touch records that a series has just been updated.
+// The caller must hold the shard lock.
+func (s *memShard) touch(key entities.MetricName, mType entities.MetricType) {
+	s.updated[seriesID{key: key, mType: mType}] = time.Now()
+}
+
+// expired reports whether a series has not been updated within its TTL.
+// Series without a recorded update time never expire.
+// The caller must hold the shard lock.
+func (s *memShard) expired(key entities.MetricName, mType entities.MetricType,
+	policy *entities.TTLPolicy, now time.Time) bool {
+	updatedAt, ok := s.updated[seriesID{key: key, mType: mType}]
+	return ok && policy.Expired(key, updatedAt, now)
+}
+
+// recordSample appends a sample for the series to its ring buffer.
+// The caller must hold the shard lock.
+func (s *memShard) recordSample(key entities.MetricName, mType entities.MetricType, value float64) {
+	id := seriesID{key: key, mType: mType}
+	ring, ok := s.history[id]
+	if !ok {
+		ring = newSampleRing(defaultHistorySize)
+		s.history[id] = ring
+	}
+	ring.add(entities.Sample{Timestamp: time.Now().UTC(), Value: value})
+}
+
+// metricFromKey builds a metrics entity of the given type from a series key,
+// splitting the key back into the metric ID and its labels.
+func metricFromKey(key entities.MetricName, mType entities.MetricType) entities.Metrics {
+	name, labels, err := entities.ParseSeriesKey(key)
+	if err != nil {
+		// Keys that cannot be parsed are treated as plain metric names.
+		return entities.Metrics{ID: string(key), MType: string(mType)}
+	}
+
+	return entities.Metrics{ID: string(name), MType: string(mType), Labels: labels}
+}
+
+// SetMetadata registers the metadata of metric names, replacing the metadata already
+// registered for the same names.
+func (ms *MemStorage) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	ms.metaMu.Lock()
+	defer ms.metaMu.Unlock()
+
+	for _, md := range metadata {
+		ms.metadata[md.Name] = md
+	}
+
+	return nil
+}
+
+// GetMetadata returns the metadata registered for a metric name, or ErrNotFound.
+func (ms *MemStorage) GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error) {
+	ms.metaMu.RLock()
+	defer ms.metaMu.RUnlock()
+
+	md, ok := ms.metadata[name]
+	if !ok {
+		return entities.Metadata{}, ErrNotFound
+	}
+
+	return md, nil
+}
+
+// GetAllMetadata returns a copy of the registered metadata keyed by metric name.
+func (ms *MemStorage) GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error) {
+	ms.metaMu.RLock()
+	defer ms.metaMu.RUnlock()
+
+	return maps.Clone(ms.metadata), nil
+}
@@ -0,0 +1,211 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// setUpdated overrides the time of the last update of a series.
+func setUpdated(ms *MemStorage, key entities.MetricName, mType entities.MetricType, at time.Time) {
+	s := ms.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.updated[seriesID{key: key, mType: mType}] = at
+}
+
+func TestMemStorageTTL(t *testing.T) {
+	ms, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+
+	policy, err := entities.NewTTLPolicy(time.Hour, "cpu_*=1m")
+	require.NoError(t, err)
+	ms.SetTTLPolicy(policy)
+
+	value := 1.5
+	for _, id := range []string{"cpu_user", "HeapAlloc"} {
+		require.NoError(t, ms.CreateRecord(context.Background(), entities.Metrics{ID: id, MType: "gauge", Value: &value}))
+	}
+
+	// The cpu gauge was last updated beyond its TTL, the heap gauge is still within the default TTL.
+	stale := time.Now().Add(-2 * time.Minute)
+	setUpdated(ms, "cpu_user", entities.GaugeMetricName, stale)
+	setUpdated(ms, "HeapAlloc", entities.GaugeMetricName, stale)
+
+	_, err = ms.GetRecord(context.Background(), "cpu_user", entities.GaugeMetricName)
+	require.ErrorIs(t, err, ErrNotFound, "expired series must be hidden")
+
+	all, err := ms.GetAllRecords(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, all.Gauge, 1)
+	assert.Contains(t, all.Gauge, entities.MetricName("HeapAlloc"))
+
+	purged, err := ms.PurgeExpired(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	assert.NotContains(t, ms.shard("cpu_user").Gauge, entities.MetricName("cpu_user"))
+	assert.NotContains(t, ms.shard("cpu_user").history, seriesID{key: "cpu_user", mType: entities.GaugeMetricName})
+
+	// An update revives a series before it is purged.
+	setUpdated(ms, "HeapAlloc", entities.GaugeMetricName, time.Now().Add(-2*time.Hour))
+	require.NoError(t, ms.CreateRecord(context.Background(),
+		entities.Metrics{ID: "HeapAlloc", MType: "gauge", Value: &value}))
+	_, err = ms.GetRecord(context.Background(), "HeapAlloc", entities.GaugeMetricName)
+	require.NoError(t, err)
+}
+
+func TestMemStorageWithoutTTL(t *testing.T) {
+	ms, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+
+	value := 1.5
+	require.NoError(t, ms.CreateRecord(context.Background(),
+		entities.Metrics{ID: "HeapAlloc", MType: "gauge", Value: &value}))
+	setUpdated(ms, "HeapAlloc", entities.GaugeMetricName, time.Time{})
+
+	purged, err := ms.PurgeExpired(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Zero(t, purged)
+
+	_, err = ms.GetRecord(context.Background(), "HeapAlloc", entities.GaugeMetricName)
+	require.NoError(t, err)
+}
+
+func TestMemStorageConcurrentBatches(t *testing.T) {
+	ms, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+
+	const (
+		workers = 8
+		rounds  = 200
+	)
+	delta := int64(1)
+	batch := make([]entities.Metrics, 0, 50)
+	for i := range cap(batch) {
+		batch = append(batch, entities.Metrics{ID: fmt.Sprintf("counter_%d", i), MType: "counter", Delta: &delta})
+	}
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rounds {
+				assert.NoError(t, ms.StoreMetricsBatch(context.Background(), batch))
+				_, err := ms.GetAllRecords(context.Background())
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	all, err := ms.GetAllRecords(context.Background())
+	require.NoError(t, err)
+	require.Len(t, all.Counter, len(batch))
+	for _, v := range all.Counter {
+		assert.Equal(t, entities.Counter(workers*rounds), v)
+	}
+}
+
+// benchmarkSeries is the number of distinct series used by the benchmarks,
+// roughly what a few hundred agents report.
+const benchmarkSeries = 4096
+
+// newBenchmarkStorage creates a storage holding benchmarkSeries gauges and returns their keys.
+func newBenchmarkStorage(b *testing.B) (*MemStorage, []entities.MetricName) {
+	b.Helper()
+
+	ms, err := NewMemStorage(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	if err != nil {
+		b.Fatalf("NewMemStorage failed: %v", err)
+	}
+
+	value := 1.0
+	keys := make([]entities.MetricName, 0, benchmarkSeries)
+	for i := range benchmarkSeries {
+		metric := entities.Metrics{
+			ID: "Alloc", MType: "gauge", Value: &value, Labels: entities.Labels{"agent": strconv.Itoa(i)},
+		}
+		if err = ms.CreateRecord(context.Background(), metric); err != nil {
+			b.Fatalf("CreateRecord failed: %v", err)
+		}
+		keys = append(keys, metric.Key())
+	}
+
+	return ms, keys
+}
+
+// Run with -cpu=1,2,4,8 to see how the throughput scales with the cores.
+func BenchmarkMemStorage_ParallelIngest(b *testing.B) {
+	ms, _ := newBenchmarkStorage(b)
+	var next atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		value := 2.0
+		agent := strconv.Itoa(int(next.Add(1)))
+		i := 0
+		for pb.Next() {
+			i++
+			err := ms.CreateRecord(context.Background(), entities.Metrics{
+				ID: "Alloc", MType: "gauge", Value: &value, Labels: entities.Labels{"agent": agent, "n": strconv.Itoa(i % 64)},
+			})
+			if err != nil {
+				b.Fatalf("CreateRecord failed: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkMemStorage_ParallelGetRecord(b *testing.B) {
+	ms, keys := newBenchmarkStorage(b)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			i++
+			if _, err := ms.GetRecord(context.Background(), keys[i%len(keys)], entities.GaugeMetricName); err != nil {
+				b.Fatalf("GetRecord failed: %v", err)
+			}
+		}
+	})
+}
+
+func BenchmarkMemStorage_ParallelMixed(b *testing.B) {
+	ms, keys := newBenchmarkStorage(b)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		value := 3.0
+		i := 0
+		for pb.Next() {
+			i++
+			var err error
+			switch {
+			case i%100 == 0: // The HTML page reads everything now and then.
+				_, err = ms.GetAllRecords(context.Background())
+			case i%2 == 0:
+				_, err = ms.GetRecord(context.Background(), keys[i%len(keys)], entities.GaugeMetricName)
+			default:
+				err = ms.StoreMetricsBatch(context.Background(), []entities.Metrics{
+					{ID: "Alloc", MType: "gauge", Value: &value, Labels: entities.Labels{"agent": strconv.Itoa(i % benchmarkSeries)}},
+				})
+			}
+			if err != nil {
+				b.Fatalf("operation failed: %v", err)
+			}
+		}
+	})
+}
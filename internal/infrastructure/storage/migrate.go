@@ -0,0 +1,282 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	pgxv5 "github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// migrationsLockID is the key of the advisory lock held while migrations run,
+// so that servers starting together apply every migration once.
+const migrationsLockID = 0x6d6b6d69677261
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationFileRe matches the migration files, e.g. 0002_series_labels.up.sql.
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+// ErrUnknownMigration is returned when the database holds a migration this build does not know.
+var ErrUnknownMigration = errors.New("unknown schema migration")
+
+// migration is a numbered schema change with the SQL applying and reverting it.
+type migration struct {
+	Name    string
+	Up      string
+	Down    string
+	Version int
+}
+
+// MigrationStatus describes a migration and whether it is applied to the database.
+type MigrationStatus struct {
+	AppliedAt time.Time // Time the migration was applied, zero if it is pending
+	Name      string    // Name of the migration
+	Version   int       // Version of the migration
+	Applied   bool      // Whether the migration is applied
+}
+
+// loadMigrations reads the embedded migrations sorted by version.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, entry := range entries {
+		match := migrationFileRe.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("unexpected migration file %s", entry.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join("migrations", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		}
+		if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d is named both %s and %s", version, m.Name, match[2])
+		}
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" || m.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+
+	return migrations, nil
+}
+
+// Migrator applies and reverts the schema migrations of the Postgres storage.
+// The applied versions are tracked in the schema_migrations table.
+type Migrator struct {
+	db         *pgxpool.Pool
+	logger     *slog.Logger
+	migrations []migration
+}
+
+// NewMigrator creates a Migrator for the migrations embedded in the binary.
+func NewMigrator(db *pgxpool.Pool, logger *slog.Logger) (*Migrator, error) {
+	migrations, err := loadMigrations(migrationFiles)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, logger: logger, migrations: migrations}, nil
+}
+
+// Up applies the pending migrations in order and returns how many were applied.
+func (m *Migrator) Up(ctx context.Context) (int, error) {
+	applied := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn, status []MigrationStatus) error {
+		for i, s := range status {
+			if s.Applied {
+				continue
+			}
+			if err := m.apply(ctx, conn, m.migrations[i], true); err != nil {
+				return err
+			}
+			applied++
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down reverts the last steps applied migrations and returns how many were reverted.
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	reverted := 0
+	err := m.locked(ctx, func(conn *pgxpool.Conn, status []MigrationStatus) error {
+		for i := len(status) - 1; i >= 0 && reverted < steps; i-- {
+			if !status[i].Applied {
+				continue
+			}
+			if err := m.apply(ctx, conn, m.migrations[i], false); err != nil {
+				return err
+			}
+			reverted++
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status returns every known migration and whether it is applied.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	var result []MigrationStatus
+	err := m.locked(ctx, func(_ *pgxpool.Conn, status []MigrationStatus) error {
+		result = status
+		return nil
+	})
+
+	return result, err
+}
+
+// locked runs fn on a connection holding the migrations advisory lock, with the
+// status of the migrations read under the lock.
+func (m *Migrator) locked(ctx context.Context, fn func(*pgxpool.Conn, []MigrationStatus) error) error {
+	conn, err := m.db.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire a connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, int64(migrationsLockID)); err != nil {
+		return fmt.Errorf("failed to take the migrations lock: %w", err)
+	}
+	defer func() {
+		// The lock goes with the session, so it is released on a fresh context
+		// even when ctx is done.
+		_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, int64(migrationsLockID))
+		if err != nil {
+			m.logger.ErrorContext(ctx, "failed to release the migrations lock", helpers.ErrAttr(err))
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create the schema_migrations table: %w", err)
+	}
+
+	status, err := m.status(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn, status)
+}
+
+// status reads the applied versions and matches them with the known migrations.
+func (m *Migrator) status(ctx context.Context, conn *pgxpool.Conn) ([]MigrationStatus, error) {
+	rows, err := conn.Query(ctx, `SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version int
+			at      time.Time
+		)
+		if err = rows.Scan(&version, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan an applied migration: %w", err)
+		}
+		applied[version] = at
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading the applied migrations error %w", err)
+	}
+
+	status := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mg := range m.migrations {
+		at, ok := applied[mg.Version]
+		status = append(status, MigrationStatus{Version: mg.Version, Name: mg.Name, Applied: ok, AppliedAt: at})
+		delete(applied, mg.Version)
+	}
+	for version := range applied {
+		return nil, fmt.Errorf("%w: version %d is applied", ErrUnknownMigration, version)
+	}
+
+	return status, nil
+}
+
+// apply runs a migration up or down in a transaction together with its bookkeeping.
+func (m *Migrator) apply(ctx context.Context, conn *pgxpool.Conn, mg migration, up bool) (err error) {
+	direction, script := "up", mg.Up
+	if !up {
+		direction, script = "down", mg.Down
+	}
+
+	trx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to start the transaction %w", err)
+	}
+	defer func() {
+		if err != nil {
+			if rbErr := trx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgxv5.ErrTxClosed) {
+				m.logger.ErrorContext(ctx, "failed to rollback the migration", helpers.ErrAttr(rbErr))
+			}
+		}
+	}()
+
+	if _, err = trx.Exec(ctx, script); err != nil {
+		return fmt.Errorf("migration %d_%s %s failed: %w", mg.Version, mg.Name, direction, err)
+	}
+	if up {
+		_, err = trx.Exec(ctx, `INSERT INTO schema_migrations (version, name) VALUES ($1, $2)`, mg.Version, mg.Name)
+	} else {
+		_, err = trx.Exec(ctx, `DELETE FROM schema_migrations WHERE version = $1`, mg.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %d_%s: %w", mg.Version, mg.Name, err)
+	}
+	if err = trx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit migration %d_%s: %w", mg.Version, mg.Name, err)
+	}
+
+	m.logger.InfoContext(ctx, "applied schema migration",
+		slog.Int("version", mg.Version), slog.String("name", mg.Name), slog.String("direction", direction))
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadEmbeddedMigrations(t *testing.T) {
+	migrations, err := loadMigrations(migrationFiles)
+	require.NoError(t, err)
+	require.NotEmpty(t, migrations)
+
+	for i, m := range migrations {
+		assert.Equal(t, i+1, m.Version, "migrations are numbered without gaps")
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(content string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(content)} }
+
+	migrations, err := loadMigrations(fstest.MapFS{
+		"migrations/0010_second.up.sql":   file("CREATE TABLE b ();"),
+		"migrations/0010_second.down.sql": file("DROP TABLE b;"),
+		"migrations/0002_first.up.sql":    file("CREATE TABLE a ();"),
+		"migrations/0002_first.down.sql":  file("DROP TABLE a;"),
+	})
+	require.NoError(t, err)
+	require.Len(t, migrations, 2)
+	assert.Equal(t, migration{Version: 2, Name: "first", Up: "CREATE TABLE a ();", Down: "DROP TABLE a;"},
+		migrations[0])
+	assert.Equal(t, 10, migrations[1].Version)
+
+	tests := []struct {
+		fsys fstest.MapFS
+		name string
+	}{
+		{
+			name: "missing down",
+			fsys: fstest.MapFS{"migrations/0001_first.up.sql": file("CREATE TABLE a ();")},
+		},
+		{
+			name: "unexpected file",
+			fsys: fstest.MapFS{"migrations/first.sql": file("CREATE TABLE a ();")},
+		},
+		{
+			name: "conflicting names",
+			fsys: fstest.MapFS{
+				"migrations/0001_first.up.sql":   file("CREATE TABLE a ();"),
+				"migrations/0001_other.down.sql": file("DROP TABLE a;"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadMigrations(tt.fsys)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS gauge_metrics (
+	id SERIAL PRIMARY KEY,
+	name TEXT UNIQUE NOT NULL,
+	value DOUBLE PRECISION NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS counter_metrics (
+	id SERIAL PRIMARY KEY,
+	name TEXT UNIQUE NOT NULL,
+	value BIGINT NOT NULL CHECK (value >= 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,10 @@
+-- Only the unlabelled series fit in a table keyed by name.
+DELETE FROM counter_metrics WHERE labels <> '';
+DROP INDEX IF EXISTS counter_metrics_series_idx;
+ALTER TABLE counter_metrics DROP COLUMN IF EXISTS labels;
+ALTER TABLE counter_metrics ADD CONSTRAINT counter_metrics_name_key UNIQUE (name);
+
+DELETE FROM gauge_metrics WHERE labels <> '';
+DROP INDEX IF EXISTS gauge_metrics_series_idx;
+ALTER TABLE gauge_metrics DROP COLUMN IF EXISTS labels;
+ALTER TABLE gauge_metrics ADD CONSTRAINT gauge_metrics_name_key UNIQUE (name);
@@ -0,0 +1,8 @@
+-- Series are identified by name and labels.
+ALTER TABLE gauge_metrics ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '';
+ALTER TABLE gauge_metrics DROP CONSTRAINT IF EXISTS gauge_metrics_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS gauge_metrics_series_idx ON gauge_metrics (name, labels);
+
+ALTER TABLE counter_metrics ADD COLUMN IF NOT EXISTS labels TEXT NOT NULL DEFAULT '';
+ALTER TABLE counter_metrics DROP CONSTRAINT IF EXISTS counter_metrics_name_key;
+CREATE UNIQUE INDEX IF NOT EXISTS counter_metrics_series_idx ON counter_metrics (name, labels);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS metric_samples (
+	id BIGSERIAL PRIMARY KEY,
+	type TEXT NOT NULL,
+	name TEXT NOT NULL,
+	labels TEXT NOT NULL DEFAULT '',
+	value DOUBLE PRECISION NOT NULL,
+	recorded_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS metric_samples_series_time_idx
+	ON metric_samples (type, name, labels, recorded_at);
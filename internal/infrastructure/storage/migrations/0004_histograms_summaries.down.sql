@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS summary_metrics;
+DROP TABLE IF EXISTS histogram_metrics;
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS histogram_metrics (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	labels TEXT NOT NULL DEFAULT '',
+	bounds DOUBLE PRECISION[] NOT NULL,
+	counts BIGINT[] NOT NULL,
+	sum DOUBLE PRECISION NOT NULL,
+	count BIGINT NOT NULL CHECK (count >= 0),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (name, labels)
+);
+
+CREATE TABLE IF NOT EXISTS summary_metrics (
+	id SERIAL PRIMARY KEY,
+	name TEXT NOT NULL,
+	labels TEXT NOT NULL DEFAULT '',
+	sketch BYTEA NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	UNIQUE (name, labels)
+);
@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS metric_metadata (
+	name TEXT PRIMARY KEY,
+	kind TEXT NOT NULL DEFAULT '',
+	unit TEXT NOT NULL DEFAULT '',
+	description TEXT NOT NULL DEFAULT '',
+	display TEXT NOT NULL DEFAULT '',
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
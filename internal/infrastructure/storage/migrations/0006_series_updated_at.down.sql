@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS summary_metrics_updated_at_idx;
+DROP INDEX IF EXISTS histogram_metrics_updated_at_idx;
+DROP INDEX IF EXISTS counter_metrics_updated_at_idx;
+DROP INDEX IF EXISTS gauge_metrics_updated_at_idx;
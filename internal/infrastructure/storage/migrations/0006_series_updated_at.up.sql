@@ -0,0 +1,5 @@
+-- The janitor only reads the series older than the shortest TTL.
+CREATE INDEX IF NOT EXISTS gauge_metrics_updated_at_idx ON gauge_metrics (updated_at);
+CREATE INDEX IF NOT EXISTS counter_metrics_updated_at_idx ON counter_metrics (updated_at);
+CREATE INDEX IF NOT EXISTS histogram_metrics_updated_at_idx ON histogram_metrics (updated_at);
+CREATE INDEX IF NOT EXISTS summary_metrics_updated_at_idx ON summary_metrics (updated_at);
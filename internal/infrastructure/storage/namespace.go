@@ -0,0 +1,260 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// namespacedStorage keeps the series of every tenant in its own namespace of the wrapped
+// storage. The tenant of a call is taken from its context, see entities.WithTenant, and
+// stored in the entities.TenantLabel label of its series. The calls without a tenant use
+// the default namespace, holding the series without the label, so a storage holding no
+// tenant series is read and written exactly as before.
+type namespacedStorage struct {
+	Storage
+}
+
+// WithNamespaces wraps a storage so that every call only sees the series of the tenant of
+// its context. Expiring and closing the storage still apply to all the namespaces.
+func WithNamespaces(store Storage) Storage {
+	return &namespacedStorage{Storage: store}
+}
+
+// tenantMarker is found in the series keys holding a tenant label.
+var tenantMarker = entities.TenantLabel + "="
+
+// namespacedKey returns the key of a series within the namespace of tenant.
+// A tenant label given by the client is replaced.
+func namespacedKey(key entities.MetricName, tenant string) (entities.MetricName, error) {
+	if tenant == "" && !strings.Contains(string(key), tenantMarker) {
+		return key, nil
+	}
+
+	name, labels, err := entities.ParseSeriesKey(key)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve the namespace: %w", err)
+	}
+
+	return entities.SeriesKey(name, namespacedLabels(labels, tenant)), nil
+}
+
+// namespacedLabels returns a copy of labels holding the tenant label of tenant.
+func namespacedLabels(labels entities.Labels, tenant string) entities.Labels {
+	if tenant == "" && labels[entities.TenantLabel] == "" {
+		return labels
+	}
+
+	namespaced := maps.Clone(labels)
+	if namespaced == nil {
+		namespaced = make(entities.Labels, 1)
+	}
+	delete(namespaced, entities.TenantLabel)
+	if tenant != "" {
+		namespaced[entities.TenantLabel] = tenant
+	}
+
+	return namespaced
+}
+
+// localKey returns the key of a series without its tenant label, and whether the
+// series belongs to the namespace of tenant.
+func localKey(key entities.MetricName, tenant string) (entities.MetricName, bool) {
+	if !strings.Contains(string(key), tenantMarker) {
+		return key, tenant == ""
+	}
+
+	name, labels, err := entities.ParseSeriesKey(key)
+	if err != nil || labels[entities.TenantLabel] != tenant {
+		return "", false
+	}
+
+	return entities.SeriesKey(name, localLabels(labels)), true
+}
+
+// localLabels returns labels without the tenant label.
+func localLabels(labels entities.Labels) entities.Labels {
+	if _, ok := labels[entities.TenantLabel]; !ok {
+		return labels
+	}
+
+	local := maps.Clone(labels)
+	delete(local, entities.TenantLabel)
+	if len(local) == 0 {
+		return nil
+	}
+
+	return local
+}
+
+// namespaced returns a copy of the metrics placed in the namespace of tenant.
+func namespaced(metrics []entities.Metrics, tenant string) []entities.Metrics {
+	out := make([]entities.Metrics, len(metrics))
+	for i, m := range metrics {
+		m.Labels = namespacedLabels(m.Labels, tenant)
+		out[i] = m
+	}
+
+	return out
+}
+
+// localRecords returns the series of records belonging to the namespace of tenant, keyed without the tenant label.
+func localRecords[V any](records map[entities.MetricName]V, tenant string) map[entities.MetricName]V {
+	local := make(map[entities.MetricName]V, len(records))
+	for key, v := range records {
+		if k, ok := localKey(key, tenant); ok {
+			local[k] = v
+		}
+	}
+
+	return local
+}
+
+// CreateRecord adds a metrics record to the namespace of the tenant of ctx.
+func (ns *namespacedStorage) CreateRecord(ctx context.Context, metrics entities.Metrics) error {
+	metrics.Labels = namespacedLabels(metrics.Labels, entities.TenantFromContext(ctx))
+	return ns.Storage.CreateRecord(ctx, metrics) //nolint:wrapcheck // the namespaces are transparent
+}
+
+// GetRecord retrieves a metrics record from the namespace of the tenant of ctx.
+func (ns *namespacedStorage) GetRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	key, err := namespacedKey(mName, entities.TenantFromContext(ctx))
+	if err != nil {
+		return entities.Metrics{}, err
+	}
+
+	record, err := ns.Storage.GetRecord(ctx, key, mType)
+	if err != nil {
+		return entities.Metrics{}, err //nolint:wrapcheck // the namespaces are transparent
+	}
+	record.Labels = localLabels(record.Labels)
+
+	return record, nil
+}
+
+// GetAllRecords returns the metrics records of the namespace of the tenant of ctx.
+func (ns *namespacedStorage) GetAllRecords(ctx context.Context) (*MetricsStorage, error) {
+	records, err := ns.Storage.GetAllRecords(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the namespaces are transparent
+	}
+
+	tenant := entities.TenantFromContext(ctx)
+	return &MetricsStorage{
+		Counter:   localRecords(records.Counter, tenant),
+		Gauge:     localRecords(records.Gauge, tenant),
+		Histogram: localRecords(records.Histogram, tenant),
+		Summary:   localRecords(records.Summary, tenant),
+	}, nil
+}
+
+// GetAllRecordsByType retrieves the metrics records of a type from the namespace of the tenant of ctx.
+func (ns *namespacedStorage) GetAllRecordsByType(ctx context.Context,
+	mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
+	records, err := ns.Storage.GetAllRecordsByType(ctx, mType)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the namespaces are transparent
+	}
+
+	local := localRecords(records, entities.TenantFromContext(ctx))
+	for key, record := range local {
+		record.Labels = localLabels(record.Labels)
+		local[key] = record
+	}
+
+	return local, nil
+}
+
+// StoreMetricsBatch stores a batch of metrics records in the namespace of the tenant of ctx.
+func (ns *namespacedStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	//nolint:wrapcheck // the namespaces are transparent
+	return ns.Storage.StoreMetricsBatch(ctx, namespaced(metrics, entities.TenantFromContext(ctx)))
+}
+
+// GetHistory returns the samples of a series of the namespace of the tenant of ctx.
+func (ns *namespacedStorage) GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+	from, to time.Time) ([]entities.Sample, error) {
+	key, err := namespacedKey(mName, entities.TenantFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return ns.Storage.GetHistory(ctx, key, mType, from, to) //nolint:wrapcheck // the namespaces are transparent
+}
+
+// DeleteRecord removes a series from the namespace of the tenant of ctx.
+func (ns *namespacedStorage) DeleteRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) error {
+	key, err := namespacedKey(mName, entities.TenantFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return ns.Storage.DeleteRecord(ctx, key, mType) //nolint:wrapcheck // the namespaces are transparent
+}
+
+// DeleteRecords removes the matching series of the namespace of the tenant of ctx. The
+// pattern is matched against the series keys without the tenant label.
+func (ns *namespacedStorage) DeleteRecords(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	tenant := entities.TenantFromContext(ctx)
+	local := matcher.Mapped(func(key entities.MetricName) (entities.MetricName, bool) {
+		return localKey(key, tenant)
+	})
+
+	return ns.Storage.DeleteRecords(ctx, mType, local) //nolint:wrapcheck // the namespaces are transparent
+}
+
+// SetMetadata registers metadata in the namespace of the tenant of ctx.
+func (ns *namespacedStorage) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	tenant := entities.TenantFromContext(ctx)
+	out := make([]entities.Metadata, len(metadata))
+	for i, md := range metadata {
+		name, err := namespacedKey(md.Name, tenant)
+		if err != nil {
+			return err
+		}
+		md.Name = name
+		out[i] = md
+	}
+
+	return ns.Storage.SetMetadata(ctx, out) //nolint:wrapcheck // the namespaces are transparent
+}
+
+// GetMetadata returns the metadata of a metric name of the namespace of the tenant of ctx.
+func (ns *namespacedStorage) GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error) {
+	key, err := namespacedKey(name, entities.TenantFromContext(ctx))
+	if err != nil {
+		return entities.Metadata{}, err
+	}
+
+	md, err := ns.Storage.GetMetadata(ctx, key)
+	if err != nil {
+		return entities.Metadata{}, err //nolint:wrapcheck // the namespaces are transparent
+	}
+	md.Name = name
+
+	return md, nil
+}
+
+// GetAllMetadata returns the metadata registered in the namespace of the tenant of ctx.
+func (ns *namespacedStorage) GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error) {
+	metadata, err := ns.Storage.GetAllMetadata(ctx)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the namespaces are transparent
+	}
+
+	local := localRecords(metadata, entities.TenantFromContext(ctx))
+	for name, md := range local {
+		md.Name = name
+		local[name] = md
+	}
+
+	return local, nil
+}
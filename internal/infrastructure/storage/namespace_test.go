@@ -0,0 +1,118 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespacedStorage(t *testing.T) {
+	mem, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	store := WithNamespaces(mem)
+
+	defaultCtx := context.Background()
+	teamA := entities.WithTenant(defaultCtx, "team-a")
+	teamB := entities.WithTenant(defaultCtx, "team-b")
+
+	gauge := func(v float64, labels entities.Labels) entities.Metrics {
+		return entities.Metrics{ID: "Alloc", MType: string(entities.GaugeMetricName), Value: &v, Labels: labels}
+	}
+	require.NoError(t, store.CreateRecord(defaultCtx, gauge(1, nil)))
+	require.NoError(t, store.CreateRecord(teamA, gauge(2, nil)))
+	// A tenant label sent by a client cannot reach another namespace.
+	require.NoError(t, store.StoreMetricsBatch(teamB, []entities.Metrics{
+		gauge(3, entities.Labels{entities.TenantLabel: "team-a"}),
+	}))
+
+	for ctx, want := range map[context.Context]float64{defaultCtx: 1, teamA: 2, teamB: 3} {
+		record, err := store.GetRecord(ctx, "Alloc", entities.GaugeMetricName)
+		require.NoError(t, err)
+		assert.Equal(t, want, *record.Value)
+		assert.Empty(t, record.Labels, "the tenant label is hidden")
+
+		all, err := store.GetAllRecords(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all.Gauge, 1)
+		assert.Equal(t, entities.Gauge(want), all.Gauge["Alloc"])
+	}
+
+	_, err = store.GetRecord(entities.WithTenant(defaultCtx, "team-c"), "Alloc", entities.GaugeMetricName)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// A forged tenant label does not leak the series of another tenant.
+	record, err := store.GetRecord(defaultCtx, `Alloc{__tenant__="team-a"}`, entities.GaugeMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, *record.Value, "the default namespace reads its own Alloc")
+
+	matcher, err := entities.NewSeriesMatcher("Al*", entities.PatternGlob)
+	require.NoError(t, err)
+	deleted, err := store.DeleteRecords(teamA, entities.GaugeMetricName, matcher)
+	require.NoError(t, err)
+	assert.Equal(t, 1, deleted)
+
+	raw, err := mem.GetAllRecordsByType(defaultCtx, entities.GaugeMetricName)
+	require.NoError(t, err)
+	assert.Len(t, raw, 2, "the series of the other namespaces are kept")
+	assert.Contains(t, raw, entities.MetricName(`Alloc{__tenant__="team-b"}`))
+}
+
+func TestNamespacedTTL(t *testing.T) {
+	mem, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	policy, err := entities.NewTTLPolicy(time.Hour, "HeapAlloc=1m")
+	require.NoError(t, err)
+	mem.SetTTLPolicy(policy)
+	store := WithNamespaces(mem)
+
+	teamA := entities.WithTenant(context.Background(), "team-a")
+	value := 1.5
+	for _, id := range []string{"HeapAlloc", "Sys"} {
+		require.NoError(t, store.CreateRecord(teamA, entities.Metrics{ID: id, MType: "gauge", Value: &value}))
+	}
+
+	// The named rule applies to the series of the tenant, not only the default TTL.
+	stale := time.Now().Add(-2 * time.Minute)
+	setUpdated(mem, `HeapAlloc{__tenant__="team-a"}`, entities.GaugeMetricName, stale)
+	setUpdated(mem, `Sys{__tenant__="team-a"}`, entities.GaugeMetricName, stale)
+
+	_, err = store.GetRecord(teamA, "HeapAlloc", entities.GaugeMetricName)
+	require.ErrorIs(t, err, ErrNotFound)
+	purged, err := mem.PurgeExpired(context.Background(), time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, 1, purged)
+	_, err = store.GetRecord(teamA, "Sys", entities.GaugeMetricName)
+	require.NoError(t, err)
+}
+
+func TestNamespacedMetadata(t *testing.T) {
+	mem, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	store := WithNamespaces(mem)
+
+	defaultCtx := context.Background()
+	teamA := entities.WithTenant(defaultCtx, "team-a")
+
+	require.NoError(t, store.SetMetadata(defaultCtx, []entities.Metadata{{Name: "Alloc", Unit: "bytes"}}))
+	require.NoError(t, store.SetMetadata(teamA, []entities.Metadata{{Name: "Alloc", Unit: "kilobytes"}}))
+
+	md, err := store.GetMetadata(teamA, "Alloc")
+	require.NoError(t, err)
+	assert.Equal(t, entities.Metadata{Name: "Alloc", Unit: "kilobytes"}, md, "the tenant label is hidden")
+
+	all, err := store.GetAllMetadata(defaultCtx)
+	require.NoError(t, err)
+	assert.Equal(t, map[entities.MetricName]entities.Metadata{"Alloc": {Name: "Alloc", Unit: "bytes"}}, all)
+
+	_, err = store.GetMetadata(entities.WithTenant(defaultCtx, "team-b"), "Alloc")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	raw, err := mem.GetAllMetadata(defaultCtx)
+	require.NoError(t, err)
+	assert.Contains(t, raw, entities.MetricName(`Alloc{__tenant__="team-a"}`))
+}
@@ -6,11 +6,12 @@ package storage
 
 import (
 	"context"
-	"database/sql"
 	"errors"
 	"fmt"
 	"log/slog"
+	"slices"
 	"strings"
+	"time"
 
 	pgxv5 "github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
@@ -18,13 +19,18 @@ import (
 	"github.com/mihailtudos/metrickit/pkg/helpers"
 )
 
+// copyThreshold is the number of rows from which a write is copied into the database
+// with COPY instead of being sent as array parameters.
+const copyThreshold = 1000
+
 // DBStore is a struct that provides methods for interacting with a PostgreSQL database to store and retrieve metrics.
 type DBStore struct {
-	db     *pgxpool.Pool // PostgreSQL connection pool
-	logger *slog.Logger  // Logger for logging operations
+	db     *pgxpool.Pool       // PostgreSQL connection pool
+	logger *slog.Logger        // Logger for logging operations
+	ttl    *entities.TTLPolicy // Expiry policy of the series, nil keeps them forever
 }
 
-// NewPostgresStorage creates a new DBStore instance and initializes the database schema.
+// NewPostgresStorage creates a new DBStore instance and applies the pending schema migrations.
 // It takes a pgxpool.Pool for database connections and a logger for logging.
 // Returns a pointer to the DBStore and an error if the initialization fails.
 func NewPostgresStorage(db *pgxpool.Pool, logger *slog.Logger) (*DBStore, error) {
@@ -33,34 +39,44 @@ func NewPostgresStorage(db *pgxpool.Pool, logger *slog.Logger) (*DBStore, error)
 		logger: logger,
 	}
 
-	if err := dbs.createScheme(context.Background()); err != nil {
-		return nil, fmt.Errorf("failed to create the db schema %w", err)
+	migrator, err := NewMigrator(db, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the schema migrations: %w", err)
+	}
+	if _, err = migrator.Up(context.Background()); err != nil {
+		return nil, fmt.Errorf("failed to migrate the db schema: %w", err)
 	}
 
 	return dbs, nil
 }
 
 // CreateRecord inserts a new metric record into the database or updates an existing one.
-// It accepts an entities.Metrics object containing the metric data.
-// Returns an error if the operation fails.
-func (ds *DBStore) CreateRecord(metric entities.Metrics) error {
-	var err error
-	ctx := context.Background()
-
-	switch {
-	case metric.Delta != nil:
-		err = ds.createCounterMetric(ctx, metric)
-	case metric.Value != nil:
-		_, err = ds.db.Exec(ctx, `
-			INSERT INTO gauge_metrics (name, value) 
-			VALUES ($1, $2)
-			ON CONFLICT (name) DO UPDATE 
-			    SET value = EXCLUDED.value
-		`, metric.ID, *metric.Value)
-	default:
-		return errors.New("invalid metric: must have either delta or value set")
-	}
+// It accepts an entities.Metrics object containing the metric data. The record and its
+// sample are written in a single transaction. Returns an error if the operation fails.
+func (ds *DBStore) CreateRecord(ctx context.Context, metric entities.Metrics) error {
+	single := []entities.Metrics{metric}
 
+	err := ds.inTx(ctx, func(tx pgxv5.Tx) error {
+		switch {
+		case metric.Histogram != nil:
+			return ds.storeHistograms(ctx, tx, single)
+		case metric.Summary != nil:
+			return ds.storeSummaries(ctx, tx, single)
+		case metric.Delta != nil:
+			totals, err := ds.storeCounters(ctx, tx, single)
+			if err != nil {
+				return err
+			}
+			return ds.storeSamples(ctx, tx, totals)
+		case metric.Value != nil:
+			if err := ds.storeGauges(ctx, tx, single); err != nil {
+				return err
+			}
+			return ds.storeSamples(ctx, tx, single)
+		default:
+			return errors.New("invalid metric: must have either delta, value, histogram or summary set")
+		}
+	})
 	if err != nil {
 		return fmt.Errorf("failed to create record: %w", err)
 	}
@@ -68,24 +84,46 @@ func (ds *DBStore) CreateRecord(metric entities.Metrics) error {
 	return nil
 }
 
-// GetRecord retrieves a metric record by its name and type from the database.
+// GetRecord retrieves a metric record by its series key and type from the database.
 // It returns the corresponding entities.Metrics object and an error if the record is not found or another issue occurs.
-func (ds *DBStore) GetRecord(mName entities.MetricName, mType entities.MetricType) (entities.Metrics, error) {
+// Expired series are reported as not found.
+func (ds *DBStore) GetRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	return ds.getRecord(ctx, mName, mType, ds.ttl.Cutoff(mName, time.Now()))
+}
+
+// getRecord retrieves a metric record updated at or after cutoff. A zero cutoff returns the record
+// regardless of its update time.
+func (ds *DBStore) getRecord(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+	cutoff time.Time) (entities.Metrics, error) {
 	var metrics entities.Metrics
 	var err error
 
-	ctx := context.Background()
+	name, labels, err := entities.ParseSeriesKey(mName)
+	if err != nil {
+		return metrics, fmt.Errorf("failed to get record: %w", err)
+	}
+	metrics.Labels = labels
+
 	switch mType {
 	case "counter":
 		err = ds.db.QueryRow(ctx, `
-			SELECT name, value FROM counter_metrics WHERE name = $1
-		`, mName).Scan(&metrics.ID, &metrics.Delta)
+			SELECT name, value FROM counter_metrics WHERE name = $1 AND labels = $2 AND updated_at >= $3
+		`, name, labels.String(), cutoff).Scan(&metrics.ID, &metrics.Delta)
 		metrics.MType = "counter"
 	case "gauge":
 		err = ds.db.QueryRow(ctx, `
-			SELECT name, value FROM gauge_metrics WHERE name = $1
-		`, mName).Scan(&metrics.ID, &metrics.Value)
+			SELECT name, value FROM gauge_metrics WHERE name = $1 AND labels = $2 AND updated_at >= $3
+		`, name, labels.String(), cutoff).Scan(&metrics.ID, &metrics.Value)
 		metrics.MType = "gauge"
+	case entities.HistogramMetricName:
+		metrics.Histogram, err = ds.getHistogram(ctx, name, labels, cutoff)
+		metrics.ID = string(name)
+		metrics.MType = string(entities.HistogramMetricName)
+	case entities.SummaryMetricName:
+		metrics.Summary, err = ds.getSummary(ctx, name, labels, cutoff)
+		metrics.ID = string(name)
+		metrics.MType = string(entities.SummaryMetricName)
 	default:
 		return metrics, fmt.Errorf("invalid metric type: %s", mType)
 	}
@@ -101,17 +139,14 @@ func (ds *DBStore) GetRecord(mName entities.MetricName, mType entities.MetricTyp
 }
 
 // GetAllRecords retrieves all metric records from the database and returns them as a MetricsStorage object.
-// It includes both gauge and counter metrics.
-func (ds *DBStore) GetAllRecords() (*MetricsStorage, error) {
-	ctx := context.Background()
-	metricsStorage := &MetricsStorage{
-		Counter: make(map[entities.MetricName]entities.Counter),
-		Gauge:   make(map[entities.MetricName]entities.Gauge),
-	}
+// It includes gauge, counter, histogram and summary metrics. Expired series are skipped.
+func (ds *DBStore) GetAllRecords(ctx context.Context) (*MetricsStorage, error) {
+	metricsStorage := NewMetricsStorage()
+	now := time.Now()
 
 	// Retrieve all gauge metrics
 	rows, err := ds.db.Query(ctx, `
-		SELECT name, value FROM gauge_metrics
+		SELECT name, labels, value, updated_at FROM gauge_metrics
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all gauge records: %w", err)
@@ -120,12 +155,16 @@ func (ds *DBStore) GetAllRecords() (*MetricsStorage, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var name string
+		var name, labels string
 		var value float64
-		if err = rows.Scan(&name, &value); err != nil {
+		var updatedAt time.Time
+		if err = rows.Scan(&name, &labels, &value, &updatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan gauge record: %w", err)
 		}
-		metricsStorage.Gauge[entities.MetricName(name)] = entities.Gauge(value)
+		key := seriesKeyFromColumns(name, labels)
+		if !ds.ttl.Expired(key, updatedAt, now) {
+			metricsStorage.Gauge[key] = entities.Gauge(value)
+		}
 	}
 
 	if err = rows.Err(); err != nil {
@@ -134,7 +173,7 @@ func (ds *DBStore) GetAllRecords() (*MetricsStorage, error) {
 
 	// Retrieve all counter metrics
 	rows, err = ds.db.Query(ctx, `
-		SELECT name, value FROM counter_metrics
+		SELECT name, labels, value, updated_at FROM counter_metrics
 	`)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all counter records: %w", err)
@@ -142,29 +181,61 @@ func (ds *DBStore) GetAllRecords() (*MetricsStorage, error) {
 	defer rows.Close()
 
 	for rows.Next() {
-		var name string
+		var name, labels string
 		var delta int64
-		if err := rows.Scan(&name, &delta); err != nil {
+		var updatedAt time.Time
+		if err := rows.Scan(&name, &labels, &delta, &updatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan counter record: %w", err)
 		}
-		metricsStorage.Counter[entities.MetricName(name)] = entities.Counter(delta)
+		key := seriesKeyFromColumns(name, labels)
+		if !ds.ttl.Expired(key, updatedAt, now) {
+			metricsStorage.Counter[key] = entities.Counter(delta)
+		}
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("reading counter type db rows error %w", err)
 	}
 
+	histograms, err := ds.GetAllRecordsByType(ctx, entities.HistogramMetricName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all histogram records: %w", err)
+	}
+	for key, metric := range histograms {
+		metricsStorage.Histogram[key] = metric.Histogram
+	}
+
+	summaries, err := ds.GetAllRecordsByType(ctx, entities.SummaryMetricName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get all summary records: %w", err)
+	}
+	for key, metric := range summaries {
+		metricsStorage.Summary[key] = metric.Summary
+	}
+
 	return metricsStorage, nil
 }
 
-// GetAllRecordsByType retrieves all metric records of a specific type (gauge or counter) from the database.
+// GetAllRecordsByType retrieves all metric records of a specific type (gauge, counter, histogram or summary)
+// from the database.
 // It returns a map of entities.Metrics indexed by metric names and an error if the operation fails.
-func (ds *DBStore) GetAllRecordsByType(mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
-	ctx := context.Background()
+// Expired series are skipped.
+func (ds *DBStore) GetAllRecordsByType(ctx context.Context,
+	mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
+	return ds.getAllRecordsByType(ctx, mType, ds.ttl)
+}
+
+// getAllRecordsByType retrieves all metric records of a specific type, skipping the series
+// expired according to the policy. A nil policy returns every series.
+func (ds *DBStore) getAllRecordsByType(ctx context.Context, mType entities.MetricType,
+	policy *entities.TTLPolicy) (map[entities.MetricName]entities.Metrics, error) {
 	metricsMap := make(map[entities.MetricName]entities.Metrics)
+	now := time.Now()
 
-	selectCountersStmt := `SELECT name, value FROM counter_metrics`
-	selectGaugesStmt := `SELECT name, value FROM gauge_metrics`
+	selectCountersStmt := `SELECT name, labels, updated_at, value FROM counter_metrics`
+	selectGaugesStmt := `SELECT name, labels, updated_at, value FROM gauge_metrics`
+	selectHistogramsStmt := `SELECT name, labels, updated_at, bounds, counts, sum, count FROM histogram_metrics`
+	selectSummariesStmt := `SELECT name, labels, updated_at, sketch FROM summary_metrics`
 
 	var stmt string
 
@@ -173,6 +244,10 @@ func (ds *DBStore) GetAllRecordsByType(mType entities.MetricType) (map[entities.
 		stmt = selectGaugesStmt
 	case entities.CounterMetricName:
 		stmt = selectCountersStmt
+	case entities.HistogramMetricName:
+		stmt = selectHistogramsStmt
+	case entities.SummaryMetricName:
+		stmt = selectSummariesStmt
 	default:
 		return nil, errors.New("invalid metric type")
 	}
@@ -184,32 +259,67 @@ func (ds *DBStore) GetAllRecordsByType(mType entities.MetricType) (map[entities.
 	defer rows.Close()
 
 	// Scan the results based on the metric type
+	var name, labels string
+	var updatedAt time.Time
 	switch mType {
 	case entities.CounterMetricName:
 		for rows.Next() {
-			var name string
 			var delta int64
-			if err = rows.Scan(&name, &delta); err != nil {
+			if err = rows.Scan(&name, &labels, &updatedAt, &delta); err != nil {
 				return nil, fmt.Errorf("failed to scan counter record: %w", err)
 			}
-			metricsMap[entities.MetricName(name)] = entities.Metrics{
-				ID:    name,
-				MType: string(entities.CounterMetricName),
-				Delta: &delta,
+			key := seriesKeyFromColumns(name, labels)
+			if policy.Expired(key, updatedAt, now) {
+				continue
 			}
+			metric := metricFromKey(key, entities.CounterMetricName)
+			metric.Delta = &delta
+			metricsMap[key] = metric
 		}
 	case entities.GaugeMetricName:
 		for rows.Next() {
-			var name string
 			var value float64
-			if err = rows.Scan(&name, &value); err != nil {
+			if err = rows.Scan(&name, &labels, &updatedAt, &value); err != nil {
 				return nil, fmt.Errorf("failed to scan gauge record: %w", err)
 			}
-			metricsMap[entities.MetricName(name)] = entities.Metrics{
-				ID:    name,
-				MType: string(entities.GaugeMetricName),
-				Value: &value,
+			key := seriesKeyFromColumns(name, labels)
+			if policy.Expired(key, updatedAt, now) {
+				continue
 			}
+			metric := metricFromKey(key, entities.GaugeMetricName)
+			metric.Value = &value
+			metricsMap[key] = metric
+		}
+	case entities.HistogramMetricName:
+		for rows.Next() {
+			var h histogramRow
+			if err = rows.Scan(&name, &labels, &updatedAt, &h.bounds, &h.counts, &h.sum, &h.count); err != nil {
+				return nil, fmt.Errorf("failed to scan histogram record: %w", err)
+			}
+			key := seriesKeyFromColumns(name, labels)
+			if policy.Expired(key, updatedAt, now) {
+				continue
+			}
+			metric := metricFromKey(key, entities.HistogramMetricName)
+			metric.Histogram = h.histogram()
+			metricsMap[key] = metric
+		}
+	case entities.SummaryMetricName:
+		for rows.Next() {
+			var sketch []byte
+			if err = rows.Scan(&name, &labels, &updatedAt, &sketch); err != nil {
+				return nil, fmt.Errorf("failed to scan summary record: %w", err)
+			}
+			key := seriesKeyFromColumns(name, labels)
+			if policy.Expired(key, updatedAt, now) {
+				continue
+			}
+			metric := metricFromKey(key, entities.SummaryMetricName)
+			metric.Summary = &entities.Summary{}
+			if err = metric.Summary.UnmarshalBinary(sketch); err != nil {
+				return nil, fmt.Errorf("failed to decode summary record: %w", err)
+			}
+			metricsMap[key] = metric
 		}
 	}
 
@@ -220,6 +330,77 @@ func (ds *DBStore) GetAllRecordsByType(mType entities.MetricType) (map[entities.
 	return metricsMap, nil
 }
 
+// SetMetadata registers the metadata of metric names in a single statement.
+func (ds *DBStore) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	// A name given twice would make the upsert touch the same row twice, the last metadata wins.
+	latest := make(map[entities.MetricName]entities.Metadata, len(metadata))
+	for _, md := range metadata {
+		latest[md.Name] = md
+	}
+
+	var names, kinds, units, descriptions, displays []string
+	for _, md := range latest {
+		names = append(names, string(md.Name))
+		kinds = append(kinds, string(md.Kind))
+		units = append(units, md.Unit)
+		descriptions = append(descriptions, md.Description)
+		displays = append(displays, string(md.Display))
+	}
+
+	_, err := ds.db.Exec(ctx, `
+		INSERT INTO metric_metadata (name, kind, unit, description, display)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::text[], $5::text[])
+			AS m(name, kind, unit, description, display)
+		ON CONFLICT (name) DO UPDATE
+		SET kind = EXCLUDED.kind, unit = EXCLUDED.unit, description = EXCLUDED.description,
+			display = EXCLUDED.display, updated_at = NOW()
+	`, names, kinds, units, descriptions, displays)
+	if err != nil {
+		return fmt.Errorf("failed to set metadata: %w", err)
+	}
+
+	return nil
+}
+
+// GetMetadata returns the metadata registered for a metric name, or ErrNotFound.
+func (ds *DBStore) GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error) {
+	md := entities.Metadata{Name: name}
+	err := ds.db.QueryRow(ctx, `
+		SELECT kind, unit, description, display FROM metric_metadata WHERE name = $1
+	`, string(name)).Scan(&md.Kind, &md.Unit, &md.Description, &md.Display)
+	if errors.Is(err, pgxv5.ErrNoRows) {
+		return entities.Metadata{}, fmt.Errorf("metadata of %s: %w", name, ErrNotFound)
+	}
+	if err != nil {
+		return entities.Metadata{}, fmt.Errorf("failed to get metadata: %w", err)
+	}
+
+	return md, nil
+}
+
+// GetAllMetadata returns the registered metadata keyed by metric name.
+func (ds *DBStore) GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error) {
+	rows, err := ds.db.Query(ctx, `SELECT name, kind, unit, description, display FROM metric_metadata`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get metadata: %w", err)
+	}
+	defer rows.Close()
+
+	metadata := make(map[entities.MetricName]entities.Metadata)
+	for rows.Next() {
+		var md entities.Metadata
+		if err = rows.Scan(&md.Name, &md.Kind, &md.Unit, &md.Description, &md.Display); err != nil {
+			return nil, fmt.Errorf("failed to read metadata: %w", err)
+		}
+		metadata[md.Name] = md
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read metadata: %w", err)
+	}
+
+	return metadata, nil
+}
+
 // Close shuts down the database connection pool and logs the action.
 // It accepts a context for logging and returns an error if the shutdown fails.
 func (ds *DBStore) Close(ctx context.Context) error {
@@ -229,229 +410,788 @@ func (ds *DBStore) Close(ctx context.Context) error {
 	return nil
 }
 
-// createScheme initializes the database schema by creating necessary tables for metrics storage.
-// It is called during the initialization of the DBStore.
-// Returns an error if the schema creation fails.
-func (ds *DBStore) createScheme(ctx context.Context) error {
-	trx, err := ds.db.Begin(ctx)
+// inTx runs fn in a transaction, which is committed if fn succeeds and rolled back otherwise.
+func (ds *DBStore) inTx(ctx context.Context, fn func(tx pgxv5.Tx) error) error {
+	if err := pgxv5.BeginFunc(ctx, ds.db, fn); err != nil {
+		return fmt.Errorf("transaction failed: %w", err)
+	}
+
+	return nil
+}
+
+// copyToStaging creates a temporary table with the create statement and copies the rows
+// into it with COPY. The table is dropped when the transaction ends.
+func copyToStaging(ctx context.Context, tx pgxv5.Tx, create, table string, rows [][]any) error {
+	if _, err := tx.Exec(ctx, create); err != nil {
+		return fmt.Errorf("failed to create %s: %w", table, err)
+	}
+
+	if _, err := tx.CopyFrom(ctx, pgxv5.Identifier{table}, []string{"name", "labels", "value"},
+		pgxv5.CopyFromRows(rows)); err != nil {
+		return fmt.Errorf("failed to copy into %s: %w", table, err)
+	}
+
+	return nil
+}
+
+// storeCounters adds the deltas of the given counters to the stored values in a single
+// statement, so the database serializes concurrent writers on the row and no increment
+// is lost. Rows are locked in series order, so concurrent batches do not deadlock.
+// The counters must be distinct series. It returns the counters holding their new totals.
+func (ds *DBStore) storeCounters(ctx context.Context, tx pgxv5.Tx,
+	metrics []entities.Metrics) ([]entities.Metrics, error) {
+	names := make([]string, 0, len(metrics))
+	labels := make([]string, 0, len(metrics))
+	deltas := make([]int64, 0, len(metrics))
+	bySeries := make(map[entities.MetricName]entities.Metrics, len(metrics))
+	for _, metric := range metrics {
+		names = append(names, metric.ID)
+		labels = append(labels, metric.Labels.String())
+		deltas = append(deltas, *metric.Delta)
+		bySeries[seriesKeyFromColumns(metric.ID, metric.Labels.String())] = metric
+	}
+
+	var (
+		rows pgxv5.Rows
+		err  error
+	)
+	if len(metrics) >= copyThreshold {
+		staged := make([][]any, 0, len(metrics))
+		for i := range names {
+			staged = append(staged, []any{names[i], labels[i], deltas[i]})
+		}
+		if err = copyToStaging(ctx, tx, `
+			CREATE TEMP TABLE counter_staging (name TEXT, labels TEXT, value BIGINT) ON COMMIT DROP
+		`, "counter_staging", staged); err != nil {
+			return nil, err
+		}
+		rows, err = tx.Query(ctx, `
+			INSERT INTO counter_metrics (name, labels, value)
+			SELECT name, labels, value FROM counter_staging ORDER BY name, labels
+			ON CONFLICT (name, labels) DO UPDATE
+			SET value = counter_metrics.value + EXCLUDED.value, updated_at = NOW()
+			RETURNING name, labels, value
+		`)
+	} else {
+		rows, err = tx.Query(ctx, `
+			INSERT INTO counter_metrics (name, labels, value)
+			SELECT * FROM unnest($1::text[], $2::text[], $3::bigint[]) AS s(name, labels, value)
+			ORDER BY name, labels
+			ON CONFLICT (name, labels) DO UPDATE
+			SET value = counter_metrics.value + EXCLUDED.value, updated_at = NOW()
+			RETURNING name, labels, value
+		`, names, labels, deltas)
+	}
 	if err != nil {
-		return fmt.Errorf("failed to start the transaction %w", err)
+		return nil, fmt.Errorf("failed to store counters: %w", err)
 	}
+	defer rows.Close()
 
-	defer func() {
-		if err = trx.Rollback(ctx); err != nil {
-			if errors.Is(err, sql.ErrTxDone) {
-				ds.logger.ErrorContext(ctx,
-					"failed to rollback the transaction ",
-					helpers.ErrAttr(err))
+	totals := make([]entities.Metrics, 0, len(metrics))
+	for rows.Next() {
+		var (
+			name, labels string
+			total        int64
+		)
+		if err = rows.Scan(&name, &labels, &total); err != nil {
+			return nil, fmt.Errorf("failed to scan counter total: %w", err)
+		}
+		metric := bySeries[seriesKeyFromColumns(name, labels)]
+		metric.Delta = &total
+		totals = append(totals, metric)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to store counters: %w", err)
+	}
+
+	return totals, nil
+}
+
+// StoreMetricsBatch stores a batch of metrics in the database in a single transaction,
+// so either the whole batch is applied or none of it. Counter deltas of the same series
+// are summed, only the latest gauge value is kept and histograms and summaries are merged
+// before being written. Batches of copyThreshold series or more are copied in with COPY.
+func (ds *DBStore) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	counterMetrics := make(map[entities.MetricName]entities.Metrics)
+	gaugeMetrics := make(map[entities.MetricName]entities.Metrics)
+	histogramMetrics := make(map[entities.MetricName]entities.Metrics)
+	summaryMetrics := make(map[entities.MetricName]entities.Metrics)
+
+	for _, metric := range metrics {
+		key := metric.Key()
+		switch entities.MetricType(metric.MType) {
+		case entities.CounterMetricName:
+			if existing, ok := counterMetrics[key]; ok {
+				sum := *existing.Delta + *metric.Delta // the caller's delta is left untouched
+				existing.Delta = &sum
+				counterMetrics[key] = existing
+			} else {
+				counterMetrics[key] = metric
+			}
+		case entities.GaugeMetricName:
+			gaugeMetrics[key] = metric // only the latest gauge value is relevant
+		case entities.HistogramMetricName:
+			if metric.Histogram == nil {
+				return fmt.Errorf("store histogram %s: %w", metric.ID, entities.ErrInvalidHistogram)
+			}
+			existing, ok := histogramMetrics[key]
+			if !ok {
+				existing = metric
+				existing.Histogram = &entities.Histogram{}
+			}
+			if err := existing.Histogram.Merge(metric.Histogram); err != nil {
+				return fmt.Errorf("store histogram %s: %w", metric.ID, err)
+			}
+			histogramMetrics[key] = existing
+		case entities.SummaryMetricName:
+			if metric.Summary == nil {
+				return fmt.Errorf("store summary %s: %w", metric.ID, entities.ErrInvalidSummary)
+			}
+			existing, ok := summaryMetrics[key]
+			if !ok {
+				existing = metric
+				existing.Summary = &entities.Summary{}
 			}
+			if err := existing.Summary.Merge(metric.Summary); err != nil {
+				return fmt.Errorf("store summary %s: %w", metric.ID, err)
+			}
+			summaryMetrics[key] = existing
 		}
-	}()
+	}
 
-	createGaugeTable := `
-		CREATE TABLE IF NOT EXISTS gauge_metrics (
-			id SERIAL PRIMARY KEY,
-			name TEXT UNIQUE NOT NULL,
-			value DOUBLE PRECISION NOT NULL,
-			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-		);
-	`
+	return ds.inTx(ctx, func(tx pgxv5.Tx) error {
+		if len(histogramMetrics) > 0 {
+			if err := ds.storeHistograms(ctx, tx, sortedSeries(histogramMetrics)); err != nil {
+				return fmt.Errorf("store histogram metrics failed %w", err)
+			}
+		}
 
-	createCounterTable := `
-		CREATE TABLE IF NOT EXISTS counter_metrics (
-			id SERIAL PRIMARY KEY,
-			name TEXT UNIQUE NOT NULL,
-			value BIGINT NOT NULL CHECK  (value >= 0) ,
-			created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
-			updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-		);
-	`
+		if len(summaryMetrics) > 0 {
+			if err := ds.storeSummaries(ctx, tx, sortedSeries(summaryMetrics)); err != nil {
+				return fmt.Errorf("store summary metrics failed %w", err)
+			}
+		}
 
-	createStatements := []string{
-		createGaugeTable,
-		createCounterTable,
+		if len(counterMetrics) > 0 {
+			totals, err := ds.storeCounters(ctx, tx, sortedSeries(counterMetrics))
+			if err != nil {
+				return fmt.Errorf("store counter metrics failed %w", err)
+			}
+
+			if err = ds.storeSamples(ctx, tx, totals); err != nil {
+				return fmt.Errorf("store counter samples failed %w", err)
+			}
+		}
+
+		if len(gaugeMetrics) > 0 {
+			gaugeMetricsList := sortedSeries(gaugeMetrics)
+			if err := ds.storeGauges(ctx, tx, gaugeMetricsList); err != nil {
+				return fmt.Errorf("store gauge metrics failed %w", err)
+			}
+
+			if err := ds.storeSamples(ctx, tx, gaugeMetricsList); err != nil {
+				return fmt.Errorf("store gauge samples failed %w", err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// sortedSeries returns the metrics of a batch sorted by series key.
+func sortedSeries(series map[entities.MetricName]entities.Metrics) []entities.Metrics {
+	keys := make([]entities.MetricName, 0, len(series))
+	for key := range series {
+		keys = append(keys, key)
 	}
+	slices.Sort(keys)
 
-	for _, stmt := range createStatements {
-		if _, err := trx.Exec(ctx, stmt); err != nil {
-			return fmt.Errorf("failed to execute transaction %w", err)
+	metrics := make([]entities.Metrics, 0, len(keys))
+	for _, key := range keys {
+		metrics = append(metrics, series[key])
+	}
+
+	return metrics
+}
+
+// storeGauges sets the values of the given gauges in a single statement.
+// The gauges must be distinct series.
+func (ds *DBStore) storeGauges(ctx context.Context, tx pgxv5.Tx, metrics []entities.Metrics) error {
+	if len(metrics) >= copyThreshold {
+		staged := make([][]any, 0, len(metrics))
+		for _, metric := range metrics {
+			staged = append(staged, []any{metric.ID, metric.Labels.String(), *metric.Value})
+		}
+		if err := copyToStaging(ctx, tx, `
+			CREATE TEMP TABLE gauge_staging (name TEXT, labels TEXT, value DOUBLE PRECISION) ON COMMIT DROP
+		`, "gauge_staging", staged); err != nil {
+			return err
 		}
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO gauge_metrics (name, labels, value)
+			SELECT name, labels, value FROM gauge_staging ORDER BY name, labels
+			ON CONFLICT (name, labels) DO UPDATE
+			SET value = EXCLUDED.value, updated_at = NOW()
+		`); err != nil {
+			return fmt.Errorf("failed to store gauges: %w", err)
+		}
+		return nil
+	}
+
+	names := make([]string, 0, len(metrics))
+	labels := make([]string, 0, len(metrics))
+	values := make([]float64, 0, len(metrics))
+	for _, metric := range metrics {
+		names = append(names, metric.ID)
+		labels = append(labels, metric.Labels.String())
+		values = append(values, *metric.Value)
 	}
 
-	if err := trx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to commit the transaction %w", err)
+	_, err := tx.Exec(ctx, `
+		INSERT INTO gauge_metrics (name, labels, value)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::double precision[]) AS s(name, labels, value)
+		ORDER BY name, labels
+		ON CONFLICT (name, labels) DO UPDATE
+		SET value = EXCLUDED.value, updated_at = NOW()
+	`, names, labels, values)
+	if err != nil {
+		return fmt.Errorf("failed to store gauges: %w", err)
 	}
 
 	return nil
 }
 
-// createCounterMetric creates or updates a counter metric in the database.
-// If the metric already exists, its value is updated by adding the new delta.
-// If the metric does not exist, it is created with the provided delta value.
-func (ds *DBStore) createCounterMetric(ctx context.Context, metric entities.Metrics) error {
-	existingMetric, err := ds.GetRecord(entities.MetricName(metric.ID), entities.MetricType(metric.MType))
-	if err != nil && !errors.Is(err, ErrNotFound) {
-		return fmt.Errorf("create counter metric failed to get current metric: %w", err)
+// histogramRow holds the columns of a histogram_metrics row. Postgres has no
+// unsigned integers, so the counts are stored as BIGINT.
+type histogramRow struct {
+	bounds []float64
+	counts []int64
+	sum    float64
+	count  int64
+}
+
+// histogram converts the row into a histogram entity.
+func (r histogramRow) histogram() *entities.Histogram {
+	h := &entities.Histogram{
+		Bounds: r.bounds,
+		Counts: make([]uint64, len(r.counts)),
+		Sum:    r.sum,
+		Count:  uint64(r.count),
+	}
+	for i, c := range r.counts {
+		h.Counts[i] = uint64(c)
 	}
 
-	if errors.Is(err, ErrNotFound) {
-		_, err = ds.db.Exec(ctx, `
-			INSERT INTO counter_metrics (name, value) 
-			VALUES ($1, $2)
-			ON CONFLICT (name) DO UPDATE 
-			    SET value = EXCLUDED.value
-		`, metric.ID, *metric.Delta)
-		if err != nil {
-			return fmt.Errorf("failed to create new counter metric: %w", err)
+	return h
+}
+
+// getHistogram retrieves the histogram of a single series.
+func (ds *DBStore) getHistogram(ctx context.Context, name entities.MetricName,
+	labels entities.Labels, cutoff time.Time) (*entities.Histogram, error) {
+	var h histogramRow
+	err := ds.db.QueryRow(ctx, `
+		SELECT bounds, counts, sum, count FROM histogram_metrics
+		WHERE name = $1 AND labels = $2 AND updated_at >= $3
+	`, name, labels.String(), cutoff).Scan(&h.bounds, &h.counts, &h.sum, &h.count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get histogram: %w", err)
+	}
+
+	return h.histogram(), nil
+}
+
+// storeHistograms merges the given histograms into the stored ones, sending the statements
+// in a single pgx batch. The bucket counts are added element-wise by the database, so
+// concurrent writers do not lose observations. A histogram whose bounds differ from the
+// stored ones fails the transaction.
+func (ds *DBStore) storeHistograms(ctx context.Context, tx pgxv5.Tx, metrics []entities.Metrics) (err error) {
+	batch := &pgxv5.Batch{}
+	for _, metric := range metrics {
+		h := metric.Histogram
+		if err = h.Validate(); err != nil {
+			return fmt.Errorf("store histogram %s: %w", metric.ID, err)
+		}
+
+		counts := make([]int64, len(h.Counts))
+		for i, c := range h.Counts {
+			counts[i] = int64(c)
+		}
+
+		batch.Queue(`
+			INSERT INTO histogram_metrics (name, labels, bounds, counts, sum, count)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (name, labels) DO UPDATE
+			SET counts = ARRAY(
+					SELECT s.a + s.b
+					FROM unnest(histogram_metrics.counts, EXCLUDED.counts) WITH ORDINALITY AS s(a, b, i)
+					ORDER BY s.i
+				),
+				sum = histogram_metrics.sum + EXCLUDED.sum,
+				count = histogram_metrics.count + EXCLUDED.count,
+				updated_at = NOW()
+			WHERE histogram_metrics.bounds = EXCLUDED.bounds
+			RETURNING id
+		`, metric.ID, metric.Labels.String(), h.Bounds, counts, h.Sum, int64(h.Count))
+	}
+
+	results := tx.SendBatch(ctx, batch)
+	defer func() {
+		if closeErr := results.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("store histograms: %w", closeErr)
+		}
+	}()
+
+	for _, metric := range metrics {
+		var id int64
+		err = results.QueryRow().Scan(&id)
+		if errors.Is(err, pgxv5.ErrNoRows) {
+			return fmt.Errorf("store histogram %s: %w", metric.ID, entities.ErrHistogramBoundsMismatch)
 		}
-	} else {
-		newValue := *metric.Delta + *existingMetric.Delta
-		metric.Delta = &newValue
-		_, err = ds.db.Exec(ctx, `
-			INSERT INTO counter_metrics (name, value) VALUES ($1, $2)
-			ON CONFLICT (name) DO UPDATE SET value = EXCLUDED.value
-		`, metric.ID, *metric.Delta)
 		if err != nil {
-			return fmt.Errorf("failed to update existing counter metric: %w", err)
+			return fmt.Errorf("store histogram %s: %w", metric.ID, err)
 		}
 	}
 
 	return nil
 }
 
-// StoreMetricsBatch stores a batch of metrics in the database.
-// It separates metrics into counters and gauges, processing them accordingly.
-// Counter metrics are summed if they already exist, while gauge metrics
-// only store the latest value.
-func (ds *DBStore) StoreMetricsBatch(metrics []entities.Metrics) error {
-	counterMetrics := make(map[string]entities.Metrics)
-	gaugeMetrics := make(map[string]entities.Metrics)
+// getSummary retrieves the summary of a single series.
+func (ds *DBStore) getSummary(ctx context.Context, name entities.MetricName,
+	labels entities.Labels, cutoff time.Time) (*entities.Summary, error) {
+	var sketch []byte
+	err := ds.db.QueryRow(ctx, `
+		SELECT sketch FROM summary_metrics WHERE name = $1 AND labels = $2 AND updated_at >= $3
+	`, name, labels.String(), cutoff).Scan(&sketch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get summary: %w", err)
+	}
+
+	summary := &entities.Summary{}
+	if err = summary.UnmarshalBinary(sketch); err != nil {
+		return nil, fmt.Errorf("failed to decode summary: %w", err)
+	}
+
+	return summary, nil
+}
 
+// storeSummaries merges the given summaries into the stored ones. A new series is
+// inserted as is, an existing one is locked, merged and written back, so concurrent
+// writers do not lose observations.
+func (ds *DBStore) storeSummaries(ctx context.Context, tx pgxv5.Tx, metrics []entities.Metrics) error {
 	for _, metric := range metrics {
-		switch entities.MetricType(metric.MType) {
-		case entities.CounterMetricName:
-			if existing, ok := counterMetrics[metric.ID]; ok {
-				*existing.Delta += *metric.Delta
-				counterMetrics[metric.ID] = existing
-			} else {
-				counterMetrics[metric.ID] = metric
-			}
-		case entities.GaugeMetricName:
-			gaugeMetrics[metric.ID] = metric // only the latest gauge value is relevant
+		if err := metric.Summary.Validate(); err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
 		}
-	}
 
-	ctx := context.Background()
+		sketch, err := metric.Summary.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
+		}
 
-	if len(counterMetrics) > 0 {
-		existingCounter, err := ds.GetAllRecordsByType(entities.CounterMetricName)
+		tag, err := tx.Exec(ctx, `
+			INSERT INTO summary_metrics (name, labels, sketch) VALUES ($1, $2, $3)
+			ON CONFLICT (name, labels) DO NOTHING
+		`, metric.ID, metric.Labels.String(), sketch)
 		if err != nil {
-			return fmt.Errorf("failed to get existing counter metrics: %w", err)
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
+		}
+		if tag.RowsAffected() > 0 {
+			continue
 		}
 
-		for id, metric := range counterMetrics {
-			if v, ok := existingCounter[entities.MetricName(id)]; ok {
-				*metric.Delta += *v.Delta
-			}
-			existingCounter[entities.MetricName(id)] = metric
+		var stored []byte
+		err = tx.QueryRow(ctx, `
+			SELECT sketch FROM summary_metrics WHERE name = $1 AND labels = $2 FOR UPDATE
+		`, metric.ID, metric.Labels.String()).Scan(&stored)
+		if err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
 		}
 
-		counterMetricsList := make([]entities.Metrics, 0, len(existingCounter))
-		for _, v := range existingCounter {
-			counterMetricsList = append(counterMetricsList, v)
+		merged := &entities.Summary{}
+		if err = merged.UnmarshalBinary(stored); err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
+		}
+		if err = merged.Merge(metric.Summary); err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
+		}
+		if sketch, err = merged.MarshalBinary(); err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
 		}
 
-		if err = ds.storeBatchMetrics(ctx, counterMetricsList); err != nil {
-			return fmt.Errorf("store counter metrics failed %w", err)
+		_, err = tx.Exec(ctx, `
+			UPDATE summary_metrics SET sketch = $3, updated_at = NOW() WHERE name = $1 AND labels = $2
+		`, metric.ID, metric.Labels.String(), sketch)
+		if err != nil {
+			return fmt.Errorf("store summary %s: %w", metric.ID, err)
 		}
 	}
 
-	if len(gaugeMetrics) > 0 {
-		gaugeMetricsList := make([]entities.Metrics, 0, len(gaugeMetrics))
-		for _, v := range gaugeMetrics {
-			gaugeMetricsList = append(gaugeMetricsList, v)
+	return nil
+}
+
+// storeSamples appends the accepted values of the given metrics to the metric_samples table.
+// For counters the recorded value is the accumulated total held in Delta. Batches of
+// copyThreshold samples or more are copied in with COPY.
+func (ds *DBStore) storeSamples(ctx context.Context, tx pgxv5.Tx, metrics []entities.Metrics) error {
+	types := make([]string, 0, len(metrics))
+	names := make([]string, 0, len(metrics))
+	labels := make([]string, 0, len(metrics))
+	values := make([]float64, 0, len(metrics))
+
+	for _, metric := range metrics {
+		switch {
+		case metric.Delta != nil:
+			values = append(values, float64(*metric.Delta))
+		case metric.Value != nil:
+			values = append(values, *metric.Value)
+		default:
+			continue
 		}
+		types = append(types, metric.MType)
+		names = append(names, metric.ID)
+		labels = append(labels, metric.Labels.String())
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
 
-		if err := ds.storeBatchMetrics(ctx, gaugeMetricsList); err != nil {
-			return fmt.Errorf("store gauge metrics failed %w", err)
+	if len(values) >= copyThreshold {
+		rows := make([][]any, 0, len(values))
+		for i := range values {
+			rows = append(rows, []any{types[i], names[i], labels[i], values[i]})
+		}
+		_, err := tx.CopyFrom(ctx, pgxv5.Identifier{"metric_samples"}, []string{"type", "name", "labels", "value"},
+			pgxv5.CopyFromRows(rows))
+		if err != nil {
+			return fmt.Errorf("failed to copy samples: %w", err)
 		}
+		return nil
+	}
+
+	_, err := tx.Exec(ctx, `
+		INSERT INTO metric_samples (type, name, labels, value)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::text[], $4::double precision[])
+	`, types, names, labels, values)
+	if err != nil {
+		return fmt.Errorf("failed to store samples: %w", err)
 	}
 
 	return nil
 }
 
-// storeBatchMetrics stores a batch of metrics in the database within a transaction.
-// It constructs and executes the necessary SQL statements to insert or update
-// counter and gauge metrics.
-func (ds *DBStore) storeBatchMetrics(ctx context.Context, metrics []entities.Metrics) error {
+// GetHistory retrieves the samples of a series recorded within [from, to] from the metric_samples table.
+func (ds *DBStore) GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+	from, to time.Time) ([]entities.Sample, error) {
+	name, labels, err := entities.ParseSeriesKey(mName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+
+	query := `
+		SELECT recorded_at, value FROM metric_samples
+		WHERE type = $1 AND name = $2 AND labels = $3
+	`
+	args := []any{string(mType), name, labels.String()}
+
+	if !from.IsZero() {
+		args = append(args, from)
+		query += fmt.Sprintf(" AND recorded_at >= $%d", len(args))
+	}
+	if !to.IsZero() {
+		args = append(args, to)
+		query += fmt.Sprintf(" AND recorded_at <= $%d", len(args))
+	}
+	query += " ORDER BY recorded_at"
+
+	rows, err := ds.db.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get history: %w", err)
+	}
+	defer rows.Close()
+
+	samples := make([]entities.Sample, 0)
+	for rows.Next() {
+		var sample entities.Sample
+		if err = rows.Scan(&sample.Timestamp, &sample.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan sample: %w", err)
+		}
+		samples = append(samples, sample)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("reading samples db rows error %w", err)
+	}
+
+	if len(samples) == 0 {
+		if _, err = ds.GetRecord(ctx, mName, mType); err != nil {
+			return nil, fmt.Errorf("failed to get history: %w", err)
+		}
+	}
+
+	return samples, nil
+}
+
+// metricTables maps every metric type to the table holding its series.
+var metricTables = map[entities.MetricType]string{
+	entities.CounterMetricName:   "counter_metrics",
+	entities.GaugeMetricName:     "gauge_metrics",
+	entities.HistogramMetricName: "histogram_metrics",
+	entities.SummaryMetricName:   "summary_metrics",
+}
+
+// DeleteRecord removes a series of the given type together with its samples within a single transaction.
+func (ds *DBStore) DeleteRecord(ctx context.Context, mName entities.MetricName, mType entities.MetricType) error {
+	table, ok := metricTables[mType]
+	if !ok {
+		return fmt.Errorf("invalid metric type: %s", mType)
+	}
+
+	name, labels, err := entities.ParseSeriesKey(mName)
+	if err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+
 	tx, err := ds.db.Begin(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction %w", err)
 	}
 	defer func() {
-		if err = tx.Rollback(ctx); err != nil {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgxv5.ErrTxClosed) {
 			ds.logger.ErrorContext(ctx, "failed to rollback", helpers.ErrAttr(err))
 		}
 	}()
 
-	// Counters update statement
-	countersUpdateStmt := `
-		INSERT INTO counter_metrics (name, value)
-		VALUES %s
-		ON CONFLICT (name) DO UPDATE
-		SET value = excluded.value, updated_at = NOW();
-	`
+	tag, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = $1 AND labels = $2`, table),
+		name, labels.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete record: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("metric not found: %w", ErrNotFound)
+	}
 
-	// Gauges update statement
-	gaugesUpdateStmt := `
-		INSERT INTO gauge_metrics (name, value)
-		VALUES %s
-		ON CONFLICT (name) DO UPDATE
-		SET value = excluded.value, updated_at = NOW();
-	`
+	_, err = tx.Exec(ctx, `
+		DELETE FROM metric_samples WHERE type = $1 AND name = $2 AND labels = $3
+	`, string(mType), name, labels.String())
+	if err != nil {
+		return fmt.Errorf("failed to delete samples: %w", err)
+	}
 
-	var counterValues []string
-	var counterParams []interface{}
-	var gaugeValues []string
-	var gaugeParams []interface{}
-	const metricsTypesCount = 2
-	const firstParam = 1
-	const secondParam = 2
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to execute transaction commit %w", err)
+	}
 
-	for i, metric := range metrics {
-		if metric.Delta != nil {
-			counterValues = append(counterValues, fmt.Sprintf("($%d, $%d)",
-				metricsTypesCount*i+firstParam, metricsTypesCount*i+secondParam))
-			counterParams = append(counterParams, metric.ID, *metric.Delta)
-		}
+	return nil
+}
 
-		if metric.Value != nil {
-			gaugeValues = append(gaugeValues, fmt.Sprintf("($%d, $%d)",
-				metricsTypesCount*i+firstParam, metricsTypesCount*i+secondParam))
-			gaugeParams = append(gaugeParams, metric.ID, *metric.Value)
+// DeleteRecords removes every series of the given type, or of all types if mType is empty,
+// whose key matches the matcher, together with their samples within a single transaction.
+// The series are matched in Go so the patterns behave the same as with the other storages.
+func (ds *DBStore) DeleteRecords(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	types := make([]entities.MetricType, 0, len(metricTables))
+	if mType != "" {
+		if _, ok := metricTables[mType]; !ok {
+			return 0, fmt.Errorf("invalid metric type: %s", mType)
+		}
+		types = append(types, mType)
+	} else {
+		for t := range metricTables {
+			types = append(types, t)
 		}
 	}
 
-	if len(counterValues) > 0 {
-		_, err = ds.db.Exec(ctx, fmt.Sprintf(countersUpdateStmt, strings.Join(counterValues, ",")), counterParams...)
-		if err != nil {
-			rollbackErr := tx.Rollback(ctx)
-			return fmt.Errorf("store counter SQL exec failed %w, started rollback %w", err, rollbackErr)
-		}
+	return ds.deleteSeriesWhere(ctx, types, seriesFilter{namePrefix: matcher.NamePrefix()},
+		func(key entities.MetricName, _ time.Time) bool {
+			return matcher.Match(key)
+		})
+}
+
+// SetTTLPolicy sets the policy deciding when series that are not updated expire,
+// based on their updated_at column. It must be called before the storage is used.
+func (ds *DBStore) SetTTLPolicy(policy *entities.TTLPolicy) {
+	ds.ttl = policy
+}
+
+// PurgeExpired removes the series whose updated_at is older than their TTL at now,
+// together with their samples. Only the series older than the shortest TTL are read.
+// Whatever the TTL policy, it also prunes the history of every series down to its
+// defaultHistorySize most recent samples, as the other storages keep.
+func (ds *DBStore) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	if err := ds.pruneSamples(ctx); err != nil {
+		return 0, err
+	}
+	if !ds.ttl.Enabled() {
+		return 0, nil
+	}
+
+	types := make([]entities.MetricType, 0, len(metricTables))
+	for t := range metricTables {
+		types = append(types, t)
+	}
+
+	return ds.deleteSeriesWhere(ctx, types, seriesFilter{updatedBefore: now.Add(-ds.ttl.Shortest())},
+		func(key entities.MetricName, updatedAt time.Time) bool {
+			return ds.ttl.Expired(key, updatedAt, now)
+		})
+}
+
+// pruneSamples removes the samples that fell out of the defaultHistorySize most recent
+// ones of their series, so the metric_samples table does not grow without bound.
+func (ds *DBStore) pruneSamples(ctx context.Context) error {
+	_, err := ds.db.Exec(ctx, `
+		DELETE FROM metric_samples
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT id, row_number() OVER (
+					PARTITION BY type, name, labels ORDER BY recorded_at DESC, id DESC
+				) AS rank
+				FROM metric_samples
+			) AS ranked
+			WHERE rank > $1
+		)
+	`, defaultHistorySize)
+	if err != nil {
+		return fmt.Errorf("failed to prune the samples: %w", err)
 	}
 
-	if len(gaugeValues) > 0 {
-		_, err = ds.db.Exec(ctx, fmt.Sprintf(gaugesUpdateStmt, strings.Join(gaugeValues, ",")), gaugeParams...)
+	return nil
+}
+
+// seriesFilter narrows the series read by deleteSeriesWhere in SQL, before they are matched.
+type seriesFilter struct {
+	updatedBefore time.Time // Only the series last updated before it, zero for any
+	namePrefix    string    // Only the series whose name starts with it, empty for any
+}
+
+// deleteSeriesWhere removes the series of the given types selected by the filter and the
+// match function, together with their samples, within a single transaction. The candidates
+// are read without locks and a series is only removed if it was not updated since it was
+// read, so writers are only blocked on the removed rows. It returns the number of removed series.
+func (ds *DBStore) deleteSeriesWhere(ctx context.Context, types []entities.MetricType, filter seriesFilter,
+	match func(key entities.MetricName, updatedAt time.Time) bool) (int, error) {
+	tx, err := ds.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction %w", err)
+	}
+	defer func() {
+		if err = tx.Rollback(ctx); err != nil && !errors.Is(err, pgxv5.ErrTxClosed) {
+			ds.logger.ErrorContext(ctx, "failed to rollback", helpers.ErrAttr(err))
+		}
+	}()
+
+	deleted := 0
+	for _, t := range types {
+		table := metricTables[t]
+		candidates, errMatch := ds.matchingSeries(ctx, tx, table, filter, match)
+		if errMatch != nil {
+			return 0, errMatch
+		}
+		if len(candidates.names) == 0 {
+			continue
+		}
+
+		rows, errDelete := tx.Query(ctx, fmt.Sprintf(`
+			DELETE FROM %s AS m
+			USING unnest($1::text[], $2::text[], $3::timestamptz[]) AS c(name, labels, updated_at)
+			WHERE m.name = c.name AND m.labels = c.labels AND m.updated_at = c.updated_at
+			RETURNING m.name, m.labels
+		`, table), candidates.names, candidates.labels, candidates.updatedAt)
+		if errDelete != nil {
+			return 0, fmt.Errorf("failed to delete %s records: %w", t, errDelete)
+		}
+		var names, labels []string
+		for rows.Next() {
+			var name, lbls string
+			if err = rows.Scan(&name, &lbls); err != nil {
+				rows.Close()
+				return 0, fmt.Errorf("failed to scan the deleted %s records: %w", t, err)
+			}
+			names = append(names, name)
+			labels = append(labels, lbls)
+		}
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return 0, fmt.Errorf("failed to delete %s records: %w", t, err)
+		}
+		deleted += len(names)
+		if len(names) == 0 {
+			continue
+		}
+
+		_, err = tx.Exec(ctx, `
+			DELETE FROM metric_samples
+			WHERE type = $1 AND (name, labels) IN (SELECT * FROM unnest($2::text[], $3::text[]))
+		`, string(t), names, labels)
 		if err != nil {
-			rollbackErr := tx.Rollback(ctx)
-			return fmt.Errorf("store gauge SQL exec failed %w, started rollback %w", err, rollbackErr)
+			return 0, fmt.Errorf("failed to delete %s samples: %w", t, err)
 		}
 	}
 
 	if err = tx.Commit(ctx); err != nil {
-		return fmt.Errorf("failed to execute transaction commit %w", err)
+		return 0, fmt.Errorf("failed to execute transaction commit %w", err)
 	}
 
-	return nil
+	return deleted, nil
+}
+
+// candidateSeries holds the columns of the series selected for removal.
+type candidateSeries struct {
+	names     []string
+	labels    []string
+	updatedAt []time.Time
+}
+
+// matchingSeries returns the series in table selected by the filter and the match function,
+// without locking them. The filter is applied in SQL, so only its candidates are read.
+func (ds *DBStore) matchingSeries(ctx context.Context, tx pgxv5.Tx, table string, filter seriesFilter,
+	match func(key entities.MetricName, updatedAt time.Time) bool) (candidateSeries, error) {
+	query := fmt.Sprintf(`SELECT name, labels, updated_at FROM %s WHERE TRUE`, table)
+	var args []any
+	if !filter.updatedBefore.IsZero() {
+		args = append(args, filter.updatedBefore)
+		query += fmt.Sprintf(" AND updated_at < $%d", len(args))
+	}
+	if filter.namePrefix != "" {
+		args = append(args, likePrefix(filter.namePrefix))
+		query += fmt.Sprintf(" AND name LIKE $%d", len(args))
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return candidateSeries{}, fmt.Errorf("failed to list %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var candidates candidateSeries
+	for rows.Next() {
+		var name, lbls string
+		var updatedAt time.Time
+		if err = rows.Scan(&name, &lbls, &updatedAt); err != nil {
+			return candidateSeries{}, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		if match(seriesKeyFromColumns(name, lbls), updatedAt) {
+			candidates.names = append(candidates.names, name)
+			candidates.labels = append(candidates.labels, lbls)
+			candidates.updatedAt = append(candidates.updatedAt, updatedAt)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return candidateSeries{}, fmt.Errorf("reading %s db rows error %w", table, err)
+	}
+
+	return candidates, nil
+}
+
+// likePrefix returns the LIKE pattern matching the strings starting with prefix.
+func likePrefix(prefix string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix) + "%"
+}
+
+// seriesKeyFromColumns builds the series key from the name and labels columns.
+// The labels column holds the canonical representation produced by entities.Labels.String.
+func seriesKeyFromColumns(name, labels string) entities.MetricName {
+	return entities.MetricName(name + labels)
 }
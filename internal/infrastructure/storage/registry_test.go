@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+	deps := Dependencies{Logger: slog.Default(), File: FileOptions{StoreInterval: 300, SnapshotsKept: 2}}
+	dir := t.TempDir()
+
+	store, err := Open(ctx, "memory://", deps)
+	require.NoError(t, err)
+	assert.IsType(t, &MemStorage{}, store)
+
+	path := filepath.Join(dir, "metrics.json")
+	store, err = Open(ctx, "file://"+path+"?interval=10s&snapshots=5&restore=false", deps)
+	require.NoError(t, err)
+	require.IsType(t, &FileStorage{}, store)
+	fs := store.(*FileStorage)
+	assert.Equal(t, path, fs.path)
+	assert.Equal(t, 10, fs.storeInterval)
+	assert.Equal(t, 5, fs.snapshotsKept)
+	require.NoError(t, fs.Close(ctx))
+
+	store, err = Open(ctx, "bolt://"+filepath.Join(dir, "metrics.db"), deps)
+	require.NoError(t, err)
+	assert.IsType(t, &BoltStore{}, store)
+	require.NoError(t, store.Close(ctx))
+
+	for _, interval := range []string{"soon", "500ms", "1500ms", "-1s"} {
+		_, err = Open(ctx, "file://"+path+"?interval="+interval, deps)
+		require.Error(t, err, interval)
+	}
+
+	_, err = Open(ctx, "redis://localhost:6379", deps)
+	require.ErrorIs(t, err, ErrUnknownScheme)
+	assert.Contains(t, err.Error(), "memory")
+}
+
+func TestRegister(t *testing.T) {
+	opened := false
+	Register("test-backend", func(_ context.Context, u *url.URL, deps Dependencies) (Storage, error) {
+		opened = u.Host == "primary"
+		return NewMemStorage(deps.Logger)
+	})
+
+	_, err := Open(context.Background(), "test-backend://primary", Dependencies{Logger: slog.Default()})
+	require.NoError(t, err)
+	assert.True(t, opened)
+	assert.Contains(t, Schemes(), "test-backend")
+
+	assert.Panics(t, func() {
+		Register("memory", openMemory)
+	}, "a scheme is registered once")
+}
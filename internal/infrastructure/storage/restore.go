@@ -0,0 +1,127 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// ErrNoSnapshot is returned when the snapshot selected for a restore does not exist.
+var ErrNoSnapshot = errors.New("no such snapshot")
+
+// restoreFrom starts the storage from the snapshot selected by from instead of the
+// latest state. The latest state is first written as a complete snapshot and then
+// kept as the previous one, so nothing on disk is destroyed and a crash never mixes
+// the new changes with the older state.
+func (fs *FileStorage) restoreFrom(from string) error {
+	data, src, err := fs.findSnapshot(from)
+	if err != nil {
+		return err
+	}
+	fs.logger.InfoContext(context.Background(), "restoring an older snapshot",
+		slog.String("path", src), slog.Time("saved", data.Saved))
+
+	if err = fs.foldWAL(); err != nil {
+		return err
+	}
+	fs.setState(data)
+
+	return fs.compactKeeping(max(fs.snapshotsKept, 1))
+}
+
+// startEmpty starts the storage empty instead of the latest state. The snapshots are
+// left as they are until the first change, which keeps the latest state as the
+// previous snapshot, so restarting empty any number of times destroys nothing.
+func (fs *FileStorage) startEmpty() error {
+	if err := fs.foldWAL(); err != nil {
+		return err
+	}
+	fs.setState(&fileSnapshot{})
+	fs.pristine = true
+
+	return nil
+}
+
+// foldWAL folds the pending changes of the write-ahead log into the latest snapshot,
+// so the log only holds the changes made after the start.
+func (fs *FileStorage) foldWAL() error {
+	if fs.wal.size == 0 {
+		return nil
+	}
+	if err := fs.loadFromFile(); err != nil {
+		return err
+	}
+
+	return fs.compact()
+}
+
+// findSnapshot reads the snapshot selected by from, which is one of:
+//   - the index of a rotated snapshot, 0 being the current one and 1 the previous one;
+//   - an RFC 3339 timestamp, selecting the newest snapshot saved at or before it;
+//   - the path of a snapshot file.
+//
+// It returns the snapshot and the path it was read from.
+func (fs *FileStorage) findSnapshot(from string) (*fileSnapshot, string, error) {
+	if i, err := strconv.Atoi(from); err == nil {
+		if i < 0 {
+			return nil, "", fmt.Errorf("%w: negative index %d", ErrNoSnapshot, i)
+		}
+		return readSnapshot(rotatedSnapshotPath(fs.path, i))
+	}
+
+	at, err := time.Parse(time.RFC3339, from)
+	if err != nil {
+		return readSnapshot(from)
+	}
+
+	for i := 0; i <= fs.snapshotsKept; i++ {
+		data, src, err := readSnapshot(rotatedSnapshotPath(fs.path, i))
+		if errors.Is(err, ErrNoSnapshot) {
+			continue
+		}
+		if err != nil {
+			fs.logger.WarnContext(context.Background(), "skipping an unreadable snapshot",
+				slog.String("path", src), helpers.ErrAttr(err))
+			continue
+		}
+		if !data.Saved.After(at) {
+			return data, src, nil
+		}
+	}
+
+	return nil, "", fmt.Errorf("%w: none saved at or before %s", ErrNoSnapshot, at.Format(time.RFC3339))
+}
+
+// readSnapshot reads and verifies the snapshot at path. Snapshots written before
+// the save time was recorded get the modification time of their file.
+func readSnapshot(path string) (*fileSnapshot, string, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) || (err == nil && info.Size() == 0) {
+		return nil, path, fmt.Errorf("%w: %s", ErrNoSnapshot, path)
+	}
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to stat snapshot: %w", err)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, path, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	data, err := decodeSnapshot(content)
+	if err != nil {
+		return nil, path, fmt.Errorf("snapshot %s: %w", path, err)
+	}
+	if data.Saved.IsZero() {
+		data.Saved = info.ModTime()
+	}
+
+	return data, path, nil
+}
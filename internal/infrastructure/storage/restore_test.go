@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// badPush stores a gauge value that replaces the seeded state.
+func badPush(t *testing.T, fs *FileStorage) {
+	t.Helper()
+
+	value := -1.0
+	require.NoError(t, fs.CreateRecord(context.Background(), entities.Metrics{
+		ID: "Alloc", MType: "gauge", Value: &value, Labels: entities.Labels{"host": "web-1"},
+	}))
+}
+
+func TestFileStorageWithoutRestore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+	seedHistory(t, path)
+
+	// Starting empty any number of times leaves the snapshots as they are.
+	for range DefaultSnapshotsKept + 2 {
+		empty, err := NewFileStorage(slog.Default(), FileOptions{
+			Path: path, SnapshotsKept: DefaultSnapshotsKept, NoRestore: true,
+		})
+		require.NoError(t, err)
+		all, err := empty.GetAllRecords(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, all.Counter)
+		assert.Empty(t, all.Gauge)
+		require.NoError(t, empty.Close(context.Background()))
+	}
+
+	previous, err := NewFileStorage(slog.Default(), FileOptions{
+		Path: path, SnapshotsKept: DefaultSnapshotsKept, RestoreFrom: "1",
+	})
+	require.NoError(t, err)
+	assertSeeded(t, previous)
+}
+
+func TestFileStorageWithoutRestoreFirstChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	seedFileStorage(t, fs)
+
+	// The changes are only in the log, they must be kept on disk anyway.
+	empty, err := NewFileStorage(slog.Default(), FileOptions{
+		Path: path, SnapshotsKept: DefaultSnapshotsKept, NoRestore: true,
+	})
+	require.NoError(t, err)
+	badPush(t, empty)
+
+	// Simulate a crash: the first change is on disk and the latest state is the previous snapshot.
+	latest, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	all, err := latest.GetAllRecords(context.Background())
+	require.NoError(t, err)
+	assert.Empty(t, all.Counter)
+	assert.Equal(t, map[entities.MetricName]entities.Gauge{`Alloc{host="web-1"}`: -1}, all.Gauge)
+
+	previous, err := NewFileStorage(slog.Default(), FileOptions{
+		Path: path, SnapshotsKept: DefaultSnapshotsKept, RestoreFrom: "1",
+	})
+	require.NoError(t, err)
+	assertSeeded(t, previous)
+}
+
+// seedHistory leaves a good snapshot followed by a bad push at path, and returns
+// the time at which the good snapshot was saved.
+func seedHistory(t *testing.T, path string) time.Time {
+	t.Helper()
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	seedFileStorage(t, fs)
+	require.NoError(t, fs.compact())
+	good := time.Now()
+
+	badPush(t, fs)
+	require.NoError(t, fs.Close(context.Background()))
+
+	return good
+}
+
+func TestFileStorageRestoreFrom(t *testing.T) {
+	tests := []struct {
+		from func(path string, good time.Time) string
+		name string
+	}{
+		{name: "by index", from: func(string, time.Time) string { return "1" }},
+		{name: "by timestamp", from: func(_ string, good time.Time) string { return good.Format(time.RFC3339Nano) }},
+		{name: "by path", from: func(path string, _ time.Time) string { return rotatedSnapshotPath(path, 1) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "metrics.json")
+			good := seedHistory(t, path)
+
+			restored, err := NewFileStorage(slog.Default(), FileOptions{
+				Path: path, SnapshotsKept: DefaultSnapshotsKept, RestoreFrom: tt.from(path, good),
+			})
+			require.NoError(t, err)
+			assertSeeded(t, restored)
+
+			// The bad state is kept as the previous snapshot.
+			latest, err := NewFileStorage(slog.Default(), FileOptions{
+				Path: path, SnapshotsKept: DefaultSnapshotsKept, RestoreFrom: "1",
+			})
+			require.NoError(t, err)
+			record, err := latest.GetRecord(context.Background(), `Alloc{host="web-1"}`, entities.GaugeMetricName)
+			require.NoError(t, err)
+			assert.Equal(t, -1.0, *record.Value)
+		})
+	}
+}
+
+func TestFileStorageRestoreFromMissingSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	for _, from := range []string{"2", "2001-01-01T00:00:00Z", path + ".bak"} {
+		_, err := NewFileStorage(slog.Default(), FileOptions{Path: path, RestoreFrom: from})
+		assert.ErrorIs(t, err, ErrNoSnapshot, from)
+	}
+}
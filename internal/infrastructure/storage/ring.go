@@ -0,0 +1,62 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// defaultHistorySize is the number of samples kept in memory for every series.
+const defaultHistorySize = 1024
+
+// seriesID identifies a series of a given type, used to key the in-memory history.
+type seriesID struct {
+	key   entities.MetricName
+	mType entities.MetricType
+}
+
+// sampleRing is a fixed size ring buffer of samples. Once full, the oldest
+// sample is overwritten by the newest one.
+type sampleRing struct {
+	samples []entities.Sample
+	next    int
+	full    bool
+}
+
+// newSampleRing creates a ring buffer able to hold size samples.
+func newSampleRing(size int) *sampleRing {
+	return &sampleRing{samples: make([]entities.Sample, size)}
+}
+
+// add appends a sample to the ring, overwriting the oldest one if the ring is full.
+func (r *sampleRing) add(s entities.Sample) {
+	r.samples[r.next] = s
+	r.next = (r.next + 1) % len(r.samples)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// between returns, in chronological order, the samples recorded within [from, to].
+// A zero from or to leaves that side of the range open.
+func (r *sampleRing) between(from, to time.Time) []entities.Sample {
+	start, count := 0, r.next
+	if r.full {
+		start, count = r.next, len(r.samples)
+	}
+
+	result := make([]entities.Sample, 0, count)
+	for i := range count {
+		s := r.samples[(start+i)%len(r.samples)]
+		if !from.IsZero() && s.Timestamp.Before(from) {
+			continue
+		}
+		if !to.IsZero() && s.Timestamp.After(to) {
+			continue
+		}
+		result = append(result, s)
+	}
+
+	return result
+}
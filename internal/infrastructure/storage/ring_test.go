@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSampleRing(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ring := newSampleRing(3)
+
+	for i := range 5 {
+		ring.add(entities.Sample{Timestamp: base.Add(time.Duration(i) * time.Minute), Value: float64(i)})
+	}
+
+	values := func(samples []entities.Sample) []float64 {
+		result := make([]float64, 0, len(samples))
+		for _, s := range samples {
+			result = append(result, s.Value)
+		}
+		return result
+	}
+
+	assert.Equal(t, []float64{2, 3, 4}, values(ring.between(time.Time{}, time.Time{})))
+	assert.Equal(t, []float64{3, 4}, values(ring.between(base.Add(3*time.Minute), time.Time{})))
+	assert.Equal(t, []float64{2, 3}, values(ring.between(time.Time{}, base.Add(3*time.Minute))))
+	assert.Empty(t, newSampleRing(3).between(time.Time{}, time.Time{}))
+}
@@ -0,0 +1,179 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// DefaultSnapshotsKept is the default number of previous snapshots kept next to the current one.
+const DefaultSnapshotsKept = 3
+
+// snapshotMagic starts the header line of every snapshot file.
+const snapshotMagic = "METRICKIT-SNAPSHOT"
+
+// snapshotVersion is the version of the snapshot format written by this package.
+const snapshotVersion = 1
+
+// ErrCorruptSnapshot is returned when a snapshot file cannot be read back, e.g. because
+// its checksum does not match its content or its header is malformed.
+var ErrCorruptSnapshot = errors.New("corrupt snapshot")
+
+// rotatedSnapshotPath returns the path of the i-th previous snapshot, e.g. metrics-db.json.2.
+// The current snapshot has index zero.
+func rotatedSnapshotPath(path string, i int) string {
+	if i == 0 {
+		return path
+	}
+
+	return path + "." + strconv.Itoa(i)
+}
+
+// encodeSnapshot serializes a snapshot as a header line holding the format version and
+// the SHA-256 checksum of the body, followed by the JSON body.
+func encodeSnapshot(data *fileSnapshot) ([]byte, error) {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	sum := sha256.Sum256(body)
+	header := fmt.Sprintf("%s %d %s\n", snapshotMagic, snapshotVersion, hex.EncodeToString(sum[:]))
+
+	return append([]byte(header), body...), nil
+}
+
+// decodeSnapshot parses a snapshot written by encodeSnapshot, verifying its checksum.
+// Files written before snapshots had a header are read as plain JSON.
+func decodeSnapshot(content []byte) (*fileSnapshot, error) {
+	body := content
+	if bytes.HasPrefix(content, []byte(snapshotMagic)) {
+		idx := bytes.IndexByte(content, '\n')
+		if idx < 0 {
+			return nil, fmt.Errorf("%w: truncated header", ErrCorruptSnapshot)
+		}
+
+		fields := strings.Fields(string(content[:idx]))
+		if len(fields) != 3 || fields[1] != strconv.Itoa(snapshotVersion) {
+			return nil, fmt.Errorf("%w: unsupported header %q", ErrCorruptSnapshot, content[:idx])
+		}
+
+		body = content[idx+1:]
+		sum := sha256.Sum256(body)
+		if hex.EncodeToString(sum[:]) != fields[2] {
+			return nil, fmt.Errorf("%w: checksum mismatch", ErrCorruptSnapshot)
+		}
+	}
+
+	var data fileSnapshot
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCorruptSnapshot, err)
+	}
+
+	return &data, nil
+}
+
+// writeSnapshot atomically replaces the snapshot at path. The content is written to a
+// temporary file, flushed to disk and renamed over the current snapshot, after the
+// previous snapshots have been shifted to path.1 ... path.keep, dropping the oldest.
+func writeSnapshot(path string, keep int, content []byte) error {
+	tmp := path + ".tmp"
+	if err := writeSyncedFile(tmp, content); err != nil {
+		return err
+	}
+
+	for i := keep; i > 0; i-- {
+		err := os.Rename(rotatedSnapshotPath(path, i-1), rotatedSnapshotPath(path, i))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to rotate snapshot: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace snapshot: %w", err)
+	}
+
+	return syncDir(filepath.Dir(path))
+}
+
+// writeSyncedFile writes content to a new file at path and flushes it to disk.
+func writeSyncedFile(path string, content []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, ownerFilePerm)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+
+	if _, err = file.Write(content); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err = file.Sync(); err != nil {
+		_ = file.Close()
+		return fmt.Errorf("failed to sync snapshot: %w", err)
+	}
+
+	if err = file.Close(); err != nil {
+		return fmt.Errorf("failed to close snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// syncDir flushes a directory to disk so that renames within it are durable.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot directory: %w", err)
+	}
+	defer func() { _ = d.Close() }()
+
+	if err = d.Sync(); err != nil {
+		return fmt.Errorf("failed to sync snapshot directory: %w", err)
+	}
+
+	return nil
+}
+
+// readLatestSnapshot returns the newest snapshot that can be read back, trying the
+// current snapshot first and then the rotated ones. Missing and empty files are
+// skipped. A nil snapshot is returned when there is none, and an error when
+// snapshots exist but all of them are corrupt.
+func readLatestSnapshot(path string, keep int, logger *slog.Logger) (*fileSnapshot, error) {
+	var lastErr error
+	for i := 0; i <= keep; i++ {
+		candidate := rotatedSnapshotPath(path, i)
+		content, err := os.ReadFile(candidate)
+		if errors.Is(err, os.ErrNotExist) || (err == nil && len(content) == 0) {
+			continue
+		}
+		if err == nil {
+			var data *fileSnapshot
+			if data, err = decodeSnapshot(content); err == nil {
+				if lastErr != nil {
+					logger.WarnContext(context.Background(), "restored an older snapshot",
+						slog.String("path", candidate))
+				}
+				return data, nil
+			}
+		}
+
+		logger.ErrorContext(context.Background(), "failed to read snapshot",
+			slog.String("path", candidate), helpers.ErrAttr(err))
+		lastErr = fmt.Errorf("snapshot %s: %w", candidate, err)
+	}
+
+	return nil, lastErr
+}
@@ -0,0 +1,110 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotEncoding(t *testing.T) {
+	value := 1.5
+	content, err := encodeSnapshot(&fileSnapshot{
+		Gauge: map[entities.MetricName]entities.Gauge{"Alloc": entities.Gauge(value)},
+		Seq:   7,
+	})
+	require.NoError(t, err)
+
+	data, err := decodeSnapshot(content)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), data.Seq)
+	assert.Equal(t, entities.Gauge(value), data.Gauge["Alloc"])
+
+	// Flipping a byte of the body must be caught by the checksum.
+	content[len(content)-2] ^= 0xff
+	_, err = decodeSnapshot(content)
+	assert.ErrorIs(t, err, ErrCorruptSnapshot)
+
+	// Files written before the header was introduced are plain JSON.
+	data, err = decodeSnapshot([]byte(`{"Counter":{"PollCount":3}}`))
+	require.NoError(t, err)
+	assert.Equal(t, entities.Counter(3), data.Counter["PollCount"])
+}
+
+func TestSnapshotRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	for i := 1; i <= 4; i++ {
+		require.NoError(t, writeSnapshot(path, 2, []byte{byte('0' + i)}))
+	}
+
+	for i, want := range []string{"4", "3", "2"} {
+		content, err := os.ReadFile(rotatedSnapshotPath(path, i))
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content))
+	}
+	assert.NoFileExists(t, rotatedSnapshotPath(path, 3))
+	assert.NoFileExists(t, path+".tmp")
+}
+
+func TestFileStorageFallsBackToPreviousSnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	seedFileStorage(t, fs)
+	require.NoError(t, fs.compact())
+	require.NoError(t, fs.Close(context.Background()))
+	assert.FileExists(t, rotatedSnapshotPath(path, 1))
+
+	// Corrupt the newest snapshot, the previous one holds the same state.
+	require.NoError(t, os.WriteFile(path, []byte("METRICKIT-SNAPSHOT 1 0000\n{}"), ownerFilePerm))
+
+	restored, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	assertSeeded(t, restored)
+}
+
+func TestFileStorageReportsTheChangesLostWithASnapshot(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	fs, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	require.NoError(t, err)
+	seedFileStorage(t, fs) // Entries 1 to 3
+	require.NoError(t, fs.compact())
+	badPush(t, fs) // Entry 4, only in the newest snapshot
+	require.NoError(t, fs.compact())
+	value := 7.0
+	require.NoError(t, fs.CreateRecord(context.Background(), entities.Metrics{ID: "Sys", MType: "gauge", Value: &value}))
+
+	// Simulate a crash with a corrupt newest snapshot: entry 4 is lost with it.
+	require.NoError(t, os.WriteFile(path, []byte("METRICKIT-SNAPSHOT 1 0000\n{}"), ownerFilePerm))
+
+	var logs bytes.Buffer
+	restored, err := NewFileStorage(slog.New(slog.NewTextHandler(&logs, nil)), FileOptions{
+		Path: path, SnapshotsKept: DefaultSnapshotsKept,
+	})
+	require.NoError(t, err)
+	all, err := restored.GetAllRecords(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, map[entities.MetricName]entities.Gauge{`Alloc{host="web-1"}`: 3.5, "Sys": 7}, all.Gauge,
+		"the entries after the gap are replayed")
+	assert.Contains(t, logs.String(), "changes are lost")
+	assert.Contains(t, logs.String(), "snapshot_seq=3 lost_from=4 lost_to=4")
+}
+
+func TestFileStorageFailsWhenAllSnapshotsAreCorrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.json")
+
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), ownerFilePerm))
+	require.NoError(t, os.WriteFile(rotatedSnapshotPath(path, 1), []byte("{not json"), ownerFilePerm))
+
+	_, err := NewFileStorage(slog.Default(), FileOptions{Path: path, SnapshotsKept: DefaultSnapshotsKept})
+	assert.ErrorIs(t, err, ErrCorruptSnapshot)
+}
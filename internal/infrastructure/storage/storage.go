@@ -1,50 +1,66 @@
 // Package storage provides an abstraction for various storage implementations
-// for metrics, including in-memory, file-based, and PostgreSQL storage.
+// for metrics, including in-memory, file-based, bbolt and PostgreSQL storage.
+// A storage is opened from a URL whose scheme selects the backend, see Open.
 package storage
 
 import (
 	"context"
-	"log/slog"
-
-	"github.com/jackc/pgx/v5/pgxpool"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 )
 
 // Storage defines the methods for storing and retrieving metrics records.
 // Any type that implements this interface can be used for metrics storage.
+// Every call is bound to the given context: implementations stop waiting
+// for the underlying store once the context is done.
 type Storage interface {
 	// CreateRecord adds a new metrics record to the storage.
-	CreateRecord(metrics entities.Metrics) error
+	CreateRecord(ctx context.Context, metrics entities.Metrics) error
 
 	// GetRecord retrieves a specific metrics record by name and type.
-	GetRecord(mName entities.MetricName, mType entities.MetricType) (entities.Metrics, error)
+	GetRecord(ctx context.Context, mName entities.MetricName, mType entities.MetricType) (entities.Metrics, error)
 
 	// GetAllRecords returns all metrics records stored.
-	GetAllRecords() (*MetricsStorage, error)
+	GetAllRecords(ctx context.Context) (*MetricsStorage, error)
 
 	// GetAllRecordsByType retrieves all metrics records of a specified type.
-	GetAllRecordsByType(mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error)
+	GetAllRecordsByType(ctx context.Context,
+		mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error)
 
 	// StoreMetricsBatch stores a batch of metrics records in the storage.
-	StoreMetricsBatch(metrics []entities.Metrics) error
+	StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error
 
-	// Close gracefully shuts down the storage, releasing any resources.
-	Close(ctx context.Context) error
-}
+	// GetHistory returns the samples of a series recorded within [from, to] in chronological order.
+	// A zero from or to leaves that side of the range open.
+	GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+		from, to time.Time) ([]entities.Sample, error)
+
+	// DeleteRecord removes a series of the given type together with its history.
+	// It returns ErrNotFound if the series does not exist.
+	DeleteRecord(ctx context.Context, mName entities.MetricName, mType entities.MetricType) error
+
+	// DeleteRecords removes every series whose key matches the matcher together with its history.
+	// An empty mType selects series of all types. It returns the number of removed series.
+	DeleteRecords(ctx context.Context, mType entities.MetricType, matcher *entities.SeriesMatcher) (int, error)
 
-// NewStorage creates a new storage instance based on the provided parameters.
-// It prioritizes PostgreSQL storage if a database connection is provided,
-// then file storage if a valid storeInterval is specified, and finally,
-// defaults to in-memory storage.
-func NewStorage(db *pgxpool.Pool, logger *slog.Logger, storeInterval int, storePath string) (Storage, error) {
-	if db != nil {
-		return NewPostgresStorage(db, logger) // Create PostgreSQL storage if db is not nil.
-	}
+	// SetMetadata registers the metadata of metric names, replacing the metadata already
+	// registered for the same names.
+	SetMetadata(ctx context.Context, metadata []entities.Metadata) error
 
-	if storeInterval >= 0 {
-		return NewFileStorage(logger, storeInterval, storePath) // Create file storage if storeInterval is valid.
-	}
+	// GetMetadata returns the metadata registered for a metric name, or ErrNotFound.
+	GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error)
 
-	return NewMemStorage(logger) // Default to in-memory storage.
+	// GetAllMetadata returns the registered metadata keyed by metric name.
+	GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error)
+
+	// SetTTLPolicy sets the policy deciding when series that are not updated expire.
+	// Expired series are hidden from reads until they are purged. A nil policy keeps them forever.
+	SetTTLPolicy(policy *entities.TTLPolicy)
+
+	// PurgeExpired removes the series that have expired at now. It returns the number of removed series.
+	PurgeExpired(ctx context.Context, now time.Time) (int, error)
+
+	// Close gracefully shuts down the storage, releasing any resources.
+	Close(ctx context.Context) error
 }
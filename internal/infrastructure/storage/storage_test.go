@@ -0,0 +1,334 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storageFactories create an empty storage of every kind. The Postgres storage runs
+// against the database given by TEST_DATABASE_DSN, its tests are skipped without it.
+var storageFactories = map[string]func(t *testing.T) Storage{
+	"mem": func(t *testing.T) Storage {
+		t.Helper()
+		ms, err := NewMemStorage(slog.Default())
+		require.NoError(t, err)
+		return ms
+	},
+	"file": func(t *testing.T) Storage {
+		t.Helper()
+		fs, err := NewFileStorage(slog.Default(), FileOptions{
+			Path: filepath.Join(t.TempDir(), "metrics.json"), SnapshotsKept: DefaultSnapshotsKept,
+		})
+		require.NoError(t, err)
+		return fs
+	},
+	"bolt": func(t *testing.T) Storage {
+		t.Helper()
+		bs, err := NewBoltStorage(filepath.Join(t.TempDir(), "metrics.db"), slog.Default())
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = bs.Close(context.Background()) })
+		return bs
+	},
+	"postgres": func(t *testing.T) Storage {
+		t.Helper()
+		dsn := os.Getenv("TEST_DATABASE_DSN")
+		if dsn == "" {
+			t.Skip("TEST_DATABASE_DSN is not set")
+		}
+		pool, err := pgxpool.New(context.Background(), dsn)
+		require.NoError(t, err)
+		ds, err := NewPostgresStorage(pool, slog.Default())
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = ds.Close(context.Background()) })
+		_, err = pool.Exec(context.Background(),
+			`TRUNCATE gauge_metrics, counter_metrics, histogram_metrics, summary_metrics, metric_samples, metric_metadata`)
+		require.NoError(t, err)
+		return ds
+	},
+}
+
+// forEachStorage runs the test against every storage returned by storageFactories.
+func forEachStorage(t *testing.T, test func(t *testing.T, s Storage)) {
+	t.Helper()
+
+	for name, factory := range storageFactories {
+		t.Run(name, func(t *testing.T) {
+			test(t, factory(t))
+		})
+	}
+}
+
+func TestStorageRecords(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		delta := int64(2)
+		value := 1.5
+		require.NoError(t, s.CreateRecord(context.Background(),
+			entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+		require.NoError(t, s.CreateRecord(context.Background(),
+			entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+		require.NoError(t, s.CreateRecord(context.Background(), entities.Metrics{
+			ID: "Alloc", MType: "gauge", Value: &value, Labels: entities.Labels{"host": "web-1"},
+		}))
+
+		counter, err := s.GetRecord(context.Background(), "PollCount", entities.CounterMetricName)
+		require.NoError(t, err)
+		assert.Equal(t, int64(4), *counter.Delta)
+
+		gauge, err := s.GetRecord(context.Background(), `Alloc{host="web-1"}`, entities.GaugeMetricName)
+		require.NoError(t, err)
+		assert.Equal(t, "Alloc", gauge.ID)
+		assert.Equal(t, entities.Labels{"host": "web-1"}, gauge.Labels)
+		assert.Equal(t, value, *gauge.Value)
+
+		_, err = s.GetRecord(context.Background(), "Alloc", entities.GaugeMetricName)
+		require.ErrorIs(t, err, ErrNotFound, "labels are part of the series")
+		_, err = s.GetRecord(context.Background(), "PollCount", entities.GaugeMetricName)
+		require.ErrorIs(t, err, ErrNotFound)
+
+		gauges, err := s.GetAllRecordsByType(context.Background(), entities.GaugeMetricName)
+		require.NoError(t, err)
+		assert.Len(t, gauges, 1)
+
+		samples, err := s.GetHistory(context.Background(), "PollCount", entities.CounterMetricName, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, samples, 2)
+		assert.Equal(t, []float64{2, 4}, []float64{samples[0].Value, samples[1].Value})
+	})
+}
+
+func TestStorageConcurrentCounter(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		const (
+			writers = 16
+			rounds  = 25
+		)
+		delta := int64(1)
+		ctx := context.Background()
+
+		var wg sync.WaitGroup
+		for i := 0; i < writers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < rounds; j++ {
+					assert.NoError(t, s.CreateRecord(ctx, entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+					assert.NoError(t, s.StoreMetricsBatch(ctx, []entities.Metrics{
+						{ID: "PollCount", MType: "counter", Delta: &delta},
+						{ID: "PollCount", MType: "counter", Delta: &delta},
+					}))
+				}
+			}()
+		}
+		wg.Wait()
+
+		record, err := s.GetRecord(ctx, "PollCount", entities.CounterMetricName)
+		require.NoError(t, err)
+		assert.Equal(t, int64(3*writers*rounds), *record.Delta)
+		assert.Equal(t, int64(1), delta, "the deltas of the caller are not modified")
+	})
+}
+
+func TestStorageBatch(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		delta := int64(1)
+		value := 2.5
+		h := entities.NewHistogram([]float64{1, 10})
+		h.Observe(5)
+		sm := entities.NewSummary(0.01)
+		sm.Observe(3)
+
+		require.NoError(t, s.StoreMetricsBatch(context.Background(), []entities.Metrics{
+			{ID: "PollCount", MType: "counter", Delta: &delta},
+			{ID: "PollCount", MType: "counter", Delta: &delta},
+			{ID: "Alloc", MType: "gauge", Value: &value},
+			{ID: "latency", MType: "histogram", Histogram: h},
+			{ID: "latency", MType: "histogram", Histogram: h},
+			{ID: "size", MType: "summary", Summary: sm},
+		}))
+
+		all, err := s.GetAllRecords(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, map[entities.MetricName]entities.Counter{"PollCount": 2}, all.Counter)
+		assert.Equal(t, map[entities.MetricName]entities.Gauge{"Alloc": 2.5}, all.Gauge)
+		require.Contains(t, all.Histogram, entities.MetricName("latency"))
+		assert.Equal(t, uint64(2), all.Histogram["latency"].Count)
+		require.Contains(t, all.Summary, entities.MetricName("size"))
+		assert.Equal(t, uint64(1), all.Summary["size"].Count)
+
+		// A histogram that cannot be merged rejects the whole batch.
+		err = s.StoreMetricsBatch(context.Background(), []entities.Metrics{
+			{ID: "PollCount", MType: "counter", Delta: &delta},
+			{ID: "latency", MType: "histogram", Histogram: entities.NewHistogram([]float64{2})},
+		})
+		require.Error(t, err)
+
+		all, err = s.GetAllRecords(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, entities.Counter(2), all.Counter["PollCount"])
+		assert.Equal(t, uint64(2), all.Histogram["latency"].Count)
+	})
+}
+
+func TestStorageLargeBatch(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		delta := int64(1)
+		value := 0.5
+		batch := make([]entities.Metrics, 0, 2*copyThreshold)
+		for i := 0; i < copyThreshold; i++ {
+			labels := entities.Labels{"id": strconv.Itoa(i)}
+			batch = append(batch,
+				entities.Metrics{ID: "requests", MType: "counter", Delta: &delta, Labels: labels},
+				entities.Metrics{ID: "load", MType: "gauge", Value: &value, Labels: labels})
+		}
+
+		ctx := context.Background()
+		require.NoError(t, s.StoreMetricsBatch(ctx, batch))
+		require.NoError(t, s.StoreMetricsBatch(ctx, batch))
+
+		all, err := s.GetAllRecords(ctx)
+		require.NoError(t, err)
+		assert.Len(t, all.Counter, copyThreshold)
+		assert.Len(t, all.Gauge, copyThreshold)
+		assert.Equal(t, entities.Counter(2), all.Counter[`requests{id="7"}`])
+
+		samples, err := s.GetHistory(ctx, `requests{id="7"}`, entities.CounterMetricName, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		assert.Len(t, samples, 2)
+	})
+}
+
+func TestStorageDelete(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		value := 1.0
+		for _, id := range []string{"cpu_user", "cpu_system", "HeapAlloc"} {
+			require.NoError(t, s.CreateRecord(context.Background(), entities.Metrics{ID: id, MType: "gauge", Value: &value}))
+		}
+
+		require.NoError(t, s.DeleteRecord(context.Background(), "HeapAlloc", entities.GaugeMetricName))
+		require.ErrorIs(t, s.DeleteRecord(context.Background(), "HeapAlloc", entities.GaugeMetricName), ErrNotFound)
+		_, err := s.GetHistory(context.Background(), "HeapAlloc", entities.GaugeMetricName, time.Time{}, time.Time{})
+		require.ErrorIs(t, err, ErrNotFound, "the history goes with the series")
+
+		matcher, err := entities.NewSeriesMatcher("cpu_*", entities.PatternGlob)
+		require.NoError(t, err)
+		deleted, err := s.DeleteRecords(context.Background(), "", matcher)
+		require.NoError(t, err)
+		assert.Equal(t, 2, deleted)
+
+		all, err := s.GetAllRecords(context.Background())
+		require.NoError(t, err)
+		assert.Empty(t, all.Gauge)
+	})
+}
+
+func TestStorageTTL(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		policy, err := entities.NewTTLPolicy(time.Hour, "cpu_*=1m")
+		require.NoError(t, err)
+		s.SetTTLPolicy(policy)
+
+		value := 1.5
+		for _, id := range []string{"cpu_user", "HeapAlloc"} {
+			require.NoError(t, s.CreateRecord(context.Background(), entities.Metrics{ID: id, MType: "gauge", Value: &value}))
+		}
+
+		// Within the TTL of the cpu gauge nothing is purged.
+		purged, err := s.PurgeExpired(context.Background(), time.Now())
+		require.NoError(t, err)
+		assert.Zero(t, purged)
+
+		purged, err = s.PurgeExpired(context.Background(), time.Now().Add(2*time.Minute))
+		require.NoError(t, err)
+		assert.Equal(t, 1, purged)
+
+		_, err = s.GetRecord(context.Background(), "cpu_user", entities.GaugeMetricName)
+		require.ErrorIs(t, err, ErrNotFound)
+		_, err = s.GetRecord(context.Background(), "HeapAlloc", entities.GaugeMetricName)
+		require.NoError(t, err)
+	})
+}
+
+func TestStorageMetadata(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		_, err := s.GetMetadata(context.Background(), "HeapAlloc")
+		require.ErrorIs(t, err, ErrNotFound)
+
+		heapAlloc := entities.Metadata{Name: "HeapAlloc", Kind: entities.GaugeMetricName, Unit: "bytes",
+			Display: entities.DisplayBytes, Description: "Bytes of allocated heap objects."}
+		require.NoError(t, s.SetMetadata(context.Background(), []entities.Metadata{
+			heapAlloc,
+			{Name: "PollCount", Kind: entities.CounterMetricName, Description: "Polls."},
+		}))
+		// Registering a name again replaces its metadata.
+		pollCount := entities.Metadata{Name: "PollCount", Kind: entities.CounterMetricName, Unit: "polls"}
+		require.NoError(t, s.SetMetadata(context.Background(), []entities.Metadata{pollCount}))
+
+		md, err := s.GetMetadata(context.Background(), "HeapAlloc")
+		require.NoError(t, err)
+		assert.Equal(t, heapAlloc, md)
+
+		all, err := s.GetAllMetadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, map[entities.MetricName]entities.Metadata{"HeapAlloc": heapAlloc, "PollCount": pollCount}, all)
+	})
+}
+
+func TestBoltStoragePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metrics.db")
+
+	bs, err := NewBoltStorage(path, slog.Default())
+	require.NoError(t, err)
+	delta := int64(3)
+	require.NoError(t, bs.CreateRecord(context.Background(),
+		entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	pollCount := entities.Metadata{Name: "PollCount", Kind: entities.CounterMetricName, Unit: "polls"}
+	require.NoError(t, bs.SetMetadata(context.Background(), []entities.Metadata{pollCount}))
+	require.NoError(t, bs.Close(context.Background()))
+
+	reopened, err := NewBoltStorage(path, slog.Default())
+	require.NoError(t, err)
+	defer func() { _ = reopened.Close(context.Background()) }()
+
+	record, err := reopened.GetRecord(context.Background(), "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), *record.Delta)
+
+	md, err := reopened.GetMetadata(context.Background(), "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, pollCount, md)
+}
+
+func TestLikePrefix(t *testing.T) {
+	assert.Equal(t, "%", likePrefix(""))
+	assert.Equal(t, `cpu\_%`, likePrefix("cpu_"))
+	assert.Equal(t, `a\%b\\%`, likePrefix(`a%b\`))
+}
+
+func TestStorageHistoryBounded(t *testing.T) {
+	forEachStorage(t, func(t *testing.T, s Storage) {
+		ctx := context.Background()
+		for i := range defaultHistorySize + 10 {
+			value := float64(i)
+			require.NoError(t, s.CreateRecord(ctx, entities.Metrics{ID: "Alloc", MType: "gauge", Value: &value}))
+		}
+
+		_, err := s.PurgeExpired(ctx, time.Now())
+		require.NoError(t, err)
+
+		samples, err := s.GetHistory(ctx, "Alloc", entities.GaugeMetricName, time.Time{}, time.Time{})
+		require.NoError(t, err)
+		require.Len(t, samples, defaultHistorySize)
+		assert.Equal(t, float64(defaultHistorySize+9), samples[len(samples)-1].Value, "the newest samples are kept")
+	})
+}
@@ -0,0 +1,167 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// apiKeyBytes is the number of random bytes of a tenant API key.
+const apiKeyBytes = 32
+
+// tenantRecord is a tenant as saved in the registry file. Only a hash of its API key is kept.
+type tenantRecord struct {
+	KeyHash string `json:"key_hash"` // Hex encoded SHA-256 of the API key.
+	entities.Tenant
+}
+
+// TenantRegistry holds the tenants of the server and authenticates their API keys.
+// The tenants are saved to a JSON file when a path is given, and kept in memory otherwise.
+type TenantRegistry struct {
+	tenants map[string]tenantRecord // Tenants by name
+	path    string                  // File the tenants are saved to, empty to keep them in memory
+	mu      sync.RWMutex
+}
+
+// NewTenantRegistry loads the tenants saved at path. A missing file starts an empty
+// registry and an empty path keeps the tenants in memory only.
+func NewTenantRegistry(path string) (*TenantRegistry, error) {
+	tr := &TenantRegistry{tenants: make(map[string]tenantRecord), path: path}
+	if path == "" {
+		return tr, nil
+	}
+
+	content, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return tr, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the tenants: %w", err)
+	}
+
+	var records []tenantRecord
+	if err = json.Unmarshal(content, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode the tenants of %s: %w", path, err)
+	}
+	for _, r := range records {
+		tr.tenants[r.Name] = r
+	}
+
+	return tr, nil
+}
+
+// Create adds a tenant and returns it together with its new API key.
+func (tr *TenantRegistry) Create(name string) (entities.TenantCredentials, error) {
+	if err := entities.ValidateTenantName(name); err != nil {
+		return entities.TenantCredentials{}, err //nolint:wrapcheck // the error is self-explanatory
+	}
+
+	key := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return entities.TenantCredentials{}, fmt.Errorf("failed to generate the API key: %w", err)
+	}
+	apiKey := hex.EncodeToString(key)
+
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+
+	if _, ok := tr.tenants[name]; ok {
+		return entities.TenantCredentials{}, fmt.Errorf("%w: %s", entities.ErrTenantExists, name)
+	}
+
+	record := tenantRecord{
+		KeyHash: hashAPIKey(apiKey),
+		Tenant:  entities.Tenant{Name: name, CreatedAt: time.Now().UTC()},
+	}
+	tr.tenants[name] = record
+	if err := tr.save(); err != nil {
+		delete(tr.tenants, name)
+		return entities.TenantCredentials{}, err
+	}
+
+	return entities.TenantCredentials{APIKey: apiKey, Tenant: record.Tenant}, nil
+}
+
+// List returns the tenants sorted by name.
+func (tr *TenantRegistry) List() []entities.Tenant {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	tenants := make([]entities.Tenant, 0, len(tr.tenants))
+	for _, r := range tr.tenants {
+		tenants = append(tenants, r.Tenant)
+	}
+	slices.SortFunc(tenants, func(a, b entities.Tenant) int { return strings.Compare(a.Name, b.Name) })
+
+	return tenants
+}
+
+// Lookup returns the tenant of the given name, or ErrUnknownTenant.
+func (tr *TenantRegistry) Lookup(name string) (entities.Tenant, error) {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	r, ok := tr.tenants[name]
+	if !ok {
+		return entities.Tenant{}, fmt.Errorf("%w: %s", entities.ErrUnknownTenant, name)
+	}
+
+	return r.Tenant, nil
+}
+
+// Authenticate returns the tenant owning the API key, or ErrUnknownTenant.
+func (tr *TenantRegistry) Authenticate(apiKey string) (entities.Tenant, error) {
+	hash := []byte(hashAPIKey(apiKey))
+
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+
+	for _, r := range tr.tenants {
+		if subtle.ConstantTimeCompare(hash, []byte(r.KeyHash)) == 1 {
+			return r.Tenant, nil
+		}
+	}
+
+	return entities.Tenant{}, entities.ErrUnknownTenant
+}
+
+// save writes the tenants to the registry file. The caller must hold tr.mu.
+func (tr *TenantRegistry) save() error {
+	if tr.path == "" {
+		return nil
+	}
+
+	records := make([]tenantRecord, 0, len(tr.tenants))
+	for _, r := range tr.tenants {
+		records = append(records, r)
+	}
+	slices.SortFunc(records, func(a, b tenantRecord) int { return strings.Compare(a.Name, b.Name) })
+
+	content, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode the tenants: %w", err)
+	}
+	if err = writeSnapshot(tr.path, 0, content); err != nil {
+		return fmt.Errorf("failed to save the tenants: %w", err)
+	}
+
+	return nil
+}
+
+// hashAPIKey returns the hex encoded SHA-256 of an API key.
+func hashAPIKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:])
+}
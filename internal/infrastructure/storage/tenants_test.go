@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantRegistry(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tenants.json")
+	registry, err := NewTenantRegistry(path)
+	require.NoError(t, err)
+
+	credentials, err := registry.Create("team-a")
+	require.NoError(t, err)
+	assert.Len(t, credentials.APIKey, 2*apiKeyBytes)
+	_, err = registry.Create("team-a")
+	require.ErrorIs(t, err, entities.ErrTenantExists)
+	_, err = registry.Create("Team A")
+	require.ErrorIs(t, err, entities.ErrInvalidTenant)
+	_, err = registry.Create("team-b")
+	require.NoError(t, err)
+
+	// The tenants survive a restart, authenticated by the same key.
+	reloaded, err := NewTenantRegistry(path)
+	require.NoError(t, err)
+	tenant, err := reloaded.Authenticate(credentials.APIKey)
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", tenant.Name)
+	_, err = reloaded.Authenticate("unknown")
+	require.ErrorIs(t, err, entities.ErrUnknownTenant)
+
+	tenants := reloaded.List()
+	require.Len(t, tenants, 2)
+	assert.Equal(t, "team-a", tenants[0].Name)
+	assert.Equal(t, "team-b", tenants[1].Name)
+
+	_, err = reloaded.Lookup("team-b")
+	require.NoError(t, err)
+	_, err = reloaded.Lookup("team-c")
+	require.ErrorIs(t, err, entities.ErrUnknownTenant)
+}
@@ -0,0 +1,178 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// DefaultTimeout is the default time a single storage call may take.
+const DefaultTimeout = 5 * time.Second
+
+// timeoutStorage bounds every call to the wrapped storage with a timeout.
+type timeoutStorage struct {
+	Storage               // Wrapped storage
+	timeout time.Duration // Time a single call may take
+}
+
+// WithTimeout wraps a storage so that every call, except Close, is given at most timeout
+// on top of the deadline of its own context. A call whose context is already done fails
+// without reaching the storage. A non-positive timeout returns the storage unchanged.
+func WithTimeout(store Storage, timeout time.Duration) Storage {
+	if timeout <= 0 {
+		return store
+	}
+
+	return &timeoutStorage{Storage: store, timeout: timeout}
+}
+
+// bound derives the context of a single call, failing if it is already done.
+func (ts *timeoutStorage) bound(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	ctx, cancel := context.WithTimeout(ctx, ts.timeout)
+	if err := ctx.Err(); err != nil {
+		cancel()
+		return nil, nil, fmt.Errorf("storage call abandoned: %w", err)
+	}
+
+	return ctx, cancel, nil
+}
+
+// CreateRecord adds a new metrics record to the wrapped storage within the timeout.
+func (ts *timeoutStorage) CreateRecord(ctx context.Context, metrics entities.Metrics) error {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return ts.Storage.CreateRecord(ctx, metrics) //nolint:wrapcheck // the decorator is transparent
+}
+
+// GetRecord retrieves a metrics record from the wrapped storage within the timeout.
+func (ts *timeoutStorage) GetRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return entities.Metrics{}, err
+	}
+	defer cancel()
+
+	return ts.Storage.GetRecord(ctx, mName, mType) //nolint:wrapcheck // the decorator is transparent
+}
+
+// GetAllRecords returns all metrics records of the wrapped storage within the timeout.
+func (ts *timeoutStorage) GetAllRecords(ctx context.Context) (*MetricsStorage, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return ts.Storage.GetAllRecords(ctx) //nolint:wrapcheck // the decorator is transparent
+}
+
+// GetAllRecordsByType retrieves the metrics records of a type from the wrapped storage within the timeout.
+func (ts *timeoutStorage) GetAllRecordsByType(ctx context.Context,
+	mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return ts.Storage.GetAllRecordsByType(ctx, mType) //nolint:wrapcheck // the decorator is transparent
+}
+
+// StoreMetricsBatch stores a batch of metrics records in the wrapped storage within the timeout.
+func (ts *timeoutStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return ts.Storage.StoreMetricsBatch(ctx, metrics) //nolint:wrapcheck // the decorator is transparent
+}
+
+// GetHistory returns the samples of a series from the wrapped storage within the timeout.
+func (ts *timeoutStorage) GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+	from, to time.Time) ([]entities.Sample, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return ts.Storage.GetHistory(ctx, mName, mType, from, to) //nolint:wrapcheck // the decorator is transparent
+}
+
+// DeleteRecord removes a series from the wrapped storage within the timeout.
+func (ts *timeoutStorage) DeleteRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) error {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return ts.Storage.DeleteRecord(ctx, mName, mType) //nolint:wrapcheck // the decorator is transparent
+}
+
+// DeleteRecords removes the matching series from the wrapped storage within the timeout.
+func (ts *timeoutStorage) DeleteRecords(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	return ts.Storage.DeleteRecords(ctx, mType, matcher) //nolint:wrapcheck // the decorator is transparent
+}
+
+// PurgeExpired removes the expired series from the wrapped storage within the timeout.
+func (ts *timeoutStorage) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer cancel()
+
+	return ts.Storage.PurgeExpired(ctx, now) //nolint:wrapcheck // the decorator is transparent
+}
+
+// SetMetadata registers metadata in the wrapped storage within the timeout.
+func (ts *timeoutStorage) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
+	return ts.Storage.SetMetadata(ctx, metadata) //nolint:wrapcheck // the decorator is transparent
+}
+
+// GetMetadata returns the metadata of a metric name from the wrapped storage within the timeout.
+func (ts *timeoutStorage) GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return entities.Metadata{}, err
+	}
+	defer cancel()
+
+	return ts.Storage.GetMetadata(ctx, name) //nolint:wrapcheck // the decorator is transparent
+}
+
+// GetAllMetadata returns the registered metadata of the wrapped storage within the timeout.
+func (ts *timeoutStorage) GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error) {
+	ctx, cancel, err := ts.bound(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer cancel()
+
+	return ts.Storage.GetAllMetadata(ctx) //nolint:wrapcheck // the decorator is transparent
+}
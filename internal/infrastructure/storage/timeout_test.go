@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// blockingStorage is a storage whose GetAllRecords waits until its context is done.
+type blockingStorage struct {
+	Storage
+}
+
+func (bs *blockingStorage) GetAllRecords(ctx context.Context) (*MetricsStorage, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestWithTimeout(t *testing.T) {
+	ms, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+
+	assert.Same(t, ms, WithTimeout(ms, 0), "a zero timeout leaves the storage unwrapped")
+
+	store := WithTimeout(ms, time.Second)
+	value := 1.5
+	require.NoError(t, store.CreateRecord(context.Background(),
+		entities.Metrics{ID: "Alloc", MType: "gauge", Value: &value}))
+	record, err := store.GetRecord(context.Background(), "Alloc", entities.GaugeMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, value, *record.Value)
+
+	// A canceled request never reaches the storage.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = store.CreateRecord(ctx, entities.Metrics{ID: "Other", MType: "gauge", Value: &value})
+	require.ErrorIs(t, err, context.Canceled)
+	_, err = ms.GetRecord(context.Background(), "Other", entities.GaugeMetricName)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// A slow call is abandoned once the timeout expires.
+	slow := WithTimeout(&blockingStorage{Storage: ms}, 10*time.Millisecond)
+	_, err = slow.GetAllRecords(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
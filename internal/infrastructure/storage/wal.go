@@ -0,0 +1,182 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// walCompactSize is the size of the write-ahead log, in bytes, above which it is
+// compacted into the snapshot when the storage saves synchronously.
+const walCompactSize = 4 << 20
+
+// walOp is the kind of change recorded by a write-ahead log entry.
+type walOp string
+
+// Changes recorded in the write-ahead log.
+const (
+	walOpUpdate   walOp = "update"   // Metrics applied as by CreateRecord or StoreMetricsBatch.
+	walOpDelete   walOp = "delete"   // Series removed from the storage.
+	walOpMetadata walOp = "metadata" // Metadata registered as by SetMetadata.
+)
+
+// walSeries identifies a deleted series in the write-ahead log.
+type walSeries struct {
+	Key   entities.MetricName `json:"key"`
+	MType entities.MetricType `json:"type"`
+}
+
+// walRecord is a single entry of the write-ahead log, stored as one JSON line.
+// Sequence numbers increase monotonically across compactions, so the entries
+// already contained in the snapshot are skipped on replay.
+type walRecord struct {
+	Op       walOp               `json:"op"`
+	Metrics  []entities.Metrics  `json:"metrics,omitempty"`
+	Series   []walSeries         `json:"series,omitempty"`
+	Metadata []entities.Metadata `json:"metadata,omitempty"`
+	Seq      uint64              `json:"seq"`
+}
+
+// wal is an append-only log of the changes applied to the storage since the last snapshot.
+type wal struct {
+	file *os.File // Log file opened for appending.
+	size int64    // Current size of the log, in bytes.
+	seq  uint64   // Sequence number of the last appended entry.
+	sync bool     // Whether every entry is flushed to disk before append returns.
+}
+
+// openWAL opens or creates the write-ahead log at path.
+func openWAL(path string, sync bool) (*wal, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, ownerFilePerm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the wal: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("failed to stat the wal: %w", err)
+	}
+
+	return &wal{file: file, size: info.Size(), sync: sync}, nil
+}
+
+// replay calls apply for every entry with a sequence number greater than after,
+// in the order they were appended. A torn or corrupt entry, e.g. left by a crash
+// in the middle of an append, ends the log: it is truncated away together with
+// anything after it. It returns the number of replayed entries and the sequence
+// number of the first of them, zero if none, which follows after unless entries are missing.
+func (w *wal) replay(after uint64, apply func(walRecord) error) (int, uint64, error) {
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, fmt.Errorf("failed to rewind the wal: %w", err)
+	}
+
+	w.seq = after
+	reader := bufio.NewReader(w.file)
+	var (
+		offset int64
+		first  uint64
+	)
+	replayed := 0
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			break
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return replayed, first, fmt.Errorf("failed to read the wal: %w", err)
+		}
+
+		var rec walRecord
+		if err != nil || json.Unmarshal(bytes.TrimSpace(line), &rec) != nil {
+			break // torn or corrupt entry
+		}
+		offset += int64(len(line))
+
+		if rec.Seq <= after {
+			continue
+		}
+		if err = apply(rec); err != nil {
+			return replayed, first, fmt.Errorf("failed to replay wal entry %d: %w", rec.Seq, err)
+		}
+		if first == 0 {
+			first = rec.Seq
+		}
+		w.seq = rec.Seq
+		replayed++
+	}
+
+	if err := w.file.Truncate(offset); err != nil {
+		return replayed, first, fmt.Errorf("failed to truncate the wal: %w", err)
+	}
+	if _, err := w.file.Seek(offset, io.SeekStart); err != nil {
+		return replayed, first, fmt.Errorf("failed to seek the wal: %w", err)
+	}
+	w.size = offset
+
+	return replayed, first, nil
+}
+
+// append writes an entry at the end of the log, assigning it the next sequence number.
+func (w *wal) append(rec walRecord) error {
+	rec.Seq = w.seq + 1
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("failed to encode wal entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	if _, err = w.file.Write(line); err != nil {
+		// Drop the partial entry so later entries are not hidden behind it on replay.
+		if errTruncate := w.file.Truncate(w.size); errTruncate != nil {
+			return fmt.Errorf("failed to append wal entry: %w, failed to truncate: %w", err, errTruncate)
+		}
+		if _, errSeek := w.file.Seek(w.size, io.SeekStart); errSeek != nil {
+			return fmt.Errorf("failed to append wal entry: %w, failed to seek: %w", err, errSeek)
+		}
+		return fmt.Errorf("failed to append wal entry: %w", err)
+	}
+	w.size += int64(len(line))
+
+	if w.sync {
+		if err = w.file.Sync(); err != nil {
+			return fmt.Errorf("failed to sync the wal: %w", err)
+		}
+	}
+	w.seq = rec.Seq
+
+	return nil
+}
+
+// reset empties the log once its entries have been compacted into a snapshot.
+func (w *wal) reset() error {
+	if err := w.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate the wal: %w", err)
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to seek the wal: %w", err)
+	}
+	w.size = 0
+
+	return nil
+}
+
+// close flushes and closes the log file.
+func (w *wal) close() error {
+	if err := w.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync the wal: %w", err)
+	}
+
+	if err := w.file.Close(); err != nil {
+		return fmt.Errorf("failed to close the wal: %w", err)
+	}
+
+	return nil
+}
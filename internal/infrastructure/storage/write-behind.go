@@ -0,0 +1,346 @@
+// Package storage provides mechanisms for storing and managing metrics.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
+)
+
+// WriteBehindStorage is a cache in front of a slower storage, typically Postgres.
+// It holds a copy of every series in memory, serves the reads from it and applies
+// the writes to it right away. The writes are also coalesced, one pending update
+// per series, and flushed to the backing storage in a single batch every interval.
+// The metadata is cached too, but written through to the backing storage.
+//
+// Writes accepted since the last flush are lost if the process stops without Close,
+// so the interval bounds the loss window. The cache assumes it is the only writer
+// of the backing storage: writes of other servers are not seen until a restart.
+type WriteBehindStorage struct {
+	backing  Storage                       // Storage the writes are flushed to
+	cache    *MemStorage                   // Copy of every series, serving the reads
+	logger   *slog.Logger                  // Logger for the flush errors
+	pending  map[seriesID]entities.Metrics // Coalesced writes waiting for the next flush
+	stop     chan struct{}                 // Closed to stop the flush loop
+	done     chan struct{}                 // Closed once the flush loop has stopped
+	interval time.Duration                 // Interval between two flushes
+	// gate is held shared by the writes, which update the cache and the pending writes
+	// together, and exclusively by the deletes, so a delete sees both in agreement.
+	gate sync.RWMutex
+	// flushMu serializes the flushes and the deletes, so a delete never races a batch
+	// that is being written to the backing storage.
+	flushMu   sync.Mutex
+	mu        sync.Mutex // Lock guarding pending
+	closeOnce sync.Once
+}
+
+// NewWriteBehindStorage loads every series of the backing storage into memory and
+// starts flushing the writes to it every interval.
+func NewWriteBehindStorage(ctx context.Context, backing Storage, logger *slog.Logger,
+	interval time.Duration) (*WriteBehindStorage, error) {
+	if interval <= 0 {
+		return nil, fmt.Errorf("invalid write-behind interval %s", interval)
+	}
+
+	records, err := backing.GetAllRecords(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the backing storage: %w", err)
+	}
+
+	metadata, err := backing.GetAllMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the metadata of the backing storage: %w", err)
+	}
+
+	cache, err := NewMemStorage(logger)
+	if err != nil {
+		return nil, err
+	}
+	cache.load(records)
+	cache.metadata = metadata
+
+	wb := &WriteBehindStorage{
+		backing:  backing,
+		cache:    cache,
+		logger:   logger,
+		pending:  make(map[seriesID]entities.Metrics),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+		interval: interval,
+	}
+	go wb.flushLoop()
+
+	return wb, nil
+}
+
+// flushLoop flushes the pending writes every interval until the storage is closed.
+func (wb *WriteBehindStorage) flushLoop() {
+	defer close(wb.done)
+
+	ticker := time.NewTicker(wb.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := wb.Flush(context.Background()); err != nil {
+				wb.logger.ErrorContext(context.Background(), "failed to flush the write-behind cache",
+					helpers.ErrAttr(err))
+			}
+		case <-wb.stop:
+			return
+		}
+	}
+}
+
+// Flush writes the pending writes to the backing storage in a single batch. If the
+// batch fails the writes are kept and retried by the next flush.
+func (wb *WriteBehindStorage) Flush(ctx context.Context) error {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+
+	wb.mu.Lock()
+	batch := wb.pending
+	wb.pending = make(map[seriesID]entities.Metrics)
+	wb.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	metrics := make([]entities.Metrics, 0, len(batch))
+	for _, m := range batch {
+		metrics = append(metrics, m)
+	}
+	if err := wb.backing.StoreMetricsBatch(ctx, metrics); err != nil {
+		wb.requeue(batch)
+		return fmt.Errorf("failed to flush %d series: %w", len(batch), err)
+	}
+
+	return nil
+}
+
+// requeue puts a failed batch back in front of the writes accepted since it was taken.
+func (wb *WriteBehindStorage) requeue(batch map[seriesID]entities.Metrics) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	newer := wb.pending
+	wb.pending = batch
+	for _, m := range newer {
+		if err := wb.addPending(m); err != nil {
+			wb.logger.ErrorContext(context.Background(), "dropping a write that cannot be merged",
+				slog.String("series", string(m.Key())), helpers.ErrAttr(err))
+		}
+	}
+}
+
+// addPending coalesces a write into the pending write of its series. It must be
+// called with mu held.
+func (wb *WriteBehindStorage) addPending(m entities.Metrics) error {
+	id := seriesID{key: m.Key(), mType: entities.MetricType(m.MType)}
+	prev, ok := wb.pending[id]
+
+	switch id.mType {
+	case entities.CounterMetricName:
+		sum := *m.Delta
+		if ok {
+			sum += *prev.Delta
+		}
+		m.Delta = &sum
+	case entities.GaugeMetricName:
+		value := *m.Value
+		m.Value = &value
+	case entities.HistogramMetricName:
+		var stored *entities.Histogram
+		if ok {
+			stored = prev.Histogram
+		}
+		merged, err := mergedHistogram(stored, m.Histogram)
+		if err != nil {
+			return err
+		}
+		m.Histogram = merged
+	case entities.SummaryMetricName:
+		var stored *entities.Summary
+		if ok {
+			stored = prev.Summary
+		}
+		merged, err := mergedSummary(stored, m.Summary)
+		if err != nil {
+			return err
+		}
+		m.Summary = merged
+	default:
+		return errors.New("store: unsupported record type " + m.MType)
+	}
+	wb.pending[id] = m
+
+	return nil
+}
+
+// dropPending forgets the pending writes of the given series.
+func (wb *WriteBehindStorage) dropPending(ids []seriesID) {
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+
+	for _, id := range ids {
+		delete(wb.pending, id)
+	}
+}
+
+// CreateRecord applies a write to the cache and queues it for the backing storage.
+func (wb *WriteBehindStorage) CreateRecord(ctx context.Context, metrics entities.Metrics) error {
+	return wb.StoreMetricsBatch(ctx, []entities.Metrics{metrics})
+}
+
+// StoreMetricsBatch applies a batch to the cache and queues it for the backing storage.
+// A batch rejected by the cache is not queued.
+func (wb *WriteBehindStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	wb.gate.RLock()
+	defer wb.gate.RUnlock()
+
+	if err := wb.cache.StoreMetricsBatch(ctx, metrics); err != nil {
+		return err //nolint:wrapcheck // the cache is transparent
+	}
+
+	wb.mu.Lock()
+	defer wb.mu.Unlock()
+	for _, m := range metrics {
+		if err := wb.addPending(m); err != nil {
+			// The cache accepted the write, so the pending write of the series merges too.
+			return fmt.Errorf("failed to queue %s: %w", m.Key(), err)
+		}
+	}
+
+	return nil
+}
+
+// GetRecord retrieves a metrics record from the cache.
+func (wb *WriteBehindStorage) GetRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	return wb.cache.GetRecord(ctx, mName, mType)
+}
+
+// GetAllRecords returns all metrics records of the cache.
+func (wb *WriteBehindStorage) GetAllRecords(ctx context.Context) (*MetricsStorage, error) {
+	return wb.cache.GetAllRecords(ctx)
+}
+
+// GetAllRecordsByType retrieves the metrics records of a type from the cache.
+func (wb *WriteBehindStorage) GetAllRecordsByType(ctx context.Context,
+	mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
+	return wb.cache.GetAllRecordsByType(ctx, mType)
+}
+
+// GetHistory returns the samples of a series from the backing storage. The samples
+// are recorded when the writes are flushed, one per series and flush.
+func (wb *WriteBehindStorage) GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+	from, to time.Time) ([]entities.Sample, error) {
+	return wb.backing.GetHistory(ctx, mName, mType, from, to) //nolint:wrapcheck // the cache is transparent
+}
+
+// SetMetadata registers the metadata in the backing storage, then in the cache.
+func (wb *WriteBehindStorage) SetMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	if err := wb.backing.SetMetadata(ctx, metadata); err != nil {
+		return err //nolint:wrapcheck // the cache is transparent
+	}
+
+	return wb.cache.SetMetadata(ctx, metadata)
+}
+
+// GetMetadata returns the metadata registered for a metric name from the cache.
+func (wb *WriteBehindStorage) GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error) {
+	return wb.cache.GetMetadata(ctx, name)
+}
+
+// GetAllMetadata returns the registered metadata from the cache.
+func (wb *WriteBehindStorage) GetAllMetadata(ctx context.Context) (map[entities.MetricName]entities.Metadata, error) {
+	return wb.cache.GetAllMetadata(ctx)
+}
+
+// DeleteRecord removes a series from the backing storage, the cache and the pending writes.
+func (wb *WriteBehindStorage) DeleteRecord(ctx context.Context, mName entities.MetricName,
+	mType entities.MetricType) error {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+	wb.gate.Lock()
+	defer wb.gate.Unlock()
+
+	// A series created since the last flush is not in the backing storage yet.
+	if err := wb.backing.DeleteRecord(ctx, mName, mType); err != nil && !errors.Is(err, ErrNotFound) {
+		return err //nolint:wrapcheck // the cache is transparent
+	}
+
+	wb.dropPending([]seriesID{{key: mName, mType: mType}})
+	if !wb.cache.deleteSeries(mName, mType) {
+		return ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteRecords removes the matching series from the backing storage, the cache and
+// the pending writes. It returns the number of series removed from the cache.
+func (wb *WriteBehindStorage) DeleteRecords(ctx context.Context, mType entities.MetricType,
+	matcher *entities.SeriesMatcher) (int, error) {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+	wb.gate.Lock()
+	defer wb.gate.Unlock()
+
+	if _, err := wb.backing.DeleteRecords(ctx, mType, matcher); err != nil {
+		return 0, err //nolint:wrapcheck // the cache is transparent
+	}
+
+	deleted := wb.cache.deleteRecords(mType, matcher)
+	wb.dropPending(deleted)
+
+	return len(deleted), nil
+}
+
+// SetTTLPolicy sets the expiry policy of both the cache and the backing storage.
+func (wb *WriteBehindStorage) SetTTLPolicy(policy *entities.TTLPolicy) {
+	wb.cache.SetTTLPolicy(policy)
+	wb.backing.SetTTLPolicy(policy)
+}
+
+// PurgeExpired removes the expired series from the backing storage, the cache and
+// the pending writes. It returns the number of series removed from the cache.
+func (wb *WriteBehindStorage) PurgeExpired(ctx context.Context, now time.Time) (int, error) {
+	wb.flushMu.Lock()
+	defer wb.flushMu.Unlock()
+	wb.gate.Lock()
+	defer wb.gate.Unlock()
+
+	if _, err := wb.backing.PurgeExpired(ctx, now); err != nil {
+		return 0, err //nolint:wrapcheck // the cache is transparent
+	}
+
+	purged := wb.cache.purgeExpired(now)
+	wb.dropPending(purged)
+
+	return len(purged), nil
+}
+
+// Close stops the flush loop, flushes the pending writes and closes the backing storage.
+func (wb *WriteBehindStorage) Close(ctx context.Context) error {
+	var err error
+	wb.closeOnce.Do(func() {
+		close(wb.stop)
+		<-wb.done
+
+		if err = wb.Flush(ctx); err != nil {
+			err = fmt.Errorf("failed to flush on close: %w", err)
+		}
+		err = errors.Join(err, wb.backing.Close(ctx))
+	})
+
+	return err
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// batchRecorder is a storage recording the batches written to it, failing them while failing is set.
+type batchRecorder struct {
+	Storage
+	batches [][]entities.Metrics
+	mu      sync.Mutex
+	failing bool
+}
+
+func (br *batchRecorder) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+
+	if br.failing {
+		return errors.New("database unavailable")
+	}
+	br.batches = append(br.batches, metrics)
+
+	return br.Storage.StoreMetricsBatch(ctx, metrics)
+}
+
+func (br *batchRecorder) setFailing(failing bool) {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.failing = failing
+}
+
+func (br *batchRecorder) batchCount() int {
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return len(br.batches)
+}
+
+func newWriteBehind(t *testing.T, interval time.Duration) (*WriteBehindStorage, *batchRecorder) {
+	t.Helper()
+
+	ms, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	backing := &batchRecorder{Storage: ms}
+
+	wb, err := NewWriteBehindStorage(context.Background(), backing, slog.Default(), interval)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = wb.Close(context.Background()) })
+
+	return wb, backing
+}
+
+func TestWriteBehindStorage(t *testing.T) {
+	ctx := context.Background()
+	wb, backing := newWriteBehind(t, time.Hour)
+
+	delta := int64(2)
+	value := 1.5
+	for range 3 {
+		require.NoError(t, wb.CreateRecord(ctx, entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	}
+	require.NoError(t, wb.StoreMetricsBatch(ctx, []entities.Metrics{
+		{ID: "Alloc", MType: "gauge", Value: &value},
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+	}))
+	assert.Equal(t, int64(2), delta, "the caller's delta is not modified")
+
+	// The reads are served before the writes reach the backing storage.
+	record, err := wb.GetRecord(ctx, "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), *record.Delta)
+	_, err = backing.GetRecord(ctx, "PollCount", entities.CounterMetricName)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	// The flush writes one coalesced batch.
+	require.NoError(t, wb.Flush(ctx))
+	require.Equal(t, 1, backing.batchCount())
+	assert.Len(t, backing.batches[0], 2)
+	record, err = backing.GetRecord(ctx, "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(8), *record.Delta)
+
+	require.NoError(t, wb.Flush(ctx))
+	assert.Equal(t, 1, backing.batchCount(), "nothing is written without pending writes")
+
+	// A failed flush is retried with the writes accepted in between.
+	backing.setFailing(true)
+	require.NoError(t, wb.CreateRecord(ctx, entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	require.Error(t, wb.Flush(ctx))
+	require.NoError(t, wb.CreateRecord(ctx, entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	backing.setFailing(false)
+	require.NoError(t, wb.Flush(ctx))
+	record, err = backing.GetRecord(ctx, "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), *record.Delta)
+
+	// A deleted series is not written back by the next flush.
+	require.NoError(t, wb.CreateRecord(ctx, entities.Metrics{ID: "Alloc", MType: "gauge", Value: &value}))
+	require.NoError(t, wb.DeleteRecord(ctx, "Alloc", entities.GaugeMetricName))
+	require.NoError(t, wb.Flush(ctx))
+	_, err = backing.GetRecord(ctx, "Alloc", entities.GaugeMetricName)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.ErrorIs(t, wb.DeleteRecord(ctx, "Alloc", entities.GaugeMetricName), ErrNotFound)
+}
+
+func TestWriteBehindStorageLoadAndClose(t *testing.T) {
+	ctx := context.Background()
+	ms, err := NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	delta := int64(5)
+	require.NoError(t, ms.CreateRecord(ctx, entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	backing := &batchRecorder{Storage: ms}
+
+	wb, err := NewWriteBehindStorage(ctx, backing, slog.Default(), time.Hour)
+	require.NoError(t, err)
+
+	// The cache starts with the series of the backing storage.
+	record, err := wb.GetRecord(ctx, "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), *record.Delta)
+
+	require.NoError(t, wb.CreateRecord(ctx, entities.Metrics{ID: "PollCount", MType: "counter", Delta: &delta}))
+	require.NoError(t, wb.Close(ctx))
+
+	require.Equal(t, 1, backing.batchCount(), "Close flushes the pending writes")
+	assert.Equal(t, int64(5), *backing.batches[0][0].Delta)
+
+	_, err = NewWriteBehindStorage(ctx, ms, slog.Default(), 0)
+	require.Error(t, err)
+}
+
+func TestWriteBehindStorageFlushLoop(t *testing.T) {
+	ctx := context.Background()
+	wb, backing := newWriteBehind(t, 10*time.Millisecond)
+
+	value := 3.0
+	require.NoError(t, wb.CreateRecord(ctx, entities.Metrics{ID: "Alloc", MType: "gauge", Value: &value}))
+	assert.Eventually(t, func() bool { return backing.batchCount() == 1 }, time.Second, 5*time.Millisecond)
+}
@@ -5,7 +5,9 @@ package agent
 import (
 	"crypto/rsa"
 	"log/slog"
+	"time"
 
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/domain/repositories"
 
 	"google.golang.org/grpc"
@@ -19,6 +21,10 @@ type MetricsService interface {
 
 	// Send transmits the collected metrics to the specified server address.
 	Send(serverAddr string) error
+
+	// Heartbeat tells the server at the specified address that the agent is alive and
+	// reports at the given interval, even when it has no metric to send.
+	Heartbeat(serverAddr string, reportInterval time.Duration) error
 }
 
 // AgentService implements the MetricsService interface.
@@ -29,11 +35,14 @@ type AgentService struct {
 
 // NewAgentService creates a new instance of the AgentService struct.
 // It initializes the agent service with the provided repository, logger, and secret.
+// The labels are attached to every metric reported by the agent, and the identity to
+// every request it sends.
 func NewAgentService(repository *repositories.AgentRepository,
 	logger *slog.Logger, secret *string,
-	publicKey *rsa.PublicKey, gRPCConn *grpc.ClientConn) *AgentService {
+	publicKey *rsa.PublicKey, gRPCConn *grpc.ClientConn, labels entities.Labels, apiKey string,
+	identity entities.AgentIdentity) *AgentService {
 	return &AgentService{
 		MetricsService: NewMetricsCollectionService(repository,
-			logger, secret, publicKey, gRPCConn), // Initialize the metrics collection service.
+			logger, secret, publicKey, gRPCConn, labels, apiKey, identity), // Initialize the metrics collection service.
 	}
 }
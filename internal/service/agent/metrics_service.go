@@ -18,6 +18,8 @@ import (
 	"net"
 	"net/http"
 	"runtime"
+	"sync/atomic"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/compressor"
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
@@ -28,6 +30,7 @@ import (
 	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/mem"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
 )
 
 const aesKeySize = 32
@@ -39,6 +42,13 @@ type MetricsCollectionService struct {
 	secret    *string
 	publicKey *rsa.PublicKey
 	gRPCConn  *grpc.ClientConn
+	labels    entities.Labels
+	apiKey    string // API key of the tenant the metrics are reported to, empty for the default namespace.
+	lastNumGC uint32 // Number of garbage collections already observed by Collect.
+	// identity of the agent sent with every request.
+	identity entities.AgentIdentity
+	// described tells whether the metadata of the reported metrics was registered on the server.
+	described atomic.Bool
 }
 
 // NewMetricsCollectionService creates a new MetricsCollectionService.
@@ -47,13 +57,19 @@ func NewMetricsCollectionService(
 	logger *slog.Logger,
 	secret *string,
 	publicKey *rsa.PublicKey,
-	gRPCConn *grpc.ClientConn) *MetricsCollectionService {
+	gRPCConn *grpc.ClientConn,
+	labels entities.Labels,
+	apiKey string,
+	identity entities.AgentIdentity) *MetricsCollectionService {
 	return &MetricsCollectionService{
 		mRepo:     repo,
 		logger:    logger,
 		secret:    secret,
 		publicKey: publicKey,
 		gRPCConn:  gRPCConn,
+		labels:    labels,
+		apiKey:    apiKey,
+		identity:  identity,
 	}
 }
 
@@ -112,28 +128,82 @@ func (m *MetricsCollectionService) Collect() error {
 		return fmt.Errorf("failed to store the metrics: %w", err)
 	}
 
+	if err := m.mRepo.ObserveHistogram(entities.GCPauseNs, entities.GCPauseBuckets, m.gcPauses(&stats)...); err != nil {
+		return fmt.Errorf("failed to store the GC pauses: %w", err)
+	}
+
 	return nil
 }
 
+// gcPauses returns the pause durations, in nanoseconds, of the garbage collections
+// completed since the previous call. The runtime keeps only the most recent pauses,
+// so older ones are skipped if too many collections happened in between.
+func (m *MetricsCollectionService) gcPauses(stats *runtime.MemStats) []float64 {
+	n := stats.NumGC - m.lastNumGC
+	if size := uint32(len(stats.PauseNs)); n > size {
+		n = size
+	}
+
+	pauses := make([]float64, 0, n)
+	for i := stats.NumGC - n; i < stats.NumGC; i++ {
+		pauses = append(pauses, float64(stats.PauseNs[i%uint32(len(stats.PauseNs))]))
+	}
+	m.lastNumGC = stats.NumGC
+
+	return pauses
+}
+
+// observeReportLatency records the time elapsed since start in the report latency histogram.
+func (m *MetricsCollectionService) observeReportLatency(start time.Time) {
+	latency := time.Since(start).Seconds()
+	if err := m.mRepo.ObserveHistogram(entities.ReportLatency, entities.DefaultLatencyBuckets, latency); err != nil {
+		m.logger.ErrorContext(context.Background(),
+			"failed to store the report latency",
+			helpers.ErrAttr(err))
+	}
+}
+
 // Send returns all metrics.
+// Histograms are reported once: the observations sent are reset, unless the report
+// fails and they are kept for the next one. The duration of the report itself is
+// observed in the report latency histogram. The metadata of the reported metrics is
+// registered before the first report, and again before the next ones until the server
+// accepts it.
 func (m *MetricsCollectionService) Send(serverAddr string) error {
 	url := fmt.Sprintf("http://%s/updates/", serverAddr)
 	ctx := context.Background()
+	defer m.observeReportLatency(time.Now())
+
+	if !m.described.Load() {
+		if err := m.registerMetadata(ctx, serverAddr); err != nil {
+			m.logger.ErrorContext(ctx,
+				"registering the metrics metadata failed, retrying at the next report: ",
+				helpers.ErrAttr(err))
+		} else {
+			m.described.Store(true)
+		}
+	}
 
 	metrics, err := m.mRepo.GetAll()
 	if err != nil {
 		return fmt.Errorf("failed to send the metrics: %w", err)
 	}
 
-	allMetrics := make([]entities.Metrics, 0, len(metrics.CounterMetrics)+len(metrics.CounterMetrics))
+	histograms, err := m.mRepo.TakeHistograms()
+	if err != nil {
+		return fmt.Errorf("failed to send the metrics: %w", err)
+	}
+
+	allMetrics := make([]entities.Metrics, 0, len(metrics.CounterMetrics)+len(metrics.GaugeMetrics)+len(histograms))
 
 	m.logger.DebugContext(ctx, "publishing counter metrics")
 	for k, v := range metrics.CounterMetrics {
 		val := int64(v)
 		metric := entities.Metrics{
-			ID:    string(k),
-			MType: string(entities.CounterMetricName),
-			Delta: &val,
+			ID:     string(k),
+			MType:  string(entities.CounterMetricName),
+			Delta:  &val,
+			Labels: m.labels,
 		}
 		allMetrics = append(allMetrics, metric)
 	}
@@ -142,13 +212,39 @@ func (m *MetricsCollectionService) Send(serverAddr string) error {
 	for k, v := range metrics.GaugeMetrics {
 		val := float64(v)
 		metric := entities.Metrics{
-			ID:    string(k),
-			MType: string(entities.GaugeMetricName),
-			Value: &val,
+			ID:     string(k),
+			MType:  string(entities.GaugeMetricName),
+			Value:  &val,
+			Labels: m.labels,
+		}
+		allMetrics = append(allMetrics, metric)
+	}
+
+	m.logger.DebugContext(ctx, "publishing histogram metrics")
+	for k, v := range histograms {
+		metric := entities.Metrics{
+			ID:        string(k),
+			MType:     string(entities.HistogramMetricName),
+			Histogram: v,
+			Labels:    m.labels,
 		}
 		allMetrics = append(allMetrics, metric)
 	}
 
+	if err := m.report(ctx, url, allMetrics); err != nil {
+		if errRestore := m.mRepo.RestoreHistograms(histograms); errRestore != nil {
+			m.logger.ErrorContext(ctx,
+				"failed to keep the histograms for the next report",
+				helpers.ErrAttr(errRestore))
+		}
+		return err
+	}
+
+	return nil
+}
+
+// report sends the metrics to the server, through the gRPC connection when there is one.
+func (m *MetricsCollectionService) report(ctx context.Context, url string, allMetrics []entities.Metrics) error {
 	if m.gRPCConn != nil {
 		m.logger.DebugContext(ctx, "publishing metrics via gRPC")
 		c := pb.NewMetricServiceClient(m.gRPCConn)
@@ -157,8 +253,9 @@ func (m *MetricsCollectionService) Send(serverAddr string) error {
 
 		for _, metric := range allMetrics {
 			mm := &pb.Metric{
-				Id:    metric.ID,
-				MType: metric.MType,
+				Id:     metric.ID,
+				MType:  metric.MType,
+				Labels: metric.Labels,
 			}
 
 			if mm.GetMType() == string(entities.CounterMetricName) {
@@ -167,16 +264,27 @@ func (m *MetricsCollectionService) Send(serverAddr string) error {
 			if mm.GetMType() == string(entities.GaugeMetricName) {
 				mm.Value = metric.Value
 			}
+			if mm.GetMType() == string(entities.HistogramMetricName) {
+				mm.Histogram = &pb.Histogram{
+					Bounds: metric.Histogram.Bounds,
+					Counts: metric.Histogram.Counts,
+					Sum:    metric.Histogram.Sum,
+					Count:  metric.Histogram.Count,
+				}
+			}
 
 			grpcRequestMetrics = append(grpcRequestMetrics, mm)
 		}
 
-		res, errClient := c.CreateMetrics(ctx, &pb.CreateMetricsRequest{Metrics: grpcRequestMetrics})
+		res, errClient := c.CreateMetrics(m.outgoingContext(ctx), &pb.CreateMetricsRequest{Metrics: grpcRequestMetrics})
+		if errClient != nil {
+			return fmt.Errorf("failed to send metrics via gRPC: %w", errClient)
+		}
 		m.logger.DebugContext(ctx, fmt.Sprintf("response from gRPC server: %v", res))
-		return fmt.Errorf("failed to send metrics via gRPC: %w", errClient)
+		return nil
 	}
 
-	err = m.publishMetric(ctx, url, "application/json", allMetrics, m.publicKey)
+	err := m.publishMetric(ctx, url, "application/json", allMetrics, m.publicKey)
 	if err != nil {
 		m.logger.ErrorContext(ctx,
 			"publishing the counter metrics failed: ",
@@ -187,13 +295,87 @@ func (m *MetricsCollectionService) Send(serverAddr string) error {
 	return nil
 }
 
+// registerMetadata registers the unit, description and display hint of the predefined
+// metrics on the server, through the gRPC connection when there is one.
+func (m *MetricsCollectionService) registerMetadata(ctx context.Context, serverAddr string) error {
+	predefined := entities.PredefinedMetadata()
+
+	if m.gRPCConn != nil {
+		req := &pb.RegisterMetadataRequest{Metadata: make([]*pb.MetricMetadata, 0, len(predefined))}
+		for _, md := range predefined {
+			req.Metadata = append(req.Metadata, &pb.MetricMetadata{
+				Name:        string(md.Name),
+				Kind:        string(md.Kind),
+				Unit:        md.Unit,
+				Description: md.Description,
+				Display:     string(md.Display),
+			})
+		}
+
+		if _, err := pb.NewMetricServiceClient(m.gRPCConn).RegisterMetadata(m.outgoingContext(ctx), req); err != nil {
+			return fmt.Errorf("failed to register the metadata via gRPC: %w", err)
+		}
+
+		return nil
+	}
+
+	url := fmt.Sprintf("http://%s/metadata/", serverAddr)
+	if err := m.publishMetric(ctx, url, "application/json", predefined, m.publicKey); err != nil {
+		return fmt.Errorf("failed to register the metadata: %w", err)
+	}
+
+	return nil
+}
+
+// Heartbeat tells the server that the agent is alive and reports at the given interval,
+// through the gRPC connection when there is one. The server flags the agents that miss
+// several heartbeats as down.
+func (m *MetricsCollectionService) Heartbeat(serverAddr string, reportInterval time.Duration) error {
+	ctx := context.Background()
+	interval := int64(reportInterval / time.Second)
+
+	if m.gRPCConn != nil {
+		_, err := pb.NewMetricServiceClient(m.gRPCConn).Heartbeat(m.outgoingContext(ctx),
+			&pb.HeartbeatRequest{ReportInterval: interval})
+		if err != nil {
+			return fmt.Errorf("failed to send the heartbeat via gRPC: %w", err)
+		}
+
+		return nil
+	}
+
+	url := fmt.Sprintf("http://%s/heartbeat", serverAddr)
+	heartbeat := entities.Heartbeat{ReportInterval: int(interval)}
+	if err := m.publishMetric(ctx, url, "application/json", heartbeat, m.publicKey); err != nil {
+		return fmt.Errorf("failed to send the heartbeat: %w", err)
+	}
+
+	return nil
+}
+
+// outgoingContext returns ctx carrying the API key and the identity of the agent in the
+// metadata of the gRPC calls.
+func (m *MetricsCollectionService) outgoingContext(ctx context.Context) context.Context {
+	if m.apiKey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", m.apiKey)
+	}
+	if m.identity.ID == "" {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx,
+		entities.AgentIDHeader, m.identity.ID,
+		entities.AgentHostnameHeader, m.identity.Hostname,
+		entities.AgentVersionHeader, m.identity.Version)
+}
+
 // ErrJSONMarshal is an error that occurs when the metrics cannot be marshaled to JSON.
 var ErrJSONMarshal = errors.New("failed to marshal to JSON")
 
-// publishMetric publishes the metrics to the server.
+// publishMetric publishes the metrics, or any other payload encoded in JSON, to the server.
 func (m *MetricsCollectionService) publishMetric(ctx context.Context, url,
-	contentType string, metrics []entities.Metrics, publicKey *rsa.PublicKey) error {
-	mJSONStruct, err := json.Marshal(metrics)
+	contentType string, payload any, publicKey *rsa.PublicKey) error {
+	mJSONStruct, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("failed serialize the metrics: %w", ErrJSONMarshal)
 	}
@@ -270,6 +452,15 @@ func (m *MetricsCollectionService) publishMetric(ctx context.Context, url,
 			"request body signed successfully")
 	}
 
+	if m.apiKey != "" {
+		req.Header.Set("X-API-Key", m.apiKey)
+	}
+	if m.identity.ID != "" {
+		req.Header.Set(entities.AgentIDHeader, m.identity.ID)
+		req.Header.Set(entities.AgentHostnameHeader, m.identity.Hostname)
+		req.Header.Set(entities.AgentVersionHeader, m.identity.Version)
+	}
+
 	// Set the X-Real-IP header with the client's IP address
 	setIPHeader(req)
 
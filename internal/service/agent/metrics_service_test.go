@@ -0,0 +1,59 @@
+package agent
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/domain/repositories"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendKeepsTheHistogramsOfAFailedReport(t *testing.T) {
+	var failing atomic.Bool
+	failing.Store(true)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/updates/" && failing.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	serverAddr := strings.TrimPrefix(srv.URL, "http://")
+
+	const name entities.MetricName = "test_latency"
+	bounds := []float64{0.1, 1}
+	repo := repositories.NewAgentRepository(storage.NewMetricsCollection(), slog.Default())
+	service := NewMetricsCollectionService(repo, slog.Default(), nil, nil, nil, nil, "", entities.AgentIdentity{})
+
+	require.NoError(t, repo.ObserveHistogram(name, bounds, 0.05, 0.5))
+	require.Error(t, service.Send(serverAddr))
+
+	require.NoError(t, repo.ObserveHistogram(name, bounds, 2))
+	failing.Store(false)
+	require.NoError(t, service.Send(serverAddr))
+
+	histograms, err := repo.TakeHistograms()
+	require.NoError(t, err)
+	assert.NotContains(t, histograms, name, "the reported observations are reset")
+
+	require.NoError(t, repo.ObserveHistogram(name, bounds, 0.05, 0.5))
+	failing.Store(true)
+	require.Error(t, service.Send(serverAddr))
+	require.NoError(t, repo.ObserveHistogram(name, bounds, 2))
+
+	histograms, err = repo.TakeHistograms()
+	require.NoError(t, err)
+	require.Contains(t, histograms, name)
+	kept := histograms[name]
+	assert.Equal(t, uint64(3), kept.Count, "the observations of the failed report are kept")
+	assert.Equal(t, []uint64{1, 1, 1}, kept.Counts)
+	assert.InDelta(t, 2.55, kept.Sum, 1e-9)
+}
@@ -6,6 +6,7 @@ package agent
 import (
 	"context"
 	"log/slog"
+	"time"
 
 	"github.com/mihailtudos/metrickit/pkg/helpers"
 )
@@ -18,12 +19,21 @@ type SendMetricsTask struct {
 	Service    *AgentService // The AgentService that provides the metrics service
 	Log        *slog.Logger  // Logger for logging errors and messages
 	ServerAddr string        // The address of the server to send metrics to
+	// Interval between two reports, told to the server with the heartbeat.
+	ReportInterval time.Duration
 }
 
-// Process executes the task of sending metrics to the specified server address.
+// Process executes the task of sending a heartbeat and the metrics to the specified
+// server address, the heartbeat being sent even when there is no metric to send.
 // If an error occurs during the sending process, it logs the error using the
 // provided logger.
 func (t *SendMetricsTask) Process() {
+	if err := t.Service.MetricsService.Heartbeat(t.ServerAddr, t.ReportInterval); err != nil {
+		t.Log.ErrorContext(context.Background(),
+			"failed to send the heartbeat",
+			helpers.ErrAttr(err))
+	}
+
 	if err := t.Service.MetricsService.Send(t.ServerAddr); err != nil {
 		t.Log.ErrorContext(context.Background(),
 			"failed to process send task",
@@ -0,0 +1,238 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+const (
+	// DefaultMissedReports is the number of report intervals an agent may miss before it is down.
+	DefaultMissedReports = 3
+	// DefaultAgentReportInterval is the report interval of the agents that sent no heartbeat yet.
+	DefaultAgentReportInterval = 10 * time.Second
+
+	// agentRetention is how long an agent that stopped reporting is remembered.
+	agentRetention = 24 * time.Hour
+	// maxAgents is the number of agents remembered, the least recently seen one is
+	// forgotten to make room for a new agent.
+	maxAgents = 10000
+)
+
+// agentKey identifies an agent of a tenant.
+type agentKey struct {
+	tenant string
+	id     string
+}
+
+// agentRecord is an agent with the series it wrote.
+type agentRecord struct {
+	series map[seriesID]struct{}
+	agent  entities.Agent
+}
+
+// agentRegistry holds the agents seen since the server started, up to maxAgents of them
+// and for agentRetention after their last request.
+type agentRegistry struct {
+	agents        map[agentKey]*agentRecord
+	missedReports int // Report intervals an agent may miss before it is down
+	mu            sync.Mutex
+}
+
+// newAgentRegistry creates an empty registry.
+func newAgentRegistry() *agentRegistry {
+	return &agentRegistry{agents: make(map[agentKey]*agentRecord), missedReports: DefaultMissedReports}
+}
+
+// SetMissedReports sets the number of report intervals an agent may miss before it is
+// down, zero or a negative number restores DefaultMissedReports.
+func (ms *MetricsService) SetMissedReports(missed int) {
+	if missed <= 0 {
+		missed = DefaultMissedReports
+	}
+
+	ms.agents.mu.Lock()
+	defer ms.agents.mu.Unlock()
+	ms.agents.missedReports = missed
+}
+
+// seen records a request of the agent of ctx and the series of the metrics it wrote.
+// Requests that did not identify their agent are ignored.
+func (ar *agentRegistry) seen(ctx context.Context, metrics []entities.Metrics) {
+	identity, ok := entities.AgentFromContext(ctx)
+	if !ok {
+		return
+	}
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	record := ar.touch(ctx, identity)
+	for _, m := range metrics {
+		record.series[seriesID{key: m.Key(), mType: entities.MetricType(m.MType)}] = struct{}{}
+	}
+}
+
+// touch records a request of an agent and returns its record. The caller must hold ar.mu.
+func (ar *agentRegistry) touch(ctx context.Context, identity entities.AgentIdentity) *agentRecord {
+	now := time.Now().UTC()
+	k := agentKey{tenant: entities.TenantFromContext(ctx), id: identity.ID}
+	record, ok := ar.agents[k]
+	if !ok {
+		ar.evict(now)
+		record = &agentRecord{series: make(map[seriesID]struct{})}
+		ar.agents[k] = record
+	}
+	record.agent.AgentIdentity = identity
+	record.agent.Address = entities.SourceFromContext(ctx)
+	record.agent.LastSeen = now
+
+	return record
+}
+
+// evict forgets the agents not seen for agentRetention at now and, if the registry is
+// still full, the least recently seen agent, making room for a new one. The caller
+// must hold ar.mu.
+func (ar *agentRegistry) evict(now time.Time) {
+	var (
+		oldest     agentKey
+		oldestSeen time.Time
+	)
+	for k, record := range ar.agents {
+		seen := record.agent.LastSeen
+		if now.Sub(seen) > agentRetention {
+			delete(ar.agents, k)
+			continue
+		}
+		if oldestSeen.IsZero() || seen.Before(oldestSeen) {
+			oldest, oldestSeen = k, seen
+		}
+	}
+
+	if len(ar.agents) >= maxAgents {
+		delete(ar.agents, oldest)
+	}
+}
+
+// forget drops the series of the agents of a tenant matched by match, so deleted
+// series no longer count towards their agent.
+func (ar *agentRegistry) forget(tenant string, match func(seriesID) bool) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	for k, record := range ar.agents {
+		if k.tenant != tenant {
+			continue
+		}
+		for id := range record.series {
+			if match(id) {
+				delete(record.series, id)
+			}
+		}
+	}
+}
+
+// list returns the agents of a tenant sorted by ID, with their series count and whether
+// they reported within their last report intervals at now.
+func (ar *agentRegistry) list(tenant string, now time.Time) []entities.Agent {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	agents := make([]entities.Agent, 0, len(ar.agents))
+	for k, record := range ar.agents {
+		if k.tenant != tenant || now.Sub(record.agent.LastSeen) > agentRetention {
+			continue
+		}
+		agent := record.agent
+		agent.Series = len(record.series)
+		interval := DefaultAgentReportInterval
+		if agent.ReportInterval > 0 {
+			interval = time.Duration(agent.ReportInterval) * time.Second
+		}
+		agent.Up = now.Sub(agent.LastSeen) <= time.Duration(ar.missedReports)*interval
+		agents = append(agents, agent)
+	}
+	slices.SortFunc(agents, func(a, b entities.Agent) int { return strings.Compare(a.ID, b.ID) })
+
+	return agents
+}
+
+// Heartbeat records that the agent of the request is alive and reports at the interval
+// of the heartbeat. It returns the state of the agent, or an error wrapping
+// entities.ErrInvalidHeartbeat for a request without an agent ID or a report interval.
+func (ms *MetricsService) Heartbeat(ctx context.Context, heartbeat entities.Heartbeat) (entities.Agent, error) {
+	identity, ok := entities.AgentFromContext(ctx)
+	if !ok {
+		return entities.Agent{}, fmt.Errorf("metrics service: %w: the agent ID is required",
+			entities.ErrInvalidHeartbeat)
+	}
+	if heartbeat.ReportInterval <= 0 {
+		return entities.Agent{}, fmt.Errorf("metrics service: %w: the report interval must be positive, got %d",
+			entities.ErrInvalidHeartbeat, heartbeat.ReportInterval)
+	}
+
+	ms.agents.mu.Lock()
+	record := ms.agents.touch(ctx, identity)
+	record.agent.ReportInterval = heartbeat.ReportInterval
+	agent := record.agent
+	agent.Series = len(record.series)
+	ms.agents.mu.Unlock()
+	agent.Up = true
+
+	ms.logger.DebugContext(ctx, fmt.Sprintf("heartbeat of agent %s", identity.ID))
+
+	return agent, nil
+}
+
+// ListAgents returns the agents of the tenant of the request seen within the last
+// day, sorted by ID, with the number of series each of them wrote and whether it is up.
+func (ms *MetricsService) ListAgents(ctx context.Context) ([]entities.Agent, error) {
+	return ms.agents.list(entities.TenantFromContext(ctx), time.Now()), nil
+}
+
+// upGauges returns the synthetic up gauge of every agent of the tenant of the request,
+// 1 for the agents that are up and 0 for the others. The gauges are computed on every
+// read, they are neither stored nor counted by the quotas.
+func (ms *MetricsService) upGauges(ctx context.Context) []entities.Metrics {
+	agents := ms.agents.list(entities.TenantFromContext(ctx), time.Now())
+	gauges := make([]entities.Metrics, 0, len(agents))
+	for _, a := range agents {
+		var up float64
+		if a.Up {
+			up = 1
+		}
+		gauges = append(gauges, entities.Metrics{
+			ID:     string(entities.AgentUpMetric),
+			MType:  string(entities.GaugeMetricName),
+			Labels: entities.Labels{entities.AgentLabel: a.ID},
+			Value:  &up,
+		})
+	}
+
+	return gauges
+}
+
+// upGauge returns the synthetic up gauge of the series key, and false when the key is
+// not the up gauge of a known agent.
+func (ms *MetricsService) upGauge(ctx context.Context, key entities.MetricName) (entities.Metrics, bool) {
+	name, labels, err := entities.ParseSeriesKey(key)
+	if err != nil || name != entities.AgentUpMetric || len(labels) != 1 || labels[entities.AgentLabel] == "" {
+		return entities.Metrics{}, false
+	}
+
+	for _, m := range ms.upGauges(ctx) {
+		if m.Labels[entities.AgentLabel] == labels[entities.AgentLabel] {
+			return m, true
+		}
+	}
+
+	return entities.Metrics{}, false
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/domain/repositories"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListAgents(t *testing.T) {
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	service := NewMetricsService(repositories.NewRepository(storage.WithNamespaces(memStore)), slog.Default())
+
+	gauge := func(name string) entities.Metrics {
+		v := 1.0
+		return entities.Metrics{ID: name, MType: string(entities.GaugeMetricName), Value: &v}
+	}
+	web := entities.WithAgent(entities.WithSource(ctx, "10.0.0.1"),
+		entities.AgentIdentity{ID: "web", Hostname: "web-host", Version: "1.0.0"})
+	db := entities.WithAgent(entities.WithSource(ctx, "10.0.0.2"), entities.AgentIdentity{ID: "db"})
+
+	require.NoError(t, service.StoreMetricsBatch(web, []entities.Metrics{gauge("Alloc"), gauge("Sys")}))
+	require.NoError(t, service.Create(web, gauge("Alloc")))
+	require.NoError(t, service.Create(db, gauge("Alloc")))
+	require.NoError(t, service.Create(ctx, gauge("anonymous")))
+	require.NoError(t, service.Create(entities.WithTenant(web, "team-a"), gauge("Alloc")))
+
+	agents, err := service.ListAgents(ctx)
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	assert.Equal(t, entities.AgentIdentity{ID: "db"}, agents[0].AgentIdentity)
+	assert.Equal(t, 1, agents[0].Series)
+	assert.Equal(t, "10.0.0.2", agents[0].Address)
+	assert.Equal(t, entities.AgentIdentity{ID: "web", Hostname: "web-host", Version: "1.0.0"}, agents[1].AgentIdentity)
+	assert.Equal(t, 2, agents[1].Series)
+	assert.False(t, agents[1].LastSeen.IsZero())
+
+	// A deleted series no longer counts towards its agents.
+	require.NoError(t, service.Delete(ctx, "Alloc", entities.GaugeMetricName))
+	agents, err = service.ListAgents(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 0, agents[0].Series)
+	assert.Equal(t, 1, agents[1].Series)
+
+	// The tenants only see their own agents.
+	agents, err = service.ListAgents(entities.WithTenant(ctx, "team-a"))
+	require.NoError(t, err)
+	require.Len(t, agents, 1)
+	assert.Equal(t, 1, agents[0].Series)
+}
+
+func TestHeartbeat(t *testing.T) {
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	service := NewMetricsService(repositories.NewRepository(storage.WithNamespaces(memStore)), slog.Default())
+	service.SetMissedReports(2)
+
+	_, err = service.Heartbeat(ctx, entities.Heartbeat{ReportInterval: 10})
+	require.ErrorIs(t, err, entities.ErrInvalidHeartbeat, "the agent ID is required")
+	web := entities.WithAgent(ctx, entities.AgentIdentity{ID: "web"})
+	_, err = service.Heartbeat(web, entities.Heartbeat{})
+	require.ErrorIs(t, err, entities.ErrInvalidHeartbeat, "the report interval is required")
+
+	agent, err := service.Heartbeat(web, entities.Heartbeat{ReportInterval: 10})
+	require.NoError(t, err)
+	assert.True(t, agent.Up)
+	assert.Equal(t, 10, agent.ReportInterval)
+	_, err = service.Heartbeat(entities.WithAgent(ctx, entities.AgentIdentity{ID: "db"}),
+		entities.Heartbeat{ReportInterval: 10})
+	require.NoError(t, err)
+
+	// The db agent missed its last two reports.
+	service.agents.agents[agentKey{id: "db"}].agent.LastSeen = time.Now().Add(-21 * time.Second)
+
+	agents, err := service.ListAgents(ctx)
+	require.NoError(t, err)
+	require.Len(t, agents, 2)
+	assert.False(t, agents[0].Up, "db is down")
+	assert.True(t, agents[1].Up, "web is up")
+
+	// The up gauges are computed from the agents.
+	all, err := service.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, map[entities.MetricName]entities.Gauge{`up{agent="db"}`: 0, `up{agent="web"}`: 1}, all.Gauge)
+	gauges, err := service.GetAllByType(ctx, entities.GaugeMetricName)
+	require.NoError(t, err)
+	assert.Len(t, gauges, 2)
+	up, err := service.Get(ctx, `up{agent="web"}`, entities.GaugeMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, entities.Labels{entities.AgentLabel: "web"}, up.Labels)
+	assert.InDelta(t, 1, *up.Value, 0)
+	_, err = service.Get(ctx, `up{agent="cache"}`, entities.GaugeMetricName)
+	require.ErrorIs(t, err, storage.ErrNotFound)
+
+	// They are neither stored nor seen by the other tenants.
+	stored, err := memStore.GetAllRecords(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, stored.Gauge)
+	all, err = service.GetAll(entities.WithTenant(ctx, "team-a"))
+	require.NoError(t, err)
+	assert.Empty(t, all.Gauge)
+}
+
+func TestAgentEviction(t *testing.T) {
+	registry := newAgentRegistry()
+	now := time.Now().UTC()
+	record := func(lastSeen time.Time) *agentRecord {
+		return &agentRecord{series: make(map[seriesID]struct{}), agent: entities.Agent{LastSeen: lastSeen}}
+	}
+	registry.agents[agentKey{id: "gone"}] = record(now.Add(-2 * agentRetention))
+	registry.agents[agentKey{id: "down"}] = record(now.Add(-time.Hour))
+
+	assert.Len(t, registry.list("", now), 1, "the agents past their retention are not listed")
+
+	ctx := context.Background()
+	registry.touch(ctx, entities.AgentIdentity{ID: "new"})
+	assert.NotContains(t, registry.agents, agentKey{id: "gone"}, "the agents past their retention are forgotten")
+	assert.Contains(t, registry.agents, agentKey{id: "down"})
+
+	for i := len(registry.agents); i < maxAgents; i++ {
+		registry.agents[agentKey{tenant: "team-a", id: strconv.Itoa(i)}] = record(now)
+	}
+	registry.touch(ctx, entities.AgentIdentity{ID: "newest"})
+	assert.Len(t, registry.agents, maxAgents)
+	assert.NotContains(t, registry.agents, agentKey{id: "down"}, "a full registry forgets the least recently seen agent")
+	assert.Contains(t, registry.agents, agentKey{id: "newest"})
+}
@@ -0,0 +1,232 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+)
+
+// DefaultRateWindow is the time range the rate of a counter is computed over by default.
+const DefaultRateWindow = time.Minute
+
+// totalsSweepInterval is the minimum interval between two sweeps of the expired baselines.
+const totalsSweepInterval = time.Minute
+
+// ErrInvalidCounter is matched by the validation errors of a counter without a delta
+// or a total, carrying both, or carrying a negative one.
+var ErrInvalidCounter = errors.New("invalid counter")
+
+// totalKey identifies the cumulative counter of a tenant.
+type totalKey struct {
+	tenant string
+	key    entities.MetricName
+}
+
+// baseline is the last raw total sent for a cumulative counter and when it was sent.
+type baseline struct {
+	at    time.Time
+	total int64
+}
+
+// baselineChange is the baseline set by a total, with the one it replaced if any.
+type baselineChange struct {
+	previous baseline
+	set      baseline
+	k        totalKey
+	existed  bool
+}
+
+// counterTotals remembers the last raw total sent for every cumulative counter, the
+// baseline the delta of the next total is computed from. The baselines expire with
+// their series, as decided by the TTL policy of the storage.
+type counterTotals struct {
+	last  map[totalKey]baseline
+	ttl   *entities.TTLPolicy // Policy the baselines expire by, nil keeps them forever
+	swept time.Time           // Last sweep of the expired baselines
+	mu    sync.Mutex
+}
+
+// newCounterTotals creates an empty set of baselines.
+func newCounterTotals() *counterTotals {
+	return &counterTotals{last: make(map[totalKey]baseline)}
+}
+
+// SetTTLPolicy sets the policy the series expire by in the storage, so the baselines
+// of the cumulative counters expire with their series. A nil policy keeps them forever.
+func (ms *MetricsService) SetTTLPolicy(policy *entities.TTLPolicy) {
+	ms.totals.mu.Lock()
+	defer ms.totals.mu.Unlock()
+	ms.totals.ttl = policy
+}
+
+// lookup returns the baseline of a counter that has not expired at now. Every
+// totalsSweepInterval, it also drops the expired baselines of the other counters.
+// The caller must hold ct.mu.
+func (ct *counterTotals) lookup(k totalKey, now time.Time) (baseline, bool) {
+	if ct.ttl.Enabled() && now.Sub(ct.swept) >= totalsSweepInterval {
+		for key, b := range ct.last {
+			if ct.ttl.Expired(key.key, b.at, now) {
+				delete(ct.last, key)
+			}
+		}
+		ct.swept = now
+	}
+
+	b, ok := ct.last[k]
+	if ok && ct.ttl.Expired(k.key, b.at, now) {
+		delete(ct.last, k)
+		return baseline{}, false
+	}
+
+	return b, ok
+}
+
+// delta returns the increase of a counter whose raw total is now total and records the
+// new baseline. A total lower than the baseline means the client restarted its counter
+// from zero, so the whole total is the increase. known reports whether the series is
+// already stored, which tells a new counter from one whose baseline was lost when the
+// server restarted: the first total of the former is counted and the one of the latter
+// only sets the baseline. An expired baseline is lost like the series it belongs to.
+// The returned change reverts the baseline if the delta is not stored.
+func (ct *counterTotals) delta(k totalKey, total int64, now time.Time, known func() bool) (int64, baselineChange) {
+	ct.mu.Lock()
+	_, ok := ct.lookup(k, now)
+	ct.mu.Unlock()
+
+	// The storage is only asked about the counters without a baseline, and without the lock held.
+	stored := !ok && known()
+
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	last, ok := ct.lookup(k, now)
+	change := baselineChange{previous: last, set: baseline{at: now, total: total}, k: k, existed: ok}
+	ct.last[k] = change.set
+	switch {
+	case !ok && stored:
+		return 0, change
+	case !ok, total < last.total:
+		return total, change
+	default:
+		return total - last.total, change
+	}
+}
+
+// revert puts back the baselines replaced by changes, latest first, so the increase
+// carried by a write that failed is counted again by the next total. A baseline
+// moved since by another write is kept.
+func (ct *counterTotals) revert(changes []baselineChange) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	for i := len(changes) - 1; i >= 0; i-- {
+		c := changes[i]
+		if current, ok := ct.last[c.k]; !ok || current != c.set {
+			continue
+		}
+		if c.existed {
+			ct.last[c.k] = c.previous
+		} else {
+			delete(ct.last, c.k)
+		}
+	}
+}
+
+// forget drops the baselines of the counters of a tenant matched by match, so a
+// deleted counter starts over from its next total.
+func (ct *counterTotals) forget(tenant string, match func(entities.MetricName) bool) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	for k := range ct.last {
+		if k.tenant == tenant && match(k.key) {
+			delete(ct.last, k)
+		}
+	}
+}
+
+// resolveTotals replaces the total of every cumulative counter of metrics by the delta
+// accumulated since the previous total of the same series. The other metrics are kept.
+// The returned function reverts the baselines, it must be called if the resolved
+// metrics are not stored.
+func (ms *MetricsService) resolveTotals(ctx context.Context, metrics []entities.Metrics) (
+	[]entities.Metrics, func(), error) {
+	var (
+		resolved []entities.Metrics
+		changes  []baselineChange
+	)
+	for i, m := range metrics {
+		if m.Total == nil || m.MType != string(entities.CounterMetricName) {
+			continue
+		}
+
+		if resolved == nil {
+			resolved = make([]entities.Metrics, len(metrics))
+			copy(resolved, metrics)
+		}
+		key := m.Key()
+		k := totalKey{tenant: entities.TenantFromContext(ctx), key: key}
+		delta, change := ms.totals.delta(k, *m.Total, time.Now(), func() bool {
+			_, err := ms.repo.Get(ctx, key, entities.CounterMetricName)
+			return err == nil
+		})
+		changes = append(changes, change)
+		resolved[i].Delta = &delta
+		resolved[i].Total = nil
+	}
+
+	revert := func() { ms.totals.revert(changes) }
+	if resolved == nil {
+		return metrics, revert, nil
+	}
+
+	return resolved, revert, nil
+}
+
+// SetRateWindow sets the time range the rate of a counter is computed over, zero or
+// a negative window restores DefaultRateWindow.
+func (ms *MetricsService) SetRateWindow(window time.Duration) {
+	if window <= 0 {
+		window = DefaultRateWindow
+	}
+	ms.rateWindow = window
+}
+
+// Rate returns the per-second rate of a counter over the rate window, computed from
+// the first and the last total recorded within the window. It is zero for a counter
+// updated less than twice within the window or whose storage keeps no history.
+func (ms *MetricsService) Rate(ctx context.Context, key entities.MetricName) (float64, error) {
+	now := time.Now()
+	samples, err := ms.repo.GetHistory(ctx, key, entities.CounterMetricName, now.Add(-ms.rateWindow), now)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("metrics service: failed to compute the rate of %s: %w", key, err)
+	}
+
+	return counterRate(samples), nil
+}
+
+// counterRate returns the per-second increase between the first and the last sample.
+func counterRate(samples []entities.Sample) float64 {
+	if len(samples) < 2 {
+		return 0
+	}
+
+	first, last := samples[0], samples[len(samples)-1]
+	elapsed := last.Timestamp.Sub(first.Timestamp).Seconds()
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return (last.Value - first.Value) / elapsed
+}
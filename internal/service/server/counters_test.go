@@ -0,0 +1,190 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/domain/repositories"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCumulativeCounters(t *testing.T) {
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	store := storage.WithNamespaces(memStore)
+	service := NewMetricsService(repositories.NewRepository(store), slog.Default())
+
+	total := func(v int64) entities.Metrics {
+		return entities.Metrics{ID: "requests", MType: string(entities.CounterMetricName), Total: &v}
+	}
+	// The client restarts after 15 and counts 4 more.
+	for _, v := range []int64{10, 15, 15} {
+		require.NoError(t, service.Create(ctx, total(v)))
+	}
+	require.NoError(t, service.StoreMetricsBatch(ctx, []entities.Metrics{total(3), total(4)}))
+
+	counter, err := service.Get(ctx, "requests", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(19), *counter.Delta)
+	assert.Nil(t, counter.Total)
+
+	// A server that lost the baselines does not count the stored total again.
+	restarted := NewMetricsService(repositories.NewRepository(store), slog.Default())
+	require.NoError(t, restarted.Create(ctx, total(6)))
+	require.NoError(t, restarted.Create(ctx, total(8)))
+	counter, err = restarted.Get(ctx, "requests", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(21), *counter.Delta)
+
+	// The tenants keep their own baselines.
+	teamA := entities.WithTenant(ctx, "team-a")
+	require.NoError(t, service.Create(teamA, total(100)))
+
+	// A deleted counter starts over from its next total.
+	require.NoError(t, service.Delete(ctx, "requests", entities.CounterMetricName))
+	require.NoError(t, service.Create(ctx, total(9)))
+	counter, err = service.Get(ctx, "requests", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(9), *counter.Delta)
+
+	require.NoError(t, service.Create(teamA, total(101)))
+	counter, err = service.Get(teamA, "requests", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(101), *counter.Delta)
+
+	delta := int64(1)
+	invalid := []entities.Metrics{
+		{ID: "requests", MType: string(entities.CounterMetricName), Delta: &delta, Total: &delta},
+		{ID: "requests", MType: string(entities.GaugeMetricName), Total: &delta},
+		total(-1),
+	}
+	for _, m := range invalid {
+		require.ErrorIs(t, service.Create(ctx, m), ErrInvalidCounter)
+	}
+}
+
+func TestCounterRate(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.InDelta(t, 0, counterRate(nil), 0)
+	assert.InDelta(t, 0, counterRate([]entities.Sample{{Timestamp: base, Value: 5}}), 0)
+	assert.InDelta(t, 2.5, counterRate([]entities.Sample{
+		{Timestamp: base, Value: 10},
+		{Timestamp: base.Add(2 * time.Second), Value: 12},
+		{Timestamp: base.Add(4 * time.Second), Value: 20},
+	}), 1e-9)
+
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	service := NewMetricsService(repositories.NewRepository(memStore), slog.Default())
+	delta := int64(3)
+	require.NoError(t, service.Create(ctx,
+		entities.Metrics{ID: "requests", MType: string(entities.CounterMetricName), Delta: &delta}))
+
+	counter, err := service.Get(ctx, "requests", entities.CounterMetricName)
+	require.NoError(t, err)
+	require.NotNil(t, counter.Rate)
+	assert.InDelta(t, 0, *counter.Rate, 0, "a single update has no rate yet")
+}
+
+func TestCounterTotalsExpire(t *testing.T) {
+	policy, err := entities.NewTTLPolicy(0, "requests=1m")
+	require.NoError(t, err)
+	totals := newCounterTotals()
+	totals.ttl = policy
+	delta := func(k totalKey, total int64, now time.Time) int64 {
+		d, _ := totals.delta(k, total, now, func() bool { return false })
+		return d
+	}
+
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	requests := totalKey{key: "requests"}
+	assert.Equal(t, int64(10), delta(requests, 10, base))
+	assert.Equal(t, int64(2), delta(requests, 12, base.Add(30*time.Second)))
+	assert.Equal(t, int64(5), delta(totalKey{key: "errors"}, 5, base))
+
+	// Once the counter expired with its series, its next total starts it over.
+	assert.Equal(t, int64(14), delta(requests, 14, base.Add(2*time.Minute)))
+	assert.Len(t, totals.last, 2, "the baselines without a TTL are kept")
+
+	assert.Equal(t, int64(1), delta(totalKey{key: "errors"}, 6, base.Add(5*time.Minute)))
+	assert.NotContains(t, totals.last, requests, "the expired baselines are swept")
+}
+
+// historyFailingStorage fails every history read.
+type historyFailingStorage struct {
+	storage.Storage
+}
+
+func (historyFailingStorage) GetHistory(context.Context, entities.MetricName, entities.MetricType,
+	time.Time, time.Time) ([]entities.Sample, error) {
+	return nil, assert.AnError
+}
+
+func TestCounterWithoutRate(t *testing.T) {
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	service := NewMetricsService(repositories.NewRepository(historyFailingStorage{memStore}), slog.Default())
+	delta := int64(3)
+	require.NoError(t, service.Create(ctx,
+		entities.Metrics{ID: "requests", MType: string(entities.CounterMetricName), Delta: &delta}))
+
+	counter, err := service.Get(ctx, "requests", entities.CounterMetricName)
+	require.NoError(t, err, "a failed rate does not fail the read")
+	assert.Equal(t, int64(3), *counter.Delta)
+	assert.Nil(t, counter.Rate)
+}
+
+// writeFailingStorage fails the writes while failing is set.
+type writeFailingStorage struct {
+	storage.Storage
+	failing bool
+}
+
+func (s *writeFailingStorage) CreateRecord(ctx context.Context, metrics entities.Metrics) error {
+	if s.failing {
+		return assert.AnError
+	}
+
+	return s.Storage.CreateRecord(ctx, metrics)
+}
+
+func (s *writeFailingStorage) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	if s.failing {
+		return assert.AnError
+	}
+
+	return s.Storage.StoreMetricsBatch(ctx, metrics)
+}
+
+func TestCumulativeCountersFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	store := &writeFailingStorage{Storage: memStore}
+	service := NewMetricsService(repositories.NewRepository(store), slog.Default())
+
+	total := func(v int64) entities.Metrics {
+		return entities.Metrics{ID: "requests", MType: string(entities.CounterMetricName), Total: &v}
+	}
+	require.NoError(t, service.Create(ctx, total(10)))
+
+	// The increases of the failed writes are carried by the next total.
+	store.failing = true
+	require.Error(t, service.Create(ctx, total(15)))
+	require.Error(t, service.StoreMetricsBatch(ctx, []entities.Metrics{total(17), total(18)}))
+	store.failing = false
+	require.NoError(t, service.Create(ctx, total(20)))
+
+	counter, err := service.Get(ctx, "requests", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(20), *counter.Delta)
+}
@@ -0,0 +1,56 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// Delete removes a single series and its history. It returns an error
+// if the series is not found or if the removal fails.
+func (ms *MetricsService) Delete(ctx context.Context, key entities.MetricName, mType entities.MetricType) error {
+	ms.logger.DebugContext(ctx, fmt.Sprintf("deleting %s metric", key))
+	if err := ms.repo.Delete(ctx, key, mType); err != nil {
+		return fmt.Errorf("metric service: %w", err)
+	}
+	ms.invalidateSeries(ctx)
+	ms.agents.forget(entities.TenantFromContext(ctx), func(id seriesID) bool {
+		return id.key == key && id.mType == mType
+	})
+	if mType == entities.CounterMetricName {
+		ms.totals.forget(entities.TenantFromContext(ctx), func(k entities.MetricName) bool { return k == key })
+	}
+
+	return nil
+}
+
+// DeleteMatching removes every series whose key matches the pattern, e.g. `cpu_*`
+// or `*{host="web-1"}` for a glob. The pattern must match the whole series key.
+// It returns the number of removed series.
+func (ms *MetricsService) DeleteMatching(ctx context.Context, mType entities.MetricType, pattern string,
+	syntax entities.PatternSyntax) (int, error) {
+	matcher, err := entities.NewSeriesMatcher(pattern, syntax)
+	if err != nil {
+		return 0, fmt.Errorf("metric service: %w", err)
+	}
+
+	deleted, err := ms.repo.DeleteMatching(ctx, mType, matcher)
+	if err != nil {
+		return 0, fmt.Errorf("metric service: %w", err)
+	}
+	ms.invalidateSeries(ctx)
+	ms.agents.forget(entities.TenantFromContext(ctx), func(id seriesID) bool {
+		return (mType == "" || id.mType == mType) && matcher.Match(id.key)
+	})
+	if mType == "" || mType == entities.CounterMetricName {
+		ms.totals.forget(entities.TenantFromContext(ctx), matcher.Match)
+	}
+	ms.logger.DebugContext(ctx,
+		fmt.Sprintf("deleted %d metrics matching %s", deleted, matcher))
+
+	return deleted, nil
+}
@@ -0,0 +1,56 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+)
+
+// Export writes every metric to w in the given format, counters holding their totals.
+// It returns the number of exported metrics.
+func (ms *MetricsService) Export(ctx context.Context, w io.Writer, format storage.Format) (int, error) {
+	records, err := ms.repo.GetAll(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("metrics service: %w", err)
+	}
+
+	metrics, err := storage.Records(records)
+	if err != nil {
+		return 0, fmt.Errorf("metrics service: %w", err)
+	}
+	if err = storage.EncodeRecords(w, format, metrics); err != nil {
+		return 0, fmt.Errorf("metrics service: %w", err)
+	}
+	ms.logger.DebugContext(ctx, fmt.Sprintf("exported %d metrics as %s", len(metrics), format))
+
+	return len(metrics), nil
+}
+
+// Import reads metrics in the given format from r and replaces their series with the
+// read values, so counters are restored to their exported totals. Nothing is stored
+// unless every metric is accepted by the validation policy. The imports restore series
+// on behalf of an administrator, so they are not limited by the quotas. It returns the
+// number of imported metrics.
+func (ms *MetricsService) Import(ctx context.Context, r io.Reader, format storage.Format) (int, error) {
+	metrics, err := storage.DecodeRecords(r, format)
+	if err != nil {
+		return 0, fmt.Errorf("metrics service: %w", err)
+	}
+	if err = ms.policy.ValidateBatch(metrics); err != nil {
+		return 0, fmt.Errorf("metrics service: %w", err)
+	}
+
+	imported, err := ms.repo.Import(ctx, metrics)
+	ms.invalidateSeries(ctx)
+	if err != nil {
+		return imported, fmt.Errorf("metrics service: %w", err)
+	}
+	ms.logger.DebugContext(ctx, fmt.Sprintf("imported %d metrics from %s", imported, format))
+
+	return imported, nil
+}
@@ -0,0 +1,114 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// ErrInvalidHistoryQuery is returned when a history range query has an invalid
+// time range, step or aggregation.
+var ErrInvalidHistoryQuery = errors.New("invalid history query")
+
+// GetHistory retrieves the samples of a series recorded within [from, to].
+// When step is positive the samples are grouped into buckets of step width,
+// aligned to the Unix epoch, and each bucket is reduced with agg. The timestamp
+// of an aggregated point is the start of its bucket. An empty agg defaults to avg.
+func (ms *MetricsService) GetHistory(ctx context.Context, key entities.MetricName, mType entities.MetricType,
+	from, to time.Time, step time.Duration, agg entities.Aggregation) ([]entities.Sample, error) {
+	if agg == "" {
+		agg = entities.AggregationAvg
+	}
+
+	if err := validateHistoryQuery(mType, from, to, step, agg); err != nil {
+		return nil, fmt.Errorf("metrics service: %w", err)
+	}
+
+	samples, err := ms.repo.GetHistory(ctx, key, mType, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("metrics service: %w", err)
+	}
+
+	if step == 0 {
+		return samples, nil
+	}
+
+	return aggregateSamples(samples, step, agg), nil
+}
+
+// validateHistoryQuery checks the parameters of a history range query.
+func validateHistoryQuery(mType entities.MetricType, from, to time.Time,
+	step time.Duration, agg entities.Aggregation) error {
+	if mType != entities.CounterMetricName && mType != entities.GaugeMetricName {
+		return fmt.Errorf("%w: unknown metric type %s", ErrInvalidHistoryQuery, mType)
+	}
+
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		return fmt.Errorf("%w: to must not be before from", ErrInvalidHistoryQuery)
+	}
+
+	if step < 0 {
+		return fmt.Errorf("%w: step must not be negative", ErrInvalidHistoryQuery)
+	}
+
+	if !agg.IsValid() {
+		return fmt.Errorf("%w: unsupported aggregation %s", ErrInvalidHistoryQuery, agg)
+	}
+
+	return nil
+}
+
+// aggregateSamples reduces chronologically ordered samples into one point per step bucket.
+func aggregateSamples(samples []entities.Sample, step time.Duration, agg entities.Aggregation) []entities.Sample {
+	points := make([]entities.Sample, 0)
+
+	var (
+		bucket entities.Sample
+		count  int
+	)
+
+	flush := func() {
+		if count == 0 {
+			return
+		}
+		if agg == entities.AggregationAvg {
+			bucket.Value /= float64(count)
+		}
+		points = append(points, bucket)
+	}
+
+	for _, s := range samples {
+		start := s.Timestamp.Truncate(step)
+		if count > 0 && !start.Equal(bucket.Timestamp) {
+			flush()
+			count = 0
+		}
+
+		if count == 0 {
+			bucket = entities.Sample{Timestamp: start, Value: s.Value}
+			count++
+			continue
+		}
+
+		switch agg {
+		case entities.AggregationAvg, entities.AggregationSum:
+			bucket.Value += s.Value
+		case entities.AggregationMin:
+			bucket.Value = min(bucket.Value, s.Value)
+		case entities.AggregationMax:
+			bucket.Value = max(bucket.Value, s.Value)
+		case entities.AggregationLast:
+			bucket.Value = s.Value
+		}
+		count++
+	}
+	flush()
+
+	return points
+}
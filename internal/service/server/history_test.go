@@ -0,0 +1,58 @@
+package server
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAggregateSamples(t *testing.T) {
+	base := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	samples := []entities.Sample{
+		{Timestamp: base, Value: 1},
+		{Timestamp: base.Add(20 * time.Second), Value: 5},
+		{Timestamp: base.Add(40 * time.Second), Value: 3},
+		{Timestamp: base.Add(70 * time.Second), Value: 10},
+	}
+
+	tests := []struct {
+		agg  entities.Aggregation
+		want []float64
+	}{
+		{agg: entities.AggregationAvg, want: []float64{3, 10}},
+		{agg: entities.AggregationMin, want: []float64{1, 10}},
+		{agg: entities.AggregationMax, want: []float64{5, 10}},
+		{agg: entities.AggregationSum, want: []float64{9, 10}},
+		{agg: entities.AggregationLast, want: []float64{3, 10}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.agg), func(t *testing.T) {
+			points := aggregateSamples(samples, time.Minute, tt.agg)
+			require.Len(t, points, len(tt.want))
+			for i, p := range points {
+				assert.InDelta(t, tt.want[i], p.Value, 0)
+			}
+			assert.Equal(t, base, points[0].Timestamp)
+			assert.Equal(t, base.Add(time.Minute), points[1].Timestamp)
+		})
+	}
+}
+
+func TestValidateHistoryQuery(t *testing.T) {
+	now := time.Now()
+
+	require.NoError(t, validateHistoryQuery(entities.GaugeMetricName, time.Time{}, time.Time{}, 0,
+		entities.AggregationAvg))
+	require.ErrorIs(t, validateHistoryQuery("histogram", time.Time{}, time.Time{}, 0,
+		entities.AggregationAvg), ErrInvalidHistoryQuery)
+	require.ErrorIs(t, validateHistoryQuery(entities.GaugeMetricName, now, now.Add(-time.Second), 0,
+		entities.AggregationAvg), ErrInvalidHistoryQuery)
+	require.ErrorIs(t, validateHistoryQuery(entities.GaugeMetricName, time.Time{}, time.Time{}, -time.Second,
+		entities.AggregationAvg), ErrInvalidHistoryQuery)
+	require.ErrorIs(t, validateHistoryQuery(entities.GaugeMetricName, time.Time{}, time.Time{}, 0,
+		"median"), ErrInvalidHistoryQuery)
+}
@@ -0,0 +1,78 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+)
+
+// RegisterMetadata validates and registers the metadata of metric names, replacing the
+// metadata already registered for the same names. It returns entities.ErrInvalidMetadata
+// if the list is empty or one of its entries is invalid, and nothing is registered then.
+func (ms *MetricsService) RegisterMetadata(ctx context.Context, metadata []entities.Metadata) error {
+	if len(metadata) == 0 {
+		return fmt.Errorf("metric service: %w: no metadata given", entities.ErrInvalidMetadata)
+	}
+	for _, md := range metadata {
+		if err := md.Validate(); err != nil {
+			return fmt.Errorf("metric service: %w", err)
+		}
+	}
+
+	ms.logger.DebugContext(ctx, fmt.Sprintf("registering the metadata of %d metrics", len(metadata)))
+	if err := ms.repo.SetMetadata(ctx, metadata); err != nil {
+		return fmt.Errorf("metric service: %w", err)
+	}
+	ms.agents.seen(ctx, nil)
+
+	return nil
+}
+
+// GetMetadata retrieves the metadata registered for a metric name.
+// It returns an error wrapping storage.ErrNotFound if none is registered.
+func (ms *MetricsService) GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error) {
+	md, err := ms.repo.GetMetadata(ctx, name)
+	if err != nil {
+		return entities.Metadata{}, fmt.Errorf("metric service: %w", err)
+	}
+
+	return md, nil
+}
+
+// ListMetadata retrieves all the registered metadata sorted by metric name.
+func (ms *MetricsService) ListMetadata(ctx context.Context) ([]entities.Metadata, error) {
+	metadata, err := ms.repo.GetAllMetadata(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("metric service: %w", err)
+	}
+
+	list := make([]entities.Metadata, 0, len(metadata))
+	for _, md := range metadata {
+		list = append(list, md)
+	}
+	slices.SortFunc(list, func(a, b entities.Metadata) int { return strings.Compare(string(a.Name), string(b.Name)) })
+
+	return list, nil
+}
+
+// describe attaches the metadata registered for the name of the metric, if any.
+func (ms *MetricsService) describe(ctx context.Context, item *entities.Metrics) error {
+	md, err := ms.repo.GetMetadata(ctx, entities.MetricName(item.ID))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil
+		}
+		return fmt.Errorf("metric service: %w", err)
+	}
+	item.Metadata = &md
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/domain/repositories"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	store, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	service := NewMetricsService(repositories.NewRepository(store), slog.Default())
+
+	require.ErrorIs(t, service.RegisterMetadata(ctx, nil), entities.ErrInvalidMetadata)
+	err = service.RegisterMetadata(ctx, []entities.Metadata{
+		{Name: "Alloc", Unit: "bytes"},
+		{Name: `Alloc{host="web-1"}`},
+	})
+	require.ErrorIs(t, err, entities.ErrInvalidMetadata)
+	_, err = service.GetMetadata(ctx, "Alloc")
+	require.ErrorIs(t, err, storage.ErrNotFound, "nothing is registered from an invalid list")
+
+	require.NoError(t, service.RegisterMetadata(ctx, entities.PredefinedMetadata()))
+	list, err := service.ListMetadata(ctx)
+	require.NoError(t, err)
+	assert.Len(t, list, len(entities.PredefinedMetadata()))
+	assert.Equal(t, entities.Alloc, list[0].Name, "the metadata is sorted by name")
+
+	value := 1.5
+	labels := entities.Labels{"host": "web-1"}
+	require.NoError(t, service.Create(ctx, entities.Metrics{ID: "Alloc", MType: "gauge", Value: &value, Labels: labels}))
+	require.NoError(t, service.Create(ctx, entities.Metrics{ID: "custom", MType: "gauge", Value: &value}))
+
+	gauge, err := service.Get(ctx, entities.SeriesKey("Alloc", labels), entities.GaugeMetricName)
+	require.NoError(t, err)
+	require.NotNil(t, gauge.Metadata, "every series of a name shares its metadata")
+	assert.Equal(t, "bytes", gauge.Metadata.Unit)
+
+	gauge, err = service.Get(ctx, "custom", entities.GaugeMetricName)
+	require.NoError(t, err)
+	assert.Nil(t, gauge.Metadata)
+}
@@ -8,46 +8,92 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/domain/repositories"
 	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/mihailtudos/metrickit/pkg/helpers"
 )
 
 // MetricsService is responsible for managing metrics. It interacts with
 // a repository for data storage and retrieval, and uses a logger for
 // logging purposes.
 type MetricsService struct {
-	repo   repositories.MetricsRepository // Repository for metric storage and retrieval
-	logger *slog.Logger                   // Logger for debug and error messages
+	repo         repositories.MetricsRepository // Repository for metric storage and retrieval
+	logger       *slog.Logger                   // Logger for debug and error messages
+	totals       *counterTotals                 // Baselines of the cumulative counters
+	maxBatchSize int                            // Largest accepted batch, zero for no limit
+	rateWindow   time.Duration                  // Time range the rates of the counters are computed over
+	policy       ValidationPolicy               // Policy the ingested metrics are validated against
+	quotas       *seriesQuotas                  // Series of the tenants tracked against the quotas
+	agents       *agentRegistry                 // Agents seen within the last day
 }
 
+// ErrBatchTooLarge is returned when a batch holds more metrics than the configured maximum.
+var ErrBatchTooLarge = errors.New("batch too large")
+
 // NewMetricService creates a new MetricsService instance with the
 // specified repository and logger.
 func NewMetricService(repo repositories.MetricsRepository, logger *slog.Logger) *MetricsService {
-	return &MetricsService{repo: repo, logger: logger}
+	return &MetricsService{
+		repo:       repo,
+		logger:     logger,
+		totals:     newCounterTotals(),
+		rateWindow: DefaultRateWindow,
+		policy:     DefaultValidationPolicy(),
+		quotas:     newSeriesQuotas(nil),
+		agents:     newAgentRegistry(),
+	}
+}
+
+// SetValidationPolicy sets the policy every ingested metric is validated against.
+func (ms *MetricsService) SetValidationPolicy(policy ValidationPolicy) {
+	ms.policy = policy
 }
 
-// Create adds a new metric to the repository. It logs the action and
-// returns an error if the operation fails.
-func (ms *MetricsService) Create(metric entities.Metrics) error {
-	if metric.MType != string(entities.CounterMetricName) && metric.MType != string(entities.GaugeMetricName) {
-		return fmt.Errorf("metric service: invalid metric type: %s", metric.MType)
+// Create adds a new metric to the repository. It logs the action and returns
+// an error wrapping a *ValidationError if the metric is rejected by the validation
+// policy, an error wrapping ErrQuotaExceeded if it would create a series exceeding
+// a quota, or an error if the operation fails.
+func (ms *MetricsService) Create(ctx context.Context, metric entities.Metrics) error {
+	if err := ms.policy.Validate(metric); err != nil {
+		return fmt.Errorf("metric service: %w", err)
+	}
+	if err := ms.admit(ctx, []entities.Metrics{metric}); err != nil {
+		return fmt.Errorf("metric service: %w", err)
+	}
+
+	resolved, revertTotals, err := ms.resolveTotals(ctx, []entities.Metrics{metric})
+	if err != nil {
+		return fmt.Errorf("metric service: %w", err)
 	}
+	metric = resolved[0]
 
-	ms.logger.DebugContext(context.Background(), fmt.Sprintf("updating %s metric", metric.ID))
-	err := ms.repo.Create(metric)
+	ms.logger.DebugContext(ctx, fmt.Sprintf("updating %s metric", metric.ID))
+	err = ms.repo.Create(ctx, metric)
 	if err != nil {
-		return fmt.Errorf("failed to create metric counter with key=%s val=%v due to: %w", metric.ID, *metric.Delta, err)
+		revertTotals()
+		ms.invalidateSeries(ctx)
+		return fmt.Errorf("failed to create %s metric with key=%s due to: %w", metric.MType, metric.ID, err)
 	}
+	ms.agents.seen(ctx, resolved)
 
 	return nil
 }
 
-// Get retrieves a specific metric by its key and type. It returns
-// an error if the metric is not found or if an error occurs during retrieval.
-func (ms *MetricsService) Get(key entities.MetricName, mType entities.MetricType) (entities.Metrics, error) {
-	item, err := ms.repo.Get(key, mType)
+// Get retrieves a specific metric by its key and type, together with its rate
+// for a counter and the metadata registered for its name. It returns an error if the metric is not found or if an error
+// occurs during retrieval. A counter whose rate cannot be computed is returned without a rate.
+func (ms *MetricsService) Get(ctx context.Context, key entities.MetricName,
+	mType entities.MetricType) (entities.Metrics, error) {
+	if mType == entities.GaugeMetricName {
+		if up, ok := ms.upGauge(ctx, key); ok {
+			return up, nil
+		}
+	}
+
+	item, err := ms.repo.Get(ctx, key, mType)
 	if err != nil {
 		if errors.Is(err, storage.ErrNotFound) {
 			return entities.Metrics{}, fmt.Errorf("metric service: %w", err)
@@ -56,38 +102,97 @@ func (ms *MetricsService) Get(key entities.MetricName, mType entities.MetricType
 		return entities.Metrics{}, fmt.Errorf("metric service: %w", err)
 	}
 
+	if mType == entities.CounterMetricName {
+		// The counter is served without a rate rather than failing the read on its history.
+		if rate, err := ms.Rate(ctx, key); err != nil {
+			ms.logger.ErrorContext(ctx, "failed to compute the counter rate", helpers.ErrAttr(err))
+		} else {
+			item.Rate = &rate
+		}
+	}
+	if err = ms.describe(ctx, &item); err != nil {
+		return entities.Metrics{}, err
+	}
+
 	return item, nil
 }
 
-// GetAll retrieves all metrics from the repository. It returns
-// an error if the retrieval fails.
-func (ms *MetricsService) GetAll() (*storage.MetricsStorage, error) {
-	items, err := ms.repo.GetAll()
+// GetAll retrieves all metrics from the repository, together with the up gauges
+// of the agents. It returns an error if the retrieval fails.
+func (ms *MetricsService) GetAll(ctx context.Context) (*storage.MetricsStorage, error) {
+	items, err := ms.repo.GetAll(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get the counter metrics: %w", err)
 	}
 
+	up := ms.upGauges(ctx)
+	if len(up) > 0 && items.Gauge == nil {
+		items.Gauge = make(map[entities.MetricName]entities.Gauge, len(up))
+	}
+	for _, m := range up {
+		items.Gauge[m.Key()] = entities.Gauge(*m.Value)
+	}
+
 	return items, nil
 }
 
-// GetAllByType retrieves all metrics of a specific type from the repository.
-// It returns an error if the retrieval fails.
-func (ms *MetricsService) GetAllByType(mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
-	metrics, err := ms.repo.GetAllByType(mType)
+// GetAllByType retrieves all metrics of a specific type from the repository, together
+// with the up gauges of the agents for the gauges. It returns an error if the retrieval fails.
+func (ms *MetricsService) GetAllByType(ctx context.Context,
+	mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
+	metrics, err := ms.repo.GetAllByType(ctx, mType)
 	if err != nil {
 		return nil, fmt.Errorf("metrics service: %w", err)
 	}
 
+	if mType == entities.GaugeMetricName {
+		up := ms.upGauges(ctx)
+		if len(up) > 0 && metrics == nil {
+			metrics = make(map[entities.MetricName]entities.Metrics, len(up))
+		}
+		for _, m := range up {
+			metrics[m.Key()] = m
+		}
+	}
+
 	return metrics, nil
 }
 
+// SetMaxBatchSize sets the largest number of metrics accepted in a batch, zero or
+// a negative size accepts batches of any size.
+func (ms *MetricsService) SetMaxBatchSize(size int) {
+	ms.maxBatchSize = size
+}
+
 // StoreMetricsBatch stores a batch of metrics in the repository.
-// It returns an error if the storage operation fails.
-func (ms *MetricsService) StoreMetricsBatch(metrics []entities.Metrics) error {
-	err := ms.repo.StoreMetricsBatch(metrics)
+// It returns ErrBatchTooLarge if the batch exceeds the maximum batch size, an error
+// wrapping a *ValidationError if a metric is rejected by the validation policy, an error
+// wrapping ErrQuotaExceeded if the batch would create series exceeding a quota, and an
+// error if the storage operation fails. Nothing is stored unless every metric is accepted.
+func (ms *MetricsService) StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error {
+	if ms.maxBatchSize > 0 && len(metrics) > ms.maxBatchSize {
+		return fmt.Errorf("metrics service: %w: %d metrics, at most %d are accepted",
+			ErrBatchTooLarge, len(metrics), ms.maxBatchSize)
+	}
+	if err := ms.policy.ValidateBatch(metrics); err != nil {
+		return fmt.Errorf("metrics service: %w", err)
+	}
+	if err := ms.admit(ctx, metrics); err != nil {
+		return fmt.Errorf("metrics service: %w", err)
+	}
+
+	metrics, revertTotals, err := ms.resolveTotals(ctx, metrics)
+	if err != nil {
+		return fmt.Errorf("metrics service: %w", err)
+	}
+
+	err = ms.repo.StoreMetricsBatch(ctx, metrics)
 	if err != nil {
+		revertTotals()
+		ms.invalidateSeries(ctx)
 		return fmt.Errorf("metrics service %w", err)
 	}
+	ms.agents.seen(ctx, metrics)
 
 	return nil
 }
@@ -5,7 +5,10 @@
 package mocks
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	entities "github.com/mihailtudos/metrickit/internal/domain/entities"
@@ -36,74 +39,237 @@ func (m *MockMetrics) EXPECT() *MockMetricsMockRecorder {
 }
 
 // Create mocks base method.
-func (m *MockMetrics) Create(arg0 entities.Metrics) error {
+func (m *MockMetrics) Create(arg0 context.Context, arg1 entities.Metrics) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Create", arg0)
+	ret := m.ctrl.Call(m, "Create", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Create indicates an expected call of Create.
-func (mr *MockMetricsMockRecorder) Create(arg0 interface{}) *gomock.Call {
+func (mr *MockMetricsMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMetrics)(nil).Create), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMetrics)(nil).Create), arg0, arg1)
+}
+
+// Delete mocks base method.
+func (m *MockMetrics) Delete(arg0 context.Context, arg1 entities.MetricName, arg2 entities.MetricType) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1, arg2)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMetricsMockRecorder) Delete(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMetrics)(nil).Delete), arg0, arg1, arg2)
+}
+
+// DeleteMatching mocks base method.
+func (m *MockMetrics) DeleteMatching(arg0 context.Context, arg1 entities.MetricType, arg2 string, arg3 entities.PatternSyntax) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteMatching", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeleteMatching indicates an expected call of DeleteMatching.
+func (mr *MockMetricsMockRecorder) DeleteMatching(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMatching", reflect.TypeOf((*MockMetrics)(nil).DeleteMatching), arg0, arg1, arg2, arg3)
+}
+
+// Export mocks base method.
+func (m *MockMetrics) Export(arg0 context.Context, arg1 io.Writer, arg2 storage.Format) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Export", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Export indicates an expected call of Export.
+func (mr *MockMetricsMockRecorder) Export(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Export", reflect.TypeOf((*MockMetrics)(nil).Export), arg0, arg1, arg2)
 }
 
 // Get mocks base method.
-func (m *MockMetrics) Get(arg0 entities.MetricName, arg1 entities.MetricType) (entities.Metrics, error) {
+func (m *MockMetrics) Get(arg0 context.Context, arg1 entities.MetricName, arg2 entities.MetricType) (entities.Metrics, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret := m.ctrl.Call(m, "Get", arg0, arg1, arg2)
 	ret0, _ := ret[0].(entities.Metrics)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get.
-func (mr *MockMetricsMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockMetricsMockRecorder) Get(arg0, arg1, arg2 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMetrics)(nil).Get), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockMetrics)(nil).Get), arg0, arg1, arg2)
 }
 
 // GetAll mocks base method.
-func (m *MockMetrics) GetAll() (*storage.MetricsStorage, error) {
+func (m *MockMetrics) GetAll(arg0 context.Context) (*storage.MetricsStorage, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll")
+	ret := m.ctrl.Call(m, "GetAll", arg0)
 	ret0, _ := ret[0].(*storage.MetricsStorage)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAll indicates an expected call of GetAll.
-func (mr *MockMetricsMockRecorder) GetAll() *gomock.Call {
+func (mr *MockMetricsMockRecorder) GetAll(arg0 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockMetrics)(nil).GetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockMetrics)(nil).GetAll), arg0)
 }
 
 // GetAllByType mocks base method.
-func (m *MockMetrics) GetAllByType(arg0 entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
+func (m *MockMetrics) GetAllByType(arg0 context.Context, arg1 entities.MetricType) (map[entities.MetricName]entities.Metrics, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllByType", arg0)
+	ret := m.ctrl.Call(m, "GetAllByType", arg0, arg1)
 	ret0, _ := ret[0].(map[entities.MetricName]entities.Metrics)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetAllByType indicates an expected call of GetAllByType.
-func (mr *MockMetricsMockRecorder) GetAllByType(arg0 interface{}) *gomock.Call {
+func (mr *MockMetricsMockRecorder) GetAllByType(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByType", reflect.TypeOf((*MockMetrics)(nil).GetAllByType), arg0, arg1)
+}
+
+// GetHistory mocks base method.
+func (m *MockMetrics) GetHistory(arg0 context.Context, arg1 entities.MetricName, arg2 entities.MetricType, arg3, arg4 time.Time, arg5 time.Duration, arg6 entities.Aggregation) ([]entities.Sample, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+	ret0, _ := ret[0].([]entities.Sample)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockMetricsMockRecorder) GetHistory(arg0, arg1, arg2, arg3, arg4, arg5, arg6 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockMetrics)(nil).GetHistory), arg0, arg1, arg2, arg3, arg4, arg5, arg6)
+}
+
+// GetMetadata mocks base method.
+func (m *MockMetrics) GetMetadata(arg0 context.Context, arg1 entities.MetricName) (entities.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMetadata", arg0, arg1)
+	ret0, _ := ret[0].(entities.Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMetadata indicates an expected call of GetMetadata.
+func (mr *MockMetricsMockRecorder) GetMetadata(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMetadata", reflect.TypeOf((*MockMetrics)(nil).GetMetadata), arg0, arg1)
+}
+
+// Heartbeat mocks base method.
+func (m *MockMetrics) Heartbeat(arg0 context.Context, arg1 entities.Heartbeat) (entities.Agent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Heartbeat", arg0, arg1)
+	ret0, _ := ret[0].(entities.Agent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Heartbeat indicates an expected call of Heartbeat.
+func (mr *MockMetricsMockRecorder) Heartbeat(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Heartbeat", reflect.TypeOf((*MockMetrics)(nil).Heartbeat), arg0, arg1)
+}
+
+// Import mocks base method.
+func (m *MockMetrics) Import(arg0 context.Context, arg1 io.Reader, arg2 storage.Format) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", arg0, arg1, arg2)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import.
+func (mr *MockMetricsMockRecorder) Import(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockMetrics)(nil).Import), arg0, arg1, arg2)
+}
+
+// ListAgents mocks base method.
+func (m *MockMetrics) ListAgents(arg0 context.Context) ([]entities.Agent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAgents", arg0)
+	ret0, _ := ret[0].([]entities.Agent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAgents indicates an expected call of ListAgents.
+func (mr *MockMetricsMockRecorder) ListAgents(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAgents", reflect.TypeOf((*MockMetrics)(nil).ListAgents), arg0)
+}
+
+// ListMetadata mocks base method.
+func (m *MockMetrics) ListMetadata(arg0 context.Context) ([]entities.Metadata, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListMetadata", arg0)
+	ret0, _ := ret[0].([]entities.Metadata)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListMetadata indicates an expected call of ListMetadata.
+func (mr *MockMetricsMockRecorder) ListMetadata(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListMetadata", reflect.TypeOf((*MockMetrics)(nil).ListMetadata), arg0)
+}
+
+// Quotas mocks base method.
+func (m *MockMetrics) Quotas(arg0 context.Context) (entities.QuotaReport, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Quotas", arg0)
+	ret0, _ := ret[0].(entities.QuotaReport)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Quotas indicates an expected call of Quotas.
+func (mr *MockMetricsMockRecorder) Quotas(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Quotas", reflect.TypeOf((*MockMetrics)(nil).Quotas), arg0)
+}
+
+// RegisterMetadata mocks base method.
+func (m *MockMetrics) RegisterMetadata(arg0 context.Context, arg1 []entities.Metadata) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RegisterMetadata", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RegisterMetadata indicates an expected call of RegisterMetadata.
+func (mr *MockMetricsMockRecorder) RegisterMetadata(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllByType", reflect.TypeOf((*MockMetrics)(nil).GetAllByType), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterMetadata", reflect.TypeOf((*MockMetrics)(nil).RegisterMetadata), arg0, arg1)
 }
 
 // StoreMetricsBatch mocks base method.
-func (m *MockMetrics) StoreMetricsBatch(arg0 []entities.Metrics) error {
+func (m *MockMetrics) StoreMetricsBatch(arg0 context.Context, arg1 []entities.Metrics) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "StoreMetricsBatch", arg0)
+	ret := m.ctrl.Call(m, "StoreMetricsBatch", arg0, arg1)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // StoreMetricsBatch indicates an expected call of StoreMetricsBatch.
-func (mr *MockMetricsMockRecorder) StoreMetricsBatch(arg0 interface{}) *gomock.Call {
+func (mr *MockMetricsMockRecorder) StoreMetricsBatch(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreMetricsBatch", reflect.TypeOf((*MockMetrics)(nil).StoreMetricsBatch), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreMetricsBatch", reflect.TypeOf((*MockMetrics)(nil).StoreMetricsBatch), arg0, arg1)
 }
@@ -0,0 +1,277 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// quotaReloadInterval is the shortest time between two reloads of the series of a tenant
+// caused by a rejected write, so a client exceeding its quota does not load the storage.
+const quotaReloadInterval = 10 * time.Second
+
+// seriesID identifies a series of a tenant, the series of distinct types are distinct.
+type seriesID struct {
+	key   entities.MetricName
+	mType entities.MetricType
+}
+
+// tenantSeries holds the series of a tenant with the source that created each of them.
+// The series loaded from the storage have no known source.
+type tenantSeries struct {
+	loadedAt time.Time
+	owners   map[seriesID]string // Source that created every series, empty when unknown
+	bySource map[string]int      // Series created by every known source
+	byPrefix []int               // Series starting with every prefix of the policy
+	rejected int                 // Writes rejected for exceeding a quota
+	stale    bool                // Whether series were removed behind the tracker, e.g. deleted
+}
+
+// seriesQuotas tracks the series of every tenant against the quota policy.
+type seriesQuotas struct {
+	policy  *entities.QuotaPolicy
+	tenants map[string]*tenantSeries
+	mu      sync.Mutex
+}
+
+// newSeriesQuotas creates a tracker enforcing the policy, a nil policy sets no limit.
+func newSeriesQuotas(policy *entities.QuotaPolicy) *seriesQuotas {
+	return &seriesQuotas{policy: policy, tenants: make(map[string]*tenantSeries)}
+}
+
+// SetQuotaPolicy sets the limits on the number of series of every tenant, a nil
+// policy sets no limit.
+func (ms *MetricsService) SetQuotaPolicy(policy *entities.QuotaPolicy) {
+	ms.quotas = newSeriesQuotas(policy)
+}
+
+// quotaSource returns the source the series created by a request are counted towards:
+// its agent when it identified one, and its IP address otherwise.
+func quotaSource(ctx context.Context) string {
+	if agent, ok := entities.AgentFromContext(ctx); ok {
+		return agent.ID
+	}
+
+	return entities.SourceFromContext(ctx)
+}
+
+// admit reserves the series the metrics would create for the source of the request. It
+// returns an error wrapping ErrQuotaExceeded if they would exceed a quota, in which case
+// none of them is reserved.
+func (ms *MetricsService) admit(ctx context.Context, metrics []entities.Metrics) error {
+	if !ms.quotas.policy.Enabled() {
+		return nil
+	}
+
+	tenant := entities.TenantFromContext(ctx)
+	if err := ms.loadSeries(ctx, tenant, false); err != nil {
+		return err
+	}
+	err := ms.quotas.reserve(tenant, quotaSource(ctx), metrics)
+	if !errors.Is(err, entities.ErrQuotaExceeded) {
+		return err
+	}
+
+	// Series may have expired since they were loaded, check again against the storage
+	if err = ms.loadSeries(ctx, tenant, true); err != nil {
+		return err
+	}
+	if err = ms.quotas.reserve(tenant, quotaSource(ctx), metrics); err != nil {
+		ms.quotas.reject(tenant)
+		ms.logger.DebugContext(ctx, fmt.Sprintf("write rejected: %v", err))
+	}
+
+	return err
+}
+
+// invalidateSeries reloads the series of the tenant of the request before the next check,
+// after series were removed or written without being reserved.
+func (ms *MetricsService) invalidateSeries(ctx context.Context) {
+	if !ms.quotas.policy.Enabled() {
+		return
+	}
+
+	ms.quotas.mu.Lock()
+	defer ms.quotas.mu.Unlock()
+
+	if ts, ok := ms.quotas.tenants[entities.TenantFromContext(ctx)]; ok {
+		ts.stale = true
+	}
+}
+
+// loadSeries loads the series of a tenant from the storage when they were never loaded
+// or are stale. expired also reloads series loaded more than quotaReloadInterval ago.
+func (ms *MetricsService) loadSeries(ctx context.Context, tenant string, expired bool) error {
+	ms.quotas.mu.Lock()
+	ts, ok := ms.quotas.tenants[tenant]
+	load := !ok || ts.stale || (expired && time.Since(ts.loadedAt) > quotaReloadInterval)
+	ms.quotas.mu.Unlock()
+	if !load {
+		return nil
+	}
+
+	// The storage is read without the lock held
+	all, err := ms.repo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("metrics service: failed to load the series: %w", err)
+	}
+	ids := make([]seriesID, 0, len(all.Counter)+len(all.Gauge)+len(all.Histogram)+len(all.Summary))
+	ids = appendSeriesIDs(ids, entities.CounterMetricName, all.Counter)
+	ids = appendSeriesIDs(ids, entities.GaugeMetricName, all.Gauge)
+	ids = appendSeriesIDs(ids, entities.HistogramMetricName, all.Histogram)
+	ids = appendSeriesIDs(ids, entities.SummaryMetricName, all.Summary)
+
+	ms.quotas.mu.Lock()
+	defer ms.quotas.mu.Unlock()
+	ms.quotas.replace(tenant, ids)
+
+	return nil
+}
+
+// appendSeriesIDs appends the identifiers of the series of a type to ids.
+func appendSeriesIDs[V any](ids []seriesID, mType entities.MetricType, series map[entities.MetricName]V) []seriesID {
+	for key := range series {
+		ids = append(ids, seriesID{key: key, mType: mType})
+	}
+
+	return ids
+}
+
+// replace sets the series of a tenant, keeping the known source of the series already
+// tracked. The caller must hold sq.mu.
+func (sq *seriesQuotas) replace(tenant string, ids []seriesID) {
+	previous := sq.tenants[tenant]
+	ts := &tenantSeries{
+		loadedAt: time.Now(),
+		owners:   make(map[seriesID]string, len(ids)),
+		bySource: make(map[string]int),
+		byPrefix: make([]int, len(sq.policy.Prefixes)),
+	}
+	if previous != nil {
+		ts.rejected = previous.rejected
+	}
+
+	for _, id := range ids {
+		var source string
+		if previous != nil {
+			source = previous.owners[id]
+		}
+		sq.add(ts, id, source)
+	}
+	sq.tenants[tenant] = ts
+}
+
+// add tracks a new series created by source. The caller must hold sq.mu.
+func (sq *seriesQuotas) add(ts *tenantSeries, id seriesID, source string) {
+	ts.owners[id] = source
+	if source != "" {
+		ts.bySource[source]++
+	}
+	for i, q := range sq.policy.Prefixes {
+		if strings.HasPrefix(string(id.key), q.Prefix) {
+			ts.byPrefix[i]++
+		}
+	}
+}
+
+// reserve tracks the new series of the metrics as created by source, unless they would
+// exceed a quota of the tenant. The tenant must be loaded.
+func (sq *seriesQuotas) reserve(tenant, source string, metrics []entities.Metrics) error {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+
+	ts := sq.tenants[tenant]
+	var created []seriesID
+	seen := make(map[seriesID]bool)
+	for _, m := range metrics {
+		id := seriesID{key: m.Key(), mType: entities.MetricType(m.MType)}
+		if _, ok := ts.owners[id]; !ok && !seen[id] {
+			seen[id] = true
+			created = append(created, id)
+		}
+	}
+	if len(created) == 0 {
+		return nil
+	}
+
+	p := sq.policy
+	if p.MaxSeries > 0 && len(ts.owners)+len(created) > p.MaxSeries {
+		return fmt.Errorf("%w: %d new series would exceed the limit of %d series, %d are already stored",
+			entities.ErrQuotaExceeded, len(created), p.MaxSeries, len(ts.owners))
+	}
+	if source != "" && p.MaxSeriesPerSource > 0 && ts.bySource[source]+len(created) > p.MaxSeriesPerSource {
+		return fmt.Errorf("%w: %d new series would exceed the limit of %d series of source %s, "+
+			"which created %d already", entities.ErrQuotaExceeded, len(created), p.MaxSeriesPerSource,
+			source, ts.bySource[source])
+	}
+	for i, q := range p.Prefixes {
+		n := 0
+		for _, id := range created {
+			if strings.HasPrefix(string(id.key), q.Prefix) {
+				n++
+			}
+		}
+		if n > 0 && ts.byPrefix[i]+n > q.MaxSeries {
+			return fmt.Errorf("%w: %d new series would exceed the limit of %d series starting with %q, "+
+				"%d are already stored", entities.ErrQuotaExceeded, n, q.MaxSeries, q.Prefix, ts.byPrefix[i])
+		}
+	}
+
+	for _, id := range created {
+		sq.add(ts, id, source)
+	}
+
+	return nil
+}
+
+// reject counts a write of the tenant rejected for exceeding a quota.
+func (sq *seriesQuotas) reject(tenant string) {
+	sq.mu.Lock()
+	defer sq.mu.Unlock()
+
+	sq.tenants[tenant].rejected++
+}
+
+// Quotas returns the limits on the number of series and the usage of every tenant
+// that wrote since the server started, together with the tenant of the request.
+func (ms *MetricsService) Quotas(ctx context.Context) (entities.QuotaReport, error) {
+	report := entities.QuotaReport{Tenants: []entities.QuotaUsage{}}
+	if !ms.quotas.policy.Enabled() {
+		return report, nil
+	}
+	report.Limits = *ms.quotas.policy
+
+	if err := ms.loadSeries(ctx, entities.TenantFromContext(ctx), false); err != nil {
+		return entities.QuotaReport{}, err
+	}
+
+	ms.quotas.mu.Lock()
+	defer ms.quotas.mu.Unlock()
+
+	for tenant, ts := range ms.quotas.tenants {
+		usage := entities.QuotaUsage{
+			Tenant:   tenant,
+			Series:   len(ts.owners),
+			Rejected: ts.rejected,
+			Sources:  maps.Clone(ts.bySource),
+			Prefixes: make(map[string]int, len(ts.byPrefix)),
+		}
+		for i, q := range ms.quotas.policy.Prefixes {
+			usage.Prefixes[q.Prefix] = ts.byPrefix[i]
+		}
+		report.Tenants = append(report.Tenants, usage)
+	}
+	slices.SortFunc(report.Tenants, func(a, b entities.QuotaUsage) int { return strings.Compare(a.Tenant, b.Tenant) })
+
+	return report, nil
+}
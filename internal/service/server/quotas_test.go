@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/domain/repositories"
+	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuotas(t *testing.T) {
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	store := storage.WithNamespaces(memStore)
+
+	// A series stored before the quotas are enforced counts towards the tenant only.
+	gauge := func(name string) entities.Metrics {
+		v := 1.0
+		return entities.Metrics{ID: name, MType: string(entities.GaugeMetricName), Value: &v}
+	}
+	unlimited := NewMetricsService(repositories.NewRepository(store), slog.Default())
+	require.NoError(t, unlimited.Create(ctx, gauge("Alloc")))
+
+	service := NewMetricsService(repositories.NewRepository(store), slog.Default())
+	policy, err := entities.NewQuotaPolicy(5, 2, "cpu_=1")
+	require.NoError(t, err)
+	service.SetQuotaPolicy(policy)
+
+	agentA := entities.WithSource(ctx, "10.0.0.1")
+	agentB := entities.WithSource(ctx, "10.0.0.2")
+	require.NoError(t, service.StoreMetricsBatch(agentA, []entities.Metrics{gauge("a1"), gauge("a2"), gauge("a1")}))
+	require.NoError(t, service.Create(agentA, gauge("a2")), "existing series are always written")
+	require.ErrorIs(t, service.Create(agentA, gauge("a3")), entities.ErrQuotaExceeded)
+
+	require.NoError(t, service.Create(agentB, gauge("cpu_user")))
+	require.ErrorIs(t, service.Create(agentB, gauge("cpu_system")), entities.ErrQuotaExceeded)
+	require.ErrorIs(t, service.StoreMetricsBatch(agentB, []entities.Metrics{gauge("b1"), gauge("b2")}),
+		entities.ErrQuotaExceeded, "a rejected batch reserves none of its series")
+	require.NoError(t, service.Create(agentB, gauge("b1")))
+
+	// The tenant holds Alloc, a1, a2, cpu_user and b1.
+	require.ErrorIs(t, service.Create(entities.WithSource(ctx, "10.0.0.3"), gauge("c1")), entities.ErrQuotaExceeded)
+	require.NoError(t, service.Delete(agentA, "a1", entities.GaugeMetricName))
+	require.NoError(t, service.Create(entities.WithSource(ctx, "10.0.0.3"), gauge("c1")))
+
+	// Every tenant has its own quotas.
+	teamA := entities.WithSource(entities.WithTenant(ctx, "team-a"), "10.0.0.1")
+	require.NoError(t, service.Create(teamA, gauge("a1")))
+
+	report, err := service.Quotas(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, *policy, report.Limits)
+	require.Len(t, report.Tenants, 2)
+	assert.Equal(t, entities.QuotaUsage{
+		Tenant:   "",
+		Series:   5,
+		Sources:  map[string]int{"10.0.0.1": 1, "10.0.0.2": 2, "10.0.0.3": 1},
+		Prefixes: map[string]int{"cpu_": 1},
+		Rejected: 4,
+	}, report.Tenants[0])
+	assert.Equal(t, "team-a", report.Tenants[1].Tenant)
+	assert.Equal(t, 1, report.Tenants[1].Series)
+}
+
+func TestQuotasPerAgent(t *testing.T) {
+	ctx := context.Background()
+	memStore, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	service := NewMetricsService(repositories.NewRepository(memStore), slog.Default())
+	policy, err := entities.NewQuotaPolicy(0, 1, "")
+	require.NoError(t, err)
+	service.SetQuotaPolicy(policy)
+
+	v := 1.0
+	gauge := func(name string) entities.Metrics {
+		return entities.Metrics{ID: name, MType: string(entities.GaugeMetricName), Value: &v}
+	}
+	// Two agents behind the same address have their own quotas.
+	host := entities.WithSource(ctx, "10.0.0.1")
+	require.NoError(t, service.Create(entities.WithAgent(host, entities.AgentIdentity{ID: "a"}), gauge("a1")))
+	require.NoError(t, service.Create(entities.WithAgent(host, entities.AgentIdentity{ID: "b"}), gauge("b1")))
+	require.ErrorIs(t, service.Create(entities.WithAgent(host, entities.AgentIdentity{ID: "b"}), gauge("b2")),
+		entities.ErrQuotaExceeded)
+}
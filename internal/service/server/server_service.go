@@ -3,7 +3,10 @@
 package server
 
 import (
+	"context"
+	"io"
 	"log/slog"
+	"time"
 
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
 	"github.com/mihailtudos/metrickit/internal/domain/repositories"
@@ -12,23 +15,63 @@ import (
 
 // Metrics defines the interface for metrics operations.
 // It includes methods for creating, retrieving, and storing metrics.
+// The context of every call is passed down to the storage, so a canceled
+// request or an expired deadline stops the storage call.
 //
 //go:generate mockgen -destination=mocks/mock_metrics.go -package=mocks github.com/mihailtudos/metrickit/internal/service/server Metrics
 type Metrics interface {
 	// Create adds a new metric to the storage.
-	Create(metric entities.Metrics) error
+	Create(ctx context.Context, metric entities.Metrics) error
 
 	// Get retrieves a metric by its name and type.
-	Get(mName entities.MetricName, mType entities.MetricType) (entities.Metrics, error)
+	Get(ctx context.Context, mName entities.MetricName, mType entities.MetricType) (entities.Metrics, error)
 
 	// GetAll retrieves all metrics from the storage.
-	GetAll() (*storage.MetricsStorage, error)
+	GetAll(ctx context.Context) (*storage.MetricsStorage, error)
 
 	// GetAllByType retrieves all metrics of a specific type from the storage.
-	GetAllByType(mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error)
+	GetAllByType(ctx context.Context, mType entities.MetricType) (map[entities.MetricName]entities.Metrics, error)
 
 	// StoreMetricsBatch stores a batch of metrics in the storage.
-	StoreMetricsBatch(metrics []entities.Metrics) error
+	StoreMetricsBatch(ctx context.Context, metrics []entities.Metrics) error
+
+	// GetHistory retrieves the samples of a series within a time range, optionally
+	// downsampled into buckets of the given step using the aggregation function.
+	GetHistory(ctx context.Context, mName entities.MetricName, mType entities.MetricType,
+		from, to time.Time, step time.Duration, agg entities.Aggregation) ([]entities.Sample, error)
+
+	// Delete removes a metric and its history from the storage.
+	Delete(ctx context.Context, mName entities.MetricName, mType entities.MetricType) error
+
+	// DeleteMatching removes the metrics whose series key matches a glob or regex pattern.
+	// An empty mType selects metrics of all types. It returns the number of removed metrics.
+	DeleteMatching(ctx context.Context, mType entities.MetricType, pattern string,
+		syntax entities.PatternSyntax) (int, error)
+
+	// Export writes every metric to w in the given format. It returns the number of exported metrics.
+	Export(ctx context.Context, w io.Writer, format storage.Format) (int, error)
+
+	// Import reads metrics in the given format from r and replaces their series with the read
+	// values, restoring counters to their totals. It returns the number of imported metrics.
+	Import(ctx context.Context, r io.Reader, format storage.Format) (int, error)
+
+	// RegisterMetadata validates and registers the unit, description and display hint of metric names.
+	RegisterMetadata(ctx context.Context, metadata []entities.Metadata) error
+
+	// GetMetadata retrieves the metadata registered for a metric name.
+	GetMetadata(ctx context.Context, name entities.MetricName) (entities.Metadata, error)
+
+	// ListMetadata retrieves all the registered metadata sorted by metric name.
+	ListMetadata(ctx context.Context) ([]entities.Metadata, error)
+
+	// Quotas retrieves the limits on the number of series and the usage of the tenants.
+	Quotas(ctx context.Context) (entities.QuotaReport, error)
+
+	// ListAgents retrieves the agents seen within the last day.
+	ListAgents(ctx context.Context) ([]entities.Agent, error)
+
+	// Heartbeat records that the agent of the request is alive and the interval it reports at.
+	Heartbeat(ctx context.Context, heartbeat entities.Heartbeat) (entities.Agent, error)
 }
 
 // Service provides methods for managing metrics.
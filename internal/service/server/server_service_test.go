@@ -3,13 +3,17 @@ package server
 import (
 	"context"
 	"log"
+	"log/slog"
 	"os"
 	"testing"
 
 	"github.com/mihailtudos/metrickit/internal/config"
 	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/mihailtudos/metrickit/internal/domain/repositories"
 	"github.com/mihailtudos/metrickit/internal/infrastructure/storage"
 	"github.com/mihailtudos/metrickit/internal/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCounterService(t *testing.T) {
@@ -19,7 +23,10 @@ func TestCounterService(t *testing.T) {
 	}
 
 	ctx := context.Background()
-	store, err := storage.NewStorage(nil, l, config.DefaultStoreInterval, config.DefaultStorePath)
+	store, err := storage.Open(ctx, "file://"+config.DefaultStorePath, storage.Dependencies{
+		Logger: l,
+		File:   storage.FileOptions{StoreInterval: config.DefaultStoreInterval, SnapshotsKept: config.DefaultSnapshotsKept},
+	})
 	if err != nil {
 		log.Fatal("failed to initiate storage: " + err.Error())
 	}
@@ -59,3 +66,27 @@ func TestCounterService(t *testing.T) {
 		})
 	}
 }
+
+func TestStoreMetricsBatchMaxSize(t *testing.T) {
+	store, err := storage.NewMemStorage(slog.Default())
+	require.NoError(t, err)
+	service := NewMetricsService(repositories.NewRepository(store), slog.Default())
+	service.SetMaxBatchSize(2)
+
+	delta := int64(1)
+	batch := []entities.Metrics{
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+		{ID: "PollCount", MType: "counter", Delta: &delta},
+	}
+	require.NoError(t, service.StoreMetricsBatch(context.Background(), batch))
+
+	err = service.StoreMetricsBatch(context.Background(), append(batch, batch[0]))
+	require.ErrorIs(t, err, ErrBatchTooLarge)
+
+	record, err := service.Get(context.Background(), "PollCount", entities.CounterMetricName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), *record.Delta, "a rejected batch is not stored")
+
+	service.SetMaxBatchSize(0)
+	require.NoError(t, service.StoreMetricsBatch(context.Background(), append(batch, batch[0])))
+}
@@ -0,0 +1,48 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"fmt"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+// Tenants holds the tenants sharing the server. The series of every tenant live in their
+// own namespace, selected by the tenant carried in the context of the metrics calls.
+type Tenants interface {
+	// Create adds a tenant and returns it together with its API key, which is not kept.
+	Create(name string) (entities.TenantCredentials, error)
+
+	// List returns the tenants sorted by name.
+	List() []entities.Tenant
+
+	// Lookup returns the tenant of the given name, or entities.ErrUnknownTenant.
+	Lookup(name string) (entities.Tenant, error)
+
+	// Authenticate returns the tenant owning the API key, or entities.ErrUnknownTenant.
+	Authenticate(apiKey string) (entities.Tenant, error)
+}
+
+// ResolveTenant returns the tenant of a request given its API key or, when the tenant
+// header is trusted, the tenant name it carries. The API key takes precedence. A request
+// with neither uses the default namespace, returned as an empty name.
+func ResolveTenant(tenants Tenants, apiKey, name string) (string, error) {
+	switch {
+	case apiKey != "":
+		tenant, err := tenants.Authenticate(apiKey)
+		if err != nil {
+			return "", fmt.Errorf("invalid API key: %w", err)
+		}
+		return tenant.Name, nil
+	case name != "":
+		tenant, err := tenants.Lookup(name)
+		if err != nil {
+			return "", fmt.Errorf("invalid tenant header: %w", err)
+		}
+		return tenant.Name, nil
+	default:
+		return "", nil
+	}
+}
@@ -0,0 +1,242 @@
+// Package server provides the MetricsService, which offers methods for
+// creating, retrieving, and managing metrics. It interacts with a repository
+// to store and fetch metrics data, and utilizes a logger for debugging and error tracking.
+package server
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+)
+
+const (
+	// DefaultMaxNameLength is the longest metric name and label name accepted by default.
+	DefaultMaxNameLength = 255
+	// DefaultMaxLabelValueLength is the longest label value accepted by default.
+	DefaultMaxLabelValueLength = 1024
+)
+
+// ErrInvalidMetric is returned for metrics rejected by the validation policy.
+// The error is a *ValidationError listing the rejected fields.
+var ErrInvalidMetric = errors.New("invalid metric")
+
+var (
+	// metricNameRe matches the accepted metric names, e.g. HeapAlloc or http.requests_total.
+	metricNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.:-]*$`)
+	// labelNameRe matches the accepted label names, e.g. host.
+	labelNameRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+)
+
+// ValidationPolicy decides which metrics are accepted by every ingestion path:
+// the HTTP uploads, the gRPC calls and the imports.
+type ValidationPolicy struct {
+	MaxNameLength       int  // Longest metric or label name, zero for DefaultMaxNameLength
+	MaxLabelValueLength int  // Longest label value, zero for DefaultMaxLabelValueLength
+	AllowNonFinite      bool // Whether gauges and histogram sums may be NaN or infinite
+	AllowNegativeGauges bool // Whether gauges may be negative
+}
+
+// DefaultValidationPolicy returns the policy applied unless another one is set:
+// finite values only and no negative gauge, as the gRPC API always required.
+func DefaultValidationPolicy() ValidationPolicy {
+	return ValidationPolicy{MaxNameLength: DefaultMaxNameLength, MaxLabelValueLength: DefaultMaxLabelValueLength}
+}
+
+// FieldError tells why a field of a metric was rejected.
+type FieldError struct {
+	cause  error  // Sentinel error matched by errors.Is, e.g. ErrInvalidCounter
+	Field  string `json:"field"`  // Path of the field, e.g. metrics[2].value
+	Reason string `json:"reason"` // Why the field was rejected
+}
+
+// ValidationError lists every field rejected by the validation policy. It matches
+// ErrInvalidMetric and the errors causing the rejections, e.g. ErrInvalidCounter.
+type ValidationError struct {
+	Fields []FieldError `json:"errors"` // Rejected fields in the order of the request
+}
+
+// Error returns the rejected fields joined in a single message.
+func (ve *ValidationError) Error() string {
+	reasons := make([]string, 0, len(ve.Fields))
+	for _, f := range ve.Fields {
+		reasons = append(reasons, f.Field+": "+f.Reason)
+	}
+
+	return fmt.Sprintf("%s: %s", ErrInvalidMetric, strings.Join(reasons, "; "))
+}
+
+// Unwrap returns ErrInvalidMetric and the causes of the rejections.
+func (ve *ValidationError) Unwrap() []error {
+	errs := []error{ErrInvalidMetric}
+	for _, f := range ve.Fields {
+		if f.cause != nil {
+			errs = append(errs, f.cause)
+		}
+	}
+
+	return errs
+}
+
+// validator collects the fields rejected while validating a request.
+type validator struct {
+	policy ValidationPolicy
+	fields []FieldError
+}
+
+// reject records a rejected field.
+func (v *validator) reject(field string, cause error, format string, args ...any) {
+	v.fields = append(v.fields, FieldError{Field: field, Reason: fmt.Sprintf(format, args...), cause: cause})
+}
+
+// Validate checks a metric against the policy, its fields are named after their JSON
+// names, e.g. value. It returns a *ValidationError listing every rejected field, or nil.
+func (p ValidationPolicy) Validate(m entities.Metrics) error {
+	v := &validator{policy: p}
+	v.metric("", m)
+
+	return v.err()
+}
+
+// ValidateBatch checks a batch of metrics against the policy, the fields are prefixed
+// by the position of their metric, e.g. metrics[2].value. It returns a *ValidationError
+// listing every rejected field of the batch, or nil.
+func (p ValidationPolicy) ValidateBatch(metrics []entities.Metrics) error {
+	v := &validator{policy: p}
+	for i, m := range metrics {
+		v.metric(fmt.Sprintf("metrics[%d].", i), m)
+	}
+
+	return v.err()
+}
+
+// err returns the *ValidationError listing the rejected fields, or nil if there are none.
+func (v *validator) err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{Fields: v.fields}
+}
+
+// metric validates the name, labels and value of a metric.
+func (v *validator) metric(prefix string, m entities.Metrics) {
+	v.name(prefix+"id", m.ID)
+	v.labels(prefix+"labels", m.Labels)
+
+	switch entities.MetricType(m.MType) {
+	case entities.CounterMetricName:
+		v.counter(prefix, m)
+	case entities.GaugeMetricName:
+		v.gauge(prefix+"value", m.Value)
+	case entities.HistogramMetricName:
+		v.histogram(prefix+"histogram", m.Histogram)
+	case entities.SummaryMetricName:
+		if m.Summary == nil {
+			v.reject(prefix+"summary", entities.ErrInvalidSummary, "is required for a summary")
+		} else if err := m.Summary.Validate(); err != nil {
+			v.reject(prefix+"summary", entities.ErrInvalidSummary, "%v", err)
+		}
+	default:
+		v.reject(prefix+"type", nil, "must be counter, gauge, histogram or summary, got %q", m.MType)
+	}
+	if m.Total != nil && m.MType != string(entities.CounterMetricName) {
+		v.reject(prefix+"total", ErrInvalidCounter, "only counters take a total")
+	}
+}
+
+// name validates a metric name.
+func (v *validator) name(field, name string) {
+	switch {
+	case name == "":
+		v.reject(field, nil, "is required")
+	case len(name) > v.maxNameLength():
+		v.reject(field, nil, "must be at most %d characters long", v.maxNameLength())
+	case !metricNameRe.MatchString(name):
+		v.reject(field, nil, "must start with a letter or '_' and hold only letters, digits, '_', '.', ':' and '-'")
+	}
+}
+
+// labels validates the names and values of the labels, in the order of their names.
+func (v *validator) labels(field string, labels entities.Labels) {
+	maxValue := v.policy.MaxLabelValueLength
+	if maxValue <= 0 {
+		maxValue = DefaultMaxLabelValueLength
+	}
+
+	for _, k := range sortedLabelNames(labels) {
+		switch {
+		case len(k) > v.maxNameLength():
+			v.reject(field, nil, "label names must be at most %d characters long", v.maxNameLength())
+		case !labelNameRe.MatchString(k):
+			v.reject(field+"."+k, nil, "label name must start with a letter or '_' and hold only letters, digits and '_'")
+		case len(labels[k]) > maxValue:
+			v.reject(field+"."+k, nil, "must be at most %d characters long", maxValue)
+		case !utf8.ValidString(labels[k]):
+			v.reject(field+"."+k, nil, "must be valid UTF-8")
+		}
+	}
+}
+
+// counter validates the delta or the total of a counter, exactly one of them is required.
+func (v *validator) counter(prefix string, m entities.Metrics) {
+	switch {
+	case m.Delta == nil && m.Total == nil:
+		v.reject(prefix+"delta", ErrInvalidCounter, "a counter requires a delta or a total")
+	case m.Delta != nil && m.Total != nil:
+		v.reject(prefix+"total", ErrInvalidCounter, "a counter takes either a delta or a total, not both")
+	case m.Delta != nil && *m.Delta < 0:
+		v.reject(prefix+"delta", ErrInvalidCounter, "must not be negative, got %d", *m.Delta)
+	case m.Total != nil && *m.Total < 0:
+		v.reject(prefix+"total", ErrInvalidCounter, "must not be negative, got %d", *m.Total)
+	}
+}
+
+// gauge validates the value of a gauge.
+func (v *validator) gauge(field string, value *float64) {
+	switch {
+	case value == nil:
+		v.reject(field, nil, "is required for a gauge")
+	case !v.policy.AllowNonFinite && (math.IsNaN(*value) || math.IsInf(*value, 0)):
+		v.reject(field, nil, "must be a finite number, got %v", *value)
+	case !v.policy.AllowNegativeGauges && *value < 0:
+		v.reject(field, nil, "must not be negative, got %v", *value)
+	}
+}
+
+// histogram validates the buckets and the sum of a histogram.
+func (v *validator) histogram(field string, h *entities.Histogram) {
+	switch {
+	case h == nil:
+		v.reject(field, entities.ErrInvalidHistogram, "is required for a histogram")
+	case h.Validate() != nil:
+		v.reject(field, entities.ErrInvalidHistogram, "%v", h.Validate())
+	case !v.policy.AllowNonFinite && (math.IsNaN(h.Sum) || math.IsInf(h.Sum, 0)):
+		v.reject(field+".sum", entities.ErrInvalidHistogram, "must be a finite number, got %v", h.Sum)
+	}
+}
+
+// maxNameLength returns the longest metric or label name accepted by the policy.
+func (v *validator) maxNameLength() int {
+	if v.policy.MaxNameLength <= 0 {
+		return DefaultMaxNameLength
+	}
+
+	return v.policy.MaxNameLength
+}
+
+// sortedLabelNames returns the names of the labels in alphabetical order.
+func sortedLabelNames(labels entities.Labels) []string {
+	names := make([]string, 0, len(labels))
+	for k := range labels {
+		names = append(names, k)
+	}
+	slices.Sort(names)
+
+	return names
+}
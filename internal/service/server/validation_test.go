@@ -0,0 +1,130 @@
+package server
+
+import (
+	"errors"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/mihailtudos/metrickit/internal/domain/entities"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidationPolicy(t *testing.T) {
+	int64Ptr := func(i int64) *int64 { return &i }
+	float64Ptr := func(f float64) *float64 { return &f }
+
+	tests := []struct {
+		name   string
+		metric entities.Metrics
+		fields []string // Rejected fields, none for a valid metric
+	}{
+		{
+			name:   "valid counter",
+			metric: entities.Metrics{ID: "counter1", MType: "counter", Delta: int64Ptr(10)},
+		},
+		{
+			name:   "valid cumulative counter",
+			metric: entities.Metrics{ID: "counter1", MType: "counter", Total: int64Ptr(10)},
+		},
+		{
+			name:   "counter without a delta",
+			metric: entities.Metrics{ID: "counter2", MType: "counter"},
+			fields: []string{"delta"},
+		},
+		{
+			name:   "negative delta",
+			metric: entities.Metrics{ID: "counter3", MType: "counter", Delta: int64Ptr(-1)},
+			fields: []string{"delta"},
+		},
+		{
+			name: "valid labelled gauge",
+			metric: entities.Metrics{
+				ID: "gauge1", MType: "gauge", Value: float64Ptr(10.5), Labels: entities.Labels{"host": "a"},
+			},
+		},
+		{
+			name:   "gauge without a value",
+			metric: entities.Metrics{ID: "gauge2", MType: "gauge"},
+			fields: []string{"value"},
+		},
+		{
+			name:   "NaN gauge",
+			metric: entities.Metrics{ID: "gauge3", MType: "gauge", Value: float64Ptr(math.NaN())},
+			fields: []string{"value"},
+		},
+		{
+			name:   "negative gauge",
+			metric: entities.Metrics{ID: "gauge4", MType: "gauge", Value: float64Ptr(-1)},
+			fields: []string{"value"},
+		},
+		{
+			name:   "invalid type",
+			metric: entities.Metrics{ID: "invalidMetric", MType: "invalid"},
+			fields: []string{"type"},
+		},
+		{
+			name:   "every invalid field",
+			metric: entities.Metrics{ID: "cpu usage", MType: "gauge", Labels: entities.Labels{"bad-key": "x"}},
+			fields: []string{"id", "labels.bad-key", "value"},
+		},
+		{
+			name:   "name too long",
+			metric: entities.Metrics{ID: strings.Repeat("a", DefaultMaxNameLength+1), MType: "counter", Delta: int64Ptr(1)},
+			fields: []string{"id"},
+		},
+		{
+			name:   "histogram without buckets",
+			metric: entities.Metrics{ID: "latency", MType: "histogram"},
+			fields: []string{"histogram"},
+		},
+		{
+			name:   "total of a gauge",
+			metric: entities.Metrics{ID: "gauge5", MType: "gauge", Value: float64Ptr(1), Total: int64Ptr(1)},
+			fields: []string{"total"},
+		},
+	}
+
+	policy := DefaultValidationPolicy()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.metric)
+			if len(tt.fields) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			require.ErrorIs(t, err, ErrInvalidMetric)
+			var ve *ValidationError
+			require.True(t, errors.As(err, &ve))
+			fields := make([]string, 0, len(ve.Fields))
+			for _, f := range ve.Fields {
+				fields = append(fields, f.Field)
+			}
+			assert.Equal(t, tt.fields, fields)
+		})
+	}
+
+	t.Run("counter errors keep their cause", func(t *testing.T) {
+		err := policy.Validate(entities.Metrics{ID: "c", MType: "counter", Delta: int64Ptr(1), Total: int64Ptr(1)})
+		require.ErrorIs(t, err, ErrInvalidCounter)
+	})
+
+	t.Run("relaxed policy", func(t *testing.T) {
+		relaxed := ValidationPolicy{AllowNonFinite: true, AllowNegativeGauges: true}
+		require.NoError(t, relaxed.Validate(entities.Metrics{ID: "g", MType: "gauge", Value: float64Ptr(math.Inf(-1))}))
+		require.NoError(t, relaxed.Validate(entities.Metrics{ID: "g", MType: "gauge", Value: float64Ptr(-1)}))
+	})
+
+	t.Run("batch fields are prefixed", func(t *testing.T) {
+		err := policy.ValidateBatch([]entities.Metrics{
+			{ID: "ok", MType: "counter", Delta: int64Ptr(1)},
+			{ID: "bad", MType: "gauge"},
+		})
+		var ve *ValidationError
+		require.True(t, errors.As(err, &ve))
+		require.Len(t, ve.Fields, 1)
+		assert.Equal(t, "metrics[1].value", ve.Fields[0].Field)
+	})
+}
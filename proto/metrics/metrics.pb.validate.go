@@ -35,6 +35,224 @@ var (
 	_       = sort.Sort
 )
 
+// Validate checks the field values on Histogram with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Histogram) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Histogram with the rules defined in
+// the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in HistogramMultiError, or nil
+// if none found.
+func (m *Histogram) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Histogram) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Sum
+
+	// no validation rules for Count
+
+	if len(errors) > 0 {
+		return HistogramMultiError(errors)
+	}
+
+	return nil
+}
+
+// HistogramMultiError is an error wrapping multiple validation errors returned
+// by Histogram.ValidateAll() if the designated constraints aren't met.
+type HistogramMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m HistogramMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m HistogramMultiError) AllErrors() []error { return m }
+
+// HistogramValidationError is the validation error returned by
+// Histogram.Validate if the designated constraints aren't met.
+type HistogramValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e HistogramValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e HistogramValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e HistogramValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e HistogramValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e HistogramValidationError) ErrorName() string { return "HistogramValidationError" }
+
+// Error satisfies the builtin error interface
+func (e HistogramValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sHistogram.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = HistogramValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = HistogramValidationError{}
+
+// Validate checks the field values on Summary with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Summary) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Summary with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in SummaryMultiError, or nil if none found.
+func (m *Summary) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Summary) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if len(m.GetSketch()) < 1 {
+		err := SummaryValidationError{
+			field:  "Sketch",
+			reason: "value length must be at least 1 bytes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Quantiles
+
+	// no validation rules for Count
+
+	// no validation rules for Sum
+
+	if len(errors) > 0 {
+		return SummaryMultiError(errors)
+	}
+
+	return nil
+}
+
+// SummaryMultiError is an error wrapping multiple validation errors returned
+// by Summary.ValidateAll() if the designated constraints aren't met.
+type SummaryMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m SummaryMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m SummaryMultiError) AllErrors() []error { return m }
+
+// SummaryValidationError is the validation error returned by Summary.Validate
+// if the designated constraints aren't met.
+type SummaryValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e SummaryValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e SummaryValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e SummaryValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e SummaryValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e SummaryValidationError) ErrorName() string { return "SummaryValidationError" }
+
+// Error satisfies the builtin error interface
+func (e SummaryValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sSummary.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = SummaryValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = SummaryValidationError{}
+
 // Validate checks the field values on Metric with the rules defined in the
 // proto definition for this message. If any rules are violated, the first
 // error encountered is returned, or nil if there are no violations.
@@ -42,85 +260,2252 @@ func (m *Metric) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on Metric with the rules defined in the
-// proto definition for this message. If any rules are violated, the result is
-// a list of violation errors wrapped in MetricMultiError, or nil if none found.
-func (m *Metric) ValidateAll() error {
-	return m.validate(true)
-}
+// ValidateAll checks the field values on Metric with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in MetricMultiError, or nil if none found.
+func (m *Metric) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Metric) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Id
+
+	// no validation rules for MType
+
+	// no validation rules for Labels
+
+	if all {
+		switch v := interface{}(m.GetHistogram()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, MetricValidationError{
+					field:  "Histogram",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, MetricValidationError{
+					field:  "Histogram",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetHistogram()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return MetricValidationError{
+				field:  "Histogram",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetSummary()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, MetricValidationError{
+					field:  "Summary",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, MetricValidationError{
+					field:  "Summary",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetSummary()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return MetricValidationError{
+				field:  "Summary",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetMetadata()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, MetricValidationError{
+					field:  "Metadata",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, MetricValidationError{
+					field:  "Metadata",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetMetadata()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return MetricValidationError{
+				field:  "Metadata",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if m.Value != nil {
+		// no validation rules for Value
+	}
+
+	if m.Delta != nil {
+		// no validation rules for Delta
+	}
+
+	if m.Total != nil {
+		// no validation rules for Total
+	}
+
+	if m.Rate != nil {
+		// no validation rules for Rate
+	}
+
+	if len(errors) > 0 {
+		return MetricMultiError(errors)
+	}
+
+	return nil
+}
+
+// MetricMultiError is an error wrapping multiple validation errors returned by
+// Metric.ValidateAll() if the designated constraints aren't met.
+type MetricMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m MetricMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m MetricMultiError) AllErrors() []error { return m }
+
+// MetricValidationError is the validation error returned by Metric.Validate if
+// the designated constraints aren't met.
+type MetricValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e MetricValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e MetricValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e MetricValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e MetricValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e MetricValidationError) ErrorName() string { return "MetricValidationError" }
+
+// Error satisfies the builtin error interface
+func (e MetricValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sMetric.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = MetricValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = MetricValidationError{}
+
+// Validate checks the field values on MetricMetadata with the rules defined in
+// the proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *MetricMetadata) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on MetricMetadata with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// result is a list of violation errors wrapped in MetricMetadataMultiError,
+// or nil if none found.
+func (m *MetricMetadata) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *MetricMetadata) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetName()) < 1 {
+		err := MetricMetadataValidationError{
+			field:  "Name",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if strings.Contains(m.GetName(), "{") {
+		err := MetricMetadataValidationError{
+			field:  "Name",
+			reason: "value contains substring \"{\"",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _MetricMetadata_Kind_InLookup[m.GetKind()]; !ok {
+		err := MetricMetadataValidationError{
+			field:  "Kind",
+			reason: "value must be in list [ gauge counter histogram summary]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	// no validation rules for Unit
+
+	// no validation rules for Description
+
+	if _, ok := _MetricMetadata_Display_InLookup[m.GetDisplay()]; !ok {
+		err := MetricMetadataValidationError{
+			field:  "Display",
+			reason: "value must be in list [ bytes duration percent]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return MetricMetadataMultiError(errors)
+	}
+
+	return nil
+}
+
+// MetricMetadataMultiError is an error wrapping multiple validation errors
+// returned by MetricMetadata.ValidateAll() if the designated constraints
+// aren't met.
+type MetricMetadataMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m MetricMetadataMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m MetricMetadataMultiError) AllErrors() []error { return m }
+
+// MetricMetadataValidationError is the validation error returned by
+// MetricMetadata.Validate if the designated constraints aren't met.
+type MetricMetadataValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e MetricMetadataValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e MetricMetadataValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e MetricMetadataValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e MetricMetadataValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e MetricMetadataValidationError) ErrorName() string { return "MetricMetadataValidationError" }
+
+// Error satisfies the builtin error interface
+func (e MetricMetadataValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sMetricMetadata.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = MetricMetadataValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = MetricMetadataValidationError{}
+
+var _MetricMetadata_Kind_InLookup = map[string]struct{}{
+	"":          {},
+	"gauge":     {},
+	"counter":   {},
+	"histogram": {},
+	"summary":   {},
+}
+
+var _MetricMetadata_Display_InLookup = map[string]struct{}{
+	"":         {},
+	"bytes":    {},
+	"duration": {},
+	"percent":  {},
+}
+
+// Validate checks the field values on CreateMetricRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateMetricRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateMetricRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateMetricRequestMultiError, or nil if none found.
+func (m *CreateMetricRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateMetricRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetMetric()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, CreateMetricRequestValidationError{
+					field:  "Metric",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, CreateMetricRequestValidationError{
+					field:  "Metric",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetMetric()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return CreateMetricRequestValidationError{
+				field:  "Metric",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if len(errors) > 0 {
+		return CreateMetricRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateMetricRequestMultiError is an error wrapping multiple validation
+// errors returned by CreateMetricRequest.ValidateAll() if the designated
+// constraints aren't met.
+type CreateMetricRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateMetricRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateMetricRequestMultiError) AllErrors() []error { return m }
+
+// CreateMetricRequestValidationError is the validation error returned by
+// CreateMetricRequest.Validate if the designated constraints aren't met.
+type CreateMetricRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateMetricRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateMetricRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateMetricRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateMetricRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateMetricRequestValidationError) ErrorName() string {
+	return "CreateMetricRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateMetricRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateMetricRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateMetricRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateMetricRequestValidationError{}
+
+// Validate checks the field values on CreateMetricResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateMetricResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateMetricResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateMetricResponseMultiError, or nil if none found.
+func (m *CreateMetricResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateMetricResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Message
+
+	if len(errors) > 0 {
+		return CreateMetricResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateMetricResponseMultiError is an error wrapping multiple validation
+// errors returned by CreateMetricResponse.ValidateAll() if the designated
+// constraints aren't met.
+type CreateMetricResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateMetricResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateMetricResponseMultiError) AllErrors() []error { return m }
+
+// CreateMetricResponseValidationError is the validation error returned by
+// CreateMetricResponse.Validate if the designated constraints aren't met.
+type CreateMetricResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateMetricResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateMetricResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateMetricResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateMetricResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateMetricResponseValidationError) ErrorName() string {
+	return "CreateMetricResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateMetricResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateMetricResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateMetricResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateMetricResponseValidationError{}
+
+// Validate checks the field values on CreateMetricsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateMetricsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateMetricsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateMetricsRequestMultiError, or nil if none found.
+func (m *CreateMetricsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateMetricsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetMetrics() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, CreateMetricsRequestValidationError{
+						field:  fmt.Sprintf("Metrics[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, CreateMetricsRequestValidationError{
+						field:  fmt.Sprintf("Metrics[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return CreateMetricsRequestValidationError{
+					field:  fmt.Sprintf("Metrics[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	if len(errors) > 0 {
+		return CreateMetricsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateMetricsRequestMultiError is an error wrapping multiple validation
+// errors returned by CreateMetricsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type CreateMetricsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateMetricsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateMetricsRequestMultiError) AllErrors() []error { return m }
+
+// CreateMetricsRequestValidationError is the validation error returned by
+// CreateMetricsRequest.Validate if the designated constraints aren't met.
+type CreateMetricsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateMetricsRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateMetricsRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateMetricsRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateMetricsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateMetricsRequestValidationError) ErrorName() string {
+	return "CreateMetricsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateMetricsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateMetricsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateMetricsRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateMetricsRequestValidationError{}
+
+// Validate checks the field values on CreateMetricsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *CreateMetricsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on CreateMetricsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// CreateMetricsResponseMultiError, or nil if none found.
+func (m *CreateMetricsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *CreateMetricsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Message
+
+	if len(errors) > 0 {
+		return CreateMetricsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// CreateMetricsResponseMultiError is an error wrapping multiple validation
+// errors returned by CreateMetricsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type CreateMetricsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m CreateMetricsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m CreateMetricsResponseMultiError) AllErrors() []error { return m }
+
+// CreateMetricsResponseValidationError is the validation error returned by
+// CreateMetricsResponse.Validate if the designated constraints aren't met.
+type CreateMetricsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e CreateMetricsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e CreateMetricsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e CreateMetricsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e CreateMetricsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e CreateMetricsResponseValidationError) ErrorName() string {
+	return "CreateMetricsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e CreateMetricsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sCreateMetricsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = CreateMetricsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = CreateMetricsResponseValidationError{}
+
+// Validate checks the field values on GetMetricRequest with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *GetMetricRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetMetricRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetMetricRequestMultiError, or nil if none found.
+func (m *GetMetricRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetMetricRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := GetMetricRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _GetMetricRequest_MType_InLookup[m.GetMType()]; !ok {
+		err := GetMetricRequestValidationError{
+			field:  "MType",
+			reason: "value must be in list [gauge counter histogram summary]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	{
+		sorted_keys := make([]string, len(m.GetLabels()))
+		i := 0
+		for key := range m.GetLabels() {
+			sorted_keys[i] = key
+			i++
+		}
+		sort.Slice(sorted_keys, func(i, j int) bool { return sorted_keys[i] < sorted_keys[j] })
+		for _, key := range sorted_keys {
+			val := m.GetLabels()[key]
+			_ = val
+
+			if utf8.RuneCountInString(key) < 1 {
+				err := GetMetricRequestValidationError{
+					field:  fmt.Sprintf("Labels[%v]", key),
+					reason: "value length must be at least 1 runes",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+			// no validation rules for Labels[key]
+		}
+	}
+
+	if len(errors) > 0 {
+		return GetMetricRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetMetricRequestMultiError is an error wrapping multiple validation errors
+// returned by GetMetricRequest.ValidateAll() if the designated constraints
+// aren't met.
+type GetMetricRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetMetricRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetMetricRequestMultiError) AllErrors() []error { return m }
+
+// GetMetricRequestValidationError is the validation error returned by
+// GetMetricRequest.Validate if the designated constraints aren't met.
+type GetMetricRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetMetricRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetMetricRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetMetricRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetMetricRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetMetricRequestValidationError) ErrorName() string { return "GetMetricRequestValidationError" }
+
+// Error satisfies the builtin error interface
+func (e GetMetricRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetMetricRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetMetricRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetMetricRequestValidationError{}
+
+var _GetMetricRequest_MType_InLookup = map[string]struct{}{
+	"gauge":     {},
+	"counter":   {},
+	"histogram": {},
+	"summary":   {},
+}
+
+// Validate checks the field values on GetMetricResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *GetMetricResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetMetricResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetMetricResponseMultiError, or nil if none found.
+func (m *GetMetricResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetMetricResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetMetric()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetMetricResponseValidationError{
+					field:  "Metric",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetMetricResponseValidationError{
+					field:  "Metric",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetMetric()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetMetricResponseValidationError{
+				field:  "Metric",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Message
+
+	if len(errors) > 0 {
+		return GetMetricResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetMetricResponseMultiError is an error wrapping multiple validation errors
+// returned by GetMetricResponse.ValidateAll() if the designated constraints
+// aren't met.
+type GetMetricResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetMetricResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetMetricResponseMultiError) AllErrors() []error { return m }
+
+// GetMetricResponseValidationError is the validation error returned by
+// GetMetricResponse.Validate if the designated constraints aren't met.
+type GetMetricResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetMetricResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetMetricResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetMetricResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetMetricResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetMetricResponseValidationError) ErrorName() string {
+	return "GetMetricResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetMetricResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetMetricResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetMetricResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetMetricResponseValidationError{}
+
+// Validate checks the field values on GetMetricsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetMetricsResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetMetricsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetMetricsResponseMultiError, or nil if none found.
+func (m *GetMetricsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetMetricsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetMetric() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, GetMetricsResponseValidationError{
+						field:  fmt.Sprintf("Metric[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, GetMetricsResponseValidationError{
+						field:  fmt.Sprintf("Metric[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetMetricsResponseValidationError{
+					field:  fmt.Sprintf("Metric[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for Message
+
+	if len(errors) > 0 {
+		return GetMetricsResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetMetricsResponseMultiError is an error wrapping multiple validation errors
+// returned by GetMetricsResponse.ValidateAll() if the designated constraints
+// aren't met.
+type GetMetricsResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetMetricsResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetMetricsResponseMultiError) AllErrors() []error { return m }
+
+// GetMetricsResponseValidationError is the validation error returned by
+// GetMetricsResponse.Validate if the designated constraints aren't met.
+type GetMetricsResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetMetricsResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetMetricsResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetMetricsResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetMetricsResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetMetricsResponseValidationError) ErrorName() string {
+	return "GetMetricsResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetMetricsResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetMetricsResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetMetricsResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetMetricsResponseValidationError{}
+
+// Validate checks the field values on GetMetricHistoryRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetMetricHistoryRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetMetricHistoryRequest with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetMetricHistoryRequestMultiError, or nil if none found.
+func (m *GetMetricHistoryRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetMetricHistoryRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := GetMetricHistoryRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _GetMetricHistoryRequest_MType_InLookup[m.GetMType()]; !ok {
+		err := GetMetricHistoryRequestValidationError{
+			field:  "MType",
+			reason: "value must be in list [gauge counter]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	{
+		sorted_keys := make([]string, len(m.GetLabels()))
+		i := 0
+		for key := range m.GetLabels() {
+			sorted_keys[i] = key
+			i++
+		}
+		sort.Slice(sorted_keys, func(i, j int) bool { return sorted_keys[i] < sorted_keys[j] })
+		for _, key := range sorted_keys {
+			val := m.GetLabels()[key]
+			_ = val
+
+			if utf8.RuneCountInString(key) < 1 {
+				err := GetMetricHistoryRequestValidationError{
+					field:  fmt.Sprintf("Labels[%v]", key),
+					reason: "value length must be at least 1 runes",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+			// no validation rules for Labels[key]
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetFrom()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetMetricHistoryRequestValidationError{
+					field:  "From",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetMetricHistoryRequestValidationError{
+					field:  "From",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetFrom()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetMetricHistoryRequestValidationError{
+				field:  "From",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetTo()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetMetricHistoryRequestValidationError{
+					field:  "To",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetMetricHistoryRequestValidationError{
+					field:  "To",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetTo()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetMetricHistoryRequestValidationError{
+				field:  "To",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if all {
+		switch v := interface{}(m.GetStep()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, GetMetricHistoryRequestValidationError{
+					field:  "Step",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, GetMetricHistoryRequestValidationError{
+					field:  "Step",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetStep()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return GetMetricHistoryRequestValidationError{
+				field:  "Step",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	if _, ok := _GetMetricHistoryRequest_Aggregation_InLookup[m.GetAggregation()]; !ok {
+		err := GetMetricHistoryRequestValidationError{
+			field:  "Aggregation",
+			reason: "value must be in list [ avg min max sum last]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return GetMetricHistoryRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetMetricHistoryRequestMultiError is an error wrapping multiple validation
+// errors returned by GetMetricHistoryRequest.ValidateAll() if the designated
+// constraints aren't met.
+type GetMetricHistoryRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetMetricHistoryRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetMetricHistoryRequestMultiError) AllErrors() []error { return m }
+
+// GetMetricHistoryRequestValidationError is the validation error returned by
+// GetMetricHistoryRequest.Validate if the designated constraints aren't met.
+type GetMetricHistoryRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetMetricHistoryRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetMetricHistoryRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetMetricHistoryRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetMetricHistoryRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetMetricHistoryRequestValidationError) ErrorName() string {
+	return "GetMetricHistoryRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetMetricHistoryRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetMetricHistoryRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetMetricHistoryRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetMetricHistoryRequestValidationError{}
+
+var _GetMetricHistoryRequest_MType_InLookup = map[string]struct{}{
+	"gauge":   {},
+	"counter": {},
+}
+
+var _GetMetricHistoryRequest_Aggregation_InLookup = map[string]struct{}{
+	"":     {},
+	"avg":  {},
+	"min":  {},
+	"max":  {},
+	"sum":  {},
+	"last": {},
+}
+
+// Validate checks the field values on Point with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Point) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Point with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in PointMultiError, or nil if none found.
+func (m *Point) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *Point) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if all {
+		switch v := interface{}(m.GetTimestamp()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, PointValidationError{
+					field:  "Timestamp",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, PointValidationError{
+					field:  "Timestamp",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetTimestamp()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return PointValidationError{
+				field:  "Timestamp",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Value
+
+	if len(errors) > 0 {
+		return PointMultiError(errors)
+	}
+
+	return nil
+}
+
+// PointMultiError is an error wrapping multiple validation errors returned by
+// Point.ValidateAll() if the designated constraints aren't met.
+type PointMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m PointMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m PointMultiError) AllErrors() []error { return m }
+
+// PointValidationError is the validation error returned by Point.Validate if
+// the designated constraints aren't met.
+type PointValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e PointValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e PointValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e PointValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e PointValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e PointValidationError) ErrorName() string { return "PointValidationError" }
+
+// Error satisfies the builtin error interface
+func (e PointValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sPoint.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = PointValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = PointValidationError{}
+
+// Validate checks the field values on GetMetricHistoryResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *GetMetricHistoryResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on GetMetricHistoryResponse with the
+// rules defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// GetMetricHistoryResponseMultiError, or nil if none found.
+func (m *GetMetricHistoryResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *GetMetricHistoryResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	for idx, item := range m.GetPoints() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, GetMetricHistoryResponseValidationError{
+						field:  fmt.Sprintf("Points[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, GetMetricHistoryResponseValidationError{
+						field:  fmt.Sprintf("Points[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return GetMetricHistoryResponseValidationError{
+					field:  fmt.Sprintf("Points[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
+		}
+
+	}
+
+	// no validation rules for Message
+
+	if len(errors) > 0 {
+		return GetMetricHistoryResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// GetMetricHistoryResponseMultiError is an error wrapping multiple validation
+// errors returned by GetMetricHistoryResponse.ValidateAll() if the designated
+// constraints aren't met.
+type GetMetricHistoryResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m GetMetricHistoryResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m GetMetricHistoryResponseMultiError) AllErrors() []error { return m }
+
+// GetMetricHistoryResponseValidationError is the validation error returned by
+// GetMetricHistoryResponse.Validate if the designated constraints aren't met.
+type GetMetricHistoryResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e GetMetricHistoryResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e GetMetricHistoryResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e GetMetricHistoryResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e GetMetricHistoryResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e GetMetricHistoryResponseValidationError) ErrorName() string {
+	return "GetMetricHistoryResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e GetMetricHistoryResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sGetMetricHistoryResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = GetMetricHistoryResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = GetMetricHistoryResponseValidationError{}
+
+// Validate checks the field values on DeleteMetricRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteMetricRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteMetricRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteMetricRequestMultiError, or nil if none found.
+func (m *DeleteMetricRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteMetricRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetId()) < 1 {
+		err := DeleteMetricRequestValidationError{
+			field:  "Id",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _DeleteMetricRequest_MType_InLookup[m.GetMType()]; !ok {
+		err := DeleteMetricRequestValidationError{
+			field:  "MType",
+			reason: "value must be in list [gauge counter histogram summary]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	{
+		sorted_keys := make([]string, len(m.GetLabels()))
+		i := 0
+		for key := range m.GetLabels() {
+			sorted_keys[i] = key
+			i++
+		}
+		sort.Slice(sorted_keys, func(i, j int) bool { return sorted_keys[i] < sorted_keys[j] })
+		for _, key := range sorted_keys {
+			val := m.GetLabels()[key]
+			_ = val
+
+			if utf8.RuneCountInString(key) < 1 {
+				err := DeleteMetricRequestValidationError{
+					field:  fmt.Sprintf("Labels[%v]", key),
+					reason: "value length must be at least 1 runes",
+				}
+				if !all {
+					return err
+				}
+				errors = append(errors, err)
+			}
+
+			// no validation rules for Labels[key]
+		}
+	}
+
+	if len(errors) > 0 {
+		return DeleteMetricRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteMetricRequestMultiError is an error wrapping multiple validation
+// errors returned by DeleteMetricRequest.ValidateAll() if the designated
+// constraints aren't met.
+type DeleteMetricRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteMetricRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteMetricRequestMultiError) AllErrors() []error { return m }
+
+// DeleteMetricRequestValidationError is the validation error returned by
+// DeleteMetricRequest.Validate if the designated constraints aren't met.
+type DeleteMetricRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteMetricRequestValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteMetricRequestValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteMetricRequestValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteMetricRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteMetricRequestValidationError) ErrorName() string {
+	return "DeleteMetricRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteMetricRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteMetricRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteMetricRequestValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteMetricRequestValidationError{}
+
+var _DeleteMetricRequest_MType_InLookup = map[string]struct{}{
+	"gauge":     {},
+	"counter":   {},
+	"histogram": {},
+	"summary":   {},
+}
+
+// Validate checks the field values on DeleteMetricResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteMetricResponse) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteMetricResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteMetricResponseMultiError, or nil if none found.
+func (m *DeleteMetricResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteMetricResponse) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	// no validation rules for Message
+
+	if len(errors) > 0 {
+		return DeleteMetricResponseMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteMetricResponseMultiError is an error wrapping multiple validation
+// errors returned by DeleteMetricResponse.ValidateAll() if the designated
+// constraints aren't met.
+type DeleteMetricResponseMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteMetricResponseMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteMetricResponseMultiError) AllErrors() []error { return m }
+
+// DeleteMetricResponseValidationError is the validation error returned by
+// DeleteMetricResponse.Validate if the designated constraints aren't met.
+type DeleteMetricResponseValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteMetricResponseValidationError) Field() string { return e.field }
+
+// Reason function returns reason value.
+func (e DeleteMetricResponseValidationError) Reason() string { return e.reason }
+
+// Cause function returns cause value.
+func (e DeleteMetricResponseValidationError) Cause() error { return e.cause }
+
+// Key function returns key value.
+func (e DeleteMetricResponseValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteMetricResponseValidationError) ErrorName() string {
+	return "DeleteMetricResponseValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteMetricResponseValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
+	}
+
+	key := ""
+	if e.key {
+		key = "key for "
+	}
+
+	return fmt.Sprintf(
+		"invalid %sDeleteMetricResponse.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
+
+var _ error = DeleteMetricResponseValidationError{}
+
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteMetricResponseValidationError{}
+
+// Validate checks the field values on DeleteMetricsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteMetricsRequest) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on DeleteMetricsRequest with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteMetricsRequestMultiError, or nil if none found.
+func (m *DeleteMetricsRequest) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteMetricsRequest) validate(all bool) error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+
+	if utf8.RuneCountInString(m.GetPattern()) < 1 {
+		err := DeleteMetricsRequestValidationError{
+			field:  "Pattern",
+			reason: "value length must be at least 1 runes",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _DeleteMetricsRequest_Syntax_InLookup[m.GetSyntax()]; !ok {
+		err := DeleteMetricsRequestValidationError{
+			field:  "Syntax",
+			reason: "value must be in list [ glob regex]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if _, ok := _DeleteMetricsRequest_MType_InLookup[m.GetMType()]; !ok {
+		err := DeleteMetricsRequestValidationError{
+			field:  "MType",
+			reason: "value must be in list [ gauge counter histogram summary]",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	if len(errors) > 0 {
+		return DeleteMetricsRequestMultiError(errors)
+	}
+
+	return nil
+}
+
+// DeleteMetricsRequestMultiError is an error wrapping multiple validation
+// errors returned by DeleteMetricsRequest.ValidateAll() if the designated
+// constraints aren't met.
+type DeleteMetricsRequestMultiError []error
+
+// Error returns a concatenation of all the error messages it wraps.
+func (m DeleteMetricsRequestMultiError) Error() string {
+	msgs := make([]string, 0, len(m))
+	for _, err := range m {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// AllErrors returns a list of validation violation errors.
+func (m DeleteMetricsRequestMultiError) AllErrors() []error { return m }
+
+// DeleteMetricsRequestValidationError is the validation error returned by
+// DeleteMetricsRequest.Validate if the designated constraints aren't met.
+type DeleteMetricsRequestValidationError struct {
+	field  string
+	reason string
+	cause  error
+	key    bool
+}
+
+// Field function returns field value.
+func (e DeleteMetricsRequestValidationError) Field() string { return e.field }
 
-func (m *Metric) validate(all bool) error {
-	if m == nil {
-		return nil
-	}
+// Reason function returns reason value.
+func (e DeleteMetricsRequestValidationError) Reason() string { return e.reason }
 
-	var errors []error
+// Cause function returns cause value.
+func (e DeleteMetricsRequestValidationError) Cause() error { return e.cause }
 
-	if utf8.RuneCountInString(m.GetId()) < 1 {
-		err := MetricValidationError{
-			field:  "Id",
-			reason: "value length must be at least 1 runes",
-		}
-		if !all {
-			return err
-		}
-		errors = append(errors, err)
+// Key function returns key value.
+func (e DeleteMetricsRequestValidationError) Key() bool { return e.key }
+
+// ErrorName returns error name.
+func (e DeleteMetricsRequestValidationError) ErrorName() string {
+	return "DeleteMetricsRequestValidationError"
+}
+
+// Error satisfies the builtin error interface
+func (e DeleteMetricsRequestValidationError) Error() string {
+	cause := ""
+	if e.cause != nil {
+		cause = fmt.Sprintf(" | caused by: %v", e.cause)
 	}
 
-	if _, ok := _Metric_MType_InLookup[m.GetMType()]; !ok {
-		err := MetricValidationError{
-			field:  "MType",
-			reason: "value must be in list [gauge counter]",
-		}
-		if !all {
-			return err
-		}
-		errors = append(errors, err)
+	key := ""
+	if e.key {
+		key = "key for "
 	}
 
-	if m.Value != nil {
+	return fmt.Sprintf(
+		"invalid %sDeleteMetricsRequest.%s: %s%s",
+		key,
+		e.field,
+		e.reason,
+		cause)
+}
 
-		if m.GetValue() < 0 {
-			err := MetricValidationError{
-				field:  "Value",
-				reason: "value must be greater than or equal to 0",
-			}
-			if !all {
-				return err
-			}
-			errors = append(errors, err)
-		}
+var _ error = DeleteMetricsRequestValidationError{}
 
-	}
+var _ interface {
+	Field() string
+	Reason() string
+	Key() bool
+	Cause() error
+	ErrorName() string
+} = DeleteMetricsRequestValidationError{}
 
-	if m.Delta != nil {
+var _DeleteMetricsRequest_Syntax_InLookup = map[string]struct{}{
+	"":      {},
+	"glob":  {},
+	"regex": {},
+}
 
-		if m.GetDelta() < 0 {
-			err := MetricValidationError{
-				field:  "Delta",
-				reason: "value must be greater than or equal to 0",
-			}
-			if !all {
-				return err
-			}
-			errors = append(errors, err)
-		}
+var _DeleteMetricsRequest_MType_InLookup = map[string]struct{}{
+	"":          {},
+	"gauge":     {},
+	"counter":   {},
+	"histogram": {},
+	"summary":   {},
+}
+
+// Validate checks the field values on DeleteMetricsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *DeleteMetricsResponse) Validate() error {
+	return m.validate(false)
+}
 
+// ValidateAll checks the field values on DeleteMetricsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the result is a list of violation errors wrapped in
+// DeleteMetricsResponseMultiError, or nil if none found.
+func (m *DeleteMetricsResponse) ValidateAll() error {
+	return m.validate(true)
+}
+
+func (m *DeleteMetricsResponse) validate(all bool) error {
+	if m == nil {
+		return nil
 	}
 
+	var errors []error
+
+	// no validation rules for Deleted
+
+	// no validation rules for Message
+
 	if len(errors) > 0 {
-		return MetricMultiError(errors)
+		return DeleteMetricsResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// MetricMultiError is an error wrapping multiple validation errors returned by
-// Metric.ValidateAll() if the designated constraints aren't met.
-type MetricMultiError []error
+// DeleteMetricsResponseMultiError is an error wrapping multiple validation
+// errors returned by DeleteMetricsResponse.ValidateAll() if the designated
+// constraints aren't met.
+type DeleteMetricsResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m MetricMultiError) Error() string {
+func (m DeleteMetricsResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -129,11 +2514,11 @@ func (m MetricMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m MetricMultiError) AllErrors() []error { return m }
+func (m DeleteMetricsResponseMultiError) AllErrors() []error { return m }
 
-// MetricValidationError is the validation error returned by Metric.Validate if
-// the designated constraints aren't met.
-type MetricValidationError struct {
+// DeleteMetricsResponseValidationError is the validation error returned by
+// DeleteMetricsResponse.Validate if the designated constraints aren't met.
+type DeleteMetricsResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -141,22 +2526,24 @@ type MetricValidationError struct {
 }
 
 // Field function returns field value.
-func (e MetricValidationError) Field() string { return e.field }
+func (e DeleteMetricsResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e MetricValidationError) Reason() string { return e.reason }
+func (e DeleteMetricsResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e MetricValidationError) Cause() error { return e.cause }
+func (e DeleteMetricsResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e MetricValidationError) Key() bool { return e.key }
+func (e DeleteMetricsResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e MetricValidationError) ErrorName() string { return "MetricValidationError" }
+func (e DeleteMetricsResponseValidationError) ErrorName() string {
+	return "DeleteMetricsResponseValidationError"
+}
 
 // Error satisfies the builtin error interface
-func (e MetricValidationError) Error() string {
+func (e DeleteMetricsResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -168,14 +2555,14 @@ func (e MetricValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sMetric.%s: %s%s",
+		"invalid %sDeleteMetricsResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = MetricValidationError{}
+var _ error = DeleteMetricsResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -183,78 +2570,89 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = MetricValidationError{}
+} = DeleteMetricsResponseValidationError{}
 
-var _Metric_MType_InLookup = map[string]struct{}{
-	"gauge":   {},
-	"counter": {},
-}
-
-// Validate checks the field values on CreateMetricRequest with the rules
+// Validate checks the field values on RegisterMetadataRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *CreateMetricRequest) Validate() error {
+func (m *RegisterMetadataRequest) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on CreateMetricRequest with the rules
-// defined in the proto definition for this message. If any rules are
+// ValidateAll checks the field values on RegisterMetadataRequest with the
+// rules defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// CreateMetricRequestMultiError, or nil if none found.
-func (m *CreateMetricRequest) ValidateAll() error {
+// RegisterMetadataRequestMultiError, or nil if none found.
+func (m *RegisterMetadataRequest) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *CreateMetricRequest) validate(all bool) error {
+func (m *RegisterMetadataRequest) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	if all {
-		switch v := interface{}(m.GetMetric()).(type) {
-		case interface{ ValidateAll() error }:
-			if err := v.ValidateAll(); err != nil {
-				errors = append(errors, CreateMetricRequestValidationError{
-					field:  "Metric",
-					reason: "embedded message failed validation",
-					cause:  err,
-				})
+	if len(m.GetMetadata()) < 1 {
+		err := RegisterMetadataRequestValidationError{
+			field:  "Metadata",
+			reason: "value must contain at least 1 item(s)",
+		}
+		if !all {
+			return err
+		}
+		errors = append(errors, err)
+	}
+
+	for idx, item := range m.GetMetadata() {
+		_, _ = idx, item
+
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, RegisterMetadataRequestValidationError{
+						field:  fmt.Sprintf("Metadata[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, RegisterMetadataRequestValidationError{
+						field:  fmt.Sprintf("Metadata[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
 			}
-		case interface{ Validate() error }:
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
 			if err := v.Validate(); err != nil {
-				errors = append(errors, CreateMetricRequestValidationError{
-					field:  "Metric",
+				return RegisterMetadataRequestValidationError{
+					field:  fmt.Sprintf("Metadata[%v]", idx),
 					reason: "embedded message failed validation",
 					cause:  err,
-				})
-			}
-		}
-	} else if v, ok := interface{}(m.GetMetric()).(interface{ Validate() error }); ok {
-		if err := v.Validate(); err != nil {
-			return CreateMetricRequestValidationError{
-				field:  "Metric",
-				reason: "embedded message failed validation",
-				cause:  err,
+				}
 			}
 		}
+
 	}
 
 	if len(errors) > 0 {
-		return CreateMetricRequestMultiError(errors)
+		return RegisterMetadataRequestMultiError(errors)
 	}
 
 	return nil
 }
 
-// CreateMetricRequestMultiError is an error wrapping multiple validation
-// errors returned by CreateMetricRequest.ValidateAll() if the designated
+// RegisterMetadataRequestMultiError is an error wrapping multiple validation
+// errors returned by RegisterMetadataRequest.ValidateAll() if the designated
 // constraints aren't met.
-type CreateMetricRequestMultiError []error
+type RegisterMetadataRequestMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m CreateMetricRequestMultiError) Error() string {
+func (m RegisterMetadataRequestMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -263,11 +2661,11 @@ func (m CreateMetricRequestMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m CreateMetricRequestMultiError) AllErrors() []error { return m }
+func (m RegisterMetadataRequestMultiError) AllErrors() []error { return m }
 
-// CreateMetricRequestValidationError is the validation error returned by
-// CreateMetricRequest.Validate if the designated constraints aren't met.
-type CreateMetricRequestValidationError struct {
+// RegisterMetadataRequestValidationError is the validation error returned by
+// RegisterMetadataRequest.Validate if the designated constraints aren't met.
+type RegisterMetadataRequestValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -275,24 +2673,24 @@ type CreateMetricRequestValidationError struct {
 }
 
 // Field function returns field value.
-func (e CreateMetricRequestValidationError) Field() string { return e.field }
+func (e RegisterMetadataRequestValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e CreateMetricRequestValidationError) Reason() string { return e.reason }
+func (e RegisterMetadataRequestValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e CreateMetricRequestValidationError) Cause() error { return e.cause }
+func (e RegisterMetadataRequestValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e CreateMetricRequestValidationError) Key() bool { return e.key }
+func (e RegisterMetadataRequestValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e CreateMetricRequestValidationError) ErrorName() string {
-	return "CreateMetricRequestValidationError"
+func (e RegisterMetadataRequestValidationError) ErrorName() string {
+	return "RegisterMetadataRequestValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e CreateMetricRequestValidationError) Error() string {
+func (e RegisterMetadataRequestValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -304,14 +2702,14 @@ func (e CreateMetricRequestValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sCreateMetricRequest.%s: %s%s",
+		"invalid %sRegisterMetadataRequest.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = CreateMetricRequestValidationError{}
+var _ error = RegisterMetadataRequestValidationError{}
 
 var _ interface {
 	Field() string
@@ -319,46 +2717,48 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = CreateMetricRequestValidationError{}
+} = RegisterMetadataRequestValidationError{}
 
-// Validate checks the field values on CreateMetricResponse with the rules
+// Validate checks the field values on RegisterMetadataResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *CreateMetricResponse) Validate() error {
+func (m *RegisterMetadataResponse) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on CreateMetricResponse with the rules
-// defined in the proto definition for this message. If any rules are
+// ValidateAll checks the field values on RegisterMetadataResponse with the
+// rules defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// CreateMetricResponseMultiError, or nil if none found.
-func (m *CreateMetricResponse) ValidateAll() error {
+// RegisterMetadataResponseMultiError, or nil if none found.
+func (m *RegisterMetadataResponse) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *CreateMetricResponse) validate(all bool) error {
+func (m *RegisterMetadataResponse) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
+	// no validation rules for Registered
+
 	// no validation rules for Message
 
 	if len(errors) > 0 {
-		return CreateMetricResponseMultiError(errors)
+		return RegisterMetadataResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// CreateMetricResponseMultiError is an error wrapping multiple validation
-// errors returned by CreateMetricResponse.ValidateAll() if the designated
+// RegisterMetadataResponseMultiError is an error wrapping multiple validation
+// errors returned by RegisterMetadataResponse.ValidateAll() if the designated
 // constraints aren't met.
-type CreateMetricResponseMultiError []error
+type RegisterMetadataResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m CreateMetricResponseMultiError) Error() string {
+func (m RegisterMetadataResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -367,11 +2767,11 @@ func (m CreateMetricResponseMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m CreateMetricResponseMultiError) AllErrors() []error { return m }
+func (m RegisterMetadataResponseMultiError) AllErrors() []error { return m }
 
-// CreateMetricResponseValidationError is the validation error returned by
-// CreateMetricResponse.Validate if the designated constraints aren't met.
-type CreateMetricResponseValidationError struct {
+// RegisterMetadataResponseValidationError is the validation error returned by
+// RegisterMetadataResponse.Validate if the designated constraints aren't met.
+type RegisterMetadataResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -379,24 +2779,24 @@ type CreateMetricResponseValidationError struct {
 }
 
 // Field function returns field value.
-func (e CreateMetricResponseValidationError) Field() string { return e.field }
+func (e RegisterMetadataResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e CreateMetricResponseValidationError) Reason() string { return e.reason }
+func (e RegisterMetadataResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e CreateMetricResponseValidationError) Cause() error { return e.cause }
+func (e RegisterMetadataResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e CreateMetricResponseValidationError) Key() bool { return e.key }
+func (e RegisterMetadataResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e CreateMetricResponseValidationError) ErrorName() string {
-	return "CreateMetricResponseValidationError"
+func (e RegisterMetadataResponseValidationError) ErrorName() string {
+	return "RegisterMetadataResponseValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e CreateMetricResponseValidationError) Error() string {
+func (e RegisterMetadataResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -408,14 +2808,14 @@ func (e CreateMetricResponseValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sCreateMetricResponse.%s: %s%s",
+		"invalid %sRegisterMetadataResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = CreateMetricResponseValidationError{}
+var _ error = RegisterMetadataResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -423,47 +2823,47 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = CreateMetricResponseValidationError{}
+} = RegisterMetadataResponseValidationError{}
 
-// Validate checks the field values on CreateMetricsRequest with the rules
+// Validate checks the field values on GetMetadataResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the first error encountered is returned, or nil if there are no violations.
-func (m *CreateMetricsRequest) Validate() error {
+func (m *GetMetadataResponse) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on CreateMetricsRequest with the rules
+// ValidateAll checks the field values on GetMetadataResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// CreateMetricsRequestMultiError, or nil if none found.
-func (m *CreateMetricsRequest) ValidateAll() error {
+// GetMetadataResponseMultiError, or nil if none found.
+func (m *GetMetadataResponse) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *CreateMetricsRequest) validate(all bool) error {
+func (m *GetMetadataResponse) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	for idx, item := range m.GetMetrics() {
+	for idx, item := range m.GetMetadata() {
 		_, _ = idx, item
 
 		if all {
 			switch v := interface{}(item).(type) {
 			case interface{ ValidateAll() error }:
 				if err := v.ValidateAll(); err != nil {
-					errors = append(errors, CreateMetricsRequestValidationError{
-						field:  fmt.Sprintf("Metrics[%v]", idx),
+					errors = append(errors, GetMetadataResponseValidationError{
+						field:  fmt.Sprintf("Metadata[%v]", idx),
 						reason: "embedded message failed validation",
 						cause:  err,
 					})
 				}
 			case interface{ Validate() error }:
 				if err := v.Validate(); err != nil {
-					errors = append(errors, CreateMetricsRequestValidationError{
-						field:  fmt.Sprintf("Metrics[%v]", idx),
+					errors = append(errors, GetMetadataResponseValidationError{
+						field:  fmt.Sprintf("Metadata[%v]", idx),
 						reason: "embedded message failed validation",
 						cause:  err,
 					})
@@ -471,8 +2871,8 @@ func (m *CreateMetricsRequest) validate(all bool) error {
 			}
 		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
 			if err := v.Validate(); err != nil {
-				return CreateMetricsRequestValidationError{
-					field:  fmt.Sprintf("Metrics[%v]", idx),
+				return GetMetadataResponseValidationError{
+					field:  fmt.Sprintf("Metadata[%v]", idx),
 					reason: "embedded message failed validation",
 					cause:  err,
 				}
@@ -481,20 +2881,22 @@ func (m *CreateMetricsRequest) validate(all bool) error {
 
 	}
 
+	// no validation rules for Message
+
 	if len(errors) > 0 {
-		return CreateMetricsRequestMultiError(errors)
+		return GetMetadataResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// CreateMetricsRequestMultiError is an error wrapping multiple validation
-// errors returned by CreateMetricsRequest.ValidateAll() if the designated
+// GetMetadataResponseMultiError is an error wrapping multiple validation
+// errors returned by GetMetadataResponse.ValidateAll() if the designated
 // constraints aren't met.
-type CreateMetricsRequestMultiError []error
+type GetMetadataResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m CreateMetricsRequestMultiError) Error() string {
+func (m GetMetadataResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -503,11 +2905,11 @@ func (m CreateMetricsRequestMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m CreateMetricsRequestMultiError) AllErrors() []error { return m }
+func (m GetMetadataResponseMultiError) AllErrors() []error { return m }
 
-// CreateMetricsRequestValidationError is the validation error returned by
-// CreateMetricsRequest.Validate if the designated constraints aren't met.
-type CreateMetricsRequestValidationError struct {
+// GetMetadataResponseValidationError is the validation error returned by
+// GetMetadataResponse.Validate if the designated constraints aren't met.
+type GetMetadataResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -515,24 +2917,24 @@ type CreateMetricsRequestValidationError struct {
 }
 
 // Field function returns field value.
-func (e CreateMetricsRequestValidationError) Field() string { return e.field }
+func (e GetMetadataResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e CreateMetricsRequestValidationError) Reason() string { return e.reason }
+func (e GetMetadataResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e CreateMetricsRequestValidationError) Cause() error { return e.cause }
+func (e GetMetadataResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e CreateMetricsRequestValidationError) Key() bool { return e.key }
+func (e GetMetadataResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e CreateMetricsRequestValidationError) ErrorName() string {
-	return "CreateMetricsRequestValidationError"
+func (e GetMetadataResponseValidationError) ErrorName() string {
+	return "GetMetadataResponseValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e CreateMetricsRequestValidationError) Error() string {
+func (e GetMetadataResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -544,14 +2946,14 @@ func (e CreateMetricsRequestValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sCreateMetricsRequest.%s: %s%s",
+		"invalid %sGetMetadataResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = CreateMetricsRequestValidationError{}
+var _ error = GetMetadataResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -559,46 +2961,85 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = CreateMetricsRequestValidationError{}
-
-// Validate checks the field values on CreateMetricsResponse with the rules
-// defined in the proto definition for this message. If any rules are
-// violated, the first error encountered is returned, or nil if there are no violations.
-func (m *CreateMetricsResponse) Validate() error {
-	return m.validate(false)
-}
+} = GetMetadataResponseValidationError{}
 
-// ValidateAll checks the field values on CreateMetricsResponse with the rules
-// defined in the proto definition for this message. If any rules are
-// violated, the result is a list of violation errors wrapped in
-// CreateMetricsResponseMultiError, or nil if none found.
-func (m *CreateMetricsResponse) ValidateAll() error {
+// Validate checks the field values on Agent with the rules defined in the
+// proto definition for this message. If any rules are violated, the first
+// error encountered is returned, or nil if there are no violations.
+func (m *Agent) Validate() error {
+	return m.validate(false)
+}
+
+// ValidateAll checks the field values on Agent with the rules defined in the
+// proto definition for this message. If any rules are violated, the result is
+// a list of violation errors wrapped in AgentMultiError, or nil if none found.
+func (m *Agent) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *CreateMetricsResponse) validate(all bool) error {
+func (m *Agent) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	// no validation rules for Message
+	// no validation rules for Id
+
+	// no validation rules for Hostname
+
+	// no validation rules for Version
+
+	// no validation rules for Address
+
+	if all {
+		switch v := interface{}(m.GetLastSeen()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, AgentValidationError{
+					field:  "LastSeen",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		case interface{ Validate() error }:
+			if err := v.Validate(); err != nil {
+				errors = append(errors, AgentValidationError{
+					field:  "LastSeen",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetLastSeen()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return AgentValidationError{
+				field:  "LastSeen",
+				reason: "embedded message failed validation",
+				cause:  err,
+			}
+		}
+	}
+
+	// no validation rules for Series
+
+	// no validation rules for ReportInterval
+
+	// no validation rules for Up
 
 	if len(errors) > 0 {
-		return CreateMetricsResponseMultiError(errors)
+		return AgentMultiError(errors)
 	}
 
 	return nil
 }
 
-// CreateMetricsResponseMultiError is an error wrapping multiple validation
-// errors returned by CreateMetricsResponse.ValidateAll() if the designated
-// constraints aren't met.
-type CreateMetricsResponseMultiError []error
+// AgentMultiError is an error wrapping multiple validation errors returned by
+// Agent.ValidateAll() if the designated constraints aren't met.
+type AgentMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m CreateMetricsResponseMultiError) Error() string {
+func (m AgentMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -607,11 +3048,11 @@ func (m CreateMetricsResponseMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m CreateMetricsResponseMultiError) AllErrors() []error { return m }
+func (m AgentMultiError) AllErrors() []error { return m }
 
-// CreateMetricsResponseValidationError is the validation error returned by
-// CreateMetricsResponse.Validate if the designated constraints aren't met.
-type CreateMetricsResponseValidationError struct {
+// AgentValidationError is the validation error returned by Agent.Validate if
+// the designated constraints aren't met.
+type AgentValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -619,24 +3060,22 @@ type CreateMetricsResponseValidationError struct {
 }
 
 // Field function returns field value.
-func (e CreateMetricsResponseValidationError) Field() string { return e.field }
+func (e AgentValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e CreateMetricsResponseValidationError) Reason() string { return e.reason }
+func (e AgentValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e CreateMetricsResponseValidationError) Cause() error { return e.cause }
+func (e AgentValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e CreateMetricsResponseValidationError) Key() bool { return e.key }
+func (e AgentValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e CreateMetricsResponseValidationError) ErrorName() string {
-	return "CreateMetricsResponseValidationError"
-}
+func (e AgentValidationError) ErrorName() string { return "AgentValidationError" }
 
 // Error satisfies the builtin error interface
-func (e CreateMetricsResponseValidationError) Error() string {
+func (e AgentValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -648,14 +3087,14 @@ func (e CreateMetricsResponseValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sCreateMetricsResponse.%s: %s%s",
+		"invalid %sAgent.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = CreateMetricsResponseValidationError{}
+var _ error = AgentValidationError{}
 
 var _ interface {
 	Field() string
@@ -663,66 +3102,80 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = CreateMetricsResponseValidationError{}
+} = AgentValidationError{}
 
-// Validate checks the field values on GetMetricRequest with the rules defined
-// in the proto definition for this message. If any rules are violated, the
-// first error encountered is returned, or nil if there are no violations.
-func (m *GetMetricRequest) Validate() error {
+// Validate checks the field values on ListAgentsResponse with the rules
+// defined in the proto definition for this message. If any rules are
+// violated, the first error encountered is returned, or nil if there are no violations.
+func (m *ListAgentsResponse) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on GetMetricRequest with the rules
+// ValidateAll checks the field values on ListAgentsResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// GetMetricRequestMultiError, or nil if none found.
-func (m *GetMetricRequest) ValidateAll() error {
+// ListAgentsResponseMultiError, or nil if none found.
+func (m *ListAgentsResponse) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *GetMetricRequest) validate(all bool) error {
+func (m *ListAgentsResponse) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	if utf8.RuneCountInString(m.GetId()) < 1 {
-		err := GetMetricRequestValidationError{
-			field:  "Id",
-			reason: "value length must be at least 1 runes",
-		}
-		if !all {
-			return err
-		}
-		errors = append(errors, err)
-	}
+	for idx, item := range m.GetAgents() {
+		_, _ = idx, item
 
-	if _, ok := _GetMetricRequest_MType_InLookup[m.GetMType()]; !ok {
-		err := GetMetricRequestValidationError{
-			field:  "MType",
-			reason: "value must be in list [gauge counter]",
-		}
-		if !all {
-			return err
+		if all {
+			switch v := interface{}(item).(type) {
+			case interface{ ValidateAll() error }:
+				if err := v.ValidateAll(); err != nil {
+					errors = append(errors, ListAgentsResponseValidationError{
+						field:  fmt.Sprintf("Agents[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			case interface{ Validate() error }:
+				if err := v.Validate(); err != nil {
+					errors = append(errors, ListAgentsResponseValidationError{
+						field:  fmt.Sprintf("Agents[%v]", idx),
+						reason: "embedded message failed validation",
+						cause:  err,
+					})
+				}
+			}
+		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+			if err := v.Validate(); err != nil {
+				return ListAgentsResponseValidationError{
+					field:  fmt.Sprintf("Agents[%v]", idx),
+					reason: "embedded message failed validation",
+					cause:  err,
+				}
+			}
 		}
-		errors = append(errors, err)
+
 	}
 
+	// no validation rules for Message
+
 	if len(errors) > 0 {
-		return GetMetricRequestMultiError(errors)
+		return ListAgentsResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// GetMetricRequestMultiError is an error wrapping multiple validation errors
-// returned by GetMetricRequest.ValidateAll() if the designated constraints
+// ListAgentsResponseMultiError is an error wrapping multiple validation errors
+// returned by ListAgentsResponse.ValidateAll() if the designated constraints
 // aren't met.
-type GetMetricRequestMultiError []error
+type ListAgentsResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m GetMetricRequestMultiError) Error() string {
+func (m ListAgentsResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -731,11 +3184,11 @@ func (m GetMetricRequestMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m GetMetricRequestMultiError) AllErrors() []error { return m }
+func (m ListAgentsResponseMultiError) AllErrors() []error { return m }
 
-// GetMetricRequestValidationError is the validation error returned by
-// GetMetricRequest.Validate if the designated constraints aren't met.
-type GetMetricRequestValidationError struct {
+// ListAgentsResponseValidationError is the validation error returned by
+// ListAgentsResponse.Validate if the designated constraints aren't met.
+type ListAgentsResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -743,22 +3196,24 @@ type GetMetricRequestValidationError struct {
 }
 
 // Field function returns field value.
-func (e GetMetricRequestValidationError) Field() string { return e.field }
+func (e ListAgentsResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e GetMetricRequestValidationError) Reason() string { return e.reason }
+func (e ListAgentsResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e GetMetricRequestValidationError) Cause() error { return e.cause }
+func (e ListAgentsResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e GetMetricRequestValidationError) Key() bool { return e.key }
+func (e ListAgentsResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e GetMetricRequestValidationError) ErrorName() string { return "GetMetricRequestValidationError" }
+func (e ListAgentsResponseValidationError) ErrorName() string {
+	return "ListAgentsResponseValidationError"
+}
 
 // Error satisfies the builtin error interface
-func (e GetMetricRequestValidationError) Error() string {
+func (e ListAgentsResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -770,14 +3225,14 @@ func (e GetMetricRequestValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sGetMetricRequest.%s: %s%s",
+		"invalid %sListAgentsResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = GetMetricRequestValidationError{}
+var _ error = ListAgentsResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -785,80 +3240,46 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = GetMetricRequestValidationError{}
-
-var _GetMetricRequest_MType_InLookup = map[string]struct{}{
-	"gauge":   {},
-	"counter": {},
-}
+} = ListAgentsResponseValidationError{}
 
-// Validate checks the field values on GetMetricResponse with the rules defined
+// Validate checks the field values on HeartbeatRequest with the rules defined
 // in the proto definition for this message. If any rules are violated, the
 // first error encountered is returned, or nil if there are no violations.
-func (m *GetMetricResponse) Validate() error {
+func (m *HeartbeatRequest) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on GetMetricResponse with the rules
+// ValidateAll checks the field values on HeartbeatRequest with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// GetMetricResponseMultiError, or nil if none found.
-func (m *GetMetricResponse) ValidateAll() error {
+// HeartbeatRequestMultiError, or nil if none found.
+func (m *HeartbeatRequest) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *GetMetricResponse) validate(all bool) error {
+func (m *HeartbeatRequest) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	if all {
-		switch v := interface{}(m.GetMetric()).(type) {
-		case interface{ ValidateAll() error }:
-			if err := v.ValidateAll(); err != nil {
-				errors = append(errors, GetMetricResponseValidationError{
-					field:  "Metric",
-					reason: "embedded message failed validation",
-					cause:  err,
-				})
-			}
-		case interface{ Validate() error }:
-			if err := v.Validate(); err != nil {
-				errors = append(errors, GetMetricResponseValidationError{
-					field:  "Metric",
-					reason: "embedded message failed validation",
-					cause:  err,
-				})
-			}
-		}
-	} else if v, ok := interface{}(m.GetMetric()).(interface{ Validate() error }); ok {
-		if err := v.Validate(); err != nil {
-			return GetMetricResponseValidationError{
-				field:  "Metric",
-				reason: "embedded message failed validation",
-				cause:  err,
-			}
-		}
-	}
-
-	// no validation rules for Message
+	// no validation rules for ReportInterval
 
 	if len(errors) > 0 {
-		return GetMetricResponseMultiError(errors)
+		return HeartbeatRequestMultiError(errors)
 	}
 
 	return nil
 }
 
-// GetMetricResponseMultiError is an error wrapping multiple validation errors
-// returned by GetMetricResponse.ValidateAll() if the designated constraints
+// HeartbeatRequestMultiError is an error wrapping multiple validation errors
+// returned by HeartbeatRequest.ValidateAll() if the designated constraints
 // aren't met.
-type GetMetricResponseMultiError []error
+type HeartbeatRequestMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m GetMetricResponseMultiError) Error() string {
+func (m HeartbeatRequestMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -867,11 +3288,11 @@ func (m GetMetricResponseMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m GetMetricResponseMultiError) AllErrors() []error { return m }
+func (m HeartbeatRequestMultiError) AllErrors() []error { return m }
 
-// GetMetricResponseValidationError is the validation error returned by
-// GetMetricResponse.Validate if the designated constraints aren't met.
-type GetMetricResponseValidationError struct {
+// HeartbeatRequestValidationError is the validation error returned by
+// HeartbeatRequest.Validate if the designated constraints aren't met.
+type HeartbeatRequestValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -879,24 +3300,22 @@ type GetMetricResponseValidationError struct {
 }
 
 // Field function returns field value.
-func (e GetMetricResponseValidationError) Field() string { return e.field }
+func (e HeartbeatRequestValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e GetMetricResponseValidationError) Reason() string { return e.reason }
+func (e HeartbeatRequestValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e GetMetricResponseValidationError) Cause() error { return e.cause }
+func (e HeartbeatRequestValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e GetMetricResponseValidationError) Key() bool { return e.key }
+func (e HeartbeatRequestValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e GetMetricResponseValidationError) ErrorName() string {
-	return "GetMetricResponseValidationError"
-}
+func (e HeartbeatRequestValidationError) ErrorName() string { return "HeartbeatRequestValidationError" }
 
 // Error satisfies the builtin error interface
-func (e GetMetricResponseValidationError) Error() string {
+func (e HeartbeatRequestValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -908,14 +3327,14 @@ func (e GetMetricResponseValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sGetMetricResponse.%s: %s%s",
+		"invalid %sHeartbeatRequest.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = GetMetricResponseValidationError{}
+var _ error = HeartbeatRequestValidationError{}
 
 var _ interface {
 	Field() string
@@ -923,80 +3342,75 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = GetMetricResponseValidationError{}
+} = HeartbeatRequestValidationError{}
 
-// Validate checks the field values on GetMetricsResponse with the rules
-// defined in the proto definition for this message. If any rules are
-// violated, the first error encountered is returned, or nil if there are no violations.
-func (m *GetMetricsResponse) Validate() error {
+// Validate checks the field values on HeartbeatResponse with the rules defined
+// in the proto definition for this message. If any rules are violated, the
+// first error encountered is returned, or nil if there are no violations.
+func (m *HeartbeatResponse) Validate() error {
 	return m.validate(false)
 }
 
-// ValidateAll checks the field values on GetMetricsResponse with the rules
+// ValidateAll checks the field values on HeartbeatResponse with the rules
 // defined in the proto definition for this message. If any rules are
 // violated, the result is a list of violation errors wrapped in
-// GetMetricsResponseMultiError, or nil if none found.
-func (m *GetMetricsResponse) ValidateAll() error {
+// HeartbeatResponseMultiError, or nil if none found.
+func (m *HeartbeatResponse) ValidateAll() error {
 	return m.validate(true)
 }
 
-func (m *GetMetricsResponse) validate(all bool) error {
+func (m *HeartbeatResponse) validate(all bool) error {
 	if m == nil {
 		return nil
 	}
 
 	var errors []error
 
-	for idx, item := range m.GetMetric() {
-		_, _ = idx, item
-
-		if all {
-			switch v := interface{}(item).(type) {
-			case interface{ ValidateAll() error }:
-				if err := v.ValidateAll(); err != nil {
-					errors = append(errors, GetMetricsResponseValidationError{
-						field:  fmt.Sprintf("Metric[%v]", idx),
-						reason: "embedded message failed validation",
-						cause:  err,
-					})
-				}
-			case interface{ Validate() error }:
-				if err := v.Validate(); err != nil {
-					errors = append(errors, GetMetricsResponseValidationError{
-						field:  fmt.Sprintf("Metric[%v]", idx),
-						reason: "embedded message failed validation",
-						cause:  err,
-					})
-				}
+	if all {
+		switch v := interface{}(m.GetAgent()).(type) {
+		case interface{ ValidateAll() error }:
+			if err := v.ValidateAll(); err != nil {
+				errors = append(errors, HeartbeatResponseValidationError{
+					field:  "Agent",
+					reason: "embedded message failed validation",
+					cause:  err,
+				})
 			}
-		} else if v, ok := interface{}(item).(interface{ Validate() error }); ok {
+		case interface{ Validate() error }:
 			if err := v.Validate(); err != nil {
-				return GetMetricsResponseValidationError{
-					field:  fmt.Sprintf("Metric[%v]", idx),
+				errors = append(errors, HeartbeatResponseValidationError{
+					field:  "Agent",
 					reason: "embedded message failed validation",
 					cause:  err,
-				}
+				})
+			}
+		}
+	} else if v, ok := interface{}(m.GetAgent()).(interface{ Validate() error }); ok {
+		if err := v.Validate(); err != nil {
+			return HeartbeatResponseValidationError{
+				field:  "Agent",
+				reason: "embedded message failed validation",
+				cause:  err,
 			}
 		}
-
 	}
 
 	// no validation rules for Message
 
 	if len(errors) > 0 {
-		return GetMetricsResponseMultiError(errors)
+		return HeartbeatResponseMultiError(errors)
 	}
 
 	return nil
 }
 
-// GetMetricsResponseMultiError is an error wrapping multiple validation errors
-// returned by GetMetricsResponse.ValidateAll() if the designated constraints
+// HeartbeatResponseMultiError is an error wrapping multiple validation errors
+// returned by HeartbeatResponse.ValidateAll() if the designated constraints
 // aren't met.
-type GetMetricsResponseMultiError []error
+type HeartbeatResponseMultiError []error
 
 // Error returns a concatenation of all the error messages it wraps.
-func (m GetMetricsResponseMultiError) Error() string {
+func (m HeartbeatResponseMultiError) Error() string {
 	msgs := make([]string, 0, len(m))
 	for _, err := range m {
 		msgs = append(msgs, err.Error())
@@ -1005,11 +3419,11 @@ func (m GetMetricsResponseMultiError) Error() string {
 }
 
 // AllErrors returns a list of validation violation errors.
-func (m GetMetricsResponseMultiError) AllErrors() []error { return m }
+func (m HeartbeatResponseMultiError) AllErrors() []error { return m }
 
-// GetMetricsResponseValidationError is the validation error returned by
-// GetMetricsResponse.Validate if the designated constraints aren't met.
-type GetMetricsResponseValidationError struct {
+// HeartbeatResponseValidationError is the validation error returned by
+// HeartbeatResponse.Validate if the designated constraints aren't met.
+type HeartbeatResponseValidationError struct {
 	field  string
 	reason string
 	cause  error
@@ -1017,24 +3431,24 @@ type GetMetricsResponseValidationError struct {
 }
 
 // Field function returns field value.
-func (e GetMetricsResponseValidationError) Field() string { return e.field }
+func (e HeartbeatResponseValidationError) Field() string { return e.field }
 
 // Reason function returns reason value.
-func (e GetMetricsResponseValidationError) Reason() string { return e.reason }
+func (e HeartbeatResponseValidationError) Reason() string { return e.reason }
 
 // Cause function returns cause value.
-func (e GetMetricsResponseValidationError) Cause() error { return e.cause }
+func (e HeartbeatResponseValidationError) Cause() error { return e.cause }
 
 // Key function returns key value.
-func (e GetMetricsResponseValidationError) Key() bool { return e.key }
+func (e HeartbeatResponseValidationError) Key() bool { return e.key }
 
 // ErrorName returns error name.
-func (e GetMetricsResponseValidationError) ErrorName() string {
-	return "GetMetricsResponseValidationError"
+func (e HeartbeatResponseValidationError) ErrorName() string {
+	return "HeartbeatResponseValidationError"
 }
 
 // Error satisfies the builtin error interface
-func (e GetMetricsResponseValidationError) Error() string {
+func (e HeartbeatResponseValidationError) Error() string {
 	cause := ""
 	if e.cause != nil {
 		cause = fmt.Sprintf(" | caused by: %v", e.cause)
@@ -1046,14 +3460,14 @@ func (e GetMetricsResponseValidationError) Error() string {
 	}
 
 	return fmt.Sprintf(
-		"invalid %sGetMetricsResponse.%s: %s%s",
+		"invalid %sHeartbeatResponse.%s: %s%s",
 		key,
 		e.field,
 		e.reason,
 		cause)
 }
 
-var _ error = GetMetricsResponseValidationError{}
+var _ error = HeartbeatResponseValidationError{}
 
 var _ interface {
 	Field() string
@@ -1061,4 +3475,4 @@ var _ interface {
 	Key() bool
 	Cause() error
 	ErrorName() string
-} = GetMetricsResponseValidationError{}
+} = HeartbeatResponseValidationError{}